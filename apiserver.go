@@ -0,0 +1,286 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	"github.com/planxnx/ethereum-wallet-generator/filter"
+	"github.com/planxnx/ethereum-wallet-generator/generator"
+	"github.com/planxnx/ethereum-wallet-generator/internal/api"
+	"github.com/planxnx/ethereum-wallet-generator/internal/throttle"
+	"github.com/planxnx/ethereum-wallet-generator/pipeline"
+	"github.com/planxnx/ethereum-wallet-generator/seeds"
+	"github.com/planxnx/ethereum-wallet-generator/wallets"
+)
+
+// runAPI serves the REST API running scan and generate jobs.
+func runAPI(args []string) {
+	fs := flag.NewFlagSet("api", flag.ExitOnError)
+	listen := fs.String("listen", ":8080", "address to serve the API on")
+	grpcListen := fs.String("grpc-listen", "", "also serve the gRPC API on this address (eg. :9000)")
+	token := fs.String("token", "", "secret clients must present as a bearer token (required)")
+	tlsCert := fs.String("tls-cert", "", "serve HTTPS with this certificate file")
+	tlsKey := fs.String("tls-key", "", "private key file of -tls-cert")
+	parallel := fs.Int("jobs", api.DefaultParallel, "number of jobs running at the same time, the others are queued")
+	keep := fs.Int("keep", api.DefaultKeep, "number of finished jobs kept with their results")
+	maxResults := fs.Int("max-results", api.DefaultMaxResults, "stop a job once it has this many results")
+	concurrency := fs.Int("c", 1, "set concurrency value (number of derivation workers per job)")
+	maxCPU := fs.String("max-cpu", "100%", "limit CPU usage of every job to the given percentage (eg. 50%)")
+	var plugins stringsFlag
+	fs.Var(&plugins, "validator-plugin", "load a Go plugin registering validators jobs can use, can be repeated")
+	metricsConfig := addMetricsFlags(fs)
+	parseFlags(fs, args)
+
+	if *token == "" {
+		fmt.Fprintln(os.Stderr, "Error: --token is required, results carry private keys")
+		os.Exit(exitUsage)
+	}
+	if (*tlsCert == "") != (*tlsKey == "") {
+		fmt.Fprintln(os.Stderr, "Error: --tls-cert and --tls-key must be given together")
+		os.Exit(exitUsage)
+	}
+	for _, path := range plugins {
+		if err := filter.LoadPlugin(path); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitUsage)
+		}
+	}
+	cpuPercent, err := throttle.ParsePercent(*maxCPU)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitUsage)
+	}
+	metricsConfig()
+	workers := max(*concurrency, 1)
+	runMetrics.SetWorkers(workers * max(*parallel, 1))
+
+	jobs := api.NewServer(api.Config{
+		Token:      *token,
+		Parallel:   *parallel,
+		Keep:       *keep,
+		MaxResults: *maxResults,
+		Run: func(ctx context.Context, spec api.Spec, job *api.Job) error {
+			slog.Info("Job started", "job", job.Status().ID, "kind", spec.Kind)
+			ctx, span := tracer.Start(ctx, "job", trace.WithAttributes(attribute.String("ewg.job", job.Status().ID), attribute.String("ewg.kind", string(spec.Kind))))
+			defer func() {
+				st := job.Status()
+				span.SetAttributes(attribute.Int64("ewg.addresses", st.Addresses), attribute.Int("ewg.matches", st.Matches))
+				span.End()
+				slog.Info("Job ended", "job", st.ID, "addresses", st.Addresses, "matches", st.Matches)
+			}()
+			if spec.Kind == api.KindGenerate {
+				return runGenerateJob(ctx, spec, job, workers)
+			}
+			return runScanJob(ctx, spec, job, workers, cpuPercent)
+		},
+	})
+	handler := jobs.Handler()
+	if runTelemetry != nil {
+		handler = otelhttp.NewHandler(handler, "api")
+	}
+	server := &http.Server{
+		Addr:              *listen,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	var grpcServer *grpc.Server
+	if *grpcListen != "" {
+		var opts []grpc.ServerOption
+		if runTelemetry != nil {
+			opts = append(opts, grpc.StatsHandler(otelgrpc.NewServerHandler()))
+		}
+		if *tlsCert != "" {
+			creds, err := credentials.NewServerTLSFromFile(*tlsCert, *tlsKey)
+			if err != nil {
+				fatal("Failed to load TLS certificate", "err", err)
+			}
+			opts = append(opts, grpc.Creds(creds))
+		}
+		lis, err := net.Listen("tcp", *grpcListen)
+		if err != nil {
+			fatal("Failed to listen", "addr", *grpcListen, "err", err)
+		}
+		grpcServer = jobs.GRPCServer(opts...)
+		go func() {
+			if err := grpcServer.Serve(lis); err != nil {
+				fatal("gRPC server failed", "err", err)
+			}
+		}()
+		slog.Info("gRPC API listening", "addr", *grpcListen)
+	}
+
+	ctx, stop := withSignals(context.Background())
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		jobs.Close()
+		if grpcServer != nil {
+			grpcServer.Stop()
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = server.Shutdown(ctx)
+	}()
+
+	slog.Info("API listening", "addr", *listen, "tls", *tlsCert != "")
+	if *tlsCert != "" {
+		err = server.ListenAndServeTLS(*tlsCert, *tlsKey)
+	} else {
+		err = server.ListenAndServe()
+	}
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fatal("API server failed", "err", err)
+	}
+	if sig := interruptSignal(ctx); sig != nil {
+		fmt.Fprintf(os.Stderr, "Interrupted by %v, running jobs were canceled\n", sig)
+	}
+}
+
+// runScanJob derives the addresses of the seeds of a scan job.
+func runScanJob(ctx context.Context, spec api.Spec, job *api.Job, workers, cpuPercent int) error {
+	live, err := filter.NewLive(spec.Filter)
+	if err != nil {
+		return err
+	}
+	job.OnFilter(live.Set)
+	depth := max(spec.Depth, 1)
+	seedCh, errCh := seeds.Stream(ctx, strings.NewReader(strings.Join(spec.Seeds, "\n")), seeds.Range{}, seeds.DefaultReadAhead)
+	total := 0
+	for _, phrase := range spec.Seeds {
+		if strings.TrimSpace(phrase) != "" {
+			total++
+		}
+	}
+	job.SetTotal(int64(total * depth))
+
+	pipeline.New(pipeline.Config{
+		Workers:          workers,
+		Depth:            depth,
+		BasePath:         wallets.DefaultBaseDerivationPath,
+		CPUPercent:       cpuPercent,
+		AddressValidator: live.ValidAddress,
+		Validator:        live,
+		OnMatch: func(m pipeline.Match) {
+			runMetrics.Match()
+			r := apiResult(m.Line, m.Index, m.Label, m.Wallet)
+			r.Mnemonic = m.Phrase
+			job.Match(r)
+		},
+		OnFailure: func(pipeline.Failure) {
+			runMetrics.Error("derivation")
+		},
+		OnProgress: func(processed int) {
+			runMetrics.Seed(processed)
+			job.Progress(processed)
+		},
+		OnSeed: func(st pipeline.SeedStats) {
+			runMetrics.Busy(st.Elapsed)
+		},
+	}).Run(ctx, seedCh)
+	return <-errCh
+}
+
+// runGenerateJob generates the random wallets of a generate job.
+func runGenerateJob(ctx context.Context, spec api.Spec, job *api.Job, workers int) error {
+	live, err := filter.NewLive(spec.Filter)
+	if err != nil {
+		return err
+	}
+	job.OnFilter(live.Set)
+	walletGen := wallets.NewGeneratorMnemonic(wallets.DefaultMnemonicBits)
+	if spec.Bits != 0 {
+		walletGen = wallets.NewGeneratorMnemonic(spec.Bits)
+	}
+	validAddress := live.ValidAddress
+	switch spec.Mode {
+	case "privatekey":
+		walletGen = wallets.NewGeneratorPrivatekey()
+	case "keyspace":
+		walletGen = wallets.NewGeneratorKeyspaceFrom(rand.Reader, validAddress)
+	}
+	limit := spec.Limit
+	if limit <= 0 {
+		limit = -1
+	}
+	job.SetTotal(int64(spec.Number))
+
+	gen := generator.New(walletGen, jobRepository{job}, generator.Config{
+		AddresValidator: validAddress,
+		Validator:       live,
+		ProgressBar:     jobProgress{job},
+		Concurrency:     workers,
+		Number:          spec.Number,
+		Limit:           limit,
+	})
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = gen.Shutdown()
+		case <-done:
+		}
+	}()
+	_, err = gen.Start()
+	return err
+}
+
+// apiResult returns the API result of a matching wallet.
+func apiResult(line, index int, label string, w *wallets.Wallet) api.Result {
+	return api.Result{
+		Line:            line,
+		Index:           index,
+		Label:           label,
+		Address:         w.Address,
+		ChecksumAddress: w.ChecksumAddress,
+		PrivateKey:      w.PrivateKey,
+		Mnemonic:        w.Mnemonic,
+		HDPath:          w.HDPath,
+	}
+}
+
+// jobRepository adds the wallets of the generator to the results of a job.
+type jobRepository struct {
+	job *api.Job
+}
+
+func (r jobRepository) Insert(wallet *wallets.Wallet) error {
+	runMetrics.Match()
+	r.job.Match(apiResult(0, 0, "", wallet))
+	return nil
+}
+
+func (jobRepository) Result() []*wallets.Wallet { return nil }
+func (jobRepository) Commit() error             { return nil }
+func (jobRepository) Close() error              { return nil }
+
+// jobProgress counts the generated wallets of a job.
+type jobProgress struct {
+	job *api.Job
+}
+
+func (p jobProgress) Increment() error {
+	runMetrics.Addresses(1)
+	p.job.Progress(1)
+	return nil
+}
+
+func (jobProgress) SetResolved(int) error { return nil }
+func (jobProgress) Finish() error         { return nil }
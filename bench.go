@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/planxnx/ethereum-wallet-generator/internal/entropy"
+	"github.com/planxnx/ethereum-wallet-generator/pipeline"
+	"github.com/planxnx/ethereum-wallet-generator/seeds"
+	"github.com/planxnx/ethereum-wallet-generator/wallets"
+)
+
+// runBench measures the scan pipeline throughput on random mnemonics.
+func runBench(args []string) {
+	fs := flag.NewFlagSet("bench", flag.ExitOnError)
+	duration := fs.Duration("duration", 10*time.Second, "how long to run the benchmark")
+	depth := fs.Int("depth", 1, "number of addresses to derive per mnemonic")
+	concurrency := fs.Int("c", 1, "set concurrency value (number of derivation workers)")
+	deterministic := fs.String("deterministic", "", "INSECURE, for benchmark comparisons only: derive the same mnemonics on every run from this seed")
+	parseFlags(fs, args)
+
+	var random io.Reader = rand.Reader
+	if *deterministic != "" {
+		warnDeterministic()
+		r, err := entropy.Deterministic(*deterministic, nil)
+		if err != nil {
+			fatal("Failed to open entropy source", "err", err)
+		}
+		random = r
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), *duration)
+	defer cancel()
+	seedCount, addresses, elapsed := measureThroughput(ctx, random, *concurrency, max(*depth, 1), 100)
+
+	fmt.Fprintf(os.Stderr, "Derived %d addresses from %d mnemonics in %v with %d workers\n", addresses, seedCount, elapsed.Round(time.Millisecond), *concurrency)
+	fmt.Printf("%.1f addr/s\n", float64(addresses)/elapsed.Seconds())
+}
+
+// measureThroughput derives mnemonics of the entropy of random with the scan pipeline until
+// ctx is done and returns the number of seeds and addresses derived.
+func measureThroughput(ctx context.Context, random io.Reader, workers, depth, cpuPercent int) (seedCount, addresses int, elapsed time.Duration) {
+	seedCh := make(chan seeds.Seed, seeds.DefaultReadAhead)
+	go func() {
+		defer close(seedCh)
+		for line := 1; ; line++ {
+			phrase, err := wallets.NewMnemonicFrom(random, wallets.DefaultMnemonicBits)
+			if err != nil {
+				slog.Error("Failed to generate mnemonic", "err", err)
+				return
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case seedCh <- seeds.Seed{Line: line, Phrase: phrase}:
+			}
+		}
+	}()
+
+	start := time.Now()
+	pipeline.New(pipeline.Config{
+		Workers:          workers,
+		Depth:            depth,
+		BasePath:         wallets.DefaultBaseDerivationPath,
+		CPUPercent:       cpuPercent,
+		AddressValidator: func(string) bool { return false },
+		OnProgress: func(processed int) {
+			seedCount++
+			addresses += processed
+		},
+	}).Run(ctx, seedCh)
+	return seedCount, addresses, time.Since(start)
+}
@@ -11,6 +11,7 @@ import (
 	"crypto/rand"
 	"crypto/sha256"
 	"crypto/sha512"
+	"io"
 	"math/big"
 	"strings"
 
@@ -32,12 +33,17 @@ var (
 //
 // bitSize has to be a multiple 32 and be within the inclusive range of {128, 256}.
 func NewEntropy(bitSize int) ([]byte, error) {
+	return NewEntropyFrom(rand.Reader, bitSize)
+}
+
+// NewEntropyFrom is NewEntropy reading the random bytes from random.
+func NewEntropyFrom(random io.Reader, bitSize int) ([]byte, error) {
 	if err := validateEntropyBitSize(bitSize); err != nil {
 		return nil, errors.WithStack(err)
 	}
 
 	entropy := make([]byte, bitSize/8)
-	if _, err := rand.Read(entropy); err != nil {
+	if _, err := io.ReadFull(random, entropy); err != nil {
 		return nil, errors.WithStack(err)
 	}
 
@@ -85,6 +91,36 @@ func NewMnemonic(entropy []byte) (string, error) {
 	return strings.Join(words, " "), nil
 }
 
+// EntropyFromMnemonic returns the entropy encoded by mnemonic, after checking its words
+// and checksum.
+func EntropyFromMnemonic(mnemonic string) ([]byte, error) {
+	fields := strings.Fields(mnemonic)
+	if len(fields)%3 != 0 || len(fields) < 12 || len(fields) > 24 {
+		return nil, errors.New("Invalid mnemonic length")
+	}
+
+	// Rebuild the entropy and checksum bits from the 11 bits of each word.
+	entropyInt := new(big.Int)
+	for _, w := range fields {
+		index, ok := wordIndexes[w]
+		if !ok {
+			return nil, errors.Errorf("Word `%s` not found in the wordlist", w)
+		}
+		entropyInt.Mul(entropyInt, shift11BitsMask)
+		entropyInt.Or(entropyInt, big.NewInt(int64(index)))
+	}
+
+	checksumBitLength := len(fields) * bitsChunkSize / 33
+	checksum := new(big.Int).And(entropyInt, big.NewInt(1<<checksumBitLength-1)).Uint64()
+	entropyInt.Rsh(entropyInt, uint(checksumBitLength))
+	entropy := entropyInt.FillBytes(make([]byte, checksumBitLength*4))
+
+	if uint64(computeChecksum(entropy)[0]>>(8-checksumBitLength)) != checksum {
+		return nil, errors.New("Checksum incorrect")
+	}
+	return entropy, nil
+}
+
 // NewSeed creates a hashed seed output given a provided string and password.
 // No checking is performed to validate that the string provided is a valid mnemonic.
 func NewSeed(mnemonic, password string) []byte {
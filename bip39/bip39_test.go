@@ -83,7 +83,65 @@ func TestGenerateFromEntropy(t *testing.T) {
 				}
 
 				assert.Equal(t, testSpec.expectedMnemonic, actualMnemonic)
+
+				entropy, err := EntropyFromMnemonic(actualMnemonic)
+				assert.NoError(t, err)
+				assert.Equal(t, testSpec.entropy, entropy)
 			}
 		})
 	}
 }
+
+func TestEntropyFromMnemonicInvalid(t *testing.T) {
+	for _, mnemonic := range []string{
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon",
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abou",
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about",
+	} {
+		_, err := EntropyFromMnemonic(mnemonic)
+		assert.Error(t, err, mnemonic)
+	}
+}
+
+func TestWeakness(t *testing.T) {
+	for mnemonic, expected := range map[string]string{
+		"test test test test test test test test test test test junk":                                 "development mnemonic",
+		"Correct  Horse battery staple":                                                               "published brainwallet",
+		"zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo when":                    "test vector",
+		"abandon ability able about above absent absorb abstract absurd abuse access acid":            "sequential wordlist words",
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon zoo": "repeated word",
+		"letter advice cage absurd amount doctor acoustic avoid letter advice cage absurd amount doctor acoustic avoid letter advice cage absurd amount doctor acoustic bless": "test vector",
+		"hedgehog pause oyster lucky zero tornado whip juice bright reveal canal expose":                                                                                       "",
+		"not a mnemonic": "",
+	} {
+		assert.Equal(t, expected, Weakness(mnemonic), mnemonic)
+	}
+
+	// entropy of a single repeated byte
+	mnemonic, err := NewMnemonic([]byte{0x42, 0x42, 0x42, 0x42, 0x42, 0x42, 0x42, 0x42, 0x42, 0x42, 0x42, 0x42, 0x42, 0x42, 0x42, 0x42})
+	assert.NoError(t, err)
+	assert.Equal(t, "repeated entropy byte", Weakness(mnemonic))
+}
+
+func TestQuality(t *testing.T) {
+	random, err := NewMnemonic([]byte{0x7f, 0x3a, 0xc1, 0x58, 0x9e, 0x02, 0xd4, 0x6b, 0xb7, 0x15, 0xe8, 0x4d, 0x90, 0x23, 0x6c, 0xfa})
+	assert.NoError(t, err)
+	q, entropy := Analyze(random)
+	assert.Equal(t, 128, q.Bits)
+	assert.Empty(t, q.Flags())
+
+	q, _ = Analyze("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about")
+	assert.Equal(t, 10, q.Repeated)
+	assert.Equal(t, []string{"repeated words", "clustered words", "biased entropy bits"}, q.Flags())
+
+	// one flipped entropy bit away from the first phrase
+	near := append([]byte(nil), entropy...)
+	near[3] ^= 0x10
+	mnemonic, err := NewMnemonic(near)
+	assert.NoError(t, err)
+	_, other := Analyze(mnemonic)
+	assert.Equal(t, 1, Distance(entropy, other))
+	assert.True(t, TooClose(Distance(entropy, other), 128))
+	assert.False(t, TooClose(64, 128))
+	assert.Equal(t, -1, Distance(entropy, make([]byte, 32)))
+}
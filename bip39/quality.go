@@ -0,0 +1,101 @@
+package bip39
+
+import (
+	"encoding/binary"
+	"math"
+	"math/bits"
+	"slices"
+	"strings"
+)
+
+// clusterWindow is the width of the wordlist window Quality.Cluster counts words in, an
+// eighth of the wordlist.
+const clusterWindow = 256
+
+// Quality are the signals of a mnemonic hinting that its entropy came from a broken random
+// generator. They are computed independently of Weakness, which only knows specific phrases.
+type Quality struct {
+	Words int `json:"words"`
+	// Repeated is the number of words repeating an earlier word of the phrase.
+	Repeated int `json:"repeated_words"`
+	// Cluster is the largest number of words falling within an eighth of the wordlist.
+	Cluster int `json:"clustered_words"`
+	// Bits is the length of the entropy and Ones its number of set bits, both zero when the
+	// phrase isn't a valid mnemonic.
+	Bits int `json:"entropy_bits,omitempty"`
+	Ones int `json:"entropy_ones"`
+}
+
+// Analyze returns the quality signals of mnemonic, and its entropy when it is a valid mnemonic
+// for comparisons with others, see Distance. The caller wipes the entropy.
+func Analyze(mnemonic string) (Quality, []byte) {
+	fields := strings.Fields(strings.ToLower(mnemonic))
+	q := Quality{Words: len(fields)}
+	seen := make(map[string]bool, len(fields))
+	indexes := make([]int, 0, len(fields))
+	for _, w := range fields {
+		if seen[w] {
+			q.Repeated++
+		}
+		seen[w] = true
+		if index, ok := wordIndexes[w]; ok {
+			indexes = append(indexes, index)
+		}
+	}
+	slices.Sort(indexes)
+	for i, j := 0, 0; i < len(indexes); i++ {
+		for indexes[i]-indexes[j] >= clusterWindow {
+			j++
+		}
+		q.Cluster = max(q.Cluster, i-j+1)
+	}
+
+	entropy, err := EntropyFromMnemonic(strings.Join(fields, " "))
+	if err != nil {
+		return q, nil
+	}
+	q.Bits = len(entropy) * 8
+	for _, b := range entropy {
+		q.Ones += bits.OnesCount8(b)
+	}
+	return q, entropy
+}
+
+// Flags returns the signals of q unlikely from a random generator, each one expected from
+// fewer than about one random phrase in ten thousand.
+func (q Quality) Flags() []string {
+	var flags []string
+	if q.Words >= 12 && q.Repeated*4 >= q.Words {
+		flags = append(flags, "repeated words")
+	}
+	if q.Words >= 12 && q.Cluster*4 >= q.Words*3 {
+		flags = append(flags, "clustered words")
+	}
+	// beyond 4 standard deviations of a binomial of p 0.5
+	if q.Bits > 0 && math.Abs(float64(q.Ones)-float64(q.Bits)/2) > 2*math.Sqrt(float64(q.Bits)) {
+		flags = append(flags, "biased entropy bits")
+	}
+	return flags
+}
+
+// Distance returns the number of differing bits of two entropies of the same length, -1 if
+// their lengths differ.
+func Distance(a, b []byte) int {
+	if len(a) != len(b) {
+		return -1
+	}
+	d, i := 0, 0
+	for ; i+8 <= len(a); i += 8 {
+		d += bits.OnesCount64(binary.LittleEndian.Uint64(a[i:]) ^ binary.LittleEndian.Uint64(b[i:]))
+	}
+	for ; i < len(a); i++ {
+		d += bits.OnesCount8(a[i] ^ b[i])
+	}
+	return d
+}
+
+// TooClose reports whether entropies of bits bits Distance apart are too close to come from a
+// random generator, a fifth of their bits differing where half of them do on average.
+func TooClose(distance, bits int) bool {
+	return distance >= 0 && distance*5 <= bits
+}
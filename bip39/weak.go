@@ -0,0 +1,74 @@
+package bip39
+
+import (
+	_ "embed"
+	"strings"
+
+	"github.com/planxnx/ethereum-wallet-generator/internal/wipe"
+)
+
+//go:embed weak.txt
+var weakPhrases string
+
+// knownWeak maps the bundled weak phrases to the reason they are known for.
+var knownWeak = make(map[string]string)
+
+func init() {
+	for _, line := range strings.Split(weakPhrases, "\n") {
+		reason, phrase, ok := strings.Cut(line, "\t")
+		if !ok || strings.HasPrefix(line, "#") {
+			continue
+		}
+		knownWeak[phrase] = reason
+	}
+}
+
+// Weakness returns why mnemonic is a weak phrase anyone may have derived the keys of, or
+// an empty string. It recognizes the bundled test vectors, development tool mnemonics and
+// published brainwallets, the mnemonics whose words but the checksum one are all the same
+// or follow each other in the wordlist at a fixed step, and the ones of entropy repeating a
+// single byte.
+func Weakness(mnemonic string) string {
+	fields := strings.Fields(strings.ToLower(mnemonic))
+	if reason, ok := knownWeak[strings.Join(fields, " ")]; ok {
+		return reason
+	}
+	if len(fields) < 12 {
+		return ""
+	}
+
+	// the last word carries the checksum, only the others were picked
+	indexes := make([]int, len(fields)-1)
+	for i, w := range fields[:len(fields)-1] {
+		index, ok := wordIndexes[w]
+		if !ok {
+			return ""
+		}
+		indexes[i] = index
+	}
+	step := indexes[1] - indexes[0]
+	sequential := true
+	for i := 2; i < len(indexes) && sequential; i++ {
+		sequential = indexes[i]-indexes[i-1] == step
+	}
+	switch {
+	case sequential && step == 0:
+		return "repeated word"
+	case sequential:
+		return "sequential wordlist words"
+	}
+
+	entropy, err := EntropyFromMnemonic(strings.Join(fields, " "))
+	if err != nil {
+		return ""
+	}
+	repeated := true
+	for _, b := range entropy[1:] {
+		repeated = repeated && b == entropy[0]
+	}
+	wipe.Bytes(entropy)
+	if repeated {
+		return "repeated entropy byte"
+	}
+	return ""
+}
@@ -16,6 +16,9 @@ var words string
 // https://raw.githubusercontent.com/bitcoin/bips/master/bip-0039/english.txt
 var (
 	Words = strings.Split(strings.TrimSpace(words), "\n")
+
+	// wordIndexes maps the words to their index in Words.
+	wordIndexes = make(map[string]int, len(Words))
 )
 
 func init() {
@@ -27,4 +30,13 @@ func init() {
 	if checksum != 0xc1dbd296 {
 		panic(errors.Errorf("wordlist checksum mismatch: expected %x, got %x", 0xc1dbd296, checksum))
 	}
+	for i, w := range Words {
+		wordIndexes[w] = i
+	}
+}
+
+// IsWord reports whether w is a word of the list.
+func IsWord(w string) bool {
+	_, ok := wordIndexes[w]
+	return ok
 }
@@ -0,0 +1,63 @@
+package coins
+
+import (
+	"crypto/ecdsa"
+
+	"github.com/ethereum/go-ethereum/accounts"
+
+	"github.com/planxnx/ethereum-wallet-generator/wallets"
+)
+
+// avalancheXPPath is the base path of the Avalanche X-chain and P-chain keys, which share
+// their addresses under a chain prefix.
+var avalancheXPPath = accounts.DerivationPath{0x80000000 + 44, 0x80000000 + 9000, 0x80000000, 0}
+
+// avalanche derives the C-chain EVM wallets, with the X-chain and P-chain addresses of the
+// same address index at m/44'/9000'/0'/0 as the Avalanche wallets do.
+type avalanche struct{}
+
+func (avalanche) Name() string                      { return "avax" }
+func (avalanche) AddressType() string               { return "c" }
+func (avalanche) BasePath() accounts.DerivationPath { return wallets.DefaultBaseDerivationPath }
+func (avalanche) Format() Format                    { return ETH.Format() }
+func (avalanche) EVM() bool                         { return true }
+
+func (avalanche) Path(basePath accounts.DerivationPath, index uint32) string {
+	return AppendIndex(basePath, index)
+}
+
+func (avalanche) NewDeriver(mnemonic, passphrase string, basePath accounts.DerivationPath) (Deriver, error) {
+	evm, err := newSecp256k1Deriver(mnemonic, passphrase, basePath, wallets.NewFromPrivatekey)
+	if err != nil {
+		return nil, err
+	}
+	xp, err := newSecp256k1Deriver(mnemonic, passphrase, avalancheXPPath, func(key *ecdsa.PrivateKey) (*wallets.Wallet, error) {
+		return cosmos{hrp: "avax"}.wallet(key)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &avalancheDeriver{evm: evm, xp: xp}, nil
+}
+
+type avalancheDeriver struct {
+	evm, xp *secp256k1Deriver
+}
+
+func (d *avalancheDeriver) Derive(index uint32) (*wallets.Wallet, error) {
+	w, err := d.evm.Derive(index)
+	if err != nil {
+		return nil, err
+	}
+	xp, err := d.xp.Derive(index)
+	if err != nil {
+		return nil, err
+	}
+	w.AvaxXAddress, w.AvaxPAddress = "X-"+xp.Address, "P-"+xp.Address
+	return w, nil
+}
+
+func (d *avalancheDeriver) Wipe() {
+	d.evm.Wipe()
+	d.xp.Wipe()
+}
@@ -0,0 +1,168 @@
+package coins
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"slices"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/base58"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+
+	"github.com/planxnx/ethereum-wallet-generator/internal/wipe"
+	"github.com/planxnx/ethereum-wallet-generator/wallets"
+)
+
+// Bitcoin address types, in the order of their BIP44 purposes 84, 49, 44 and 86.
+const (
+	P2WPKH     = "p2wpkh"
+	P2SHP2WPKH = "p2sh-p2wpkh"
+	P2PKH      = "p2pkh"
+	P2TR       = "p2tr"
+)
+
+var bitcoinTypes = []string{P2WPKH, P2SHP2WPKH, P2PKH, P2TR}
+
+// bitcoinPurposes are the BIP44 purposes of the address types, BIP84, 49, 44 and 86.
+var bitcoinPurposes = map[string]uint32{P2WPKH: 84, P2SHP2WPKH: 49, P2PKH: 44, P2TR: 86}
+
+var bitcoinParams = chaincfg.MainNetParams
+
+// base58CheckHashLength is the length of the 20 byte hash and 4 byte checksum following the
+// version byte of base58check addresses.
+const base58CheckHashLength = 24
+
+// Litecoin has no Taproot and Dogecoin no SegWit addresses.
+var (
+	litecoinTypes = []string{P2WPKH, P2SHP2WPKH, P2PKH}
+	dogecoinTypes = []string{P2PKH}
+)
+
+// litecoinParams and dogecoinParams only set what encodes their addresses and WIF keys.
+var (
+	litecoinParams = chaincfg.Params{
+		Name:             "litecoin",
+		PubKeyHashAddrID: 0x30,
+		ScriptHashAddrID: 0x32,
+		PrivateKeyID:     0xb0,
+		Bech32HRPSegwit:  "ltc",
+	}
+	dogecoinParams = chaincfg.Params{
+		Name:             "dogecoin",
+		PubKeyHashAddrID: 0x1e,
+		ScriptHashAddrID: 0x16,
+		PrivateKeyID:     0x9e,
+	}
+)
+
+// bitcoin derives the addresses of Bitcoin and the chains sharing its key and address
+// encodings, with their own network parameters and BIP44 coin type.
+type bitcoin struct {
+	name        string
+	params      *chaincfg.Params
+	coinType    uint32
+	addressType string
+}
+
+func newBitcoin(name string, params *chaincfg.Params, coinType uint32, addressType string) Coin {
+	return &bitcoin{name: name, params: params, coinType: coinType, addressType: addressType}
+}
+
+func (c *bitcoin) Name() string        { return c.name }
+func (c *bitcoin) AddressType() string { return c.addressType }
+
+func (c *bitcoin) BasePath() accounts.DerivationPath {
+	return accounts.DerivationPath{
+		0x80000000 + bitcoinPurposes[c.addressType],
+		0x80000000 + c.coinType,
+		0x80000000,
+		0,
+	}
+}
+
+func (c *bitcoin) Path(basePath accounts.DerivationPath, index uint32) string {
+	return AppendIndex(basePath, index)
+}
+
+func (c *bitcoin) Format() Format {
+	switch c.addressType {
+	case P2PKH:
+		return Format{Lead: base58Lead([]byte{c.params.PubKeyHashAddrID}, base58CheckHashLength), Charset: Base58Charset}
+	case P2SHP2WPKH:
+		return Format{Lead: base58Lead([]byte{c.params.ScriptHashAddrID}, base58CheckHashLength), Charset: Base58Charset}
+	case P2TR:
+		return Format{Lead: c.params.Bech32HRPSegwit + "1p", Charset: Bech32Charset}
+	default:
+		return Format{Lead: c.params.Bech32HRPSegwit + "1q", Charset: Bech32Charset}
+	}
+}
+
+func (c *bitcoin) NewDeriver(mnemonic, passphrase string, basePath accounts.DerivationPath) (Deriver, error) {
+	return NewSecp256k1Deriver(mnemonic, passphrase, basePath, c.wallet)
+}
+
+// wallet returns the wallet of key, its private key in the compressed WIF wallets import.
+func (c *bitcoin) wallet(key *ecdsa.PrivateKey) (*wallets.Wallet, error) {
+	raw := crypto.FromECDSA(key)
+	defer wipe.Bytes(raw)
+	priv, pub := btcec.PrivKeyFromBytes(raw)
+	defer priv.Zero()
+	address, err := c.address(pub)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to encode %s address", c.addressType)
+	}
+	wif, err := btcutil.NewWIF(priv, c.params, true)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode WIF")
+	}
+	compressed := hex.EncodeToString(pub.SerializeCompressed())
+	return &wallets.Wallet{
+		Address:             address,
+		ChecksumAddress:     address,
+		PrivateKey:          wif.String(),
+		PublicKey:           compressed,
+		CompressedPublicKey: compressed,
+	}, nil
+}
+
+func (c *bitcoin) address(pub *btcec.PublicKey) (string, error) {
+	hash := btcutil.Hash160(pub.SerializeCompressed())
+	var (
+		address btcutil.Address
+		err     error
+	)
+	switch c.addressType {
+	case P2PKH:
+		address, err = btcutil.NewAddressPubKeyHash(hash, c.params)
+	case P2SHP2WPKH:
+		// the redeem script is the P2WPKH witness program, OP_0 <20 byte hash>
+		script := append([]byte{txscript.OP_0, txscript.OP_DATA_20}, hash...)
+		address, err = btcutil.NewAddressScriptHash(script, c.params)
+	case P2TR:
+		address, err = btcutil.NewAddressTaproot(schnorr.SerializePubKey(txscript.ComputeTaprootKeyNoScript(pub)), c.params)
+	default:
+		address, err = btcutil.NewAddressWitnessPubKeyHash(hash, c.params)
+	}
+	if err != nil {
+		return "", err
+	}
+	return address.EncodeAddress(), nil
+}
+
+// base58Lead is the first character of the base58 addresses made of prefix followed by n
+// bytes, when every address of it shares one.
+func base58Lead(prefix []byte, n int) string {
+	low := base58.Encode(append(slices.Clone(prefix), make([]byte, n)...))
+	high := base58.Encode(append(slices.Clone(prefix), bytes.Repeat([]byte{0xff}, n)...))
+	if low[0] != high[0] {
+		return ""
+	}
+	return low[:1]
+}
@@ -0,0 +1,186 @@
+package coins
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"encoding/hex"
+
+	"filippo.io/edwards25519"
+	"github.com/btcsuite/btcd/btcutil/bech32"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/pbkdf2"
+
+	"github.com/planxnx/ethereum-wallet-generator/bip39"
+	"github.com/planxnx/ethereum-wallet-generator/internal/wipe"
+	"github.com/planxnx/ethereum-wallet-generator/wallets"
+)
+
+// cardanoBaseHeader is the header byte of the mainnet base addresses, a payment key hash
+// followed by a stake key hash.
+const cardanoBaseHeader = 0x01
+
+// cardano derives the Shelley base addresses of the CIP-1852 accounts, the payment key at
+// m/1852'/1815'/0'/0/index and the stake key at m/1852'/1815'/0'/2/0, with the Icarus master
+// key of Yoroi, Eternl, Lace and Daedalus.
+type cardano struct{}
+
+func (cardano) Name() string        { return "ada" }
+func (cardano) AddressType() string { return "base" }
+
+func (cardano) BasePath() accounts.DerivationPath {
+	return accounts.DerivationPath{hardened + 1852, hardened + 1815, hardened, 0}
+}
+
+func (cardano) Path(basePath accounts.DerivationPath, index uint32) string {
+	return AppendIndex(basePath, index)
+}
+
+func (cardano) Format() Format { return Format{Lead: "addr1q", Charset: Bech32Charset} }
+
+func (cardano) NewDeriver(mnemonic, passphrase string, basePath accounts.DerivationPath) (Deriver, error) {
+	if len(basePath) == 0 {
+		return nil, errors.New("cardano accounts need a base path, eg. m/1852'/1815'/0'/0")
+	}
+	master, err := newCardanoMaster(mnemonic, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	defer master.wipe()
+	base := master.derive(basePath)
+	// the stake key is the first one of the staking role 2 of the account
+	stakePath := append(append(accounts.DerivationPath{}, basePath[:len(basePath)-1]...), 2, 0)
+	stake := master.derive(stakePath)
+	defer stake.wipe()
+	return &cardanoDeriver{base: base, basePath: basePath, stake: blake2bSum(28, stake.public())}, nil
+}
+
+type cardanoDeriver struct {
+	base     *cardanoKey
+	basePath accounts.DerivationPath
+	stake    []byte
+}
+
+func (d *cardanoDeriver) Derive(index uint32) (*wallets.Wallet, error) {
+	key := d.base.child(index)
+	defer key.wipe()
+	pub := key.public()
+	payload := append(append([]byte{cardanoBaseHeader}, blake2bSum(28, pub)...), d.stake...)
+	address, err := bech32.EncodeFromBase256("addr", payload)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode bech32 address")
+	}
+	extended := append(key.key[:], key.chainCode[:]...)
+	defer wipe.Bytes(extended)
+	xsk, err := bech32.EncodeFromBase256("addr_xsk", extended)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode bech32 private key")
+	}
+	return &wallets.Wallet{
+		Address:         address,
+		ChecksumAddress: address,
+		// the extended signing key, as cardano-address and cardano-cli import it
+		PrivateKey: xsk,
+		PublicKey:  hex.EncodeToString(pub),
+		HDPath:     AppendIndex(d.basePath, index),
+	}, nil
+}
+
+func (d *cardanoDeriver) Wipe() { d.base.wipe() }
+
+// cardanoKey is an ed25519-bip32 extended key, the scalar kL and the nonce kR, and its chain
+// code.
+type cardanoKey struct {
+	key       [64]byte
+	chainCode [32]byte
+}
+
+// newCardanoMaster returns the Icarus master key of a mnemonic, stretching its entropy with
+// the passphrase.
+func newCardanoMaster(mnemonic, passphrase string) (*cardanoKey, error) {
+	entropy, err := bip39.EntropyFromMnemonic(mnemonic)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid mnemonic")
+	}
+	defer wipe.Bytes(entropy)
+	seed := pbkdf2.Key([]byte(passphrase), entropy, 4096, 96, sha512.New)
+	defer wipe.Bytes(seed)
+	seed[0] &= 0b1111_1000
+	seed[31] &= 0b0001_1111
+	seed[31] |= 0b0100_0000
+	k := &cardanoKey{}
+	copy(k.key[:], seed[:64])
+	copy(k.chainCode[:], seed[64:])
+	return k, nil
+}
+
+// derive returns the key at path under k, wiping the intermediate keys. path must not be
+// empty, the key returned is another one than k.
+func (k *cardanoKey) derive(path accounts.DerivationPath) *cardanoKey {
+	for i, index := range path {
+		child := k.child(index)
+		if i > 0 {
+			k.wipe()
+		}
+		k = child
+	}
+	return k
+}
+
+// wipe zeroes the key and chain code of k.
+func (k *cardanoKey) wipe() {
+	wipe.Bytes(k.key[:])
+	wipe.Bytes(k.chainCode[:])
+}
+
+// child returns the child key of index, hashing the private key for a hardened index and
+// the public key otherwise.
+func (k *cardanoKey) child(index uint32) *cardanoKey {
+	var data []byte
+	if index >= hardened {
+		data = append([]byte{0}, k.key[:]...)
+	} else {
+		data = append([]byte{2}, k.public()...)
+	}
+	data = binary.LittleEndian.AppendUint32(data, index)
+	z := hmacSHA512(k.chainCode[:], data)
+	data[0]++
+	i := hmacSHA512(k.chainCode[:], data)
+	defer wipe.Bytes(data)
+	defer wipe.Bytes(z)
+	defer wipe.Bytes(i)
+
+	child := &cardanoKey{}
+	// kL + 8 * the first 28 bytes of z, and kR + the last 32 bytes of z mod 2^256
+	var carry uint16
+	for j := 0; j < 32; j++ {
+		sum := uint16(k.key[j]) + carry
+		if j < 28 {
+			sum += uint16(z[j]) << 3
+		}
+		child.key[j], carry = byte(sum), sum>>8
+	}
+	carry = 0
+	for j := 32; j < 64; j++ {
+		sum := uint16(k.key[j]) + uint16(z[j]) + carry
+		child.key[j], carry = byte(sum), sum>>8
+	}
+	copy(child.chainCode[:], i[32:])
+	return child
+}
+
+// public returns the ed25519 public key of kL, used as the scalar itself.
+func (k *cardanoKey) public() []byte {
+	var wide [64]byte
+	defer wipe.Bytes(wide[:])
+	copy(wide[:], k.key[:32])
+	s, _ := edwards25519.NewScalar().SetUniformBytes(wide[:])
+	return new(edwards25519.Point).ScalarBaseMult(s).Bytes()
+}
+
+func hmacSHA512(key, data []byte) []byte {
+	h := hmac.New(sha512.New, key)
+	h.Write(data)
+	return h.Sum(nil)
+}
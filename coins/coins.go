@@ -0,0 +1,225 @@
+// Package coins derives the addresses of other chains than Ethereum from the same BIP39
+// mnemonics, so a recovery job scans every chain a phrase may hold funds on.
+package coins
+
+import (
+	"crypto/ecdsa"
+	"plugin"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/pkg/errors"
+
+	"github.com/planxnx/ethereum-wallet-generator/filter"
+	"github.com/planxnx/ethereum-wallet-generator/wallets"
+)
+
+// Coin is an address type of a chain, derived from BIP39 mnemonics.
+type Coin interface {
+	// Name is the name of the chain, eg. btc.
+	Name() string
+	// AddressType is the kind of address derived, eg. p2wpkh.
+	AddressType() string
+	// BasePath is the default base derivation path, the address index is appended to it.
+	BasePath() accounts.DerivationPath
+	// Path returns the hd path of the wallet of an address index under basePath.
+	Path(basePath accounts.DerivationPath, index uint32) string
+	// Format describes the addresses, to check the filters against.
+	Format() Format
+	// NewDeriver returns the deriver of the wallets of a mnemonic under basePath.
+	NewDeriver(mnemonic, passphrase string, basePath accounts.DerivationPath) (Deriver, error)
+}
+
+// Deriver derives the wallets of a single mnemonic.
+type Deriver interface {
+	// Derive returns the wallet at the address index appended to the base path. Its Address
+	// is the address of the chain, PrivateKey is encoded as the wallets of the chain import it.
+	Derive(index uint32) (*wallets.Wallet, error)
+}
+
+// Wipe zeroes the keys a deriver caches, once it derived its wallets. The derivers caching
+// any have a Wipe method.
+func Wipe(d Deriver) {
+	if w, ok := d.(interface{ Wipe() }); ok {
+		w.Wipe()
+	}
+}
+
+// NewSecp256k1Deriver returns the deriver of the BIP32 secp256k1 keys of a mnemonic under
+// basePath, which wallet turns into the wallets of a chain. Chains whose addresses encode
+// secp256k1 keys only implement wallet.
+func NewSecp256k1Deriver(mnemonic, passphrase string, basePath accounts.DerivationPath, wallet func(key *ecdsa.PrivateKey) (*wallets.Wallet, error)) (Deriver, error) {
+	d, err := newSecp256k1Deriver(mnemonic, passphrase, basePath, wallet)
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// AppendIndex returns the path of an address index appended to basePath, the Path of the
+// coins deriving BIP32 keys.
+func AppendIndex(basePath accounts.DerivationPath, index uint32) string {
+	return append(append(accounts.DerivationPath{}, basePath...), index).String()
+}
+
+// Format is the shape of the addresses of a coin.
+type Format struct {
+	// Lead is the fixed start of every address, eg. 0x or bc1q, empty if there is none.
+	Lead string
+	// Charset has every character following the lead.
+	Charset string
+}
+
+// Charsets of the address encodings.
+const (
+	HexCharset    = "0123456789abcdef"
+	Base58Charset = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+	Bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+	Base32Charset = "abcdefghijklmnopqrstuvwxyz234567"
+	// RippleCharset is the base58 alphabet of XRP, in the order of its digits.
+	RippleCharset = "rpshnaf39wBUDNEGHJKLM4PQRST7VWXYZ2bcdeCg65jkm8oFqi1tuvAxyz"
+)
+
+// ETH is the default coin, Ethereum and the EVM chains sharing its addresses.
+var ETH Coin = ethereum{}
+
+// EVM reports whether coin derives the keys and addresses of Ethereum, which keystores,
+// proofs of control and validators apply to. Those coins have an EVM method returning true.
+func EVM(coin Coin) bool {
+	evm, ok := coin.(interface{ EVM() bool })
+	return ok && evm.EVM()
+}
+
+// Family is a chain and the constructor of its address types, the first type is the default.
+// Open replaces New for the families taking any address type, checking it instead.
+type Family struct {
+	Types []string
+	New   func(addressType string) Coin
+	Open  func(addressType string) (Coin, error)
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Family)
+)
+
+// Register makes the address types of a chain available to Lookup under the given name.
+// It is meant to be called from init functions, including the ones of plugins, and panics
+// if the name is already registered or the family has no address type.
+func Register(name string, f Family) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, ok := registry[name]; ok {
+		panic("coins: coin " + name + " registered twice")
+	}
+	if len(f.Types) == 0 || (f.New == nil && f.Open == nil) {
+		panic("coins: coin " + name + " registered without address types")
+	}
+	registry[name] = f
+}
+
+// LoadPlugin opens a Go plugin, whose init functions are expected to Register its coins.
+// The plugin must be built with the same Go version and module versions as this program.
+func LoadPlugin(path string) error {
+	if _, err := plugin.Open(path); err != nil {
+		return errors.Wrapf(err, "failed to load coin plugin %s", path)
+	}
+	return nil
+}
+
+func init() {
+	Register("eth", Family{Types: []string{"eth"}, New: func(string) Coin { return ETH }})
+	Register("btc", Family{Types: bitcoinTypes, New: func(t string) Coin { return newBitcoin("btc", &bitcoinParams, 0, t) }})
+	Register("ltc", Family{Types: litecoinTypes, New: func(t string) Coin { return newBitcoin("ltc", &litecoinParams, 2, t) }})
+	Register("doge", Family{Types: dogecoinTypes, New: func(t string) Coin { return newBitcoin("doge", &dogecoinParams, 3, t) }})
+	Register("trx", Family{Types: []string{"trx"}, New: func(string) Coin { return tron{} }})
+	Register("sol", Family{Types: []string{"sol"}, New: func(string) Coin { return solana{} }})
+	Register("avax", Family{Types: []string{"c"}, New: func(string) Coin { return avalanche{} }})
+	Register("fil", Family{Types: []string{"f1"}, New: func(string) Coin { return filecoin{} }})
+	Register("xrp", Family{Types: []string{"xrp"}, New: func(string) Coin { return ripple{} }})
+	Register("ada", Family{Types: []string{"base"}, New: func(string) Coin { return cardano{} }})
+	Register("strk", Family{Types: starknetTypes, New: func(t string) Coin { return starknet{account: t} }})
+	// the address types of cosmos are the bech32 prefixes of the chains
+	Register("cosmos", Family{Types: cosmosHRPs, Open: newCosmos})
+	Register("dot", Family{Types: substrateSchemes, New: func(t string) Coin { return newSubstrate("dot", t, 0) }})
+	Register("ksm", Family{Types: substrateSchemes, New: func(t string) Coin { return newSubstrate("ksm", t, 2) }})
+	// the address types of substrate are scheme:network, eg. sr25519:42
+	Register("substrate", Family{Types: []string{SR25519 + ":42", ED25519 + ":42"}, Open: newSubstrateNetwork})
+}
+
+// Names returns the names of the registered chains, sorted.
+func Names() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// AddressTypes returns the address types of a chain, the default one first.
+func AddressTypes(name string) []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	return registry[name].Types
+}
+
+// Lookup returns the coin of a chain name and address type, an empty address type selects
+// the default one of the chain.
+func Lookup(name, addressType string) (Coin, error) {
+	registryMu.RLock()
+	f, ok := registry[name]
+	registryMu.RUnlock()
+	if !ok {
+		return nil, errors.Errorf("unknown coin %q, must be one of %v", name, Names())
+	}
+	if addressType == "" {
+		addressType = f.Types[0]
+	}
+	if f.Open != nil {
+		return f.Open(addressType)
+	}
+	for _, t := range f.Types {
+		if t == addressType {
+			return f.New(t), nil
+		}
+	}
+	return nil, errors.Errorf("unknown %s address type %q, must be one of %v", name, addressType, f.Types)
+}
+
+// ApplyFilters checks that the filters can match the addresses of coin and returns them
+// with its lead, so that a prefix lacking the lead is completed with it. The filters of
+// Ethereum addresses are returned unchanged.
+func ApplyFilters(coin Coin, cfg filter.Config) (filter.Config, error) {
+	if EVM(coin) {
+		return cfg, nil
+	}
+	f := coin.Format()
+	cfg.Coin, cfg.Lead = coin.Name(), f.Lead
+	if len(cfg.Validators) > 0 {
+		return cfg, errors.Errorf("validators only apply to Ethereum addresses, not %s ones", coin.Name())
+	}
+	prefix := strings.TrimPrefix(cfg.FullPrefix(), f.Lead)
+	check := func(name, value string) error {
+		if i := strings.IndexFunc(value, func(r rune) bool { return !strings.ContainsRune(f.Charset, r) }); i >= 0 {
+			return errors.Errorf("%s %q can't match %s %s addresses, %q isn't one of their characters %s", name, value, coin.Name(), coin.AddressType(), value[i:i+1], f.Charset)
+		}
+		return nil
+	}
+	if err := check("prefix", prefix); err != nil {
+		return cfg, err
+	}
+	if err := check("suffix", cfg.Suffix); err != nil {
+		return cfg, err
+	}
+	for _, c := range cfg.Contains {
+		if err := check("contains", c); err != nil {
+			return cfg, err
+		}
+	}
+	return cfg, nil
+}
@@ -0,0 +1,239 @@
+package coins
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc/stark-curve/fp"
+	"github.com/ethereum/go-ethereum/accounts"
+
+	"github.com/planxnx/ethereum-wallet-generator/filter"
+)
+
+const testMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+// the BIP44, 49, 84 and 86 test vectors of the first address of testMnemonic
+func TestDerive(t *testing.T) {
+	tests := []struct {
+		name, addressType, path, address, privateKey string
+	}{
+		{"eth", "", "m/44'/60'/0'/0/0", "0x9858effd232b4033e47d90003d41ec34ecaeda94", "1ab42cc412b618bdea3a599e3c9bae199ebf030895b039e9db1e30dafb12b727"},
+		{"btc", P2PKH, "m/44'/0'/0'/0/0", "1LqBGSKuX5yYUonjxT5qGfpUsXKYYWeabA", "L4p2b9VAf8k5aUahF1JCJUzZkgNEAqLfq8DDdQiyAprQAKSbu8hf"},
+		{"btc", P2SHP2WPKH, "m/49'/0'/0'/0/0", "37VucYSaXLCAsxYyAPfbSi9eh4iEcbShgf", "KyvHbRLNXfXaHuZb3QRaeqA5wovkjg4RuUpFGCxdH5UWc1Foih9o"},
+		{"btc", "", "m/84'/0'/0'/0/0", "bc1qcr8te4kr609gcawutmrza0j4xv80jy8z306fyu", "KyZpNDKnfs94vbrwhJneDi77V6jF64PWPF8x5cdJb8ifgg2DUc9d"},
+		{"btc", P2TR, "m/86'/0'/0'/0/0", "bc1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqkedrcr", "KyRv5iFPHG7iB5E4CqvMzH3WFJVhbfYK4VY7XAedd9Ys69mEsPLQ"},
+		{"ltc", P2PKH, "m/44'/2'/0'/0/0", "LUWPbpM43E2p7ZSh8cyTBEkvpHmr3cB8Ez", "T5b4RiWRs7XG8xZ2bCHBoJcn4JrpMTbGRFYXgoZHd7nD8izwqhMK"},
+		{"ltc", P2SHP2WPKH, "m/49'/2'/0'/0/0", "M7wtsL7wSHDBJVMWWhtQfTMSYYkyooAAXM", "T8xSEcthDYN4rNUu4eTqtZTDSvphsjgBNbKawBeCkUqZLZ9MH8Ff"},
+		{"ltc", "", "m/84'/2'/0'/0/0", "ltc1qjmxnz78nmc8nq77wuxh25n2es7rzm5c2rkk4wh", "T5ZCYhLqXu6EJKk2nhjvwsaLH357CisixhLGWpKXEiqWTUtzte6o"},
+		{"trx", "", "m/44'/195'/0'/0/0", "TUEZSdKsoDHQMeZwihtdoBiN46zxhGWYdH", "b5a4cea271ff424d7c31dc12a3e43e401df7a40d7412a15750f3f0b6b5449a28"},
+		{"sol", "", "m/44'/501'/0'/0'", "HAgk14JpMQLgt6rVgv7cBQFJWFto5Dqxi472uT3DKpqk", "27npWoNE4HfmLeQo1TyWcW7NEA28qnsnDK7kcttDQEWrCWnro83HMJ97rMmpvYYZRwDAvG4KRuB7hTBacvwD7bgi"},
+		{"cosmos", "", "m/44'/118'/0'/0/0", "cosmos19rl4cm2hmr8afy4kldpxz3fka4jguq0auqdal4", "c4a48e2fce1481cd3294b4490f6678090ea98d3d0e5cd984558ab0968741b104"},
+		{"cosmos", "osmo", "m/44'/118'/0'/0/0", "osmo19rl4cm2hmr8afy4kldpxz3fka4jguq0a5m7df8", "c4a48e2fce1481cd3294b4490f6678090ea98d3d0e5cd984558ab0968741b104"},
+		{"fil", "", "m/44'/461'/0'/0/0", "f1qode47ievxlxzk6z2viuovedabmn3tq6t57uqhq", "7b2254797065223a22736563703235366b31222c22507269766174654b6579223a223459434165635a7a54762b614748795264465863477978774f46345438633173374d6c4a654f562f6633593d227d"},
+		{"xrp", "", "m/44'/144'/0'/0/0", "rHsMGQEkVNJmpGWs8XUBoTBiAAbwxZN5v3", "0090802A50AA84EFB6CDB225F17C27616EA94048C179142FECF03F4712A07EA7A4"},
+		{"ada", "", "m/1852'/1815'/0'/0/0", "addr1qy8ac7qqy0vtulyl7wntmsxc6wex80gvcyjy33qffrhm7sh927ysx5sftuw0dlft05dz3c7revpf7jx0xnlcjz3g69mq4afdhv", "addr_xsk1zpwjausey9gx2k5jd09fen84utmwf9h049vq2zqe9c0557gwdafaupjjjy54z8gu4jcxvj70qjznlhqq2kj8e3kjcmfq2ynsypmqv55gsj8g4a32y7jhaxpzz46pe84vzlnwgh9l6m4xtg8qmnqrhdmhkggh7mvv"},
+		{"strk", "", "m/44'/9004'/0'/0/0", "0x0424e0202bce2d9abbee87ee8faa8fd416bcf02ad8fe18d66320f23b1b07fc02", "0x018a556cbd949d1e6d25ed391bf032559fb6055f321c3e02714f7a6268bff3d1"},
+		{"strk", Braavos, "m/44'/9004'/0'/0/0", "0x0040fa216b227ce6e2ad4b61dd519975b64e46fe6980d2b3270f60fa291c38c3", "0x001b8e16cdf31892c56c0370f0e4ca0da096ef4e0c81007b3ba10b11452f8971"},
+		{"doge", "", "m/44'/3'/0'/0/0", "DBus3bamQjgJULBJtYXpEzDWQRwF5iwxgC", "QPkeC1ZfHx3c9g7WTj9cQ8gnvk2iSAfAcbq1aVAWjNTwDAKfZUzx"},
+	}
+	for _, tt := range tests {
+		coin, err := Lookup(tt.name, tt.addressType)
+		if err != nil {
+			t.Fatal(err)
+		}
+		d, err := coin.NewDeriver(testMnemonic, "", coin.BasePath())
+		if err != nil {
+			t.Fatal(err)
+		}
+		w, err := d.Derive(0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if w.HDPath != tt.path || w.Address != tt.address || w.PrivateKey != tt.privateKey {
+			t.Errorf("%s %s = %s %s %s, want %s %s %s", tt.name, coin.AddressType(), w.HDPath, w.Address, w.PrivateKey, tt.path, tt.address, tt.privateKey)
+		}
+		if lead := coin.Format().Lead; !strings.HasPrefix(w.Address, lead) {
+			t.Errorf("%s %s address %s lacks the lead %q", tt.name, coin.AddressType(), w.Address, lead)
+		}
+	}
+}
+
+// the first steps of the SLIP-0010 ed25519 test vector 1
+func TestEd25519Derivation(t *testing.T) {
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	master := newEd25519Master(seed)
+	if got := hex.EncodeToString(master.key); got != "2b4be7f19ee27bbf30c667b642d5f4aa69fd169872f8fc3059c08ebae2eb19e7" {
+		t.Errorf("master key = %s", got)
+	}
+	child, err := master.derive(accounts.DerivationPath{hardened})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := hex.EncodeToString(child.key); got != "68e0fe46dfb67e368c75379acec591dad19df3cde26e63b93a8e704f1dade7a3" {
+		t.Errorf("m/0' key = %s", got)
+	}
+	if _, err := master.derive(accounts.DerivationPath{hardened, 1}); err == nil {
+		t.Error("derived a non hardened ed25519 child")
+	}
+}
+
+// the accounts of the Substrate development phrase, and the SS58 encodings of Alice
+func TestSubstrate(t *testing.T) {
+	const devPhrase = "bottom drive obey lake curtain smoke basket hold race lonely fit walk"
+	seed, err := substrateSeed(devPhrase, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		scheme, root, alice string
+	}{
+		{SR25519, "5DfhGyQdFobKM8NsWvEeAKk5EQQgYe9AydgJ7rMB6E1EqRzV", "5GrwvaEF5zXb26Fz9rcQpDWS57CtERHpNehXCPcNoHGKutQY"},
+		{ED25519, "5DFJF7tY4bpbpcKPJcBTQaKuCDEPCpiz8TRjpmLeTtweqmXL", "5FA9nQDVg267DEd8m1ZypXLBnvN7SFxYwV7ndqSYGiN9TTpu"},
+	}
+	for _, tt := range tests {
+		coin, err := Lookup("substrate", tt.scheme)
+		if err != nil {
+			t.Fatal(err)
+		}
+		d, err := coin.NewDeriver(devPhrase, "", coin.BasePath())
+		if err != nil {
+			t.Fatal(err)
+		}
+		w, err := d.Derive(0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if w.Address != tt.root || w.HDPath != "m" {
+			t.Errorf("%s root = %s %s, want %s", tt.scheme, w.Address, w.HDPath, tt.root)
+		}
+		aliceSeed, err := coin.(substrate).hardDerive(seed, substrateJunction("Alice"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		pub, err := coin.(substrate).publicKey(aliceSeed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := ss58Encode(pub, DefaultSS58Prefix); got != tt.alice {
+			t.Errorf("%s //Alice = %s, want %s", tt.scheme, got, tt.alice)
+		}
+		if tt.scheme == SR25519 {
+			if got := ss58Encode(pub, 0); got != "15oF4uVJwmo4TdGW7VfQxNLavjCXviqxT9S1MgbjMNHr6Sp5" {
+				t.Errorf("polkadot //Alice = %s", got)
+			}
+			if got := ss58Encode(pub, 2); got != "HNZata7iMYWmk5RvZRTiAsSDhV8366zq2YGb3tLH5Upf74F" {
+				t.Errorf("kusama //Alice = %s", got)
+			}
+		}
+	}
+	d, err := newSubstrate("dot", SR25519, 0).NewDeriver(devPhrase, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w, err := d.Derive(3); err != nil || w.HDPath != "//3" || w.Address[:1] != "1" {
+		t.Errorf("dot //3 = %+v, %v", w, err)
+	}
+	if _, err := Lookup("substrate", "sr25519:16384"); err == nil {
+		t.Error("looked up an out of range network prefix")
+	}
+}
+
+// the C-chain wallet of testMnemonic, with the X-chain and P-chain addresses of m/44'/9000'/0'/0/0
+func TestAvalanche(t *testing.T) {
+	d, err := avalanche{}.NewDeriver(testMnemonic, "", avalanche{}.BasePath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := d.Derive(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.Address != "0x9858effd232b4033e47d90003d41ec34ecaeda94" || w.HDPath != "m/44'/60'/0'/0/0" {
+		t.Errorf("avax C-chain = %s %s", w.Address, w.HDPath)
+	}
+	if w.AvaxXAddress != "X-avax1p9575chzhvcwvmvzaqh7yeld76r3af0ha56phl" || w.AvaxPAddress != "P-avax1p9575chzhvcwvmvzaqh7yeld76r3af0ha56phl" {
+		t.Errorf("avax X-chain, P-chain = %s %s", w.AvaxXAddress, w.AvaxPAddress)
+	}
+}
+
+// the grindKey vector of starknet.js, and the account addresses of a public key
+func TestStarknet(t *testing.T) {
+	seed, _ := hex.DecodeString("86f3e7293141f20a8baff320e8ee4accb9d4a4bf2b4d295e8cee784db46e0519")
+	if got := starkGrind(seed).Text(16); got != "5c8c8683596c732541a59e03007b2d30dbbbb873556fe65b5fb63c16688f941" {
+		t.Errorf("ground key = %s", got)
+	}
+	pub, _ := new(fp.Element).SetString("0x6a78b5ad5abdb109d4d362c14895efbd45a111d5f80157f669fd127ad0c0fd")
+	for account, want := range map[string]string{
+		Argent:  "401b4501ca192e5351db276852d814e520aba7dd50fcdc58e67eca6df1488d2",
+		Braavos: "243437bda9ef115ce31df335c8926f0af01e32ac58e9736ec88c77036854189",
+	} {
+		if got := (starknet{account: account}).address(pub).Text(16); got != want {
+			t.Errorf("%s address = %s, want %s", account, got, want)
+		}
+	}
+}
+
+func TestLookup(t *testing.T) {
+	if _, err := Lookup("xyz", ""); err == nil {
+		t.Error("looked up an unknown coin")
+	}
+	if _, err := Lookup("btc", "eth"); err == nil {
+		t.Error("looked up an unknown address type")
+	}
+	if coin, err := Lookup("cosmos", "inj"); err != nil || coin.Format().Lead != "inj1" {
+		t.Errorf("cosmos inj = %v, %v", coin, err)
+	}
+	if _, err := Lookup("cosmos", "Osmo"); err == nil {
+		t.Error("looked up an invalid bech32 prefix")
+	}
+	if _, err := Lookup("doge", P2WPKH); err == nil {
+		t.Error("looked up dogecoin SegWit addresses")
+	}
+	if coin, err := Lookup("eth", ""); err != nil || coin != ETH {
+		t.Errorf("eth = %v, %v", coin, err)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	Register("test-tron", Family{Types: []string{"trx"}, New: func(string) Coin { return tron{} }})
+	if coin, err := Lookup("test-tron", ""); err != nil || coin.Name() != "trx" {
+		t.Errorf("test-tron = %v, %v", coin, err)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("registered test-tron twice")
+		}
+	}()
+	Register("test-tron", Family{Types: []string{"trx"}, New: func(string) Coin { return tron{} }})
+}
+
+func TestApplyFilters(t *testing.T) {
+	btc, err := Lookup("btc", P2WPKH)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := ApplyFilters(btc, filter.Config{Prefix: "qqq"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.FullPrefix() != "bc1qqqq" {
+		t.Errorf("full prefix = %q", cfg.FullPrefix())
+	}
+	if cfg, _ := ApplyFilters(btc, filter.Config{Prefix: "bc1qqqq"}); cfg.FullPrefix() != "bc1qqqq" {
+		t.Errorf("full prefix of a prefix with the lead = %q", cfg.FullPrefix())
+	}
+	for _, cfg := range []filter.Config{
+		{Prefix: "bc1qb"},
+		{Suffix: "O"},
+		{Contains: []string{"qq", "1"}},
+		{Validators: []string{"leading-zeros:2"}},
+	} {
+		if _, err := ApplyFilters(btc, cfg); err == nil {
+			t.Errorf("applied %+v to btc addresses", cfg)
+		}
+	}
+	if _, err := ApplyFilters(ETH, filter.Config{Prefix: "0xdead", Contains: []string{""}, Validators: []string{"leading-zeros:2"}}); err != nil {
+		t.Error(err)
+	}
+}
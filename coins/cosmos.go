@@ -0,0 +1,73 @@
+package coins
+
+import (
+	"crypto/ecdsa"
+	"encoding/hex"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/bech32"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+
+	"github.com/planxnx/ethereum-wallet-generator/internal/wipe"
+	"github.com/planxnx/ethereum-wallet-generator/wallets"
+)
+
+// cosmosHRPs are the bech32 prefixes of common Cosmos SDK chains sharing coin type 118, the
+// first is the default address type. Any other one is accepted too.
+var cosmosHRPs = []string{"cosmos", "osmo", "celestia", "juno", "akash", "stars", "dydx", "axelar", "stride"}
+
+// cosmos derives the bech32 account addresses of a Cosmos SDK chain, whose prefix is hrp, at
+// m/44'/118'/0'/0.
+type cosmos struct {
+	hrp string
+}
+
+// newCosmos returns the coin of the chain using the bech32 prefix hrp.
+func newCosmos(hrp string) (Coin, error) {
+	if hrp == "" || strings.Trim(hrp, "abcdefghijklmnopqrstuvwxyz0123456789") != "" {
+		return nil, errors.Errorf("invalid cosmos address prefix %q, must be lowercase letters and digits", hrp)
+	}
+	return cosmos{hrp: hrp}, nil
+}
+
+func (cosmos) Name() string          { return "cosmos" }
+func (c cosmos) AddressType() string { return c.hrp }
+
+func (cosmos) BasePath() accounts.DerivationPath {
+	return accounts.DerivationPath{0x80000000 + 44, 0x80000000 + 118, 0x80000000, 0}
+}
+
+func (cosmos) Path(basePath accounts.DerivationPath, index uint32) string {
+	return AppendIndex(basePath, index)
+}
+
+func (c cosmos) Format() Format { return Format{Lead: c.hrp + "1", Charset: Bech32Charset} }
+
+func (c cosmos) NewDeriver(mnemonic, passphrase string, basePath accounts.DerivationPath) (Deriver, error) {
+	return NewSecp256k1Deriver(mnemonic, passphrase, basePath, c.wallet)
+}
+
+// wallet returns the wallet of key, its private key in the hex Keplr and the chain CLIs
+// import.
+func (c cosmos) wallet(key *ecdsa.PrivateKey) (*wallets.Wallet, error) {
+	raw := crypto.FromECDSA(key)
+	defer wipe.Bytes(raw)
+	priv, pub := btcec.PrivKeyFromBytes(raw)
+	defer priv.Zero()
+	address, err := bech32.EncodeFromBase256(c.hrp, btcutil.Hash160(pub.SerializeCompressed()))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode bech32 address")
+	}
+	compressed := hex.EncodeToString(pub.SerializeCompressed())
+	return &wallets.Wallet{
+		Address:             address,
+		ChecksumAddress:     address,
+		PrivateKey:          hex.EncodeToString(raw),
+		PublicKey:           compressed,
+		CompressedPublicKey: compressed,
+	}, nil
+}
@@ -0,0 +1,107 @@
+package coins
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/pkg/errors"
+
+	"github.com/planxnx/ethereum-wallet-generator/bip39"
+	"github.com/planxnx/ethereum-wallet-generator/internal/wipe"
+	"github.com/planxnx/ethereum-wallet-generator/wallets"
+)
+
+// hardened is the offset of the hardened child indexes, the only ones of ed25519 keys.
+const hardened = 0x80000000
+
+// ed25519Key is a SLIP-0010 ed25519 extended private key.
+type ed25519Key struct {
+	key, chainCode []byte
+}
+
+// newEd25519Master returns the SLIP-0010 master key of a BIP39 seed.
+func newEd25519Master(seed []byte) ed25519Key {
+	mac := hmac.New(sha512.New, []byte("ed25519 seed"))
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+	return ed25519Key{key: sum[:32], chainCode: sum[32:]}
+}
+
+// child returns the hardened child at index, which must be hardened already.
+func (k ed25519Key) child(index uint32) ed25519Key {
+	mac := hmac.New(sha512.New, k.chainCode)
+	mac.Write([]byte{0})
+	mac.Write(k.key)
+	mac.Write(binary.BigEndian.AppendUint32(nil, index))
+	sum := mac.Sum(nil)
+	return ed25519Key{key: sum[:32], chainCode: sum[32:]}
+}
+
+// derive returns the key at path, every index of which must be hardened as SLIP-0010 has no
+// public derivation of ed25519 keys. The intermediate keys are wiped, not k itself.
+func (k ed25519Key) derive(path accounts.DerivationPath) (ed25519Key, error) {
+	for i, index := range path {
+		if index < hardened {
+			return k, errors.Errorf("ed25519 derivation path %s has a non hardened index %d", path, index)
+		}
+		child := k.child(index)
+		if i > 0 {
+			k.wipe()
+		}
+		k = child
+	}
+	return k, nil
+}
+
+// wipe zeroes the key and chain code of k.
+func (k ed25519Key) wipe() {
+	wipe.Bytes(k.key)
+	wipe.Bytes(k.chainCode)
+}
+
+// ed25519Deriver derives SLIP-0010 ed25519 keys, turned into the wallets of a chain. path
+// returns the derivation path of an address index, which may append more than it to the
+// base path, eg. the m/44'/501'/index'/0' of Solana.
+type ed25519Deriver struct {
+	base     ed25519Key
+	basePath accounts.DerivationPath
+	path     func(basePath accounts.DerivationPath, index uint32) accounts.DerivationPath
+	wallet   func(key ed25519.PrivateKey) *wallets.Wallet
+}
+
+// newEd25519Deriver derives the base key of the BIP39 seed of mnemonic once, so every
+// address index only costs the derivation steps following the base path.
+func newEd25519Deriver(mnemonic, passphrase string, basePath accounts.DerivationPath, path func(accounts.DerivationPath, uint32) accounts.DerivationPath, wallet func(ed25519.PrivateKey) *wallets.Wallet) (*ed25519Deriver, error) {
+	seed := bip39.NewSeed(mnemonic, passphrase)
+	defer wipe.Bytes(seed)
+	master := newEd25519Master(seed)
+	base, err := master.derive(basePath)
+	if len(basePath) > 0 {
+		master.wipe()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &ed25519Deriver{base: base, basePath: basePath, path: path, wallet: wallet}, nil
+}
+
+func (d *ed25519Deriver) Derive(index uint32) (*wallets.Wallet, error) {
+	path := d.path(d.basePath, index)
+	k, err := d.base.derive(path[len(d.basePath):])
+	if err != nil {
+		return nil, err
+	}
+	key := ed25519.NewKeyFromSeed(k.key)
+	if len(path) > len(d.basePath) {
+		k.wipe()
+	}
+	defer wipe.Bytes(key)
+	w := d.wallet(key)
+	w.HDPath = path.String()
+	return w, nil
+}
+
+func (d *ed25519Deriver) Wipe() { d.base.wipe() }
@@ -0,0 +1,62 @@
+package coins
+
+import (
+	"crypto/ecdsa"
+
+	"github.com/ethereum/go-ethereum/accounts"
+
+	"github.com/planxnx/ethereum-wallet-generator/bip39"
+	"github.com/planxnx/ethereum-wallet-generator/internal/wipe"
+	"github.com/planxnx/ethereum-wallet-generator/wallets"
+)
+
+// ethereum derives the wallets the default way, at m/44'/60'/0'/0.
+type ethereum struct{}
+
+func (ethereum) Name() string                      { return "eth" }
+func (ethereum) AddressType() string               { return "eth" }
+func (ethereum) BasePath() accounts.DerivationPath { return wallets.DefaultBaseDerivationPath }
+func (ethereum) Format() Format                    { return Format{Lead: "0x", Charset: HexCharset} }
+func (ethereum) EVM() bool                         { return true }
+
+func (ethereum) Path(basePath accounts.DerivationPath, index uint32) string {
+	return AppendIndex(basePath, index)
+}
+
+func (ethereum) NewDeriver(mnemonic, passphrase string, basePath accounts.DerivationPath) (Deriver, error) {
+	return NewSecp256k1Deriver(mnemonic, passphrase, basePath, wallets.NewFromPrivatekey)
+}
+
+// secp256k1Deriver derives BIP32 secp256k1 keys, turned into the wallets of a chain.
+type secp256k1Deriver struct {
+	hd     *wallets.HDWallet
+	wallet func(key *ecdsa.PrivateKey) (*wallets.Wallet, error)
+}
+
+// newSecp256k1Deriver derives the base key of the BIP39 seed of mnemonic once, so every
+// address index only costs a single derivation step.
+func newSecp256k1Deriver(mnemonic, passphrase string, basePath accounts.DerivationPath, wallet func(*ecdsa.PrivateKey) (*wallets.Wallet, error)) (*secp256k1Deriver, error) {
+	seed := bip39.NewSeed(mnemonic, passphrase)
+	defer wipe.Bytes(seed)
+	hd, err := wallets.NewHDWallet(seed, basePath)
+	if err != nil {
+		return nil, err
+	}
+	return &secp256k1Deriver{hd: hd, wallet: wallet}, nil
+}
+
+func (d *secp256k1Deriver) Derive(index uint32) (*wallets.Wallet, error) {
+	key, err := d.hd.Derive(index)
+	if err != nil {
+		return nil, err
+	}
+	defer wipe.Key(key)
+	w, err := d.wallet(key)
+	if err != nil {
+		return nil, err
+	}
+	w.HDPath = d.hd.Path(index).String()
+	return w, nil
+}
+
+func (d *secp256k1Deriver) Wipe() { d.hd.Wipe() }
@@ -0,0 +1,74 @@
+package coins
+
+import (
+	"crypto/ecdsa"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/blake2b"
+
+	"github.com/planxnx/ethereum-wallet-generator/internal/wipe"
+	"github.com/planxnx/ethereum-wallet-generator/wallets"
+)
+
+// filecoinSecp256k1 is the protocol byte of the f1 addresses, hashing a secp256k1 key.
+const filecoinSecp256k1 = 1
+
+// filecoinEncoding is the lowercase unpadded base32 of Filecoin addresses.
+var filecoinEncoding = base32.NewEncoding(Base32Charset).WithPadding(base32.NoPadding)
+
+// filecoin derives the Filecoin f1 addresses of the secp256k1 keys at m/44'/461'/0'/0, the
+// path of Glif and the Ledger app.
+type filecoin struct{}
+
+func (filecoin) Name() string        { return "fil" }
+func (filecoin) AddressType() string { return "f1" }
+
+func (filecoin) BasePath() accounts.DerivationPath {
+	return accounts.DerivationPath{0x80000000 + 44, 0x80000000 + 461, 0x80000000, 0}
+}
+
+func (filecoin) Path(basePath accounts.DerivationPath, index uint32) string {
+	return AppendIndex(basePath, index)
+}
+
+func (filecoin) Format() Format { return Format{Lead: "f1", Charset: Base32Charset} }
+
+func (c filecoin) NewDeriver(mnemonic, passphrase string, basePath accounts.DerivationPath) (Deriver, error) {
+	return NewSecp256k1Deriver(mnemonic, passphrase, basePath, c.wallet)
+}
+
+// wallet returns the wallet of key, its private key in the hex encoded key info lotus wallet
+// export prints and lotus wallet import reads.
+func (filecoin) wallet(key *ecdsa.PrivateKey) (*wallets.Wallet, error) {
+	pub := crypto.FromECDSAPub(&key.PublicKey)
+	payload := blake2bSum(20, pub)
+	checksum := blake2bSum(4, append([]byte{filecoinSecp256k1}, payload...))
+	address := "f1" + filecoinEncoding.EncodeToString(append(payload, checksum...))
+	raw := crypto.FromECDSA(key)
+	defer wipe.Bytes(raw)
+	info, err := json.Marshal(struct{ Type, PrivateKey string }{"secp256k1", base64.StdEncoding.EncodeToString(raw)})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode key info")
+	}
+	defer wipe.Bytes(info)
+	return &wallets.Wallet{
+		Address:             address,
+		ChecksumAddress:     address,
+		PrivateKey:          hex.EncodeToString(info),
+		PublicKey:           hex.EncodeToString(pub),
+		CompressedPublicKey: hex.EncodeToString(crypto.CompressPubkey(&key.PublicKey)),
+	}, nil
+}
+
+// blake2bSum returns the blake2b hash of data of size bytes.
+func blake2bSum(size int, data []byte) []byte {
+	h, _ := blake2b.New(size, nil)
+	h.Write(data)
+	return h.Sum(nil)
+}
@@ -0,0 +1,70 @@
+package coins
+
+import (
+	"crypto/ecdsa"
+	"encoding/hex"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/base58"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/planxnx/ethereum-wallet-generator/internal/wipe"
+	"github.com/planxnx/ethereum-wallet-generator/wallets"
+)
+
+// rippleAccountVersion is the version byte of XRP account addresses, they all start with r.
+const rippleAccountVersion = 0
+
+// rippleAlphabet maps the characters of the Bitcoin base58 alphabet to those of the same
+// digits in the Ripple one.
+var rippleAlphabet = func() *strings.Replacer {
+	pairs := make([]string, 0, 2*len(Base58Charset))
+	for i := range Base58Charset {
+		pairs = append(pairs, Base58Charset[i:i+1], RippleCharset[i:i+1])
+	}
+	return strings.NewReplacer(pairs...)
+}()
+
+// ripple derives the XRP Ledger classic addresses, the base58check account ID of the
+// compressed keys in the Ripple alphabet, at m/44'/144'/0'/0 as Xaman and Ledger do.
+type ripple struct{}
+
+func (ripple) Name() string        { return "xrp" }
+func (ripple) AddressType() string { return "xrp" }
+
+func (ripple) BasePath() accounts.DerivationPath {
+	return accounts.DerivationPath{0x80000000 + 44, 0x80000000 + 144, 0x80000000, 0}
+}
+
+func (ripple) Path(basePath accounts.DerivationPath, index uint32) string {
+	return AppendIndex(basePath, index)
+}
+
+func (ripple) Format() Format {
+	return Format{Lead: rippleAlphabet.Replace(base58Lead([]byte{rippleAccountVersion}, base58CheckHashLength)), Charset: RippleCharset}
+}
+
+func (c ripple) NewDeriver(mnemonic, passphrase string, basePath accounts.DerivationPath) (Deriver, error) {
+	return NewSecp256k1Deriver(mnemonic, passphrase, basePath, c.wallet)
+}
+
+// wallet returns the wallet of key, its private key in the 00 prefixed uppercase hex of
+// xrpl.js and xrpl-py.
+func (ripple) wallet(key *ecdsa.PrivateKey) (*wallets.Wallet, error) {
+	raw := crypto.FromECDSA(key)
+	defer wipe.Bytes(raw)
+	priv, pub := btcec.PrivKeyFromBytes(raw)
+	defer priv.Zero()
+	address := rippleAlphabet.Replace(base58.CheckEncode(btcutil.Hash160(pub.SerializeCompressed()), rippleAccountVersion))
+	compressed := strings.ToUpper(hex.EncodeToString(pub.SerializeCompressed()))
+	return &wallets.Wallet{
+		Address:             address,
+		ChecksumAddress:     address,
+		PrivateKey:          "00" + strings.ToUpper(hex.EncodeToString(raw)),
+		PublicKey:           compressed,
+		CompressedPublicKey: compressed,
+	}, nil
+}
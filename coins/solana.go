@@ -0,0 +1,51 @@
+package coins
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+
+	"github.com/btcsuite/btcd/btcutil/base58"
+	"github.com/ethereum/go-ethereum/accounts"
+
+	"github.com/planxnx/ethereum-wallet-generator/wallets"
+)
+
+// solana derives the Solana ed25519 keys at m/44'/501'/index'/0', the path of Phantom and
+// Solflare, whose addresses are the base58 public keys.
+type solana struct{}
+
+func (solana) Name() string        { return "sol" }
+func (solana) AddressType() string { return "sol" }
+
+// BasePath is the path before the account index, which takes the place of the address one.
+func (solana) BasePath() accounts.DerivationPath {
+	return accounts.DerivationPath{hardened + 44, hardened + 501}
+}
+
+func (solana) Path(basePath accounts.DerivationPath, index uint32) string {
+	return solanaPath(basePath, index).String()
+}
+
+// solanaPath appends the hardened account index and the 0' change of the Solana wallets.
+func solanaPath(basePath accounts.DerivationPath, index uint32) accounts.DerivationPath {
+	return append(append(accounts.DerivationPath{}, basePath...), hardened+index, hardened)
+}
+
+func (solana) Format() Format { return Format{Charset: Base58Charset} }
+
+func (c solana) NewDeriver(mnemonic, passphrase string, basePath accounts.DerivationPath) (Deriver, error) {
+	return newEd25519Deriver(mnemonic, passphrase, basePath, solanaPath, c.wallet)
+}
+
+// wallet returns the wallet of key, its private key is the base58 64 byte keypair Solana
+// wallets import.
+func (solana) wallet(key ed25519.PrivateKey) *wallets.Wallet {
+	pub := key.Public().(ed25519.PublicKey)
+	address := base58.Encode(pub)
+	return &wallets.Wallet{
+		Address:         address,
+		ChecksumAddress: address,
+		PrivateKey:      base58.Encode(key),
+		PublicKey:       hex.EncodeToString(pub),
+	}
+}
@@ -0,0 +1,143 @@
+package coins
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+
+	starkcurve "github.com/consensys/gnark-crypto/ecc/stark-curve"
+	"github.com/consensys/gnark-crypto/ecc/stark-curve/fp"
+	"github.com/consensys/gnark-crypto/ecc/stark-curve/fr"
+	pedersenhash "github.com/consensys/gnark-crypto/ecc/stark-curve/pedersen-hash"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/planxnx/ethereum-wallet-generator/bip39"
+	"github.com/planxnx/ethereum-wallet-generator/internal/wipe"
+	"github.com/planxnx/ethereum-wallet-generator/wallets"
+)
+
+// Starknet account contracts, the address types of strk.
+const (
+	Argent  = "argent"
+	Braavos = "braavos"
+)
+
+var starknetTypes = []string{Argent, Braavos}
+
+// starknetClassHashes are the class hashes the wallets deploy their accounts with, Argent X
+// 0.4.0 and the Braavos base account.
+var starknetClassHashes = map[string]string{
+	Argent:  "0x036078334509b514626504edc9fb252328d1a240e4e948bef8d0c08dff45927f",
+	Braavos: "0x013bfe114fb1cf405bfc3a7f8dbe2d91db146c17521d40dcf57e16d6b59fa8e6",
+}
+
+var (
+	// starknetAddressPrefix is the felt of "STARKNET_CONTRACT_ADDRESS" hashed first into the
+	// contract addresses.
+	starknetAddressPrefix = new(fp.Element).SetBytes([]byte("STARKNET_CONTRACT_ADDRESS"))
+	// starknetAddressBound is 2^251 - 256, the contract addresses are reduced modulo it.
+	starknetAddressBound = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 251), big.NewInt(256))
+)
+
+// starknet derives the Stark keys of the Argent X and Braavos wallets at m/44'/9004'/0'/0,
+// ground into the order of the Stark curve as EIP-2645 does, and the counterfactual addresses
+// of the accounts they deploy for them. Braavos derives them from the BIP39 seed, Argent X
+// from the Ethereum key of m/44'/60'/0'/0/0 used as seed.
+type starknet struct {
+	account string
+}
+
+func (starknet) Name() string          { return "strk" }
+func (c starknet) AddressType() string { return c.account }
+
+func (starknet) BasePath() accounts.DerivationPath {
+	return accounts.DerivationPath{0x80000000 + 44, 0x80000000 + 9004, 0x80000000, 0}
+}
+
+func (starknet) Path(basePath accounts.DerivationPath, index uint32) string {
+	return AppendIndex(basePath, index)
+}
+
+func (starknet) Format() Format { return Format{Lead: "0x", Charset: HexCharset} }
+
+func (c starknet) NewDeriver(mnemonic, passphrase string, basePath accounts.DerivationPath) (Deriver, error) {
+	seed := bip39.NewSeed(mnemonic, passphrase)
+	defer func() { wipe.Bytes(seed) }()
+	if c.account == Argent {
+		eth, err := wallets.NewHDWallet(seed, wallets.DefaultBaseDerivationPath)
+		if err != nil {
+			return nil, err
+		}
+		defer eth.Wipe()
+		key, err := eth.Derive(0)
+		if err != nil {
+			return nil, err
+		}
+		defer wipe.Key(key)
+		wipe.Bytes(seed)
+		// the key as the big number of ethers, without its leading zero bytes
+		seed = key.D.Bytes()
+	}
+	hd, err := wallets.NewHDWallet(seed, basePath)
+	if err != nil {
+		return nil, err
+	}
+	return &secp256k1Deriver{hd: hd, wallet: c.wallet}, nil
+}
+
+// wallet returns the account wallet of the Stark key ground from key, and its public key.
+func (c starknet) wallet(key *ecdsa.PrivateKey) (*wallets.Wallet, error) {
+	raw := crypto.FromECDSA(key)
+	defer wipe.Bytes(raw)
+	priv := starkGrind(raw)
+	defer wipe.Int(priv)
+	var point starkcurve.G1Affine
+	point.ScalarMultiplicationBase(priv)
+	pub := point.X
+	address := fmt.Sprintf("%#066x", c.address(&pub))
+	return &wallets.Wallet{
+		Address:         address,
+		ChecksumAddress: address,
+		PrivateKey:      fmt.Sprintf("%#066x", priv),
+		PublicKey:       fmt.Sprintf("%#066x", pub.BigInt(new(big.Int))),
+	}, nil
+}
+
+// address returns the address the account contract of the public key pub is deployed at,
+// with pub as salt and by no deployer.
+func (c starknet) address(pub *fp.Element) *big.Int {
+	var calldata []*fp.Element
+	if c.account == Argent {
+		// the Starknet signer variant 0 of the owner, and no guardian
+		calldata = []*fp.Element{new(fp.Element), pub, new(fp.Element).SetOne()}
+	} else {
+		calldata = []*fp.Element{pub}
+	}
+	classHash, _ := new(fp.Element).SetString(starknetClassHashes[c.account])
+	calldataHash := pedersenhash.PedersenArray(calldata...)
+	hash := pedersenhash.PedersenArray(starknetAddressPrefix, new(fp.Element), pub, classHash, &calldataHash)
+	address := hash.BigInt(new(big.Int))
+	return address.Mod(address, starknetAddressBound)
+}
+
+// starkGrind returns the Stark private key of a secp256k1 one: the first sha256 of the key
+// and a counter below the largest multiple of the curve order, reduced modulo it.
+func starkGrind(seed []byte) *big.Int {
+	order := fr.Modulus()
+	limit := new(big.Int).Lsh(big.NewInt(1), 256)
+	limit.Sub(limit, new(big.Int).Mod(limit, order))
+	// each try fails with a chance under 1/31, the counter never outgrows its byte
+	for i := 0; ; i++ {
+		data := append(seed[:len(seed):len(seed)], byte(i))
+		h := sha256.Sum256(data)
+		wipe.Bytes(data)
+		key := new(big.Int).SetBytes(h[:])
+		wipe.Bytes(h[:])
+		if key.Cmp(limit) < 0 {
+			return key.Mod(key, order)
+		}
+		wipe.Int(key)
+	}
+}
@@ -0,0 +1,233 @@
+package coins
+
+import (
+	"crypto/ed25519"
+	"crypto/sha512"
+	"encoding/binary"
+	"encoding/hex"
+	"strconv"
+	"strings"
+
+	"github.com/ChainSafe/go-schnorrkel"
+	"github.com/btcsuite/btcd/btcutil/base58"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/pbkdf2"
+
+	"github.com/planxnx/ethereum-wallet-generator/bip39"
+	"github.com/planxnx/ethereum-wallet-generator/internal/wipe"
+	"github.com/planxnx/ethereum-wallet-generator/wallets"
+)
+
+// Substrate key schemes, sr25519 is the default of Polkadot wallets.
+const (
+	SR25519 = "sr25519"
+	ED25519 = "ed25519"
+)
+
+var substrateSchemes = []string{SR25519, ED25519}
+
+// DefaultSS58Prefix is the SS58 network prefix of the generic Substrate addresses.
+const DefaultSS58Prefix = 42
+
+// substrate derives the accounts of a Substrate chain the way Polkadot.js, Talisman and
+// subkey do: the address index 0 is the account of the mnemonic itself, the index i the
+// hard junction //i of it. Their addresses are SS58 encoded with the network prefix.
+type substrate struct {
+	name    string
+	scheme  string
+	network uint16
+}
+
+// newSubstrate returns the coin of the chain name using the SS58 network prefix.
+func newSubstrate(name, scheme string, network uint16) Coin {
+	return substrate{name: name, scheme: scheme, network: network}
+}
+
+// newSubstrateNetwork returns the coin of an address type scheme[:network prefix], the
+// network defaulting to the generic Substrate one.
+func newSubstrateNetwork(addressType string) (Coin, error) {
+	scheme, prefix, ok := strings.Cut(addressType, ":")
+	network := uint64(DefaultSS58Prefix)
+	if ok {
+		var err error
+		if network, err = strconv.ParseUint(prefix, 10, 16); err != nil || network > 16383 {
+			return nil, errors.Errorf("invalid SS58 network prefix %q, must be within [0, 16383]", prefix)
+		}
+	}
+	if scheme != SR25519 && scheme != ED25519 {
+		return nil, errors.Errorf("unknown substrate key scheme %q, must be one of %v", scheme, substrateSchemes)
+	}
+	return newSubstrate("substrate", scheme, uint16(network)), nil
+}
+
+func (c substrate) Name() string { return c.name }
+
+func (c substrate) AddressType() string {
+	if c.name == "substrate" {
+		return c.scheme + ":" + strconv.Itoa(int(c.network))
+	}
+	return c.scheme
+}
+
+// BasePath is empty, Substrate accounts aren't derived along a BIP32 path.
+func (substrate) BasePath() accounts.DerivationPath { return nil }
+
+// Path is m for the account of the mnemonic, the hard junction //index otherwise.
+func (substrate) Path(_ accounts.DerivationPath, index uint32) string {
+	if index == 0 {
+		return "m"
+	}
+	return "//" + strconv.FormatUint(uint64(index), 10)
+}
+
+func (c substrate) Format() Format {
+	return Format{Lead: base58Lead(ss58Prefix(c.network), 32+2), Charset: Base58Charset}
+}
+
+func (c substrate) NewDeriver(mnemonic, passphrase string, basePath accounts.DerivationPath) (Deriver, error) {
+	if len(basePath) > 0 {
+		return nil, errors.Errorf("substrate accounts take no BIP32 base path, got %s", basePath)
+	}
+	seed, err := substrateSeed(mnemonic, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return &substrateDeriver{coin: c, seed: seed}, nil
+}
+
+// substrateSeed returns the mini secret key of a mnemonic. Unlike BIP39 seeds, Substrate
+// stretches the entropy of the mnemonic rather than its words.
+func substrateSeed(mnemonic, passphrase string) ([32]byte, error) {
+	var seed [32]byte
+	entropy, err := bip39.EntropyFromMnemonic(mnemonic)
+	if err != nil {
+		return seed, errors.Wrap(err, "invalid mnemonic")
+	}
+	defer wipe.Bytes(entropy)
+	key := pbkdf2.Key(entropy, []byte("mnemonic"+passphrase), 2048, 64, sha512.New)
+	defer wipe.Bytes(key)
+	copy(seed[:], key)
+	return seed, nil
+}
+
+// substrateDeriver derives the accounts of a single mnemonic.
+type substrateDeriver struct {
+	coin substrate
+	seed [32]byte
+}
+
+func (d *substrateDeriver) Derive(index uint32) (*wallets.Wallet, error) {
+	seed := d.seed
+	defer wipe.Bytes(seed[:])
+	if index > 0 {
+		var err error
+		if seed, err = d.coin.hardDerive(seed, substrateJunction(strconv.FormatUint(uint64(index), 10))); err != nil {
+			return nil, err
+		}
+	}
+	pub, err := d.coin.publicKey(seed)
+	if err != nil {
+		return nil, err
+	}
+	address := ss58Encode(pub, d.coin.network)
+	return &wallets.Wallet{
+		Address:         address,
+		ChecksumAddress: address,
+		// the raw seed of the account, as Polkadot.js and subkey import it
+		PrivateKey: "0x" + hex.EncodeToString(seed[:]),
+		PublicKey:  "0x" + hex.EncodeToString(pub),
+		HDPath:     d.coin.Path(nil, index),
+	}, nil
+}
+
+func (d *substrateDeriver) Wipe() { wipe.Bytes(d.seed[:]) }
+
+// hardDerive returns the seed of the hard junction of chain code cc of seed.
+func (c substrate) hardDerive(seed, cc [32]byte) ([32]byte, error) {
+	if c.scheme == ED25519 {
+		// blake2b-256 of the SCALE encoded ("Ed25519HDKD", seed, cc)
+		h, _ := blake2b.New256(nil)
+		h.Write(scaleString("Ed25519HDKD"))
+		h.Write(seed[:])
+		h.Write(cc[:])
+		var child [32]byte
+		copy(child[:], h.Sum(nil))
+		return child, nil
+	}
+	mini, err := schnorrkel.NewMiniSecretKeyFromRaw(seed)
+	if err != nil {
+		return seed, err
+	}
+	child, _, err := mini.HardDeriveMiniSecretKey(nil, cc)
+	if err != nil {
+		return seed, errors.Wrap(err, "failed to derive sr25519 key")
+	}
+	return child.Encode(), nil
+}
+
+// publicKey returns the public key of seed.
+func (c substrate) publicKey(seed [32]byte) ([]byte, error) {
+	if c.scheme == ED25519 {
+		return ed25519.NewKeyFromSeed(seed[:]).Public().(ed25519.PublicKey), nil
+	}
+	mini, err := schnorrkel.NewMiniSecretKeyFromRaw(seed)
+	if err != nil {
+		return nil, err
+	}
+	pub := mini.Public().Encode()
+	return pub[:], nil
+}
+
+// substrateJunction returns the chain code of a junction: the little endian integer of a
+// numeric one, the SCALE encoded string otherwise, hashed if it is longer than 32 bytes.
+func substrateJunction(junction string) [32]byte {
+	var cc [32]byte
+	if n, err := strconv.ParseUint(junction, 10, 64); err == nil {
+		binary.LittleEndian.PutUint64(cc[:], n)
+		return cc
+	}
+	encoded := scaleString(junction)
+	if len(encoded) > len(cc) {
+		sum := blake2b.Sum256(encoded)
+		return sum
+	}
+	copy(cc[:], encoded)
+	return cc
+}
+
+// scaleString returns the SCALE encoding of s, its compact length and its bytes.
+func scaleString(s string) []byte {
+	var encoded []byte
+	switch n := len(s); {
+	case n < 1<<6:
+		encoded = []byte{byte(n << 2)}
+	case n < 1<<14:
+		encoded = binary.LittleEndian.AppendUint16(nil, uint16(n<<2|1))
+	default:
+		encoded = binary.LittleEndian.AppendUint32(nil, uint32(n<<2|2))
+	}
+	return append(encoded, s...)
+}
+
+// ss58Prefix returns the one or two byte encoding of an SS58 network prefix.
+func ss58Prefix(network uint16) []byte {
+	if network < 64 {
+		return []byte{byte(network)}
+	}
+	return []byte{
+		byte(network&0b1111_1100>>2) | 0b0100_0000,
+		byte(network>>8) | byte(network&0b11)<<6,
+	}
+}
+
+// ss58Encode returns the SS58 address of a public key, with the first two bytes of the
+// blake2b-512 of the SS58PRE prefixed payload as checksum.
+func ss58Encode(pub []byte, network uint16) string {
+	payload := append(ss58Prefix(network), pub...)
+	h, _ := blake2b.New512(nil)
+	h.Write([]byte("SS58PRE"))
+	h.Write(payload)
+	return base58.Encode(append(payload, h.Sum(nil)[:2]...))
+}
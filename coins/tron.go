@@ -0,0 +1,49 @@
+package coins
+
+import (
+	"crypto/ecdsa"
+
+	"github.com/btcsuite/btcd/btcutil/base58"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/planxnx/ethereum-wallet-generator/wallets"
+)
+
+// tronAddressVersion is the version byte of Tron addresses, they all start with T.
+const tronAddressVersion = 0x41
+
+// tron derives the Tron addresses of the Ethereum keys, base58check encoded with their own
+// version byte, at m/44'/195'/0'/0.
+type tron struct{}
+
+func (tron) Name() string        { return "trx" }
+func (tron) AddressType() string { return "trx" }
+
+func (tron) BasePath() accounts.DerivationPath {
+	return accounts.DerivationPath{0x80000000 + 44, 0x80000000 + 195, 0x80000000, 0}
+}
+
+func (tron) Path(basePath accounts.DerivationPath, index uint32) string {
+	return AppendIndex(basePath, index)
+}
+
+func (tron) Format() Format {
+	return Format{Lead: base58Lead([]byte{tronAddressVersion}, base58CheckHashLength), Charset: Base58Charset}
+}
+
+func (c tron) NewDeriver(mnemonic, passphrase string, basePath accounts.DerivationPath) (Deriver, error) {
+	return NewSecp256k1Deriver(mnemonic, passphrase, basePath, c.wallet)
+}
+
+// wallet returns the Ethereum wallet of key with its Tron address, the private key stays the
+// hex one Tron wallets import.
+func (tron) wallet(key *ecdsa.PrivateKey) (*wallets.Wallet, error) {
+	w, err := wallets.NewFromPrivatekey(key)
+	if err != nil {
+		return nil, err
+	}
+	address := base58.CheckEncode(crypto.PubkeyToAddress(key.PublicKey).Bytes(), tronAddressVersion)
+	w.Address, w.ChecksumAddress = address, address
+	return w, nil
+}
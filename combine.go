@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bufio"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/planxnx/ethereum-wallet-generator/internal/shamir"
+)
+
+// runCombine rebuilds a private key or mnemonic split by export -shamir from at least K of
+// its shares, given as arguments or one per line on stdin.
+func runCombine(args []string) {
+	fs := flag.NewFlagSet("combine", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s combine [SHARE...]\n\nRebuilds a private key or mnemonic from K of the hex shares written by export -shamir K/N, read from stdin when none is given.\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	parseFlags(fs, args)
+
+	encoded := fs.Args()
+	if len(encoded) == 0 {
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			if line := strings.TrimSpace(scanner.Text()); line != "" {
+				encoded = append(encoded, line)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			fatal("Failed to read shares", "err", err)
+		}
+	}
+	shares := make([][]byte, len(encoded))
+	for i, s := range encoded {
+		share, err := hex.DecodeString(s)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: share %d isn't hex: %v\n", i+1, err)
+			os.Exit(exitUsage)
+		}
+		shares[i] = share
+	}
+	secret, err := shamir.Combine(shares)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitUsage)
+	}
+	fmt.Println(string(secret))
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/planxnx/ethereum-wallet-generator/coins"
+	"github.com/planxnx/ethereum-wallet-generator/filter"
+	"github.com/planxnx/ethereum-wallet-generator/internal/entropy"
+	"github.com/planxnx/ethereum-wallet-generator/internal/output"
+	"github.com/planxnx/ethereum-wallet-generator/internal/qrcode"
+	"github.com/planxnx/ethereum-wallet-generator/seeds"
+	"github.com/planxnx/ethereum-wallet-generator/store"
+)
+
+// completeCommand is the hidden command the completion scripts call back into.
+const completeCommand = "__complete"
+
+// flagValues lists the values completed for the flags taking one of a known set. Values of
+// the other flags complete as file names.
+var flagValues = map[string]func() []string{
+	"format":         func() []string { return output.Formats },
+	"matches-format": func() []string { return output.Formats },
+	"seeds-format": func() []string {
+		formats := make([]string, len(seeds.Formats))
+		for i, f := range seeds.Formats {
+			formats[i] = string(f)
+		}
+		return formats
+	},
+	"duplicates": func() []string { return seeds.DuplicatesModes },
+	"columns":    func() []string { return output.Columns },
+	"compress":   func() []string { return output.Compressions },
+	"db-driver":  func() []string { return []string{"gorm", "raw"} },
+	"db-on-conflict": func() []string {
+		return []string{string(store.ConflictSkip), string(store.ConflictUpdate), string(store.ConflictMerge), string(store.ConflictError)}
+	},
+	"qr-format":        func() []string { return []string{qrcode.FormatPNG, qrcode.FormatSVG} },
+	"qr-content":       func() []string { return []string{qrcode.ContentAddress, qrcode.ContentPrivateKey, qrcode.ContentBoth} },
+	"mode":             func() []string { return []string{"mnemonic", "privatekey", "keyspace"} },
+	"bit":              func() []string { return []string{"128", "256"} },
+	"keyspace-backend": func() []string { return []string{"random", "incremental"} },
+	"input":            func() []string { return entropy.Inputs },
+	"debias":           func() []string { return entropy.DebiasMethods },
+	"device":           func() []string { return []string{"any", "ledger", "trezor"} },
+	"log-level":        func() []string { return []string{"debug", "info", "warn", "error"} },
+	"log-format":       func() []string { return []string{logFormatText, logFormatJSON} },
+	"validator":        filter.Registered,
+	"coin":             coins.Names,
+	"address-type": func() []string {
+		var types []string
+		for _, name := range coins.Names() {
+			for _, t := range coins.AddressTypes(name) {
+				if !slices.Contains(types, t) {
+					types = append(types, t)
+				}
+			}
+		}
+		return types
+	},
+}
+
+// listFlagValues are the flags taking a comma separated list, whose last item is completed.
+var listFlagValues = map[string]bool{"columns": true}
+
+// completeFlags is set while completing, parseFlags hands it the flags of the command
+// instead of parsing them.
+var completeFlags func(fs *flag.FlagSet)
+
+// runCompletion prints the completion script of a shell.
+func runCompletion(args []string) {
+	fs := flag.NewFlagSet("completion", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s completion bash|zsh|fish\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Load it in the current shell with eg. source <(%s completion bash)\n", os.Args[0])
+	}
+	_ = fs.Parse(args)
+
+	name := filepath.Base(os.Args[0])
+	var script string
+	switch fs.Arg(0) {
+	case "bash":
+		script = bashCompletion
+	case "zsh":
+		script = zshCompletion
+	case "fish":
+		script = fishCompletion
+	default:
+		fs.Usage()
+		os.Exit(exitUsage)
+	}
+	fmt.Print(strings.NewReplacer("{{name}}", name, "{{fn}}", strings.NewReplacer("-", "_", ".", "_").Replace(name), "{{complete}}", completeCommand).Replace(script))
+}
+
+// runComplete prints the completions of the last word of args, the command line after the
+// binary name, one per line. Nothing is printed when file names should be completed.
+func runComplete(args []string) {
+	if len(args) == 0 {
+		args = []string{""}
+	}
+	cur := args[len(args)-1]
+	if len(args) == 1 && !strings.HasPrefix(cur, "-") {
+		for _, cmd := range commands {
+			printCompletion(cmd.name, cur)
+		}
+		return
+	}
+
+	run := runScan
+	words := args[:len(args)-1]
+	if name := args[0]; len(args) > 1 && !strings.HasPrefix(name, "-") {
+		if name == "serve-coordinator" {
+			name = "serve"
+		}
+		run = nil
+		for _, cmd := range commands {
+			if cmd.name == name {
+				run = cmd.run
+			}
+		}
+		if run == nil {
+			return
+		}
+		words = words[1:]
+	}
+
+	// bash splits -flag=value into -flag, = and value, then only the value is replaced
+	switch n := len(words); {
+	case cur == "=" && n > 0:
+		printFlagValues(strings.TrimLeft(words[n-1], "-"), "", "")
+		return
+	case n > 1 && words[n-1] == "=":
+		printFlagValues(strings.TrimLeft(words[n-2], "-"), cur, "")
+		return
+	}
+
+	completeFlags = func(fs *flag.FlagSet) {
+		completeFlagSet(fs, words, cur)
+		os.Exit(0)
+	}
+	run(nil)
+}
+
+// completeFlagSet prints the completions of cur for a command with the flags of fs, after
+// the given words.
+func completeFlagSet(fs *flag.FlagSet, words []string, cur string) {
+	if name, value, ok := strings.Cut(strings.TrimLeft(cur, "-"), "="); ok && strings.HasPrefix(cur, "-") {
+		printFlagValues(name, value, cur[:len(cur)-len(value)])
+		return
+	}
+	if strings.HasPrefix(cur, "-") {
+		dashes := "-"
+		if strings.HasPrefix(cur, "--") {
+			dashes = "--"
+		}
+		fs.VisitAll(func(f *flag.Flag) {
+			printCompletion(dashes+f.Name, cur)
+		})
+		return
+	}
+	if len(words) == 0 {
+		return
+	}
+	prev := words[len(words)-1]
+	if !strings.HasPrefix(prev, "-") || strings.Contains(prev, "=") {
+		return
+	}
+	name := strings.TrimLeft(prev, "-")
+	f := fs.Lookup(name)
+	if f == nil {
+		return
+	}
+	if b, ok := f.Value.(interface{ IsBoolFlag() bool }); ok && b.IsBoolFlag() {
+		return
+	}
+	printFlagValues(name, cur, "")
+}
+
+// printFlagValues prints the known values of a flag matching value, each preceded by prefix.
+func printFlagValues(name, value, prefix string) {
+	values, ok := flagValues[name]
+	if !ok {
+		return
+	}
+	if listFlagValues[name] {
+		if i := strings.LastIndexByte(value, ','); i >= 0 {
+			prefix, value = prefix+value[:i+1], value[i+1:]
+		}
+	}
+	for _, v := range values() {
+		printCompletion(prefix+v, prefix+value)
+	}
+}
+
+// printCompletion prints candidate if it completes cur.
+func printCompletion(candidate, cur string) {
+	if strings.HasPrefix(candidate, cur) {
+		fmt.Println(candidate)
+	}
+}
+
+const bashCompletion = `# bash completion for {{name}}
+_{{fn}}_complete() {
+    local IFS=$'\n'
+    local cur="${COMP_WORDS[COMP_CWORD]}" out
+    [[ "$cur" == "=" ]] && cur=""
+    out=$("${COMP_WORDS[0]}" {{complete}} "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null)
+    if [[ -z "$out" ]]; then
+        COMPREPLY=($(compgen -f -- "$cur"))
+    else
+        COMPREPLY=($out)
+    fi
+}
+complete -o filenames -F _{{fn}}_complete {{name}}
+`
+
+const zshCompletion = `#compdef {{name}}
+_{{fn}}() {
+    local -a candidates
+    candidates=("${(@f)$(${words[1]} {{complete}} "${(@)words[2,CURRENT]}" 2>/dev/null)}")
+    if [[ -z "${candidates[*]}" ]]; then
+        _files
+    else
+        compadd -Q -S '' -a candidates
+    fi
+}
+compdef _{{fn}} {{name}}
+`
+
+const fishCompletion = `# fish completion for {{name}}
+function __{{fn}}_complete
+    set -l tokens (commandline -opc) (commandline -ct)
+    set -l out ($tokens[1] {{complete}} $tokens[2..-1] 2>/dev/null)
+    if test (count $out) -eq 0
+        __fish_complete_path (commandline -ct)
+    else
+        printf '%s\n' $out
+    end
+end
+complete -c {{name}} -f -a '(__{{fn}}_complete)'
+`
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/planxnx/ethereum-wallet-generator/filter"
+	"github.com/planxnx/ethereum-wallet-generator/internal/distributed"
+	"github.com/planxnx/ethereum-wallet-generator/internal/output"
+	"github.com/planxnx/ethereum-wallet-generator/internal/queue"
+	"github.com/planxnx/ethereum-wallet-generator/internal/throttle"
+	"github.com/planxnx/ethereum-wallet-generator/seeds"
+)
+
+// The message kinds of the consume command.
+const (
+	messagesSeeds = "seeds"
+	messagesUnits = "units"
+)
+
+// runConsume processes the seeds or work units of a message queue in batches, acknowledging
+// the messages of a batch only once its matches are flushed to the sinks. A worker stopping
+// before that leaves them to be delivered again, so fleets grow and shrink at will.
+func runConsume(args []string) {
+	fs := flag.NewFlagSet("consume", flag.ExitOnError)
+	queueURL := fs.String("queue", "", "queue to consume: redis://host:6379/STREAM, nats://host:4222/STREAM or kafka://broker:9092/TOPIC, see the README for their parameters")
+	messages := fs.String("messages", messagesSeeds, "content of the messages: seeds, a seeds file line of -seeds-format each, or units, the JSON work units of the distributed mode carrying their own depth and filters")
+	seedsFormat := fs.String("seeds-format", string(seeds.FormatText), "format of the seed messages: text (a mnemonic), or tsv/csv lines of mnemonic, passphrase, hdpath and label fields")
+	depth := fs.Int("depth", 1, "number of addresses to derive per seed message (default 1, >=1)")
+	coinConfig := addCoinFlags(fs)
+	batch := fs.Int("batch", 100, "number of messages processed together, acknowledged once their matches are flushed")
+	batchWait := fs.Duration("batch-wait", time.Second, "longest wait for a batch to fill before processing the messages received")
+	concurrency := fs.Int("c", 1, "set concurrency value (number of derivation workers)")
+	maxCPU := fs.String("max-cpu", "100%", "limit CPU usage of the derivation to the given percentage (eg. 50%)")
+	sinksConfig := addSinkFlags(fs)
+	filterConfig := addFilterFlags(fs)
+	parseFlags(fs, args)
+
+	if *queueURL == "" {
+		fmt.Fprintln(os.Stderr, "Error: --queue parameter required")
+		os.Exit(exitUsage)
+	}
+	if !slices.Contains([]string{messagesSeeds, messagesUnits}, *messages) {
+		fmt.Fprintf(os.Stderr, "Error: unknown --messages %q, must be seeds or units\n", *messages)
+		os.Exit(exitUsage)
+	}
+	format := seeds.Format(*seedsFormat)
+	if _, err := format.Parse("probe"); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitUsage)
+	}
+	if *batch < 1 {
+		fmt.Fprintln(os.Stderr, "Error: --batch must be >= 1")
+		os.Exit(exitUsage)
+	}
+	if fs.Lookup("top").Value.String() != "0" {
+		// the ranked matches are only written at the end, after their messages are acknowledged
+		fmt.Fprintln(os.Stderr, "Error: --top can't be combined with consume")
+		os.Exit(exitUsage)
+	}
+	cpuPercent, err := throttle.ParsePercent(*maxCPU)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitUsage)
+	}
+	job := distributedJob(*depth, coinConfig, filterConfig())
+	if _, err := filter.NewValidators(job.Filter.Validators); err != nil {
+		fatal("Failed to create validators", "err", err)
+	}
+
+	ctx, stop := withSignals(context.Background())
+	defer stop()
+	consumer, err := queue.Open(ctx, *queueURL)
+	if err != nil {
+		fatal("Failed to open queue", "err", err)
+	}
+	defer consumer.Close()
+	sinks := sinksConfig()
+	if sinks.recordsAll() {
+		fmt.Fprintln(os.Stderr, "Error: --matches-out isn't supported by consume, its batches only keep their matches")
+		os.Exit(exitUsage)
+	}
+	sinks.setBackpressure(ctx, nil)
+	slog.Info("Consuming queue", "messages", *messages, "batch", *batch)
+
+	var total distributed.UnitResult
+	consumed, matches := 0, 0
+	for ctx.Err() == nil {
+		msgs, err := receiveBatch(ctx, consumer, *batch, *batchWait)
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			sinks.Close()
+			fatal("Failed to receive messages", "err", err)
+		}
+		if len(msgs) == 0 {
+			continue
+		}
+
+		var results []consumedResult
+		if *messages == messagesSeeds {
+			results = processSeedMessages(ctx, msgs, format, job, *concurrency, cpuPercent)
+		} else {
+			results = processUnitMessages(ctx, msgs, *concurrency, cpuPercent)
+		}
+		if ctx.Err() != nil {
+			// the batch may be partly processed, it is delivered again
+			break
+		}
+		for _, r := range results {
+			for _, m := range r.Matches {
+				sinks.Save(output.Record{SeedFile: r.msg.ID, Line: m.Line, SeedLabel: m.Label, Index: m.Index, Mnemonic: m.Phrase, Wallet: m.Wallet})
+			}
+			total.Processed += r.Processed
+			total.Failed += r.Failed
+			matches += len(r.Matches)
+		}
+		// the matches must be durable before the messages are gone from the queue
+		if err := sinks.Flush(); err != nil {
+			sinks.Close()
+			fatal("Failed to flush results, the batch will be delivered again", "err", err)
+		}
+		if err := consumer.Ack(ctx, msgs); err != nil {
+			slog.Error("Failed to acknowledge messages, they will be delivered again", "messages", len(msgs), "err", err)
+		}
+		consumed += len(msgs)
+		slog.Debug("Batch done", "messages", len(msgs), "consumed", consumed, "matches", matches)
+	}
+	sinks.Close()
+
+	if sig := interruptSignal(ctx); sig != nil {
+		fmt.Fprintf(os.Stderr, "Interrupted by %v, the unacknowledged messages will be delivered again\n", sig)
+	}
+	fmt.Fprintf(os.Stderr, "Consumed %d messages, processed %d, failed %d, matches %d\n", consumed, total.Processed, total.Failed, matches)
+	if sinks.Failed() {
+		exitCode = exitFailure
+	}
+}
+
+// consumedResult is the result of the seeds of a message.
+type consumedResult struct {
+	msg *queue.Message
+	distributed.UnitResult
+}
+
+// receiveBatch returns up to n messages, waiting at most wait once the first one came.
+func receiveBatch(ctx context.Context, consumer queue.Consumer, n int, wait time.Duration) ([]*queue.Message, error) {
+	var (
+		msgs     []*queue.Message
+		deadline time.Time
+	)
+	for len(msgs) < n {
+		remaining := wait
+		if len(msgs) > 0 {
+			if remaining = time.Until(deadline); remaining <= 0 {
+				break
+			}
+		}
+		msg, err := consumer.Receive(ctx, remaining)
+		if err != nil {
+			return nil, err
+		}
+		if msg == nil {
+			if len(msgs) > 0 || ctx.Err() != nil {
+				break
+			}
+			continue
+		}
+		if len(msgs) == 0 {
+			deadline = time.Now().Add(wait)
+		}
+		msgs = append(msgs, msg)
+	}
+	return msgs, nil
+}
+
+// processSeedMessages derives the seeds of msgs as one unit of job. A malformed message has
+// no result and is acknowledged with the batch, it would fail again.
+func processSeedMessages(ctx context.Context, msgs []*queue.Message, format seeds.Format, job distributed.Job, concurrency, cpuPercent int) []consumedResult {
+	unit := &distributed.Unit{Job: job}
+	var results []consumedResult
+	for _, msg := range msgs {
+		seed, err := format.Parse(string(msg.Body))
+		if err != nil {
+			slog.Warn("Skipped malformed seed message", "id", msg.ID, "err", err)
+			continue
+		}
+		// the line of a seed in the unit is the one of its result
+		seed.Line = len(unit.Seeds) + 1
+		unit.Seeds = append(unit.Seeds, seed)
+		results = append(results, consumedResult{msg: msg})
+	}
+	res, err := distributed.Process(ctx, unit, concurrency, cpuPercent)
+	if err != nil {
+		fatal("Failed to process seeds", "err", err)
+	}
+	for _, m := range res.Matches {
+		r := &results[m.Line-1]
+		m.Line = 1
+		r.Matches = append(r.Matches, m)
+	}
+	// the processed and failed counts are of the whole unit
+	if len(results) > 0 {
+		results[0].Processed, results[0].Failed = res.Processed, res.Failed
+	}
+	return results
+}
+
+// processUnitMessages processes the work unit of every message.
+func processUnitMessages(ctx context.Context, msgs []*queue.Message, concurrency, cpuPercent int) []consumedResult {
+	results := make([]consumedResult, 0, len(msgs))
+	for _, msg := range msgs {
+		var unit distributed.Unit
+		if err := json.Unmarshal(msg.Body, &unit); err != nil {
+			slog.Warn("Skipped malformed work unit message", "id", msg.ID, "err", err)
+			continue
+		}
+		unit.Job.Depth = max(unit.Job.Depth, 1)
+		res, err := distributed.Process(ctx, &unit, concurrency, cpuPercent)
+		if err != nil {
+			slog.Warn("Skipped work unit message", "id", msg.ID, "err", err)
+			continue
+		}
+		results = append(results, consumedResult{msg: msg, UnitResult: res})
+	}
+	return results
+}
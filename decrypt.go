@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/planxnx/ethereum-wallet-generator/internal/envelope"
+	"github.com/planxnx/ethereum-wallet-generator/internal/keycrypt"
+	"github.com/planxnx/ethereum-wallet-generator/internal/output"
+	"github.com/planxnx/ethereum-wallet-generator/wallets"
+)
+
+// runDecrypt copies files or stdin to stdout, opening the values envelope-encrypted with -kms
+// on the way, eg. ewg export -db found.db | ewg decrypt -kms vault://ewg. With -db, it
+// exports the wallets of a DB instead, their private keys stored with -db-encrypt-keys
+// decrypted.
+func runDecrypt(args []string) {
+	fs := flag.NewFlagSet("decrypt", flag.ExitOnError)
+	kms := fs.String("kms", "", "key the values were sealed with, awskms://KEY_ID, gcpkms://KEY_NAME or vault://[MOUNT/]KEY")
+	dbPath := fs.String("db", "", "export the wallets of this DB, decrypting the private keys stored with -db-encrypt-keys")
+	dbKey := fs.String("db-key", "", "SQLCipher passphrase of an encrypted DB")
+	passphrase := fs.String("db-encrypt-keys", "", "passphrase the private keys of -db were encrypted with")
+	format := fs.String("format", output.FormatCSV, fmt.Sprintf("-db export format %v", output.Formats))
+	outPath := fs.String("out", "", "write the -db export to this file instead of stdout")
+	columns := fs.String("columns", strings.Join(output.DefaultColumns, ","), fmt.Sprintf("comma separated columns of the csv format %v", output.Columns))
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s decrypt -kms KEY [files...]\n       %s decrypt -db DB -db-encrypt-keys PASSPHRASE [-out FILE]\n\n", os.Args[0], os.Args[0])
+		fs.PrintDefaults()
+	}
+	parseFlags(fs, args)
+
+	if *dbPath != "" {
+		if *passphrase == "" {
+			fmt.Fprintln(os.Stderr, "Error: --db requires --db-encrypt-keys")
+			os.Exit(exitUsage)
+		}
+		decryptDB(*dbPath, *dbKey, keycrypt.NewDecrypter(*passphrase), *format, *outPath, strings.Split(*columns, ","))
+		return
+	}
+	if *kms == "" {
+		fmt.Fprintln(os.Stderr, "Error: --kms or --db parameter required")
+		os.Exit(exitUsage)
+	}
+	w, err := envelope.Parse(*kms)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: --kms: %v\n", err)
+		os.Exit(exitUsage)
+	}
+	opener := envelope.NewOpener(w)
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+
+	decrypt := func(name string, r io.Reader) {
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+		for line := 1; scanner.Scan(); line++ {
+			text, err := opener.OpenAll(context.Background(), scanner.Text())
+			if err != nil {
+				out.Flush()
+				fatal("Failed to decrypt", "file", name, "line", line, "err", err)
+			}
+			fmt.Fprintln(out, text)
+		}
+		if err := scanner.Err(); err != nil {
+			out.Flush()
+			fatal("Failed to read input", "file", name, "err", err)
+		}
+	}
+	if fs.NArg() == 0 {
+		decrypt("-", os.Stdin)
+		return
+	}
+	for _, name := range fs.Args() {
+		f, err := os.Open(name)
+		if err != nil {
+			out.Flush()
+			fatal("Failed to open input", "err", err)
+		}
+		decrypt(name, f)
+		f.Close()
+	}
+}
+
+// decryptDB exports the wallets of a DB, decrypting their encrypted private keys. The
+// wallets stored without -db-encrypt-keys are exported as they are.
+func decryptDB(path, key string, decrypter *keycrypt.Decrypter, format, outPath string, columns []string) {
+	if !isServerDSN(path) {
+		if _, err := os.Stat(sqlitePath(path)); err != nil {
+			fatal("Failed to open sqlite DB", "err", err)
+		}
+	}
+	if outPath != "" {
+		prepareOutputDir("out", filepath.Dir(outPath))
+	}
+	if format == output.FormatParquet && outPath == "" {
+		fmt.Fprintln(os.Stderr, "Error: --format parquet requires --out")
+		os.Exit(exitUsage)
+	}
+
+	query := openDB(path, key).Model(&wallets.Wallet{}).Order("id")
+	out := openOutput(format, outPath, true, output.CompressNone, nil, output.Rotation{}, output.Options{Columns: columns}, false)
+	rows, err := query.Rows()
+	if err != nil {
+		fatal("Failed to query DB", "err", err)
+	}
+	defer rows.Close()
+
+	decrypted := 0
+	for rows.Next() {
+		var wallet wallets.Wallet
+		if err := query.ScanRows(rows, &wallet); err != nil {
+			fatal("Failed to read DB", "err", err)
+		}
+		if wallet.PrivateKeyCiphertext != "" {
+			privateKey, err := decrypter.Decrypt(keycrypt.Sealed{
+				Salt:       wallet.PrivateKeySalt,
+				Nonce:      wallet.PrivateKeyNonce,
+				Ciphertext: wallet.PrivateKeyCiphertext,
+			})
+			if err != nil {
+				out.Close()
+				fatal("Failed to decrypt", "address", wallet.Address, "err", err)
+			}
+			wallet.PrivateKey = privateKey
+			decrypted++
+		}
+		if err := out.Write(storedRecord(&wallet)); err != nil {
+			fatal("Failed to write export", "err", err)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		fatal("Failed to read DB", "err", err)
+	}
+	if err := out.Close(); err != nil {
+		fatal("Failed to close export", "err", err)
+	}
+	fmt.Fprintf(os.Stderr, "Decrypted %d wallets\n", decrypted)
+}
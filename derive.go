@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/planxnx/ethereum-wallet-generator/coins"
+	"github.com/planxnx/ethereum-wallet-generator/filter"
+	"github.com/planxnx/ethereum-wallet-generator/internal/keystore"
+	"github.com/planxnx/ethereum-wallet-generator/internal/output"
+	"github.com/planxnx/ethereum-wallet-generator/seeds"
+	"github.com/planxnx/ethereum-wallet-generator/wallets"
+)
+
+// runDerive derives the addresses of a single mnemonic.
+func runDerive(args []string) {
+	fs := flag.NewFlagSet("derive", flag.ExitOnError)
+	mnemonic := fs.String("mnemonic", "", "BIP39 mnemonic to derive, read from stdin if empty")
+	passphrase := fs.String("passphrase", "", "optional BIP39 passphrase")
+	basePath := fs.String("path", "", "base derivation path, the address index is appended to it, empty for the one of the --coin (m/44'/60'/0'/0 for eth)")
+	from := fs.Int("from", 0, "first address index to derive")
+	depth := fs.Int("depth", 1, "number of addresses to derive")
+	refuseWeak := fs.Bool("refuse-weak", false, "refuse a weak phrase (test vector, development tool mnemonic, published brainwallet, repeated or sequential words) instead of deriving it with a warning")
+	coinConfig := addCoinFlags(fs)
+	sinksConfig := addSinkFlags(fs)
+	offlineConfig := addOfflineFlag(fs)
+	parseFlags(fs, args)
+	offlineConfig()
+
+	phrase := strings.TrimSpace(*mnemonic)
+	if phrase == "" {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			fmt.Fprintln(os.Stderr, "Error: --mnemonic parameter or a mnemonic on stdin required")
+			os.Exit(exitUsage)
+		}
+		phrase = strings.TrimSpace(line)
+	}
+	if err := checkWeak(seeds.Seed{Phrase: phrase}); err != nil {
+		if *refuseWeak {
+			fmt.Fprintf(os.Stderr, "Error: %v, anyone may hold its keys (--refuse-weak)\n", err)
+			os.Exit(exitUsage)
+		}
+		slog.Warn("Weak mnemonic", "err", err)
+	}
+	coin, err := coinConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitUsage)
+	}
+	path := coin.BasePath()
+	if *basePath != "" {
+		if path, err = accounts.ParseDerivationPath(*basePath); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid --path: %v\n", err)
+			os.Exit(exitUsage)
+		}
+	}
+
+	deriver, err := coin.NewDeriver(phrase, *passphrase, path)
+	if err != nil {
+		fatal("Failed to derive base key", "err", err)
+	}
+	defer coins.Wipe(deriver)
+
+	sinks := sinksConfig()
+	defer sinks.Close()
+	if err := sinks.checkCoin(coin); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitUsage)
+	}
+	for i := max(*from, 0); i < max(*from, 0)+max(*depth, 1); i++ {
+		wallet, err := deriver.Derive(uint32(i))
+		if err != nil {
+			slog.Warn("Wallet derivation failed", "index", i, "err", err)
+			continue
+		}
+		sinks.Save(output.Record{Line: 1, Index: i, Mnemonic: phrase, Wallet: wallet})
+	}
+}
+
+// runRecover rebuilds the wallet details of a private key, or of the keystore files of a
+// directory.
+func runRecover(args []string) {
+	fs := flag.NewFlagSet("recover", flag.ExitOnError)
+	privateKey := fs.String("private-key", "", "hex private key to recover, read from stdin if empty")
+	keystoreIn := fs.String("keystore-in", "", "recover the keys of the keystore V3 files of this directory instead, decrypted with -keystore-password")
+	filterConfig := addFilterFlags(fs)
+	sinksConfig := addSinkFlags(fs)
+	offlineConfig := addOfflineFlag(fs)
+	parseFlags(fs, args)
+	offlineConfig()
+
+	filters := filterConfig()
+	validateAddress := filter.NewAddressValidator(filters)
+	validator := newValidators(filters)
+	matches := func(wallet *wallets.Wallet) bool {
+		return validateAddress(wallet.Address) && (validator == nil || validator.Valid(common.HexToAddress(wallet.Address), wallet))
+	}
+	if *keystoreIn != "" {
+		recoverKeystores(*keystoreIn, keystorePassword(fs), matches, sinksConfig)
+		return
+	}
+
+	hexKey := strings.TrimSpace(*privateKey)
+	if hexKey == "" {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			fmt.Fprintln(os.Stderr, "Error: --private-key parameter or a private key on stdin required")
+			os.Exit(exitUsage)
+		}
+		hexKey = strings.TrimSpace(line)
+	}
+
+	key, err := crypto.HexToECDSA(strings.TrimPrefix(hexKey, "0x"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: invalid private key: %v\n", err)
+		os.Exit(exitUsage)
+	}
+	wallet, err := wallets.NewFromPrivatekey(key)
+	if err != nil {
+		fatal("Failed to recover wallet", "err", err)
+	}
+
+	if !matches(wallet) {
+		fmt.Fprintln(os.Stderr, "The address doesn't match the filters")
+		exitCode = exitNoMatch
+		return
+	}
+	sinks := sinksConfig()
+	defer sinks.Close()
+	sinks.Save(output.Record{Wallet: wallet})
+}
+
+// recoverKeystores decrypts the keystore files of dir and saves the wallets matching the
+// filters, with the file as their seed file. Files failing to decrypt are logged and skipped.
+func recoverKeystores(dir, password string, matches func(*wallets.Wallet) bool, sinksConfig func() *resultSinks) {
+	paths, err := keystore.Files(dir)
+	if err != nil {
+		fatal("Failed to read keystore directory", "err", err)
+	}
+	if password == "" {
+		fmt.Fprintln(os.Stderr, "Error: --keystore-in requires --keystore-password or --keystore-password-file")
+		os.Exit(exitUsage)
+	}
+	sinks := sinksConfig()
+	found, failed := 0, 0
+	for _, path := range paths {
+		wallet, err := keystore.Read(path, password)
+		if err != nil {
+			slog.Warn("Keystore file skipped", "file", path, "err", err)
+			failed++
+			continue
+		}
+		if !matches(wallet) {
+			sinks.SaveMiss(output.Record{SeedFile: path, Wallet: wallet})
+			continue
+		}
+		sinks.Save(output.Record{SeedFile: path, Wallet: wallet})
+		found++
+	}
+	fmt.Fprintf(os.Stderr, "Recovered %d matching wallets from %d keystore files, %d failed to decrypt\n", found, len(paths), failed)
+	sinks.Close()
+	exitCode = runExitCode(int64(found), sinks.Failed(), false)
+}
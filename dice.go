@@ -0,0 +1,123 @@
+package main
+
+import (
+	"crypto/sha256"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/planxnx/ethereum-wallet-generator/bip39"
+	"github.com/planxnx/ethereum-wallet-generator/internal/entropy"
+	"github.com/planxnx/ethereum-wallet-generator/internal/wipe"
+	"github.com/planxnx/ethereum-wallet-generator/wallets"
+)
+
+// chiSquareCutoffs are the chi-square statistics of the face counts that a fair die or coin
+// only exceeds once in a thousand runs, with 5 and 1 degrees of freedom.
+var chiSquareCutoffs = map[string]float64{entropy.InputDice: 20.52, entropy.InputCoin: 10.83}
+
+// runDice creates a mnemonic from the entropy of dice or coin throws and prints how it was
+// built, so that the mnemonic can be checked by hand against the throws.
+func runDice(args []string) {
+	fs := flag.NewFlagSet("dice", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s dice [flags] [THROWS...]\n\nCreates a mnemonic from the entropy of physical dice rolls (1 to 6) or coin flips (H/T or 1/0), read from stdin when none is given.\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	input := fs.String("input", entropy.InputDice, fmt.Sprintf("what the throws are %v", entropy.Inputs))
+	bits := fs.Int("bit", wallets.DefaultMnemonicBits, "set number of entropy bits [128 for 12 words, 256 for 24 words]")
+	debias := fs.String("debias", entropy.DebiasVonNeumann, fmt.Sprintf("debiasing of the throws %v: vonneumann pairs them up and works with a loaded die or coin, but takes about 2.4 dice rolls or 4 coin flips per bit, none takes the throws of a fair die or coin as they are, about 0.6 dice rolls or 1 coin flip per bit", entropy.DebiasMethods))
+	passphrase := fs.String("passphrase", "", "BIP39 passphrase the printed address is derived with")
+	parseFlags(fs, args)
+
+	text := strings.Join(fs.Args(), " ")
+	if fs.NArg() == 0 {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			fatal("Failed to read throws", "err", err)
+		}
+		text = string(data)
+	}
+	throws, err := entropy.ParseThrows(*input, text)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitUsage)
+	}
+	x, err := entropy.Extract(*input, throws, *bits, *debias)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitUsage)
+	}
+	defer wipe.Bytes(x.Entropy)
+	mnemonic, err := bip39.NewMnemonic(x.Entropy)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitUsage)
+	}
+	key, err := wallets.DeriveWallet(bip39.NewSeed(mnemonic, *passphrase), append(slices.Clone(wallets.DefaultBaseDerivationPath), 0))
+	if err != nil {
+		fatal("Failed to derive the address", "err", err)
+	}
+	address := crypto.PubkeyToAddress(key.PublicKey).Hex()
+	wipe.Key(key)
+
+	printDiceSheet(os.Stdout, *input, *debias, x, mnemonic, address)
+}
+
+// printDiceSheet writes the throws read, the entropy extracted from them, its words bit by bit
+// and the resulting mnemonic and address to w.
+func printDiceSheet(w io.Writer, input, debias string, x *entropy.Extraction, mnemonic, address string) {
+	fmt.Fprintf(w, "Throws:    %d %s throws, %d used", x.Throws, input, x.Used)
+	if x.Discarded > 0 {
+		fmt.Fprintf(w, ", %d of them dropped by the %s debiasing", x.Discarded, debias)
+	}
+	if left := x.Throws - x.Used; left > 0 {
+		fmt.Fprintf(w, ", %d left over", left)
+	}
+	fmt.Fprintln(w)
+
+	fmt.Fprint(w, "Counts:   ")
+	expected := float64(x.Used) / float64(len(x.Counts))
+	chi := 0.0
+	for i, n := range x.Counts {
+		face := fmt.Sprint(i + 1)
+		if input == entropy.InputCoin {
+			face = []string{"T", "H"}[i]
+		}
+		fmt.Fprintf(w, " %s: %d", face, n)
+		chi += (float64(n) - expected) * (float64(n) - expected) / expected
+	}
+	fmt.Fprintf(w, " (chi-square %.2f)\n", chi)
+	if chi > chiSquareCutoffs[input] {
+		fmt.Fprintf(w, "WARNING:   the counts are unlikely for a fair %s, check the throws were written down correctly\n", input)
+	}
+
+	bits := len(x.Entropy) * 8
+	checksumBits := bits / 32
+	var b strings.Builder
+	for _, c := range x.Entropy {
+		fmt.Fprintf(&b, "%08b", c)
+	}
+	fmt.Fprintf(&b, "%08b", sha256.Sum256(x.Entropy)[0])
+	all := b.String()[:bits+checksumBits]
+	fmt.Fprintf(w, "Entropy:   %d bits %x\n", bits, x.Entropy)
+	fmt.Fprintf(w, "Checksum:  %d bits %s, the first bits of the SHA-256 of the entropy\n\n", checksumBits, all[bits:])
+
+	fmt.Fprintln(w, " #  bits         index  word")
+	for i, word := range strings.Fields(mnemonic) {
+		group := all[i*11 : i*11+11]
+		if i*11+11 > bits {
+			// the last word ends with the checksum
+			split := bits - i*11
+			group = group[:split] + " " + group[split:]
+		}
+		fmt.Fprintf(w, "%2d  %-12s  %4d  %s\n", i+1, group, slices.Index(bip39.Words, word), word)
+	}
+	fmt.Fprintf(w, "\nMnemonic:  %s\n", mnemonic)
+	fmt.Fprintf(w, "Address:   %s (%s/0)\n", address, wallets.DefaultBaseDerivationPathString)
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/planxnx/ethereum-wallet-generator/coins"
+	"github.com/planxnx/ethereum-wallet-generator/filter"
+	"github.com/planxnx/ethereum-wallet-generator/internal/distributed"
+	"github.com/planxnx/ethereum-wallet-generator/internal/output"
+	"github.com/planxnx/ethereum-wallet-generator/internal/throttle"
+	"github.com/planxnx/ethereum-wallet-generator/seeds"
+)
+
+// runCoordinator serves seed work units to remote workers and collects their matches.
+func runCoordinator(args []string) {
+	fs := flag.NewFlagSet("serve-coordinator", flag.ExitOnError)
+	listen := fs.String("listen", "127.0.0.1:7070", "address to serve the coordinator API on, eg. :7070 to serve the other machines with -tls-cert")
+	token := fs.String("token", "", "shared secret workers must present as a bearer token (required)")
+	tlsCert := fs.String("tls-cert", "", "serve HTTPS with this certificate file")
+	tlsKey := fs.String("tls-key", "", "private key file of -tls-cert")
+	var seedPatterns seeds.Patterns
+	fs.Var(&seedPatterns, "seeds", "file containing list of BIP39 mnemonics (one per line), - to read them from stdin. Repeat it or use glob patterns to read several files in order")
+	seedsFormat := fs.String("seeds-format", string(seeds.FormatText), "seeds file line format: text (one mnemonic per line), or tsv/csv lines of mnemonic, passphrase, hdpath and label fields")
+	seedRangeConfig := addSeedRangeFlags(fs)
+	seedKeyConfig := addSeedKeyFlags(fs)
+	dedupConfig := addDuplicatesFlag(fs)
+	depth := fs.Int("depth", 1, "number of addresses to derive per seed/mnemonic (default 1, >=1)")
+	coinConfig := addCoinFlags(fs)
+	sinksConfig := addSinkFlags(fs)
+	unitSize := fs.Int("unit-size", distributed.DefaultUnitSize, "number of seeds per work unit")
+	leaseTimeout := fs.Duration("lease-timeout", distributed.DefaultLeaseTimeout, "hand out a unit again if it isn't completed within this duration")
+	filterConfig := addFilterFlags(fs)
+	parseFlags(fs, args)
+
+	if *token == "" {
+		fmt.Fprintln(os.Stderr, "Error: --token is required, units carry mnemonics and results private keys")
+		os.Exit(exitUsage)
+	}
+	if (*tlsCert == "") != (*tlsKey == "") {
+		fmt.Fprintln(os.Stderr, "Error: --tls-cert and --tls-key must be given together")
+		os.Exit(exitUsage)
+	}
+	if len(seedPatterns) == 0 {
+		fmt.Fprintln(os.Stderr, "Error: --seeds parameter required, pointing to a file containing mnemonics")
+		os.Exit(exitUsage)
+	}
+	input, err := seeds.NewInput(seedPatterns, seeds.Format(*seedsFormat))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitUsage)
+	}
+	if err := seedKeyConfig(input); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitUsage)
+	}
+	seedRange, err := seedRangeConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitUsage)
+	}
+	duplicatesMode, err := dedupConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitUsage)
+	}
+
+	job := distributedJob(*depth, coinConfig, filterConfig())
+
+	seedCount := 0
+	if !input.IsStdin() {
+		if seedCount, err = input.CountLines(seedRange); err != nil {
+			fatal("Failed to open seeds file", "err", err)
+		}
+	}
+
+	sinks := sinksConfig()
+	if sinks.recordsAll() {
+		fmt.Fprintln(os.Stderr, "Error: --matches-out isn't supported by serve, its workers only report their matches")
+		os.Exit(exitUsage)
+	}
+	ctx, stop := withSignals(context.Background())
+	defer stop()
+	sinks.setBackpressure(ctx, nil)
+	seedCh, seedErrCh := input.Stream(ctx, seedRange, seeds.DefaultReadAhead)
+	var duplicates atomic.Int64
+	seedCh = dedupSeeds(ctx, seedCh, duplicatesMode, input, func(seeds.Seed, bool) { duplicates.Add(1) })
+	coordinator := distributed.NewCoordinator(distributed.CoordinatorConfig{
+		Seeds:        seedCh,
+		TotalSeeds:   seedCount,
+		UnitSize:     *unitSize,
+		Job:          job,
+		LeaseTimeout: *leaseTimeout,
+		Token:        *token,
+		OnMatch: func(m distributed.Match) {
+			file, line := input.Locate(m.Line)
+			sinks.Save(output.Record{SeedFile: file, Line: line, SeedLabel: m.Label, Index: m.Index, Mnemonic: m.Phrase, Wallet: m.Wallet})
+		},
+	})
+
+	server := &http.Server{
+		Addr:              *listen,
+		Handler:           coordinator.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		select {
+		case <-coordinator.Done():
+			time.Sleep(distributed.ShutdownGrace)
+		case <-ctx.Done():
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = server.Shutdown(ctx)
+	}()
+
+	if *tlsCert == "" && !isLoopback(*listen) {
+		slog.Warn("Coordinator served over plain HTTP off localhost, the mnemonics and private keys are readable on the network, use -tls-cert", "addr", *listen)
+	}
+	slog.Info("Coordinator listening", "addr", *listen, "seeds", seedCount, "tls", *tlsCert != "")
+	if *tlsCert != "" {
+		err = server.ListenAndServeTLS(*tlsCert, *tlsKey)
+	} else {
+		err = server.ListenAndServe()
+	}
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fatal("Coordinator server failed", "err", err)
+	}
+
+	if err := <-seedErrCh; err != nil {
+		slog.Error("Failed to read seeds file", "err", err)
+	}
+
+	sinks.Close()
+
+	status := coordinator.Status()
+	if sig := interruptSignal(ctx); sig != nil {
+		fmt.Fprintf(os.Stderr, "Interrupted by %v, %d seeds dispatched, %d units leased but not completed\n", sig, status.DispatchedSeeds, status.LeasedUnits)
+	}
+	fmt.Fprintf(os.Stderr, "Processed %d, failed %d, matches %d in %d units\n", status.Processed, status.Failed, status.Matches, status.CompletedUnits)
+	if n := duplicates.Load(); n > 0 {
+		fmt.Fprintf(os.Stderr, "Found %d duplicate seeds (--duplicates %s)\n", n, duplicatesMode)
+	}
+}
+
+// distributedJob returns the job of the -depth, -coin and filter flags handed to the workers,
+// exiting if the coin is unknown or the filters can't match its addresses.
+func distributedJob(depth int, coinConfig func() (coins.Coin, error), filters filter.Config) distributed.Job {
+	coin, err := coinConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitUsage)
+	}
+	if filters, err = coins.ApplyFilters(coin, filters); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitUsage)
+	}
+	return distributed.NewJob(max(depth, 1), filters, coin)
+}
+
+// runWorker processes work units leased from a coordinator until the run is done.
+func runWorker(args []string) {
+	fs := flag.NewFlagSet("worker", flag.ExitOnError)
+	coordinatorURL := fs.String("coordinator", "http://localhost:7070", "coordinator base URL, https:// for a coordinator served with -tls-cert")
+	token := fs.String("token", "", "shared secret to present to the coordinator as a bearer token (required)")
+	tlsCA := fs.String("tls-ca", "", "trust the coordinator certificate signed by this PEM CA file, or self-signed, in addition to the system roots")
+	poll := fs.Duration("poll", distributed.DefaultPollInterval, "delay between lease attempts when no work is available")
+	concurrency := fs.Int("c", 1, "set concurrency value (number of derivation workers)")
+	maxCPU := fs.String("max-cpu", "100%", "limit CPU usage of unit processing to the given percentage (eg. 50%)")
+	var plugins stringsFlag
+	fs.Var(&plugins, "validator-plugin", "load a Go plugin registering validators used by the run, can be repeated")
+	parseFlags(fs, args)
+
+	if *token == "" {
+		fmt.Fprintln(os.Stderr, "Error: --token is required, the coordinator refuses workers without it")
+		os.Exit(exitUsage)
+	}
+	coordinator, err := url.Parse(*coordinatorURL)
+	if err != nil || (coordinator.Scheme != "http" && coordinator.Scheme != "https") || coordinator.Host == "" {
+		fmt.Fprintf(os.Stderr, "Error: invalid --coordinator %q, expected an http:// or https:// URL\n", *coordinatorURL)
+		os.Exit(exitUsage)
+	}
+	client, err := coordinatorClient(*tlsCA)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: --tls-ca: %v\n", err)
+		os.Exit(exitUsage)
+	}
+	if coordinator.Scheme == "http" && !isLoopback(coordinator.Host) {
+		slog.Warn("Coordinator reached over plain HTTP, the mnemonics and private keys are readable on the network, serve it with -tls-cert", "coordinator", coordinator.Host)
+	}
+	for _, path := range plugins {
+		if err := filter.LoadPlugin(path); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitUsage)
+		}
+	}
+
+	cpuPercent, err := throttle.ParsePercent(*maxCPU)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitUsage)
+	}
+
+	worker := distributed.NewWorker(distributed.WorkerConfig{
+		CoordinatorURL: *coordinatorURL,
+		Token:          *token,
+		Client:         client,
+		PollInterval:   *poll,
+		Concurrency:    *concurrency,
+		CPUPercent:     cpuPercent,
+	})
+	// the unit being processed when interrupted is handed out again once its lease expires
+	ctx, stop := withSignals(context.Background())
+	defer stop()
+	if err := worker.Run(ctx); err != nil {
+		if sig := interruptSignal(ctx); sig != nil {
+			fmt.Fprintf(os.Stderr, "Interrupted by %v\n", sig)
+			return
+		}
+		fatal("Worker failed", "err", err)
+	}
+}
+
+// coordinatorClient returns the HTTP client of a worker, trusting the certificates of the
+// caFile PEM bundle if any.
+func coordinatorClient(caFile string) (*http.Client, error) {
+	client := &http.Client{Timeout: time.Minute}
+	if caFile == "" {
+		return client, nil
+	}
+	pem, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, err
+	}
+	roots, err := x509.SystemCertPool()
+	if err != nil {
+		roots = x509.NewCertPool()
+	}
+	if !roots.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no PEM certificate in %s", caFile)
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12}
+	client.Transport = transport
+	return client, nil
+}
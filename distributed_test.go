@@ -0,0 +1,46 @@
+package main
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCoordinatorClient(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer srv.Close()
+	ca := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(ca, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	system, err := coordinatorClient("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := system.Get(srv.URL); err == nil {
+		t.Error("self-signed coordinator trusted without -tls-ca")
+	}
+	client, err := coordinatorClient(ca)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("coordinator of -tls-ca refused: %v", err)
+	}
+	resp.Body.Close()
+
+	if _, err := coordinatorClient(filepath.Join(t.TempDir(), "missing.pem")); err == nil {
+		t.Error("missing -tls-ca accepted")
+	}
+	if err := os.WriteFile(ca, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := coordinatorClient(ca); err == nil {
+		t.Error("-tls-ca without certificate accepted")
+	}
+}
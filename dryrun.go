@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	cryptorand "crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/planxnx/ethereum-wallet-generator/filter"
+	"github.com/planxnx/ethereum-wallet-generator/seeds"
+	"github.com/planxnx/ethereum-wallet-generator/utils"
+	"github.com/planxnx/ethereum-wallet-generator/wallets"
+)
+
+const (
+	// calibrationBurst is how long a dry run derives random mnemonics to measure the throughput.
+	calibrationBurst = 2 * time.Second
+	// matchSamples is the number of random addresses a dry run checks against the filters.
+	matchSamples = 200_000
+	// maxReportedSeedErrors is the number of invalid seeds a dry run lists.
+	maxReportedSeedErrors = 5
+)
+
+// dryRun estimates a scan without deriving the seeds or writing anything.
+type dryRun struct {
+	input *seeds.Input
+	rng   seeds.Range
+	depth int
+	// skipped is the number of address indexes of the first seed a resumed run skips.
+	skipped    int
+	workers    int
+	cpuPercent int
+	filters    filter.Config
+}
+
+// run reads the selected seeds, checking their hd paths, calibrates the derivation speed
+// and prints the estimation to w.
+func (d dryRun) run(ctx context.Context, w io.Writer) error {
+	seedCh, errCh := d.input.Stream(ctx, d.rng, seeds.DefaultReadAhead)
+	seedCount, invalid := 0, 0
+	var seedErrors []string
+	for seed := range seedCh {
+		seedCount++
+		if seed.Path == "" {
+			continue
+		}
+		if _, err := accounts.ParseDerivationPath(seed.Path); err != nil {
+			invalid++
+			if len(seedErrors) < maxReportedSeedErrors {
+				file, line := d.input.Locate(seed.Line)
+				seedErrors = append(seedErrors, fmt.Sprintf("%sline %d: invalid hd path %q", filePrefix(file), line, seed.Path))
+			}
+		}
+	}
+	if err := <-errCh; err != nil {
+		return err
+	}
+	total := max(seedCount*d.depth-d.skipped, 0)
+
+	burst, cancel := context.WithTimeout(ctx, calibrationBurst)
+	defer cancel()
+	_, calibrated, elapsed := measureThroughput(burst, cryptorand.Reader, d.workers, d.depth, d.cpuPercent)
+	rate := float64(calibrated) / elapsed.Seconds()
+
+	// the sampled addresses are Ethereum ones, the filters of other coins aren't estimated
+	var (
+		matchRate float64
+		hits      int
+	)
+	if d.filters.Coin == "" {
+		matchRate, hits = estimateMatchRate(d.filters, matchSamples)
+	}
+
+	fmt.Fprintln(w, "Dry run, nothing was derived or written:")
+	fmt.Fprintf(w, "  Seeds:              %d (%d with an invalid hd path)\n", seedCount, invalid)
+	for _, msg := range seedErrors {
+		fmt.Fprintf(w, "    %s\n", msg)
+	}
+	fmt.Fprintf(w, "  Depth:              %d\n", d.depth)
+	fmt.Fprintf(w, "  Addresses:          %d\n", total)
+	fmt.Fprintf(w, "  Throughput:         %.1f addr/s with %d workers (%v calibration)\n", rate, d.workers, calibrationBurst)
+	if rate > 0 {
+		fmt.Fprintf(w, "  Estimated runtime:  %v\n", (time.Duration(float64(total)/rate) * time.Second).Round(time.Second))
+	}
+	switch {
+	case d.filters.Coin != "":
+		fmt.Fprintln(w, "  Match rate:         not estimated for the addresses of other coins than eth")
+	case hits == 0:
+		fmt.Fprintf(w, "  Match rate:         below %s (no hit in %d samples)\n", oneIn(matchRate), matchSamples)
+		fmt.Fprintf(w, "  Expected matches:   below %.2f\n", float64(total)*matchRate)
+	default:
+		fmt.Fprintf(w, "  Match rate:         %s\n", oneIn(matchRate))
+		fmt.Fprintf(w, "  Expected matches:   %.2f\n", float64(total)*matchRate)
+	}
+	return nil
+}
+
+// estimateMatchRate returns the probability a random address passes the filters, by sampling
+// random addresses, along with the number of sampled hits. A lowercase hex prefix and suffix
+// are forced on the samples and accounted for exactly, so that rare patterns still get hits.
+// When no sample hits, the rate is an upper bound. Validators receive wallets with only
+// their address set.
+func estimateMatchRate(cfg filter.Config, samples int) (float64, int) {
+	validate := filter.NewAddressValidator(cfg)
+	validator := newValidators(cfg)
+
+	var prefix string
+	if cfg.Prefix != "" {
+		prefix = strings.TrimPrefix(utils.Add0xPrefix(cfg.Prefix), "0x")
+	}
+	suffix := cfg.Suffix
+	fixed := 1.0
+	if !isLowerHex(prefix) || !isLowerHex(suffix) || len(prefix)+len(suffix) > 2*common.AddressLength {
+		prefix, suffix = "", ""
+	} else {
+		fixed = math.Pow(16, -float64(len(prefix)+len(suffix)))
+	}
+
+	hits := 0
+	var raw [common.AddressLength]byte
+	for i := 0; i < samples; i++ {
+		for j := range raw {
+			raw[j] = byte(rand.Uint32())
+		}
+		digits := hex.EncodeToString(raw[:])
+		address := "0x" + prefix + digits[len(prefix):len(digits)-len(suffix)] + suffix
+		if !validate(address) {
+			continue
+		}
+		if validator != nil && !validator.Valid(common.HexToAddress(address), &wallets.Wallet{Address: address}) {
+			continue
+		}
+		hits++
+	}
+	return fixed * float64(max(hits, 1)) / float64(samples), hits
+}
+
+// isLowerHex reports whether s only has lowercase hex digits.
+func isLowerHex(s string) bool {
+	return strings.Trim(s, "0123456789abcdef") == ""
+}
+
+// oneIn formats a probability as "1 in N".
+func oneIn(p float64) string {
+	if p >= 1 {
+		return "1 in 1"
+	}
+	return fmt.Sprintf("1 in %.0f", 1/p)
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// diskFlags are the flags writing to disk, refused with -ephemeral.
+var diskFlags = []string{
+	"db",
+	"out",
+	"matches-out",
+	"keystore",
+	"qr-dir",
+	"paper-wallet-dir",
+	"checkpoint",
+	"resume",
+	"summary-json",
+	"errors-file",
+	"progress-file",
+	"log-file",
+	"seen-filter",
+	"upload",
+}
+
+// checkEphemeral refuses the diskFlags set on fs when its -ephemeral flag is, and keeps the
+// process from dumping its memory, keys included, to a core file. The results then only go
+// to stdout, encrypted with -encrypt-output or -gpg-recipient if set.
+func checkEphemeral(fs *flag.FlagSet) error {
+	if f := fs.Lookup("ephemeral"); f == nil || f.Value.String() != "true" {
+		return nil
+	}
+	var set []string
+	for _, name := range diskFlags {
+		if f := fs.Lookup(name); f != nil && f.Value.String() != f.DefValue {
+			set = append(set, "--"+name)
+		}
+	}
+	if len(set) > 0 {
+		return errors.Errorf("--ephemeral writes nothing to disk, it can't be combined with %s", strings.Join(set, ", "))
+	}
+	// a key ID or e-mail is looked up by running gpg, which may update its home directory
+	if f := fs.Lookup("gpg-recipient"); f != nil {
+		for _, recipient := range *f.Value.(*stringsFlag) {
+			if info, err := os.Stat(recipient); err != nil || info.IsDir() {
+				return errors.Errorf("--ephemeral requires the --gpg-recipient %q to be a public key file, not a key of the gpg keyring", recipient)
+			}
+		}
+	}
+	return errors.WithMessage(disableCoreDumps(), "--ephemeral: failed to disable core dumps")
+}
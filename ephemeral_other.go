@@ -0,0 +1,8 @@
+//go:build !unix
+
+package main
+
+// disableCoreDumps does nothing, the platform has no core files.
+func disableCoreDumps() error {
+	return nil
+}
@@ -0,0 +1,14 @@
+//go:build unix
+
+package main
+
+import (
+	"syscall"
+
+	"github.com/pkg/errors"
+)
+
+// disableCoreDumps sets the core file size limit of the process to zero.
+func disableCoreDumps() error {
+	return errors.WithStack(syscall.Setrlimit(syscall.RLIMIT_CORE, &syscall.Rlimit{}))
+}
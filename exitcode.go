@@ -0,0 +1,36 @@
+package main
+
+// The exit codes of the commands, the contract scripts and CI jobs branch on.
+const (
+	// exitOK is a completed run that found matches, or any other command that succeeded.
+	exitOK = 0
+	// exitNoMatch is a completed run without any match, or a verification that failed.
+	exitNoMatch = 1
+	// exitUsage is an invalid flag, configuration or input found before anything ran, the code
+	// the flag package exits with too.
+	exitUsage = 2
+	// exitFailure is a failure while running: the run stopped midway, or results were lost.
+	exitFailure = 3
+	// exitStopped is a run interrupted by a signal, its -timeout or the dashboard before it
+	// completed, with or without matches. A checkpointed run can be resumed.
+	exitStopped = 4
+)
+
+// exitCode is the exit code of the command once it returns, set by the commands whose
+// outcome isn't a plain success.
+var exitCode = exitOK
+
+// runExitCode returns the exit code of a run that found matches, failed if some seeds
+// couldn't be read or results couldn't be written, stopped before it completed.
+func runExitCode(matches int64, failed, stopped bool) int {
+	switch {
+	case failed:
+		return exitFailure
+	case stopped:
+		return exitStopped
+	case matches == 0:
+		return exitNoMatch
+	default:
+		return exitOK
+	}
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"cmp"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/planxnx/ethereum-wallet-generator/internal/output"
+	"github.com/planxnx/ethereum-wallet-generator/internal/shamir"
+	"github.com/planxnx/ethereum-wallet-generator/wallets"
+)
+
+// runExport dumps the wallets stored in a DB to stdout or a file in one of the output formats.
+func runExport(args []string) {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	dbPath := fs.String("db", "", "sqlite DB file to export eg. wallets.db (a bare file name is read from ./db) or out/wallets.db, or a postgres:// or mysql:// DSN")
+	dbKey := fs.String("db-key", "", "SQLCipher passphrase of an encrypted DB")
+	format := fs.String("format", output.FormatCSV, fmt.Sprintf("export format %v", output.Formats))
+	outPath := fs.String("out", "", "write the export to this file instead of stdout")
+	columns := fs.String("columns", strings.Join(output.DefaultColumns, ","), fmt.Sprintf("comma separated columns of the csv format %v", output.Columns))
+	formatTemplate := fs.String("format-template", "", "text/template rendering one line per wallet (implies -format template)")
+	prefix := fs.String("prefix", "", "export only addresses starting with this prefix")
+	hdPath := fs.String("hd-path", "", "export only wallets whose derivation path starts with this prefix (eg. m/44'/60'/0'/0)")
+	since := fs.String("since", "", "export only wallets stored at or after this date (2006-01-02 or RFC3339)")
+	until := fs.String("until", "", "export only wallets stored before this date (2006-01-02 or RFC3339)")
+	runID := fs.String("run", "", "export only wallets stored by the run with this ID")
+	split := fs.String("shamir", "", "split the private key and mnemonic of every wallet into K/N Shamir shares (eg. 3/5), writing share I to share-I/ next to --out, so no file holds a complete key")
+	parseFlags(fs, args)
+
+	if *dbPath == "" {
+		fmt.Fprintln(os.Stderr, "Error: --db parameter required")
+		os.Exit(exitUsage)
+	}
+	if !isServerDSN(*dbPath) {
+		if _, err := os.Stat(sqlitePath(*dbPath)); err != nil {
+			fatal("Failed to open sqlite DB", "err", err)
+		}
+	}
+	if *outPath != "" {
+		prepareOutputDir("out", filepath.Dir(*outPath))
+	}
+	if *format == output.FormatParquet && *outPath == "" {
+		fmt.Fprintln(os.Stderr, "Error: --format parquet requires --out")
+		os.Exit(exitUsage)
+	}
+	if *formatTemplate != "" {
+		*format = output.FormatTemplate
+	}
+	var k, n int
+	if *split != "" {
+		var err error
+		if k, n, err = parseShamir(*split); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid --shamir: %v\n", err)
+			os.Exit(exitUsage)
+		}
+		if *outPath == "" {
+			fmt.Fprintln(os.Stderr, "Error: --shamir requires --out")
+			os.Exit(exitUsage)
+		}
+	}
+
+	query := openDB(*dbPath, *dbKey).Model(&wallets.Wallet{}).Order("id")
+	if *prefix != "" {
+		query = query.Where("address LIKE ?", strings.ToLower(*prefix)+"%")
+	}
+	if *hdPath != "" {
+		query = query.Where("hd_path LIKE ?", *hdPath+"%")
+	}
+	if *runID != "" {
+		query = query.Where("run_id = ?", *runID)
+	}
+	for _, bound := range []struct {
+		value, cond string
+	}{{*since, "created_at >= ?"}, {*until, "created_at < ?"}} {
+		if bound.value == "" {
+			continue
+		}
+		t, err := parseDate(bound.value)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitUsage)
+		}
+		query = query.Where(bound.cond, t)
+	}
+
+	opts := output.Options{
+		Columns:  strings.Split(*columns, ","),
+		Template: *formatTemplate,
+	}
+	outs := []*output.Writer{}
+	if n == 0 {
+		outs = append(outs, openOutput(*format, *outPath, true, output.CompressNone, nil, output.Rotation{}, opts, false))
+	}
+	for i := 1; i <= n; i++ {
+		path := sharePath(*outPath, i)
+		prepareOutputDir("out", filepath.Dir(path))
+		outs = append(outs, openOutput(*format, path, false, output.CompressNone, nil, output.Rotation{}, opts, false))
+	}
+	rows, err := query.Rows()
+	if err != nil {
+		fatal("Failed to query DB", "err", err)
+	}
+	defer rows.Close()
+
+	exported := 0
+	for rows.Next() {
+		var wallet wallets.Wallet
+		if err := query.ScanRows(rows, &wallet); err != nil {
+			fatal("Failed to read DB", "err", err)
+		}
+		records := []output.Record{storedRecord(&wallet)}
+		if n > 0 {
+			if records, err = splitRecord(records[0], k, n); err != nil {
+				fatal("Failed to split private key", "address", wallet.Address, "err", err)
+			}
+		}
+		for i, out := range outs {
+			if err := out.Write(records[i]); err != nil {
+				fatal("Failed to write export", "err", err)
+			}
+		}
+		exported++
+	}
+	if err := rows.Err(); err != nil {
+		fatal("Failed to read DB", "err", err)
+	}
+	for _, out := range outs {
+		if err := out.Close(); err != nil {
+			fatal("Failed to close export", "err", err)
+		}
+	}
+	if n > 0 {
+		fmt.Fprintf(os.Stderr, "Exported %d wallets split into %d-of-%d shares under %s\n", exported, k, n, filepath.Join(filepath.Dir(*outPath), "share-*"))
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Exported %d wallets\n", exported)
+}
+
+// parseShamir parses a K/N Shamir split.
+func parseShamir(s string) (k, n int, err error) {
+	if _, err := fmt.Sscanf(s, "%d/%d", &k, &n); err != nil {
+		return 0, 0, errors.Errorf("%q isn't K/N", s)
+	}
+	if k < 2 || k > n || n > shamir.MaxShares {
+		return 0, 0, errors.Errorf("want 2 <= K <= N <= %d, got %s", shamir.MaxShares, s)
+	}
+	return k, n, nil
+}
+
+// sharePath returns the path of share i of the export to path, in its own share-I directory.
+func sharePath(path string, i int) string {
+	return filepath.Join(filepath.Dir(path), fmt.Sprintf("share-%d", i), filepath.Base(path))
+}
+
+// splitRecord returns the n records of r whose private key and mnemonic are replaced by
+// their hex encoded k-of-n Shamir shares.
+func splitRecord(r output.Record, k, n int) ([]output.Record, error) {
+	records := make([]output.Record, n)
+	copies := make([]wallets.Wallet, n)
+	for i := range records {
+		copies[i] = *r.Wallet
+		records[i] = r
+		records[i].Wallet = &copies[i]
+	}
+	if r.Wallet.PrivateKey != "" {
+		shares, err := shamir.Split([]byte(r.Wallet.PrivateKey), k, n)
+		if err != nil {
+			return nil, err
+		}
+		for i, share := range shares {
+			copies[i].PrivateKey = hex.EncodeToString(share)
+		}
+	}
+	if mnemonic := cmp.Or(r.Mnemonic, r.Wallet.Mnemonic); mnemonic != "" {
+		shares, err := shamir.Split([]byte(mnemonic), k, n)
+		if err != nil {
+			return nil, err
+		}
+		for i, share := range shares {
+			copies[i].Mnemonic = hex.EncodeToString(share)
+			records[i].Mnemonic = copies[i].Mnemonic
+		}
+	}
+	return records, nil
+}
+
+// storedRecord returns the output record of a wallet read from a DB.
+func storedRecord(w *wallets.Wallet) output.Record {
+	if w.ChecksumAddress == "" && w.Address != "" {
+		w.ChecksumAddress = output.ChecksumAddress(w)
+	}
+	return output.Record{SeedFile: w.SeedFile, Line: w.SeedLine, SeedLabel: w.SeedLabel, Index: w.AddressIndex, Mnemonic: w.Mnemonic, Wallet: w}
+}
+
+// parseDate parses a date or an RFC3339 timestamp.
+func parseDate(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation(time.DateOnly, s, time.Local)
+	if err != nil {
+		return time.Time{}, errors.Errorf("invalid date %q, expected 2006-01-02 or RFC3339", s)
+	}
+	return t, nil
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/planxnx/ethereum-wallet-generator/bip39"
+	"github.com/planxnx/ethereum-wallet-generator/internal/wipe"
+	"github.com/planxnx/ethereum-wallet-generator/pipeline"
+	"github.com/planxnx/ethereum-wallet-generator/seeds"
+)
+
+// Error policies of -on-error.
+const (
+	// onErrorContinue logs the errors and goes on, the default.
+	onErrorContinue = "continue"
+	// onErrorFail stops the run on the first derivation or sink error.
+	onErrorFail = "fail"
+	// onErrorSkipSeed also skips the address indexes of a seed after one failed to derive.
+	onErrorSkipSeed = "skip-seed"
+)
+
+// errOnErrorFail is the cancellation cause of a run stopped by -on-error fail.
+var errOnErrorFail = errors.New("stopped on the first error")
+
+// failureRecord is a line of the -errors-file report.
+type failureRecord struct {
+	File string `json:"file,omitempty"`
+	Line int    `json:"line"`
+	// Index is the address index that failed, nil when the whole seed did.
+	Index    *int   `json:"index,omitempty"`
+	Category string `json:"category"`
+	Reason   string `json:"reason"`
+}
+
+// failureReport writes the failed seed lines of a run as JSON lines, so they can be fixed and
+// scanned again. It never holds the seeds themselves.
+type failureReport struct {
+	f   *os.File
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+// openFailureReport creates the report at path, or appends to it when a run is resumed.
+func openFailureReport(path string, resume bool) (*failureReport, error) {
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if resume {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+	f, err := os.OpenFile(path, flags, 0o644)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	w := bufio.NewWriter(f)
+	return &failureReport{f: f, w: w, enc: json.NewEncoder(w)}, nil
+}
+
+// Add writes a failure of the seed at line of file.
+func (r *failureReport) Add(file string, line int, f pipeline.Failure) error {
+	rec := failureRecord{File: file, Line: line, Category: f.Category, Reason: f.Err.Error()}
+	if f.Index >= 0 {
+		rec.Index = &f.Index
+	}
+	return errors.WithStack(r.enc.Encode(rec))
+}
+
+// Close flushes and closes the report.
+func (r *failureReport) Close() error {
+	if err := r.w.Flush(); err != nil {
+		r.f.Close()
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(r.f.Close())
+}
+
+// checkMnemonic rejects the phrases that aren't BIP39 mnemonics, without quoting their words.
+func checkMnemonic(seed seeds.Seed) error {
+	entropy, err := bip39.EntropyFromMnemonic(seed.Phrase)
+	if err == nil {
+		wipe.Bytes(entropy)
+		return nil
+	}
+	for i, w := range strings.Fields(seed.Phrase) {
+		if !bip39.IsWord(w) {
+			return errors.Errorf("word %d isn't in the BIP39 wordlist", i+1)
+		}
+	}
+	return errors.Errorf("not a BIP39 mnemonic: %s", strings.ToLower(err.Error()))
+}
+
+// checkWeak rejects the weak phrases of bip39.Weakness, whose keys anyone may have derived.
+func checkWeak(seed seeds.Seed) error {
+	if reason := bip39.Weakness(seed.Phrase); reason != "" {
+		return errors.Errorf("weak mnemonic: %s", reason)
+	}
+	return nil
+}
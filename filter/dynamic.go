@@ -0,0 +1,102 @@
+package filter
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+// Dynamic is an address validator that counts the hits of each of its filters and can be
+// tightened with more regexes or reset while in use. Validate may run concurrently with
+// AddRegex and Reset.
+type Dynamic struct {
+	checked atomic.Int64
+	stages  atomic.Pointer[[]*stage]
+}
+
+// stage is a filter of a Dynamic validator, an address must pass every stage in order.
+type stage struct {
+	name  string
+	match func(address string) bool
+	hits  atomic.Int64
+	// since is the number of hits of the previous stage when this one was added.
+	since int64
+}
+
+// StageStats is the number of addresses that passed a filter, out of the ones reaching it.
+type StageStats struct {
+	Name    string
+	Checked int64
+	Hits    int64
+}
+
+// NewDynamic returns a validator starting with the filters of cfg.
+func NewDynamic(cfg Config) *Dynamic {
+	d := &Dynamic{}
+	d.stages.Store(&[]*stage{{name: cfg.String(), match: NewAddressValidator(cfg)}})
+	return d
+}
+
+// Validate reports whether the address passes every filter.
+func (d *Dynamic) Validate(address string) bool {
+	d.checked.Add(1)
+	for _, s := range *d.stages.Load() {
+		if !s.match(address) {
+			return false
+		}
+		s.hits.Add(1)
+	}
+	return true
+}
+
+// AddRegex adds a regex every further address must also match.
+func (d *Dynamic) AddRegex(expr string) error {
+	r, err := CompileRegex(expr)
+	if err != nil {
+		return err
+	}
+	stages := append([]*stage(nil), *d.stages.Load()...)
+	last := stages[len(stages)-1]
+	stages = append(stages, &stage{name: "regex=" + expr, match: r.MatchString, since: last.hits.Load()})
+	d.stages.Store(&stages)
+	return nil
+}
+
+// Reset replaces every filter by the ones of cfg, the regexes added included.
+func (d *Dynamic) Reset(cfg Config) {
+	d.stages.Store(&[]*stage{{name: cfg.String(), match: NewAddressValidator(cfg), since: d.checked.Load()}})
+}
+
+// Stats returns the hits of every filter. A filter added mid-run only counts the
+// addresses checked since then.
+func (d *Dynamic) Stats() []StageStats {
+	stages := *d.stages.Load()
+	stats := make([]StageStats, len(stages))
+	checked := d.checked.Load()
+	for i, s := range stages {
+		stats[i] = StageStats{Name: s.name, Checked: checked - s.since, Hits: s.hits.Load()}
+		checked = stats[i].Hits
+	}
+	return stats
+}
+
+// String returns the non-empty filters of the config, eg. "prefix=0x00 suffix=ff".
+func (cfg Config) String() string {
+	var parts []string
+	if contains := strings.Join(cfg.Contains, ","); contains != "" {
+		mode := "contains"
+		if cfg.Strict {
+			mode = "contains(strict)"
+		}
+		parts = append(parts, mode+"="+contains)
+	}
+	for _, f := range []struct{ name, value string }{{"prefix", cfg.Prefix}, {"suffix", cfg.Suffix}, {"regex", strings.Join(cfg.Regex, "|")}} {
+		if f.value != "" {
+			parts = append(parts, fmt.Sprintf("%s=%s", f.name, f.value))
+		}
+	}
+	if len(parts) == 0 {
+		return "none"
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,121 @@
+// Package filter matches wallet addresses against contains, prefix, suffix and regex filters.
+package filter
+
+import (
+	"regexp"
+	"regexp/syntax"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/planxnx/ethereum-wallet-generator/utils"
+)
+
+// Config is the set of address filters, all of them must match for an address to be valid.
+type Config struct {
+	Contains []string `json:"contains,omitempty"`
+	Strict   bool     `json:"strict,omitempty"`
+	Prefix   string   `json:"prefix,omitempty"`
+	Suffix   string   `json:"suffix,omitempty"`
+	// Regex are alternative patterns, an address must match at least one of them.
+	Regex []string `json:"regex,omitempty"`
+	// Validators are the specs of registered validators, see NewValidators. They need the
+	// wallet and are not applied by NewAddressValidator.
+	Validators []string `json:"validators,omitempty"`
+	// Coin is the chain of the addresses of another chain than Ethereum, eg. btc. Lead is the
+	// fixed start of its addresses, eg. bc1q, a prefix lacking it is completed with it. Both
+	// are empty for Ethereum addresses, whose prefix is completed with 0x.
+	Coin string `json:"coin,omitempty"`
+	Lead string `json:"lead,omitempty"`
+}
+
+// FullPrefix returns the prefix filter starting with the address lead, "" if there is none.
+func (cfg Config) FullPrefix() string {
+	switch {
+	case cfg.Prefix == "":
+		return ""
+	case cfg.Coin == "":
+		return utils.Add0xPrefix(cfg.Prefix)
+	case strings.HasPrefix(cfg.Prefix, cfg.Lead):
+		return cfg.Prefix
+	default:
+		return cfg.Lead + cfg.Prefix
+	}
+}
+
+// Validate reports an invalid regex or validator spec, which NewAddressValidator and
+// NewValidators would fail on.
+func (cfg Config) Validate() error {
+	for _, expr := range cfg.Regex {
+		if _, err := CompileRegex(expr); err != nil {
+			return err
+		}
+	}
+	_, err := NewValidators(cfg.Validators)
+	return err
+}
+
+// CompileRegex compiles an address regex, with an error locating the invalid part of the pattern.
+func CompileRegex(expr string) (*regexp.Regexp, error) {
+	r, err := regexp.Compile(expr)
+	if err == nil {
+		return r, nil
+	}
+	var syntaxErr *syntax.Error
+	if !errors.As(err, &syntaxErr) {
+		return nil, errors.Wrapf(err, "invalid regex %q", expr)
+	}
+
+	// the reported fragment is the whole pattern for unbalanced parentheses
+	pos := strings.Index(expr, syntaxErr.Expr)
+	switch syntaxErr.Code {
+	case syntax.ErrMissingParen:
+		pos = strings.LastIndex(expr, "(")
+	case syntax.ErrUnexpectedParen:
+		pos = strings.LastIndex(expr, ")")
+	}
+	if pos < 0 {
+		return nil, errors.Errorf("invalid regex %q: %s", expr, syntaxErr.Code)
+	}
+	return nil, errors.Errorf("invalid regex %q: %s at character %d (%q)", expr, syntaxErr.Code, pos+1, expr[pos:])
+}
+
+// NewAddressValidator returns a function that reports whether an address matches every filter of the config.
+// It panics if a regex is invalid, see Validate.
+func NewAddressValidator(cfg Config) func(address string) bool {
+	regexes := make([]*regexp.Regexp, len(cfg.Regex))
+	for i, expr := range cfg.Regex {
+		r, err := CompileRegex(expr)
+		if err != nil {
+			panic(err)
+		}
+		regexes[i] = r
+	}
+	prefix := cfg.FullPrefix()
+
+	return func(address string) bool {
+		isValid := true
+		// contains logic
+		if len(cfg.Contains) > 0 && cfg.Contains[0] != "" {
+			contains := func(c string) bool {
+				return strings.Contains(address, c)
+			}
+			if cfg.Strict && !utils.Have(cfg.Contains, contains) {
+				isValid = false
+			}
+			if !cfg.Strict && !utils.Some(cfg.Contains, contains) {
+				isValid = false
+			}
+		}
+		if prefix != "" && !strings.HasPrefix(address, prefix) {
+			isValid = false
+		}
+		if cfg.Suffix != "" && !strings.HasSuffix(address, cfg.Suffix) {
+			isValid = false
+		}
+		if len(regexes) > 0 && !utils.Some(regexes, func(r *regexp.Regexp) bool { return r.MatchString(address) }) {
+			isValid = false
+		}
+		return isValid
+	}
+}
@@ -0,0 +1,76 @@
+package filter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/planxnx/ethereum-wallet-generator/wallets"
+)
+
+func TestNewAddressValidatorRegex(t *testing.T) {
+	validate := NewAddressValidator(Config{Regex: []string{"^0x00", "ff$"}})
+	for addr, want := range map[string]bool{
+		"0x00" + strings.Repeat("1", 38):      true,
+		"0x" + strings.Repeat("1", 38) + "ff": true,
+		"0x" + strings.Repeat("1", 40):        false,
+	} {
+		if got := validate(addr); got != want {
+			t.Errorf("validate(%s) = %v, want %v", addr, got, want)
+		}
+	}
+}
+
+func TestCompileRegex(t *testing.T) {
+	_, err := CompileRegex("^0x(ab")
+	if err == nil || !strings.Contains(err.Error(), "at character 4") {
+		t.Errorf("CompileRegex error = %v, want the position of the unclosed parenthesis", err)
+	}
+}
+
+func TestTally(t *testing.T) {
+	tally, err := NewTally(Config{Prefix: "00", Suffix: "ff", Validators: []string{"zero-bytes:1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, addr := range []string{
+		"0x00" + strings.Repeat("1", 36) + "ff",
+		"0x00" + strings.Repeat("1", 38),
+		"0x" + strings.Repeat("1", 40),
+	} {
+		if tally.Valid(common.HexToAddress(addr), &wallets.Wallet{Address: addr}) {
+			t.Errorf("tally accepted %s", addr)
+		}
+	}
+
+	if got := tally.Checked(); got != 3 {
+		t.Errorf("checked = %d, want 3", got)
+	}
+	if got := tally.Matches(); got != 1 {
+		t.Errorf("matches = %d, want 1", got)
+	}
+	want := []PatternCount{{"prefix=00", 2}, {"suffix=ff", 1}, {"validator=zero-bytes:1", 2}}
+	for i, c := range tally.Counts() {
+		if c != want[i] {
+			t.Errorf("count %d = %+v, want %+v", i, c, want[i])
+		}
+	}
+}
+
+func TestLive(t *testing.T) {
+	live, err := NewLive(Config{Prefix: "00"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := "0x00" + strings.Repeat("1", 36) + "ff"
+	if !live.ValidAddress(addr) {
+		t.Errorf("%s doesn't pass the prefix", addr)
+	}
+	if err := live.Set(Config{Regex: []string{"[("}}); err == nil || live.Config().Prefix != "00" {
+		t.Errorf("an invalid config replaced the filters: %v", err)
+	}
+	if err := live.Set(Config{Suffix: "ee"}); err != nil || live.ValidAddress(addr) {
+		t.Errorf("%s passes the replaced filters: %v", addr, err)
+	}
+}
@@ -0,0 +1,61 @@
+package filter
+
+import (
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/planxnx/ethereum-wallet-generator/wallets"
+)
+
+// Live is a filter whose config can be replaced while in use, eg. to reload it mid-run. Its
+// methods may run concurrently, an address checked during Set passes the old or the new
+// filters.
+type Live struct {
+	current atomic.Pointer[liveFilters]
+}
+
+// liveFilters are the compiled filters of a config.
+type liveFilters struct {
+	cfg       Config
+	address   func(address string) bool
+	validator Validator
+}
+
+// NewLive returns a live filter starting with the filters of cfg.
+func NewLive(cfg Config) (*Live, error) {
+	l := &Live{}
+	if err := l.Set(cfg); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Set replaces the filters by the ones of cfg, keeping the current ones when cfg is invalid.
+func (l *Live) Set(cfg Config) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	validator, err := NewValidators(cfg.Validators)
+	if err != nil {
+		return err
+	}
+	l.current.Store(&liveFilters{cfg: cfg, address: NewAddressValidator(cfg), validator: validator})
+	return nil
+}
+
+// Config returns the config of the current filters.
+func (l *Live) Config() Config {
+	return l.current.Load().cfg
+}
+
+// ValidAddress reports whether the address passes the address filters, see NewAddressValidator.
+func (l *Live) ValidAddress(address string) bool {
+	return l.current.Load().address(address)
+}
+
+// Valid reports whether the wallet passes the validators of the config, see NewValidators.
+func (l *Live) Valid(addr common.Address, w *wallets.Wallet) bool {
+	v := l.current.Load().validator
+	return v == nil || v.Valid(addr, w)
+}
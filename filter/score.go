@@ -0,0 +1,83 @@
+package filter
+
+import (
+	"encoding/hex"
+	"math/bits"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+)
+
+// Scorer rates how nice an address looks, the higher the nicer.
+type Scorer func(addr common.Address) int
+
+// DefaultScorer is the scoring of the addresses ranked without one named.
+const DefaultScorer = "vanity"
+
+// scorers are the scorings ranking addresses, by name.
+var scorers = map[string]Scorer{
+	"vanity":        Vanity,
+	"leading-zeros": LeadingZeros,
+	"zero-bytes":    ZeroBytes,
+}
+
+// Scorers returns the names of the address scorings, sorted.
+func Scorers() []string {
+	names := make([]string, 0, len(scorers))
+	for name := range scorers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewScorer returns the address scoring of a name.
+func NewScorer(name string) (Scorer, error) {
+	scorer, ok := scorers[name]
+	if !ok {
+		return nil, errors.Errorf("unknown scorer %q, must be one of %s", name, strings.Join(Scorers(), ", "))
+	}
+	return scorer, nil
+}
+
+// Vanity counts the hex digits repeating the first one at the start of the address and the
+// last one at its end, leading zeros counting twice, so 0x0000...beef scores above
+// 0xaaaa...beef and 0x7777...7777 above both.
+func Vanity(addr common.Address) int {
+	digits := hex.EncodeToString(addr[:])
+	head := len(digits) - len(strings.TrimLeft(digits, digits[:1]))
+	if head == len(digits) {
+		return 2 * head
+	}
+	tail := len(digits) - len(strings.TrimRight(digits, digits[len(digits)-1:]))
+	score := head + tail
+	if digits[0] == '0' {
+		score += head
+	}
+	return score
+}
+
+// LeadingZeros counts the zero hex digits the address starts with.
+func LeadingZeros(addr common.Address) int {
+	n := 0
+	for _, b := range addr {
+		if b != 0 {
+			return n + bits.LeadingZeros8(b)/4
+		}
+		n += 2
+	}
+	return n
+}
+
+// ZeroBytes counts the zero bytes of the address, the ones cheapest in calldata.
+func ZeroBytes(addr common.Address) int {
+	n := 0
+	for _, b := range addr {
+		if b == 0 {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,108 @@
+package filter
+
+import (
+	"strings"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/planxnx/ethereum-wallet-generator/wallets"
+)
+
+// Tally is a validator that rejects every wallet, counting instead the wallets each pattern
+// of a config matches on its own, and the ones the whole config matches.
+type Tally struct {
+	checked  atomic.Int64
+	all      atomic.Int64
+	patterns []*tallyPattern
+	address  func(address string) bool
+	valid    Validator
+}
+
+type tallyPattern struct {
+	name  string
+	match func(addr common.Address, w *wallets.Wallet) bool
+	hits  atomic.Int64
+}
+
+// PatternCount is the number of wallets a pattern matched.
+type PatternCount struct {
+	Pattern string
+	Matches int64
+}
+
+// NewTally returns a tally of the patterns of cfg: each contains string, the prefix, the suffix,
+// each regex and each validator. The config must be valid, see Validate.
+func NewTally(cfg Config) (*Tally, error) {
+	t := &Tally{address: NewAddressValidator(cfg)}
+	addAddress := func(name string, match func(address string) bool) {
+		t.patterns = append(t.patterns, &tallyPattern{name: name, match: func(_ common.Address, w *wallets.Wallet) bool {
+			return match(w.Address)
+		}})
+	}
+	for _, c := range cfg.Contains {
+		if c != "" {
+			addAddress("contains="+c, func(address string) bool { return strings.Contains(address, c) })
+		}
+	}
+	if cfg.Prefix != "" {
+		prefix := cfg.FullPrefix()
+		addAddress("prefix="+cfg.Prefix, func(address string) bool { return strings.HasPrefix(address, prefix) })
+	}
+	if cfg.Suffix != "" {
+		addAddress("suffix="+cfg.Suffix, func(address string) bool { return strings.HasSuffix(address, cfg.Suffix) })
+	}
+	for _, expr := range cfg.Regex {
+		r, err := CompileRegex(expr)
+		if err != nil {
+			return nil, err
+		}
+		addAddress("regex="+expr, r.MatchString)
+	}
+	for _, spec := range cfg.Validators {
+		v, err := NewValidator(spec)
+		if err != nil {
+			return nil, err
+		}
+		t.patterns = append(t.patterns, &tallyPattern{name: "validator=" + spec, match: v.Valid})
+	}
+	valid, err := NewValidators(cfg.Validators)
+	if err != nil {
+		return nil, err
+	}
+	t.valid = valid
+	return t, nil
+}
+
+// Valid counts the patterns the wallet matches and returns false.
+func (t *Tally) Valid(addr common.Address, w *wallets.Wallet) bool {
+	t.checked.Add(1)
+	for _, p := range t.patterns {
+		if p.match(addr, w) {
+			p.hits.Add(1)
+		}
+	}
+	if t.address(w.Address) && (t.valid == nil || t.valid.Valid(addr, w)) {
+		t.all.Add(1)
+	}
+	return false
+}
+
+// Checked returns the number of wallets counted.
+func (t *Tally) Checked() int64 {
+	return t.checked.Load()
+}
+
+// Matches returns the number of wallets matching the whole config.
+func (t *Tally) Matches() int64 {
+	return t.all.Load()
+}
+
+// Counts returns the matches of every pattern, in config order.
+func (t *Tally) Counts() []PatternCount {
+	counts := make([]PatternCount, len(t.patterns))
+	for i, p := range t.patterns {
+		counts[i] = PatternCount{Pattern: p.name, Matches: p.hits.Load()}
+	}
+	return counts
+}
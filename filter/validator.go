@@ -0,0 +1,145 @@
+package filter
+
+import (
+	"plugin"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+
+	"github.com/planxnx/ethereum-wallet-generator/wallets"
+)
+
+// Validator selects wallets with logic the address filters cannot express, such as a
+// scoring of the address bytes.
+type Validator interface {
+	Valid(addr common.Address, w *wallets.Wallet) bool
+}
+
+// ValidatorFunc adapts a function to a Validator.
+type ValidatorFunc func(addr common.Address, w *wallets.Wallet) bool
+
+// Valid calls f.
+func (f ValidatorFunc) Valid(addr common.Address, w *wallets.Wallet) bool {
+	return f(addr, w)
+}
+
+// Factory returns a validator configured by the argument of its spec, "" when there is none.
+type Factory func(arg string) (Validator, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Factory)
+)
+
+// Register makes a validator available to the validator specs under the given name.
+// It is meant to be called from init functions, including the ones of plugins, and
+// panics if the name is already registered.
+func Register(name string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, ok := registry[name]; ok {
+		panic("filter: validator " + name + " registered twice")
+	}
+	registry[name] = factory
+}
+
+// Registered returns the names of the registered validators, sorted.
+func Registered() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LoadPlugin opens a Go plugin, whose init functions are expected to Register its validators.
+// The plugin must be built with the same Go version and module versions as this program.
+func LoadPlugin(path string) error {
+	if _, err := plugin.Open(path); err != nil {
+		return errors.Wrapf(err, "failed to load validator plugin %s", path)
+	}
+	return nil
+}
+
+// NewValidator returns the validator of a spec, a registered name optionally followed by
+// a colon and its argument (eg. "leading-zeros:4").
+func NewValidator(spec string) (Validator, error) {
+	name, arg, _ := strings.Cut(spec, ":")
+	registryMu.RLock()
+	factory, ok := registry[name]
+	registryMu.RUnlock()
+	if !ok {
+		return nil, errors.Errorf("unknown validator %q, registered validators are %s", name, strings.Join(Registered(), ", "))
+	}
+	v, err := factory(arg)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid validator %q", spec)
+	}
+	return v, nil
+}
+
+// NewValidators returns a validator passing the wallets every validator of the specs accepts,
+// or nil when there are no specs.
+func NewValidators(specs []string) (Validator, error) {
+	if len(specs) == 0 {
+		return nil, nil
+	}
+	validators := make([]Validator, len(specs))
+	for i, spec := range specs {
+		v, err := NewValidator(spec)
+		if err != nil {
+			return nil, err
+		}
+		validators[i] = v
+	}
+	return ValidatorFunc(func(addr common.Address, w *wallets.Wallet) bool {
+		for _, v := range validators {
+			if !v.Valid(addr, w) {
+				return false
+			}
+		}
+		return true
+	}), nil
+}
+
+func init() {
+	Register("leading-zeros", countValidator(LeadingZeros))
+	Register("zero-bytes", countValidator(ZeroBytes))
+	Register("icap", icapValidator)
+}
+
+// icapValidator returns a validator passing the addresses whose ICAP form (XE...) matches
+// the regex argument, eg. icap:^XE..GAV.
+func icapValidator(arg string) (Validator, error) {
+	if arg == "" {
+		return nil, errors.New("expected a regex argument")
+	}
+	r, err := CompileRegex(arg)
+	if err != nil {
+		return nil, err
+	}
+	return ValidatorFunc(func(addr common.Address, _ *wallets.Wallet) bool {
+		return r.MatchString(wallets.ICAP(addr))
+	}), nil
+}
+
+// countValidator returns a factory of validators passing the addresses whose count is at
+// least the argument.
+func countValidator(count func(addr common.Address) int) Factory {
+	return func(arg string) (Validator, error) {
+		minimum, err := strconv.Atoi(arg)
+		if err != nil || minimum < 1 {
+			return nil, errors.New("expected a positive minimum count argument")
+		}
+		return ValidatorFunc(func(addr common.Address, _ *wallets.Wallet) bool {
+			return count(addr) >= minimum
+		}), nil
+	}
+}
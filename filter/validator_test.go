@@ -0,0 +1,62 @@
+package filter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestNewValidators(t *testing.T) {
+	v, err := NewValidators([]string{"leading-zeros:3", "zero-bytes:2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"0x000f" + strings.Repeat("0", 36), true},
+		{"0x000f" + strings.Repeat("1", 32) + "0000", true},
+		{"0x000f" + strings.Repeat("1", 36), false},
+		{"0x00f0" + strings.Repeat("0", 36), false},
+	}
+	for _, tt := range tests {
+		if got := v.Valid(common.HexToAddress(tt.addr), nil); got != tt.want {
+			t.Errorf("Valid(%s) = %v, want %v", tt.addr, got, tt.want)
+		}
+	}
+
+	icap, err := NewValidator("icap:^XE7338O073")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !icap.Valid(common.HexToAddress("0x00c5496aee77c1ba1f0854206a26dda82a81d6d8"), nil) || icap.Valid(common.HexToAddress("0x52dc504a422f0e2a9e7632a34a50f1a82f8224c7"), nil) {
+		t.Error("icap validator didn't match the ICAP of the address")
+	}
+
+	for _, spec := range []string{"unknown", "leading-zeros", "zero-bytes:0", "icap", "icap:("} {
+		if _, err := NewValidators([]string{spec}); err == nil {
+			t.Errorf("NewValidators(%q) succeeded, want an error", spec)
+		}
+	}
+}
+
+func TestVanity(t *testing.T) {
+	tests := []struct {
+		addr string
+		want int
+	}{
+		{"0x0000" + strings.Repeat("12", 16) + "beef", 9},
+		{"0xaaaa" + strings.Repeat("12", 16) + "beef", 5},
+		{"0xaaaa" + strings.Repeat("12", 16) + "ffff", 8},
+		{"0x" + strings.Repeat("7", 40), 80},
+		{"0x1" + strings.Repeat("23", 19) + "4", 2},
+	}
+	for _, tt := range tests {
+		if got := Vanity(common.HexToAddress(tt.addr)); got != tt.want {
+			t.Errorf("Vanity(%s) = %d, want %d", tt.addr, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/planxnx/ethereum-wallet-generator/coins"
+	"github.com/planxnx/ethereum-wallet-generator/seeds"
+)
+
+// Placeholders of the path templates searched by find-path. {index} ends every template, it is
+// the address index the coin appends to the base path.
+const (
+	accountPlaceholder = "{account}"
+	changePlaceholder  = "{change}"
+	indexPlaceholder   = "{index}"
+)
+
+// evmPathTemplates are the paths the Ethereum wallets put their addresses at: BIP44 (MetaMask,
+// Trezor, and Ledger Live with one account per address), the legacy Ledger and MyEtherWallet
+// one, Ethereum Classic and the testnet coin type.
+var evmPathTemplates = []string{
+	"m/44'/60'/{account}'/{change}/{index}",
+	"m/44'/60'/{account}'/{index}",
+	"m/44'/61'/{account}'/{change}/{index}",
+	"m/44'/1'/{account}'/{change}/{index}",
+}
+
+// runFindPath searches the derivation paths of the seeds for the ones producing known addresses,
+// for a wallet whose path was lost.
+func runFindPath(args []string) {
+	fs := flag.NewFlagSet("find-path", flag.ExitOnError)
+	var targets stringsFlag
+	fs.Var(&targets, "address", "address to find, can be repeated")
+	var seedPatterns seeds.Patterns
+	fs.Var(&seedPatterns, "seeds", "file containing list of BIP39 mnemonics (one per line), - to read them from stdin (default). Repeat it or use glob patterns to read several files in order")
+	seedsFormat := fs.String("seeds-format", string(seeds.FormatText), "seeds file line format: text (one mnemonic per line), or tsv/csv lines of mnemonic, passphrase, hdpath and label fields")
+	seedKeyConfig := addSeedKeyFlags(fs)
+	passphrase := fs.String("passphrase", "", "BIP39 passphrase of the seeds without one")
+	var templates stringsFlag
+	fs.Var(&templates, "template", "path template searched, with {account} and {change} placeholders and ending with /{index}, eg. \"m/44'/60'/{account}'/{change}/{index}\". Repeat it to search several (default the common ones of the --coin)")
+	accountsBound := fs.Int("accounts", 5, "number of account numbers searched, from 0")
+	changesBound := fs.Int("changes", 2, "number of change numbers searched, from 0 (0 for external, 1 for internal addresses)")
+	indexesBound := fs.Int("indexes", 20, "number of address indexes searched, from 0")
+	all := fs.Bool("all", false, "search every seed and path instead of stopping once every address is found")
+	concurrency := fs.Int("c", 1, "set concurrency value (number of derivation workers)")
+	coinConfig := addCoinFlags(fs)
+	parseFlags(fs, args)
+
+	if len(targets) == 0 {
+		fmt.Fprintln(os.Stderr, "Error: --address parameter required")
+		os.Exit(exitUsage)
+	}
+	coin, err := coinConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitUsage)
+	}
+	found := make(map[string]bool)
+	for _, target := range targets {
+		if coins.EVM(coin) && !common.IsHexAddress(target) {
+			fmt.Fprintf(os.Stderr, "Error: invalid --address %q\n", target)
+			os.Exit(exitUsage)
+		}
+		found[addressKey(coin, target)] = false
+	}
+	if len(templates) == 0 {
+		templates = defaultPathTemplates(coin)
+	}
+	bases, err := expandPathTemplates(templates, max(*accountsBound, 1), max(*changesBound, 1))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitUsage)
+	}
+
+	if len(seedPatterns) == 0 {
+		seedPatterns = seeds.Patterns{seeds.Stdin}
+	}
+	input, err := seeds.NewInput(seedPatterns, seeds.Format(*seedsFormat))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitUsage)
+	}
+	if err := seedKeyConfig(input); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitUsage)
+	}
+	fmt.Fprintf(os.Stderr, "Searching %d base paths and %d address indexes per seed for %d addresses\n", len(bases), max(*indexesBound, 1), len(found))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	seedCh, errCh := input.Stream(ctx, seeds.Range{}, seeds.DefaultReadAhead)
+	var (
+		mu      sync.Mutex
+		missing = len(found)
+		wg      sync.WaitGroup
+	)
+	for range max(*concurrency, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for seed := range seedCh {
+				if seed.Passphrase == "" {
+					seed.Passphrase = *passphrase
+				}
+				seedBases := bases
+				if seed.Path != "" {
+					path, err := accounts.ParseDerivationPath(seed.Path)
+					if err != nil {
+						slog.Warn("Seed skipped", "line", seed.Line, "err", fmt.Sprintf("invalid hd path %q", seed.Path))
+						continue
+					}
+					seedBases = []accounts.DerivationPath{path}
+				}
+				searchPaths(ctx, coin, seed, seedBases, max(*indexesBound, 1), func(address, path string) {
+					key := addressKey(coin, address)
+					mu.Lock()
+					defer mu.Unlock()
+					done, ok := found[key]
+					if !ok || (done && !*all) {
+						return
+					}
+					if !done {
+						found[key] = true
+						missing--
+					}
+					file, line := input.Locate(seed.Line)
+					where := fmt.Sprint(line)
+					if file != "" {
+						where = fmt.Sprintf("%s:%d", file, line)
+					}
+					fmt.Printf("%s\t%s\t%s\n", address, where, path)
+					if missing == 0 && !*all {
+						cancel()
+					}
+				})
+			}
+		}()
+	}
+	wg.Wait()
+	if err := <-errCh; err != nil && ctx.Err() == nil {
+		fatal("Failed to read seeds", "err", err)
+	}
+
+	if missing > 0 {
+		for _, target := range targets {
+			if !found[addressKey(coin, target)] {
+				fmt.Fprintf(os.Stderr, "%s not found\n", target)
+			}
+		}
+		os.Exit(exitNoMatch)
+	}
+	fmt.Fprintln(os.Stderr, "Every address found")
+}
+
+// searchPaths derives the address indexes of seed under every base path, calling fn with the
+// address and hd path of each, until ctx is canceled.
+func searchPaths(ctx context.Context, coin coins.Coin, seed seeds.Seed, bases []accounts.DerivationPath, indexes int, fn func(address, path string)) {
+	for _, base := range bases {
+		if ctx.Err() != nil {
+			return
+		}
+		deriver, err := coin.NewDeriver(seed.Phrase, seed.Passphrase, base)
+		if err != nil {
+			slog.Warn("Failed to derive base key", "line", seed.Line, "path", base.String(), "err", err)
+			continue
+		}
+		for i := 0; i < indexes; i++ {
+			w, err := deriver.Derive(uint32(i))
+			if err != nil {
+				continue
+			}
+			fn(w.Address, coin.Path(base, uint32(i)))
+		}
+		coins.Wipe(deriver)
+	}
+}
+
+// defaultPathTemplates returns the common templates of the EVM coins, or the BIP44 one of the
+// base path of another coin, whose account and change numbers vary.
+func defaultPathTemplates(coin coins.Coin) []string {
+	if coins.EVM(coin) {
+		return evmPathTemplates
+	}
+	base := coin.BasePath()
+	if len(base) == 4 && base[2] >= 0x80000000 {
+		return []string{accounts.DerivationPath(base[:2]).String() + "/" + accountPlaceholder + "'/" + changePlaceholder + "/" + indexPlaceholder}
+	}
+	return []string{base.String() + "/" + indexPlaceholder}
+}
+
+// expandPathTemplates returns the distinct base paths of the templates, their {account} and
+// {change} placeholders replaced by the numbers below the bounds and their /{index} dropped.
+func expandPathTemplates(templates []string, accountsBound, changesBound int) ([]accounts.DerivationPath, error) {
+	var bases []accounts.DerivationPath
+	seen := make(map[string]bool)
+	for _, template := range templates {
+		base, ok := strings.CutSuffix(strings.TrimSpace(template), "/"+indexPlaceholder)
+		if !ok || strings.Contains(base, indexPlaceholder) {
+			return nil, fmt.Errorf("invalid --template %q, it must end with /%s", template, indexPlaceholder)
+		}
+		for account := range accountsBound {
+			for change := range changesBound {
+				p := strings.ReplaceAll(base, accountPlaceholder, strconv.Itoa(account))
+				p = strings.ReplaceAll(p, changePlaceholder, strconv.Itoa(change))
+				if seen[p] {
+					continue
+				}
+				seen[p] = true
+				// m alone is the master key, the base of the coins without a BIP44 path
+				var path accounts.DerivationPath
+				if p != "m" {
+					var err error
+					if path, err = accounts.ParseDerivationPath(p); err != nil {
+						return nil, fmt.Errorf("invalid --template %q: %v", template, err)
+					}
+				}
+				bases = append(bases, path)
+			}
+		}
+	}
+	return bases, nil
+}
+
+// addressKey returns the address compared with the derived ones, EVM addresses without their case.
+func addressKey(coin coins.Coin, address string) string {
+	if coins.EVM(coin) {
+		return strings.ToLower(address)
+	}
+	return address
+}
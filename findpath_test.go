@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+
+	"github.com/planxnx/ethereum-wallet-generator/coins"
+	"github.com/planxnx/ethereum-wallet-generator/seeds"
+)
+
+func TestExpandPathTemplates(t *testing.T) {
+	bases, err := expandPathTemplates([]string{"m/44'/60'/{account}'/{change}/{index}", "m/44'/60'/{account}'/{index}"}, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, b := range bases {
+		got = append(got, b.String())
+	}
+	want := "m/44'/60'/0'/0 m/44'/60'/0'/1 m/44'/60'/1'/0 m/44'/60'/1'/1 m/44'/60'/0' m/44'/60'/1'"
+	if strings.Join(got, " ") != want {
+		t.Errorf("bases %v, want %s", got, want)
+	}
+
+	for _, template := range []string{"m/44'/60'/0'/0", "m/44'/60'/{index}'/{index}", "m/44'/x/{index}"} {
+		if _, err := expandPathTemplates([]string{template}, 1, 1); err == nil {
+			t.Errorf("template %q accepted", template)
+		}
+	}
+}
+
+func TestSearchPaths(t *testing.T) {
+	bases, err := expandPathTemplates(evmPathTemplates, 3, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the second address of the second account, on the change chain
+	base, _ := accounts.ParseDerivationPath("m/44'/60'/1'/1")
+	deriver, err := coins.ETH.NewDeriver(testMnemonic, "", base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lost, err := deriver.Derive(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	found := map[string]string{}
+	searchPaths(context.Background(), coins.ETH, seeds.Seed{Phrase: testMnemonic}, bases, 2, func(address, path string) {
+		found[addressKey(coins.ETH, address)] = path
+	})
+	if path := found[addressKey(coins.ETH, lost.Address)]; path != "m/44'/60'/1'/1/1" {
+		t.Errorf("found %s at %q", lost.Address, path)
+	}
+	if _, ok := found["0x0000000000000000000000000000000000000001"]; ok {
+		t.Error("found an address no path derives")
+	}
+	if len(found) != len(bases)*2 {
+		t.Errorf("searched %d addresses, want %d", len(found), len(bases)*2)
+	}
+}
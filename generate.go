@@ -0,0 +1,285 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/planxnx/ethereum-wallet-generator/filter"
+	"github.com/planxnx/ethereum-wallet-generator/generator"
+	"github.com/planxnx/ethereum-wallet-generator/internal/entropy"
+	"github.com/planxnx/ethereum-wallet-generator/internal/output"
+	"github.com/planxnx/ethereum-wallet-generator/internal/wipe"
+	"github.com/planxnx/ethereum-wallet-generator/store"
+	"github.com/planxnx/ethereum-wallet-generator/wallets"
+)
+
+// runGenerate generates random wallets and keeps the ones passing the address filters.
+func runGenerate(args []string) {
+	fs := flag.NewFlagSet("generate", flag.ExitOnError)
+	number := fs.Int("n", 10, "number of wallets to generate (-1 for no limit, stop with Ctrl+C)")
+	limit := fs.Int("limit", 0, "stop after this many matching wallets (0 for no limit)")
+	mode := fs.String("mode", "1", "wallet generation mode [1 or mnemonic: normal mode, 2 or privatekey: only private key mode, 3 or keyspace: private key vanity search computing only the address of the keys until one passes the address filters]")
+	keyspaceBackend := fs.String("keyspace-backend", "random", "key search of -mode keyspace [random: a scalar multiplication per random key, incremental: walks of consecutive keys from a random one, adding the generator point from key to key, an order of magnitude faster]")
+	bits := fs.Int("bit", wallets.DefaultMnemonicBits, "set number of entropy bits [128 for 12 words, 256 for 24 words]")
+	concurrency := fs.Int("c", 1, "set concurrency value (number of generation workers)")
+	entropySource := fs.String("entropy", "crypto", fmt.Sprintf("random source of the mnemonics and private keys %v, device takes the path of a hardware RNG", entropy.Sources))
+	entropyMix := fs.String("entropy-mix", "", "file of user supplied entropy (eg. dice rolls) hashed into every random byte of -entropy")
+	deterministic := fs.String("deterministic", "", "INSECURE, for tests and benchmarks only: generate the same mnemonics and private keys on every run from this seed instead of -entropy, anyone knowing it has the keys")
+	dryRun := fs.Bool("dryrun", false, "generate wallets without storing or printing results (used for benchmark speed)")
+	sinksConfig := addSinkFlags(fs)
+	filterConfig := addFilterFlags(fs)
+	metricsConfig := addMetricsFlags(fs)
+	progressConfig := addProgressFlags(fs)
+	uploadConfig := addUploadFlags(fs)
+	offlineConfig := addOfflineFlag(fs)
+	parseFlags(fs, args)
+	offlineConfig()
+	uploadConfig()
+	metricsConfig()
+	runMetrics.SetWorkers(max(*concurrency, 1))
+	runMetrics.SetTotal(*number)
+	newProgressBar := progressConfig()
+
+	var mix []byte
+	if *entropyMix != "" {
+		var err error
+		if mix, err = os.ReadFile(*entropyMix); err != nil {
+			fatal("Failed to read entropy mix file", "err", err)
+		}
+	}
+	var (
+		random *entropy.Reader
+		err    error
+	)
+	if *deterministic != "" {
+		warnDeterministic()
+		random, err = entropy.Deterministic(*deterministic, mix)
+	} else {
+		random, err = entropy.Open(*entropySource, mix)
+	}
+	wipe.Bytes(mix)
+	if err != nil {
+		fatal("Failed to open entropy source", "source", *entropySource, "err", err)
+	}
+	defer random.Close()
+
+	filters := filterConfig()
+	// the filters and -limit are replaced by a SIGHUP reloading the -config file
+	liveFilter, err := filter.NewLive(filters)
+	if err != nil {
+		fatal("Failed to prepare filters", "err", err)
+	}
+	validAddress := liveFilter.ValidAddress
+	var (
+		repo      store.Repository = store.NewInMemoryRepository()
+		sinks     *resultSinks
+		walletGen wallets.Generator
+	)
+	switch *mode {
+	case "1", "mnemonic":
+		walletGen = wallets.NewGeneratorMnemonicFrom(random, *bits)
+	case "2", "privatekey":
+		walletGen = wallets.NewGeneratorPrivatekeyFrom(random)
+	case "3", "keyspace":
+		// the wallets of every key are computed when the sinks record them all
+		accept := func(address string) bool {
+			return sinks.recordsAll() || validAddress(address)
+		}
+		switch *keyspaceBackend {
+		case "random":
+			walletGen = wallets.NewGeneratorKeyspaceFrom(random, accept)
+		case "incremental":
+			walletGen = wallets.NewGeneratorIncrementalFrom(random, accept)
+		default:
+			fmt.Fprintf(os.Stderr, "Error: unknown --keyspace-backend %q, must be random or incremental\n", *keyspaceBackend)
+			os.Exit(exitUsage)
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "Error: unknown --mode %q, must be 1 (mnemonic), 2 (privatekey) or 3 (keyspace)\n", *mode)
+		os.Exit(exitUsage)
+	}
+
+	if !*dryRun {
+		sinks = sinksConfig()
+		repo = &sinkRepository{sinks: sinks}
+	}
+	if sinks.recordsAll() && *keyspaceBackend == "incremental" {
+		// every key would end its walk
+		fmt.Fprintln(os.Stderr, "Error: --keyspace-backend incremental can't be combined with --matches-out, use the random backend to record every key")
+		os.Exit(exitUsage)
+	}
+	var onMiss func(*wallets.Wallet)
+	if sinks.recordsAll() {
+		onMiss = repo.(*sinkRepository).InsertMiss
+	}
+	if *limit <= 0 {
+		*limit = -1
+	}
+	var gen *generator.Generator
+	walletGen, entropyFailure := failClosed(walletGen, func() { go gen.Shutdown() })
+	gen = generator.New(walletGen, repo, generator.Config{
+		AddresValidator: validAddress,
+		Validator:       liveFilter,
+		OnMiss:          onMiss,
+		ProgressBar:     meteredProgress{newProgressBar(*number, false)},
+		Concurrency:     max(*concurrency, 1),
+		Number:          *number,
+		Limit:           *limit,
+		DryRun:          *dryRun,
+	})
+
+	ctx, stop := withSignals(context.Background())
+	defer stop()
+	sinks.setBackpressure(ctx, nil)
+	go func() {
+		<-ctx.Done()
+		if interruptSignal(ctx) != nil {
+			_ = gen.Shutdown()
+		}
+	}()
+	watchReload(ctx, fs, func(r reloadedFlags) error {
+		if err := liveFilter.Set(r.filters); err != nil {
+			return err
+		}
+		if r.limit <= 0 {
+			r.limit = -1
+		}
+		gen.SetLimit(r.limit)
+		return nil
+	})
+
+	_, span := tracer.Start(ctx, "generate", trace.WithAttributes(attribute.String("ewg.mode", *mode), attribute.Int("ewg.number", *number)))
+	stats, err := gen.Start()
+	if err != nil {
+		span.RecordError(err)
+	}
+	span.SetAttributes(attribute.Int64("ewg.resolved", int64(stats.Resolved)))
+	span.End()
+	if err != nil {
+		fatal("Generator failed", "err", err)
+	}
+	if err := entropyFailure(); err != nil {
+		fatal("Stopped generating", "err", err)
+	}
+	if !*dryRun {
+		printWallets(repo.Result())
+		uploadArtifacts(sinks.dbFile())
+	}
+	exitCode = runExitCode(int64(stats.Resolved), sinks.Failed(), interruptSignal(ctx) != nil)
+
+	fmt.Fprintf(os.Stderr, "\nResolved Speed: %.2f w/s\n", float64(stats.Resolved)/stats.Duration.Seconds())
+	fmt.Fprintf(os.Stderr, "Total Duration: %v\n", stats.Duration)
+	fmt.Fprintf(os.Stderr, "Total Wallet Resolved: %d w\n", stats.Resolved)
+	fmt.Fprintf(os.Stderr, "\nCopyright (C) 2023 Planxnx <planxthanee@gmail.com>\n")
+}
+
+// warnDeterministic warns that the keys of -deterministic are reproducible by anyone.
+func warnDeterministic() {
+	slog.Warn("INSECURE deterministic mode: the wallets are reproducible from the -deterministic seed, never fund them")
+}
+
+// failClosed returns walletGen calling shutdown once its entropy source failed, rather than
+// generating from it again, and a function returning that failure.
+func failClosed(walletGen wallets.Generator, shutdown func()) (wallets.Generator, func() error) {
+	var (
+		once   sync.Once
+		mu     sync.Mutex
+		failed error
+	)
+	gen := func() (*wallets.Wallet, error) {
+		w, err := walletGen()
+		if errors.Is(err, entropy.ErrSourceFailed) {
+			mu.Lock()
+			failed = err
+			mu.Unlock()
+			once.Do(shutdown)
+		}
+		return w, err
+	}
+	return gen, func() error {
+		mu.Lock()
+		defer mu.Unlock()
+		return failed
+	}
+}
+
+// printWallets prints a table of the wallet addresses along with their seed, or their
+// private key in private key mode.
+func printWallets(w []*wallets.Wallet) {
+	if len(w) == 0 {
+		return
+	}
+	col2Name := "Seed"
+	var result strings.Builder
+	for _, wallet := range w {
+		col2 := wallet.Mnemonic
+		if wallet.Mnemonic == "" {
+			col2 = wallet.PrivateKey
+			col2Name = "Private Key"
+		}
+		if _, err := fmt.Fprintf(&result, "%-42s %s\n", wallet.Address, col2); err != nil {
+			continue
+		}
+	}
+	fmt.Printf("\n%-42s %s\n", "Address", col2Name)
+	fmt.Printf("%-42s %s\n", strings.Repeat("-", 42), strings.Repeat("-", 90))
+	fmt.Println(result.String())
+}
+
+// sinkRepository adapts the result sinks to the repository used by the generator,
+// which inserts from several goroutines.
+type sinkRepository struct {
+	mu    sync.Mutex
+	sinks *resultSinks
+}
+
+func (r *sinkRepository) Insert(wallet *wallets.Wallet) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sinks.Save(output.Record{Mnemonic: wallet.Mnemonic, Wallet: wallet})
+	runMetrics.Match()
+	return nil
+}
+
+// InsertMiss records a generated wallet that isn't a match, with -matches-out.
+func (r *sinkRepository) InsertMiss(wallet *wallets.Wallet) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sinks.SaveMiss(output.Record{Mnemonic: wallet.Mnemonic, Wallet: wallet})
+}
+
+func (r *sinkRepository) Result() []*wallets.Wallet {
+	return nil
+}
+
+func (r *sinkRepository) Commit() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.sinks.Flush()
+}
+
+func (r *sinkRepository) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sinks.Close()
+	return nil
+}
+
+// meteredProgress counts the generated wallets in the metrics.
+type meteredProgress struct {
+	generator.Progress
+}
+
+func (p meteredProgress) Increment() error {
+	runMetrics.Addresses(1)
+	return p.Progress.Increment()
+}
@@ -0,0 +1,201 @@
+// Package generator generates random wallets concurrently and stores the ones whose
+// address passes a validator.
+//
+//	gen := generator.New(wallets.NewGeneratorMnemonic(128), store.NewInMemoryRepository(), generator.Config{
+//		AddresValidator: filter.NewAddressValidator(filter.Config{Prefix: "0x00"}),
+//		Concurrency:     4,
+//		Number:          1000,
+//		Limit:           -1,
+//	})
+//	stats, err := gen.Start()
+package generator
+
+import (
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/planxnx/ethereum-wallet-generator/filter"
+	"github.com/planxnx/ethereum-wallet-generator/store"
+	"github.com/planxnx/ethereum-wallet-generator/wallets"
+)
+
+// Progress receives the progress of a run.
+type Progress interface {
+	Increment() error
+	SetResolved(resolved int) error
+	Finish() error
+}
+
+// Config configures a Generator.
+type Config struct {
+	// AddresValidator keeps the wallets whose address it accepts, nil keeps them all.
+	AddresValidator func(address string) bool
+	// Validator further selects the wallets of the addresses AddresValidator accepted, it may be nil.
+	Validator filter.Validator
+	// OnMiss is called for every generated wallet that isn't stored, from the workers. It may
+	// be nil, and isn't called in DryRun.
+	OnMiss func(*wallets.Wallet)
+	// ProgressBar is notified of every generated wallet, it may be nil.
+	ProgressBar Progress
+	// DryRun generates wallets without storing them.
+	DryRun      bool
+	Concurrency int
+	// Number is the number of wallets to generate, negative for no limit.
+	Number int
+	// Limit stops the run after this many stored wallets, negative for no limit.
+	Limit int
+}
+
+// Stats summarizes a run.
+type Stats struct {
+	// Resolved is the number of wallets that passed the validator.
+	Resolved int
+	Duration time.Duration
+}
+
+// Generator generates wallets and inserts the matching ones into a repository.
+type Generator struct {
+	walletGen wallets.Generator
+	repo      store.Repository
+	config    Config
+	// limit is Config.Limit, which SetLimit changes while running.
+	limit atomic.Int64
+
+	isShutdown     atomic.Bool
+	shutdownSignal chan struct{}
+	shutDownWg     sync.WaitGroup
+}
+
+// New returns a generator of wallets made by walletGen, stored in repo.
+func New(walletGen wallets.Generator, repo store.Repository, cfg Config) *Generator {
+	if cfg.ProgressBar == nil {
+		cfg.ProgressBar = noProgress{}
+	}
+	g := &Generator{
+		walletGen:      walletGen,
+		repo:           repo,
+		config:         cfg,
+		shutdownSignal: make(chan struct{}),
+	}
+	g.limit.Store(int64(cfg.Limit))
+	return g
+}
+
+// SetLimit changes the number of stored wallets stopping the run, negative for no limit. A
+// run already past the new limit stops.
+func (g *Generator) SetLimit(limit int) {
+	g.limit.Store(int64(limit))
+}
+
+// belowLimit reports whether a run that stored resolved wallets goes on.
+func (g *Generator) belowLimit(resolved int64) bool {
+	limit := g.limit.Load()
+	return limit < 0 || resolved < limit
+}
+
+// Start generates wallets until Number is reached, Limit wallets are stored or Shutdown is
+// called. The repository is closed before it returns.
+func (g *Generator) Start() (stats Stats, err error) {
+	g.isShutdown.Store(false)
+	g.shutDownWg.Add(1)
+	defer g.shutDownWg.Done()
+
+	var (
+		bar           = g.config.ProgressBar
+		resolvedCount atomic.Int64
+		start         = time.Now()
+	)
+	defer func() {
+		_ = bar.Finish()
+
+		if err := g.repo.Close(); err != nil {
+			// Ignore error
+			slog.Error("Failed to close repository", "err", err)
+		}
+
+		stats = Stats{Resolved: int(resolvedCount.Load()), Duration: time.Since(start)}
+		g.isShutdown.Store(true)
+	}()
+
+	var wg sync.WaitGroup
+	commands := make(chan struct{})
+	for i := 0; i < g.config.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range commands {
+				if !g.belowLimit(resolvedCount.Load()) {
+					return
+				}
+
+				wallet, err := g.walletGen()
+				if err != nil {
+					// Ignore error
+					slog.Error("Failed to generate wallet", "err", err)
+					continue
+				}
+
+				isOk := true
+				if g.config.AddresValidator != nil {
+					isOk = g.config.AddresValidator(wallet.Address)
+				}
+				if isOk && g.config.Validator != nil {
+					isOk = g.config.Validator.Valid(common.HexToAddress(wallet.Address), wallet)
+				}
+
+				if isOk && !g.config.DryRun {
+					if err := g.repo.Insert(wallet); err != nil {
+						// Ignore error
+						slog.Error("Failed to insert wallet to db", "err", err)
+						continue
+					}
+				}
+				if isOk {
+					resolvedCount.Add(1)
+				} else if g.config.OnMiss != nil && !g.config.DryRun {
+					g.config.OnMiss(wallet)
+				}
+
+				_ = bar.SetResolved(int(resolvedCount.Load()))
+				_ = bar.Increment()
+			}
+		}()
+	}
+
+mainloop:
+	for i := 0; (i < g.config.Number || g.config.Number < 0) && g.belowLimit(resolvedCount.Load()); i++ {
+		select {
+		case <-g.shutdownSignal:
+			break mainloop
+		default:
+			commands <- struct{}{}
+		}
+	}
+
+	close(commands)
+	wg.Wait()
+	return stats, nil
+}
+
+// Shutdown stops a running Start and waits for it to return.
+func (g *Generator) Shutdown() (err error) {
+	if g.isShutdown.Load() {
+		return nil
+	}
+	go func() {
+		g.shutdownSignal <- struct{}{}
+	}()
+	g.shutDownWg.Wait()
+	return nil
+}
+
+// noProgress is the Progress used when none is configured.
+type noProgress struct{}
+
+func (noProgress) Increment() error        { return nil }
+func (noProgress) SetResolved(_ int) error { return nil }
+func (noProgress) Finish() error           { return nil }
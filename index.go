@@ -0,0 +1,293 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/planxnx/ethereum-wallet-generator/coins"
+	"github.com/planxnx/ethereum-wallet-generator/internal/addrindex"
+	"github.com/planxnx/ethereum-wallet-generator/seeds"
+)
+
+// indexEntry is an address derived by build-index, with the indexes of its seed file and base
+// path in the metadata of the index.
+type indexEntry struct {
+	key        string
+	file, line int
+	path       int
+	index      uint32
+}
+
+// runBuildIndex derives the addresses of a seed corpus once and stores where each comes from in
+// an index file, which lookup searches for target addresses without deriving the keys again.
+func runBuildIndex(args []string) {
+	fs := flag.NewFlagSet("build-index", flag.ExitOnError)
+	var seedPatterns seeds.Patterns
+	fs.Var(&seedPatterns, "seeds", "file containing list of BIP39 mnemonics (one per line), - to read them from stdin. Repeat it or use glob patterns to read several files in order")
+	seedsFormat := fs.String("seeds-format", string(seeds.FormatText), "seeds file line format: text (one mnemonic per line), or tsv/csv lines of mnemonic, passphrase, hdpath and label fields")
+	seedKeyConfig := addSeedKeyFlags(fs)
+	passphrase := fs.String("passphrase", "", "BIP39 passphrase of the seeds without one")
+	var basePaths stringsFlag
+	fs.Var(&basePaths, "path", "base derivation path indexed, the address index is appended to it. Repeat it to index several, the hd path field of a line replaces them (default the one of the --coin, m/44'/60'/0'/0 for eth)")
+	from := fs.Int("from", 0, "first address index derived under every path")
+	depth := fs.Int("depth", 10, "number of address indexes derived under every path")
+	out := fs.String("out", "", "index file written, replaced once the corpus is indexed")
+	concurrency := fs.Int("c", 1, "set concurrency value (number of derivation workers)")
+	coinConfig := addCoinFlags(fs)
+	parseFlags(fs, args)
+
+	if len(seedPatterns) == 0 || *out == "" {
+		fmt.Fprintln(os.Stderr, "Error: --seeds and --out parameters required")
+		os.Exit(exitUsage)
+	}
+	input, err := seeds.NewInput(seedPatterns, seeds.Format(*seedsFormat))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitUsage)
+	}
+	if err := seedKeyConfig(input); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitUsage)
+	}
+	coin, err := coinConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitUsage)
+	}
+	meta := addrindex.Meta{
+		Coin:        coin.Name(),
+		AddressType: coin.AddressType(),
+		EVM:         coins.EVM(coin),
+		Files:       input.Files,
+		From:        max(*from, 0),
+		Depth:       max(*depth, 1),
+		Created:     time.Now().UTC(),
+	}
+	if len(basePaths) == 0 {
+		basePaths = stringsFlag{coin.BasePath().String()}
+	}
+	paths := make(map[string]int)
+	for _, p := range basePaths {
+		path, err := accounts.ParseDerivationPath(p)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid --path %q: %v\n", p, err)
+			os.Exit(exitUsage)
+		}
+		if _, ok := paths[path.String()]; !ok {
+			paths[path.String()] = len(meta.Paths)
+			meta.Paths = append(meta.Paths, path.String())
+		}
+	}
+	bases := make([]accounts.DerivationPath, len(meta.Paths))
+	for i, p := range meta.Paths {
+		bases[i], _ = accounts.ParseDerivationPath(p)
+	}
+	fileIndexes := make(map[string]int)
+	for i, name := range input.Files {
+		fileIndexes[name] = i
+	}
+
+	var (
+		pathsMu sync.Mutex
+		indexed atomic.Int64
+		skipped atomic.Int64
+		wg      sync.WaitGroup
+	)
+	// pathIndex returns the index of the hd path field of a line in meta.Paths.
+	pathIndex := func(path accounts.DerivationPath) int {
+		pathsMu.Lock()
+		defer pathsMu.Unlock()
+		i, ok := paths[path.String()]
+		if !ok {
+			i = len(meta.Paths)
+			paths[path.String()] = i
+			meta.Paths = append(meta.Paths, path.String())
+		}
+		return i
+	}
+
+	builder := addrindex.NewBuilder(filepath.Dir(*out))
+	entries := make(chan indexEntry, 1024)
+	added := make(chan error, 1)
+	go func() {
+		var err error
+		for e := range entries {
+			if err == nil {
+				err = builder.Add(e.key, e.file, e.line, e.path, e.index)
+			}
+		}
+		added <- err
+	}()
+
+	start := time.Now()
+	seedCh, errCh := input.Stream(context.Background(), seeds.Range{}, seeds.DefaultReadAhead)
+	for range max(*concurrency, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for seed := range seedCh {
+				if seed.Passphrase == "" {
+					seed.Passphrase = *passphrase
+				}
+				file, line := input.Locate(seed.Line)
+				fileIdx := 0
+				if file != "" {
+					fileIdx = fileIndexes[file]
+				}
+				if err := checkMnemonic(seed); err != nil {
+					slog.Warn("Seed skipped", append(seedAttrs(file, line), "err", err)...)
+					skipped.Add(1)
+					continue
+				}
+				seedBases, seedPaths := bases, []int(nil)
+				if seed.Path != "" {
+					path, err := accounts.ParseDerivationPath(seed.Path)
+					if err != nil {
+						slog.Warn("Seed skipped", append(seedAttrs(file, line), "err", fmt.Sprintf("invalid hd path %q", seed.Path))...)
+						skipped.Add(1)
+						continue
+					}
+					seedBases, seedPaths = []accounts.DerivationPath{path}, []int{pathIndex(path)}
+				}
+				for b, base := range seedBases {
+					deriver, err := coin.NewDeriver(seed.Phrase, seed.Passphrase, base)
+					if err != nil {
+						slog.Warn("Failed to derive base key", append(seedAttrs(file, line), "path", base.String(), "err", err)...)
+						continue
+					}
+					pathIdx := b
+					if seedPaths != nil {
+						pathIdx = seedPaths[b]
+					}
+					for i := meta.From; i < meta.From+meta.Depth; i++ {
+						w, err := deriver.Derive(uint32(i))
+						if err != nil {
+							continue
+						}
+						entries <- indexEntry{key: addressKey(coin, w.Address), file: fileIdx, line: line, path: pathIdx, index: uint32(i)}
+					}
+					coins.Wipe(deriver)
+				}
+				indexed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	close(entries)
+	if err := <-errCh; err != nil {
+		builder.Discard()
+		fatal("Failed to read seeds", "err", err)
+	}
+	if err := <-added; err != nil {
+		builder.Discard()
+		fatal("Failed to build the index", "err", err)
+	}
+
+	meta.Seeds = indexed.Load()
+	if err := builder.Write(*out, meta); err != nil {
+		fatal("Failed to write the index", "err", err)
+	}
+	fmt.Fprintf(os.Stderr, "Indexed %d seeds (%d skipped) under %d paths to %s in %s\n", meta.Seeds, skipped.Load(), len(meta.Paths), *out, time.Since(start).Round(time.Millisecond))
+}
+
+// runLookup searches an index built by build-index for target addresses, printing the seed
+// line and hd path of every one found.
+func runLookup(args []string) {
+	fs := flag.NewFlagSet("lookup", flag.ExitOnError)
+	indexPath := fs.String("index", "", "index file written by build-index")
+	var targets stringsFlag
+	fs.Var(&targets, "address", "address to look up, can be repeated")
+	targetsFile := fs.String("targets", "", "file of addresses to look up (one per line), - to read them from stdin (default without --address)")
+	parseFlags(fs, args)
+
+	if *indexPath == "" {
+		fmt.Fprintln(os.Stderr, "Error: --index parameter required")
+		os.Exit(exitUsage)
+	}
+	idx, err := addrindex.Open(*indexPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitUsage)
+	}
+	defer idx.Close()
+	meta := idx.Meta()
+	coin, err := coins.Lookup(meta.Coin, meta.AddressType)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: coin of the index: %v\n", err)
+		os.Exit(exitUsage)
+	}
+	for _, name := range meta.Files {
+		if info, err := os.Stat(name); err == nil && info.ModTime().After(meta.Created) {
+			slog.Warn("Seed file changed since the index was built, its lines may have moved", "file", name)
+		}
+	}
+
+	if *targetsFile != "" || len(targets) == 0 {
+		if err := readTargets(*targetsFile, &targets); err != nil {
+			fatal("Failed to read targets", "err", err)
+		}
+	}
+	found := 0
+	for _, target := range targets {
+		if coins.EVM(coin) && !common.IsHexAddress(target) {
+			slog.Warn("Invalid address skipped", "address", target)
+			continue
+		}
+		locs, err := idx.Lookup(addressKey(coin, target))
+		if err != nil {
+			fatal("Failed to look up address", "address", target, "err", err)
+		}
+		if len(locs) > 0 {
+			found++
+		}
+		for _, loc := range locs {
+			where := fmt.Sprint(loc.Line)
+			if loc.File != "" && loc.File != seeds.Stdin {
+				where = fmt.Sprintf("%s:%d", loc.File, loc.Line)
+			}
+			path := loc.Path
+			if base, err := accounts.ParseDerivationPath(loc.Path); err == nil {
+				path = coin.Path(base, loc.Index)
+			}
+			fmt.Printf("%s\t%s\t%s\n", target, where, path)
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Found %d of %d addresses in the %d entries of %s\n", found, len(targets), meta.Entries, *indexPath)
+	if found == 0 {
+		exitCode = exitNoMatch
+	}
+}
+
+// readTargets appends the addresses of the lines of the file at name, or stdin if it is empty
+// or -, to targets.
+func readTargets(name string, targets *stringsFlag) error {
+	var r io.Reader = os.Stdin
+	if name != "" && name != seeds.Stdin {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" && !strings.HasPrefix(line, "#") {
+			*targets = append(*targets, line)
+		}
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,374 @@
+// Package addrindex stores the addresses derived from a seed corpus in a sorted index file,
+// mapping every address to the seed line and path deriving it, so a target list is looked up
+// in it without deriving the keys again.
+//
+// An index file starts with Magic and the length prefixed JSON Meta, followed by fixed size
+// records sorted by the truncated SHA-256 of their address. It holds no key nor mnemonic,
+// only where each address comes from.
+package addrindex
+
+import (
+	"bufio"
+	"bytes"
+	"container/heap"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Magic starts every index file, its last byte is the format version.
+const Magic = "EWGIDX\x00\x01"
+
+// DefaultChunkSize is the default number of records sorted in memory before being spilled to
+// a temporary file, 32 MiB of records.
+const DefaultChunkSize = 1 << 20
+
+const (
+	keySize    = 16
+	recordSize = keySize + 4*4
+)
+
+// Meta describes the corpus and derivation settings of an index.
+type Meta struct {
+	Coin        string `json:"coin"`
+	AddressType string `json:"address_type"`
+	// EVM is true when the addresses are compared without their case.
+	EVM bool `json:"evm"`
+	// Files are the seed files of the corpus, Paths the base paths the addresses are derived
+	// under, the records refer to them by index.
+	Files   []string  `json:"files"`
+	Paths   []string  `json:"paths"`
+	From    int       `json:"from"`
+	Depth   int       `json:"depth"`
+	Seeds   int64     `json:"seeds"`
+	Entries int64     `json:"entries"`
+	Created time.Time `json:"created"`
+}
+
+// Location is where an address of the index comes from.
+type Location struct {
+	// File and Line are the seed file and its line, Path the base path and Index the address
+	// index appended to it.
+	File  string
+	Line  int
+	Path  string
+	Index uint32
+}
+
+// Key returns the key an address is sorted and looked up by.
+func Key(address string) [keySize]byte {
+	sum := sha256.Sum256([]byte(address))
+	return [keySize]byte(sum[:keySize])
+}
+
+type record [recordSize]byte
+
+func newRecord(address string, file, line, path, index uint32) record {
+	var r record
+	key := Key(address)
+	copy(r[:], key[:])
+	binary.BigEndian.PutUint32(r[keySize:], file)
+	binary.BigEndian.PutUint32(r[keySize+4:], line)
+	binary.BigEndian.PutUint32(r[keySize+8:], path)
+	binary.BigEndian.PutUint32(r[keySize+12:], index)
+	return r
+}
+
+func (r *record) field(i int) uint32 {
+	return binary.BigEndian.Uint32(r[keySize+4*i:])
+}
+
+func compareRecords(a, b record) int {
+	return bytes.Compare(a[:], b[:])
+}
+
+// Builder collects the records of an index, spilling them to sorted temporary files once
+// ChunkSize of them are held, and writes the index in a single merge. It isn't goroutine safe.
+type Builder struct {
+	// ChunkSize is the number of records held in memory, DefaultChunkSize if zero.
+	ChunkSize int
+	dir       string
+	chunk     []record
+	spills    []string
+	entries   int64
+}
+
+// NewBuilder returns a builder spilling its chunks to temporary files of dir.
+func NewBuilder(dir string) *Builder {
+	return &Builder{dir: dir}
+}
+
+// Add records that address is derived from the line of the file at the index of the path,
+// the file and path being indexes of Meta.Files and Meta.Paths.
+func (b *Builder) Add(address string, file, line, path int, index uint32) error {
+	size := b.ChunkSize
+	if size <= 0 {
+		size = DefaultChunkSize
+	}
+	if b.chunk == nil {
+		b.chunk = make([]record, 0, size)
+	}
+	b.chunk = append(b.chunk, newRecord(address, uint32(file), uint32(line), uint32(path), index))
+	b.entries++
+	if len(b.chunk) >= size {
+		return b.spill()
+	}
+	return nil
+}
+
+// spill writes the sorted chunk to a temporary file.
+func (b *Builder) spill() error {
+	slices.SortFunc(b.chunk, compareRecords)
+	f, err := os.CreateTemp(b.dir, ".addrindex-*")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	b.spills = append(b.spills, f.Name())
+	w := bufio.NewWriter(f)
+	for i := range b.chunk {
+		if _, err := w.Write(b.chunk[i][:]); err != nil {
+			f.Close()
+			return errors.WithStack(err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return errors.WithStack(err)
+	}
+	b.chunk = b.chunk[:0]
+	return errors.WithStack(f.Close())
+}
+
+// Write merges the records into the index file at name, replaced only once it is complete,
+// and removes the temporary files.
+func (b *Builder) Write(name string, meta Meta) error {
+	defer b.Discard()
+	slices.SortFunc(b.chunk, compareRecords)
+	meta.Entries = b.entries
+
+	tmp := name + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer os.Remove(tmp)
+	if err := b.writeTo(f, meta); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return errors.WithStack(err)
+	}
+	if err := f.Close(); err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(os.Rename(tmp, name))
+}
+
+func (b *Builder) writeTo(f *os.File, meta Meta) error {
+	header, err := json.Marshal(meta)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	w := bufio.NewWriter(f)
+	w.WriteString(Magic)
+	binary.Write(w, binary.BigEndian, uint32(len(header)))
+	w.Write(header)
+
+	sources := []*source{{records: b.chunk}}
+	for _, name := range b.spills {
+		s, err := openSpill(name)
+		if err != nil {
+			return err
+		}
+		defer s.file.Close()
+		sources = append(sources, s)
+	}
+	h := &merger{}
+	for _, s := range sources {
+		if err := s.next(); err != nil {
+			return err
+		}
+		if s.ok {
+			h.sources = append(h.sources, s)
+		}
+	}
+	heap.Init(h)
+	for h.Len() > 0 {
+		s := h.sources[0]
+		if _, err := w.Write(s.head[:]); err != nil {
+			return errors.WithStack(err)
+		}
+		if err := s.next(); err != nil {
+			return err
+		}
+		if s.ok {
+			heap.Fix(h, 0)
+		} else {
+			heap.Pop(h)
+		}
+	}
+	return errors.WithStack(w.Flush())
+}
+
+// Discard removes the temporary files of the builder.
+func (b *Builder) Discard() {
+	for _, name := range b.spills {
+		os.Remove(name)
+	}
+	b.spills = nil
+	b.chunk = nil
+}
+
+// source is a sorted run of records, the in-memory chunk or a spilled file.
+type source struct {
+	records []record
+	file    *os.File
+	r       *bufio.Reader
+	head    record
+	ok      bool
+}
+
+func openSpill(name string) (*source, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &source{file: f, r: bufio.NewReader(f)}, nil
+}
+
+func (s *source) next() error {
+	if s.r == nil {
+		s.ok = len(s.records) > 0
+		if s.ok {
+			s.head, s.records = s.records[0], s.records[1:]
+		}
+		return nil
+	}
+	_, err := io.ReadFull(s.r, s.head[:])
+	if err == io.EOF {
+		s.ok = false
+		return nil
+	}
+	s.ok = err == nil
+	return errors.Wrap(err, "failed to read a spilled chunk")
+}
+
+// merger is a min-heap of the sources by their head record.
+type merger struct{ sources []*source }
+
+func (m *merger) Len() int           { return len(m.sources) }
+func (m *merger) Less(i, j int) bool { return compareRecords(m.sources[i].head, m.sources[j].head) < 0 }
+func (m *merger) Swap(i, j int)      { m.sources[i], m.sources[j] = m.sources[j], m.sources[i] }
+func (m *merger) Push(x any)         { m.sources = append(m.sources, x.(*source)) }
+func (m *merger) Pop() any {
+	s := m.sources[len(m.sources)-1]
+	m.sources = m.sources[:len(m.sources)-1]
+	return s
+}
+
+// Index is an open index file, its records are read from disk by binary search.
+type Index struct {
+	f      *os.File
+	meta   Meta
+	offset int64
+	n      int64
+}
+
+// Open opens the index file at name.
+func Open(name string) (*Index, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	idx, err := open(f)
+	if err != nil {
+		f.Close()
+		return nil, errors.Wrapf(err, "invalid index file %s", name)
+	}
+	return idx, nil
+}
+
+func open(f *os.File) (*Index, error) {
+	prefix := make([]byte, len(Magic)+4)
+	if _, err := io.ReadFull(f, prefix); err != nil || string(prefix[:len(Magic)]) != Magic {
+		return nil, errors.New("not an address index")
+	}
+	header := make([]byte, binary.BigEndian.Uint32(prefix[len(Magic):]))
+	if _, err := io.ReadFull(f, header); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	idx := &Index{f: f, offset: int64(len(prefix) + len(header))}
+	if err := json.Unmarshal(header, &idx.meta); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if size := info.Size() - idx.offset; size%recordSize != 0 || size/recordSize != idx.meta.Entries {
+		return nil, errors.New("truncated index")
+	}
+	idx.n = idx.meta.Entries
+	return idx, nil
+}
+
+// Meta returns the description of the index.
+func (idx *Index) Meta() Meta {
+	return idx.meta
+}
+
+// Lookup returns the locations address is derived at, none if it isn't in the index. The
+// records hold a 128-bit hash of their address, too long for a collision to be expected.
+func (idx *Index) Lookup(address string) ([]Location, error) {
+	key := Key(address)
+	var (
+		r   record
+		err error
+	)
+	read := func(i int64) {
+		if err == nil {
+			_, err = idx.f.ReadAt(r[:], idx.offset+i*recordSize)
+		}
+	}
+	first := int64(sort.Search(int(idx.n), func(i int) bool {
+		read(int64(i))
+		return bytes.Compare(r[:keySize], key[:]) >= 0
+	}))
+	var locs []Location
+	for i := first; i < idx.n && err == nil; i++ {
+		read(i)
+		if err != nil || !bytes.Equal(r[:keySize], key[:]) {
+			break
+		}
+		locs = append(locs, idx.location(&r))
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read the index")
+	}
+	return locs, nil
+}
+
+func (idx *Index) location(r *record) Location {
+	loc := Location{Line: int(r.field(1)), Index: r.field(3)}
+	if i := int(r.field(0)); i < len(idx.meta.Files) {
+		loc.File = idx.meta.Files[i]
+	}
+	if i := int(r.field(2)); i < len(idx.meta.Paths) {
+		loc.Path = idx.meta.Paths[i]
+	}
+	return loc
+}
+
+// Close closes the index file.
+func (idx *Index) Close() error {
+	return errors.WithStack(idx.f.Close())
+}
@@ -0,0 +1,75 @@
+package addrindex
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBuildLookup(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "corpus.idx")
+	b := NewBuilder(dir)
+	b.ChunkSize = 7
+	for line := 1; line <= 20; line++ {
+		for i := range 3 {
+			if err := b.Add(fmt.Sprintf("addr-%d-%d", line, i), line%2, line, 0, uint32(i)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := b.Add("addr-5-1", 0, 21, 1, 4); err != nil {
+		t.Fatal(err)
+	}
+	meta := Meta{Coin: "eth", Files: []string{"a.txt", "b.txt"}, Paths: []string{"m/44'/60'/0'/0", "m/44'/60'/1'/0"}, Depth: 3}
+	if err := b.Write(name, meta); err != nil {
+		t.Fatal(err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("left %d files in the directory, want only the index", len(entries))
+	}
+
+	idx, err := Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer idx.Close()
+	if m := idx.Meta(); m.Entries != 61 || m.Coin != "eth" {
+		t.Errorf("meta %+v", m)
+	}
+	locs, err := idx.Lookup("addr-5-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[Location]bool{
+		{File: "b.txt", Line: 5, Path: "m/44'/60'/0'/0", Index: 1}:  true,
+		{File: "a.txt", Line: 21, Path: "m/44'/60'/1'/0", Index: 4}: true,
+	}
+	if len(locs) != len(want) {
+		t.Fatalf("found %+v", locs)
+	}
+	for _, loc := range locs {
+		if !want[loc] {
+			t.Errorf("unexpected location %+v", loc)
+		}
+	}
+	for line := 1; line <= 20; line++ {
+		if locs, err := idx.Lookup(fmt.Sprintf("addr-%d-2", line)); err != nil || len(locs) != 1 || locs[0].Line != line {
+			t.Errorf("line %d: %+v, %v", line, locs, err)
+		}
+	}
+	if locs, err := idx.Lookup("addr-0-0"); err != nil || len(locs) != 0 {
+		t.Errorf("found a missing address: %+v, %v", locs, err)
+	}
+}
+
+func TestOpenInvalid(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "bad.idx")
+	if err := os.WriteFile(name, []byte("mnemonic words\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(name); err == nil {
+		t.Error("opened a file that isn't an index")
+	}
+}
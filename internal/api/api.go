@@ -0,0 +1,130 @@
+// Package api serves a REST API to submit scan and generate jobs, poll their progress and
+// fetch their matches, so other tools can drive the engine without shelling out.
+package api
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/planxnx/ethereum-wallet-generator/filter"
+)
+
+const (
+	// JobsPath is the endpoint listing and submitting jobs, a job is at JobsPath/{id}, and
+	// JobsPath/{id}/filter replaces the filters of a running one.
+	JobsPath = "/v1/jobs"
+	// MaxRequestSize is the maximum size of a submitted job.
+	MaxRequestSize = 16 << 20
+)
+
+// Job kinds.
+const (
+	// KindScan derives the addresses of the mnemonics of the job.
+	KindScan = "scan"
+	// KindGenerate generates random wallets.
+	KindGenerate = "generate"
+)
+
+// State is the lifecycle state of a job.
+type State string
+
+// Job states.
+const (
+	StateQueued   State = "queued"
+	StateRunning  State = "running"
+	StateDone     State = "done"
+	StateFailed   State = "failed"
+	StateCanceled State = "canceled"
+)
+
+// Finished reports whether a job in this state will not change anymore.
+func (s State) Finished() bool {
+	return s == StateDone || s == StateFailed || s == StateCanceled
+}
+
+// Spec is a submitted job.
+type Spec struct {
+	Kind string `json:"kind"`
+	// Seeds are the mnemonics of a scan job, one per item.
+	Seeds []string `json:"seeds,omitempty"`
+	// Depth is the number of addresses derived per mnemonic of a scan job, 1 by default.
+	Depth int `json:"depth,omitempty"`
+	// Number is the number of wallets a generate job generates.
+	Number int `json:"number,omitempty"`
+	// Limit stops a generate job after this many matches, 0 for no limit.
+	Limit int `json:"limit,omitempty"`
+	// Mode is the wallet generation mode of a generate job, mnemonic (default), privatekey or
+	// keyspace.
+	Mode string `json:"mode,omitempty"`
+	// Bits is the entropy of the generated mnemonics, 128 by default.
+	Bits   int           `json:"bits,omitempty"`
+	Filter filter.Config `json:"filter"`
+}
+
+// Validate reports a spec that can't be run.
+func (s Spec) Validate() error {
+	switch s.Kind {
+	case KindScan:
+		if len(s.Seeds) == 0 {
+			return errors.New("a scan job needs seeds")
+		}
+		if s.Depth < 0 {
+			return errors.New("depth can't be negative")
+		}
+	case KindGenerate:
+		if s.Number <= 0 {
+			return errors.New("a generate job needs a positive number of wallets")
+		}
+		if s.Limit < 0 {
+			return errors.New("limit can't be negative")
+		}
+		switch s.Mode {
+		case "", "mnemonic", "privatekey", "keyspace":
+		default:
+			return errors.Errorf("unknown mode %q, must be mnemonic, privatekey or keyspace", s.Mode)
+		}
+		if s.Bits != 0 && s.Bits != 128 && s.Bits != 256 {
+			return errors.Errorf("invalid bits %d, must be 128 or 256", s.Bits)
+		}
+	default:
+		return errors.Errorf("unknown job kind %q, must be %s or %s", s.Kind, KindScan, KindGenerate)
+	}
+	return errors.WithStack(s.Filter.Validate())
+}
+
+// Result is a matching wallet of a job. Line is the 1-based position of the mnemonic in the
+// seeds of a scan job.
+type Result struct {
+	Line            int    `json:"line,omitempty"`
+	Index           int    `json:"index"`
+	Label           string `json:"label,omitempty"`
+	Address         string `json:"address"`
+	ChecksumAddress string `json:"checksum_address"`
+	PrivateKey      string `json:"private_key"`
+	Mnemonic        string `json:"mnemonic,omitempty"`
+	HDPath          string `json:"hd_path,omitempty"`
+}
+
+// Status is the progress of a job. Total is the number of addresses the job derives.
+type Status struct {
+	ID        string     `json:"id"`
+	Kind      string     `json:"kind"`
+	State     State      `json:"state"`
+	Error     string     `json:"error,omitempty"`
+	Total     int64      `json:"total"`
+	Addresses int64      `json:"addresses"`
+	Matches   int        `json:"matches"`
+	Truncated bool       `json:"truncated,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	StartedAt *time.Time `json:"started_at,omitempty"`
+	EndedAt   *time.Time `json:"ended_at,omitempty"`
+}
+
+// Results is a page of the results of a job, Next is the offset of the following page.
+// Done is set once the job is finished and every result was returned.
+type Results struct {
+	Results []Result `json:"results"`
+	Next    int      `json:"next"`
+	Done    bool     `json:"done"`
+}
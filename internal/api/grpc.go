@@ -0,0 +1,174 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/planxnx/ethereum-wallet-generator/filter"
+	"github.com/planxnx/ethereum-wallet-generator/internal/api/apipb"
+)
+
+// GRPCServer returns a gRPC server serving the Jobs service of the proto definitions in
+// apipb, with the same jobs and token as the REST API.
+func (s *Server) GRPCServer(opts ...grpc.ServerOption) *grpc.Server {
+	opts = append(opts,
+		grpc.ChainUnaryInterceptor(func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			if err := s.authorizeGRPC(ctx); err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}),
+		grpc.ChainStreamInterceptor(func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			if err := s.authorizeGRPC(ss.Context()); err != nil {
+				return err
+			}
+			return handler(srv, ss)
+		}),
+	)
+	g := grpc.NewServer(opts...)
+	apipb.RegisterJobsServer(g, jobsService{server: s})
+	return g
+}
+
+func (s *Server) authorizeGRPC(ctx context.Context) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	want := []byte("Bearer " + s.config.Token)
+	for _, v := range md.Get("authorization") {
+		if subtle.ConstantTimeCompare([]byte(v), want) == 1 {
+			return nil
+		}
+	}
+	return status.Error(codes.Unauthenticated, "unauthorized")
+}
+
+// jobsService implements the Jobs gRPC service on top of a Server.
+type jobsService struct {
+	apipb.UnimplementedJobsServer
+	server *Server
+}
+
+func (j jobsService) Submit(_ context.Context, req *apipb.JobSpec) (*apipb.JobStatus, error) {
+	spec := specFromProto(req)
+	if err := spec.Validate(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid job: "+err.Error())
+	}
+	job, err := j.server.Submit(spec)
+	if err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	return statusToProto(job.Status()), nil
+}
+
+func (j jobsService) Get(_ context.Context, req *apipb.JobRequest) (*apipb.JobStatus, error) {
+	job, err := j.job(req.GetId())
+	if err != nil {
+		return nil, err
+	}
+	return statusToProto(job.Status()), nil
+}
+
+func (j jobsService) List(context.Context, *apipb.ListRequest) (*apipb.ListResponse, error) {
+	resp := &apipb.ListResponse{}
+	for _, st := range j.server.List() {
+		resp.Jobs = append(resp.Jobs, statusToProto(st))
+	}
+	return resp, nil
+}
+
+func (j jobsService) Cancel(_ context.Context, req *apipb.JobRequest) (*apipb.JobStatus, error) {
+	job, err := j.job(req.GetId())
+	if err != nil {
+		return nil, err
+	}
+	job.cancel(context.Canceled)
+	return statusToProto(job.Status()), nil
+}
+
+func (j jobsService) Delete(_ context.Context, req *apipb.JobRequest) (*apipb.DeleteResponse, error) {
+	if _, err := j.job(req.GetId()); err != nil {
+		return nil, err
+	}
+	j.server.Remove(req.GetId())
+	return &apipb.DeleteResponse{}, nil
+}
+
+func (j jobsService) Watch(req *apipb.WatchRequest, stream grpc.ServerStreamingServer[apipb.JobEvent]) error {
+	job, err := j.job(req.GetId())
+	if err != nil {
+		return err
+	}
+	return job.watch(stream.Context(), int(req.GetOffset()), func(r Result) error {
+		return stream.Send(&apipb.JobEvent{Event: &apipb.JobEvent_Result{Result: resultToProto(r)}})
+	}, func(st Status) error {
+		return stream.Send(&apipb.JobEvent{Event: &apipb.JobEvent_Status{Status: statusToProto(st)}})
+	})
+}
+
+func (j jobsService) job(id string) (*Job, error) {
+	job := j.server.Get(id)
+	if job == nil {
+		return nil, status.Error(codes.NotFound, "job not found")
+	}
+	return job, nil
+}
+
+func specFromProto(p *apipb.JobSpec) Spec {
+	f := p.GetFilter()
+	return Spec{
+		Kind:   p.GetKind(),
+		Seeds:  p.GetSeeds(),
+		Depth:  int(p.GetDepth()),
+		Number: int(p.GetNumber()),
+		Limit:  int(p.GetLimit()),
+		Mode:   p.GetMode(),
+		Bits:   int(p.GetBits()),
+		Filter: filter.Config{
+			Contains:   f.GetContains(),
+			Strict:     f.GetStrict(),
+			Prefix:     f.GetPrefix(),
+			Suffix:     f.GetSuffix(),
+			Regex:      f.GetRegex(),
+			Validators: f.GetValidators(),
+		},
+	}
+}
+
+func statusToProto(st Status) *apipb.JobStatus {
+	p := &apipb.JobStatus{
+		Id:        st.ID,
+		Kind:      st.Kind,
+		State:     string(st.State),
+		Error:     st.Error,
+		Total:     st.Total,
+		Addresses: st.Addresses,
+		Matches:   int64(st.Matches),
+		Truncated: st.Truncated,
+		CreatedAt: timestamppb.New(st.CreatedAt),
+	}
+	if st.StartedAt != nil {
+		p.StartedAt = timestamppb.New(*st.StartedAt)
+	}
+	if st.EndedAt != nil {
+		p.EndedAt = timestamppb.New(*st.EndedAt)
+	}
+	return p
+}
+
+func resultToProto(r Result) *apipb.Result {
+	return &apipb.Result{
+		Line:            int64(r.Line),
+		Index:           int64(r.Index),
+		Label:           r.Label,
+		Address:         r.Address,
+		ChecksumAddress: r.ChecksumAddress,
+		PrivateKey:      r.PrivateKey,
+		Mnemonic:        r.Mnemonic,
+		HdPath:          r.HDPath,
+	}
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/planxnx/ethereum-wallet-generator/internal/api/apipb"
+)
+
+func TestGRPCWatch(t *testing.T) {
+	release := make(chan struct{})
+	s := NewServer(Config{
+		Token: "secret",
+		Run: func(ctx context.Context, spec Spec, job *Job) error {
+			job.SetTotal(int64(len(spec.Seeds)))
+			for i := range spec.Seeds {
+				job.Progress(1)
+				job.Match(Result{Line: i + 1, Address: "0x01"})
+				if i == 0 {
+					<-release
+				}
+			}
+			return nil
+		},
+	})
+	defer s.Close()
+
+	lis := bufconn.Listen(1 << 20)
+	g := s.GRPCServer()
+	go func() { _ = g.Serve(lis) }()
+	defer g.Stop()
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	client := apipb.NewJobsClient(conn)
+
+	if _, err := client.List(context.Background(), &apipb.ListRequest{}); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("unauthenticated call: %v", err)
+	}
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer secret")
+	if _, err := client.Submit(ctx, &apipb.JobSpec{Kind: "scan"}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("invalid job: %v", err)
+	}
+	st, err := client.Submit(ctx, &apipb.JobSpec{Kind: "scan", Seeds: []string{"a", "b", "c"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stream, err := client.Watch(ctx, &apipb.WatchRequest{Id: st.GetId()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lines []int64
+	var last *apipb.JobStatus
+	for {
+		ev, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r := ev.GetResult(); r != nil {
+			lines = append(lines, r.GetLine())
+			if len(lines) == 1 {
+				close(release)
+			}
+		}
+		if ev.GetStatus() != nil {
+			last = ev.GetStatus()
+		}
+	}
+	if len(lines) != 3 || lines[2] != 3 {
+		t.Errorf("streamed lines = %v", lines)
+	}
+	if last.GetState() != string(StateDone) || last.GetMatches() != 3 {
+		t.Errorf("last status = %v", last)
+	}
+}
@@ -0,0 +1,488 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+
+	"github.com/planxnx/ethereum-wallet-generator/filter"
+)
+
+const (
+	// DefaultParallel is the default number of jobs running at the same time.
+	DefaultParallel = 1
+	// DefaultKeep is the default number of finished jobs kept with their results.
+	DefaultKeep = 100
+	// DefaultMaxResults is the default number of results kept per job.
+	DefaultMaxResults = 10_000
+	// MaxPageSize is the maximum number of results returned per page.
+	MaxPageSize = 1000
+	// WatchStatusInterval is how often a watched job sends its status when its progress
+	// changed, a state change is sent right away.
+	WatchStatusInterval = time.Second
+)
+
+// errResultLimit is the cancellation cause of a job that reached its result limit.
+var errResultLimit = errors.New("result limit reached")
+
+// ErrNotRunning is returned by SetFilter for a job that isn't running filters it can replace.
+var ErrNotRunning = errors.New("the filters of a job can only be replaced while it runs")
+
+// RunFunc runs a job until it is done or ctx is canceled, reporting to job.
+type RunFunc func(ctx context.Context, spec Spec, job *Job) error
+
+// Config configures a Server.
+type Config struct {
+	// Token must be sent by clients as a bearer token.
+	Token string
+	Run   RunFunc
+	// Parallel is the number of jobs running at the same time, the others are queued.
+	Parallel int
+	// Keep is the number of finished jobs kept, the oldest ones are dropped.
+	Keep int
+	// MaxResults is the number of results kept per job, a job stops once it has that many.
+	MaxResults int
+}
+
+// Job is a submitted job, the run function reports its progress and matches to it.
+type Job struct {
+	spec       Spec
+	maxResults int
+	cancel     context.CancelCauseFunc
+	done       chan struct{}
+
+	mu      sync.Mutex
+	status  Status
+	results []Result
+	// setFilter replaces the filters of the running job, nil if it can't.
+	setFilter func(filter.Config) error
+	// changed is closed and replaced at every result and state change.
+	changed chan struct{}
+}
+
+// SetTotal sets the number of addresses the job derives.
+func (j *Job) SetTotal(n int64) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.status.Total = n
+}
+
+// Progress adds n derived addresses.
+func (j *Job) Progress(n int) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.status.Addresses += int64(n)
+}
+
+// Match adds a result, the job is stopped once it reaches the result limit.
+func (j *Job) Match(r Result) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if len(j.results) >= j.maxResults {
+		return
+	}
+	j.results = append(j.results, r)
+	j.status.Matches++
+	j.notify()
+	if len(j.results) == j.maxResults {
+		j.cancel(errResultLimit)
+	}
+}
+
+// OnFilter registers the function replacing the filters of the running job, see SetFilter.
+func (j *Job) OnFilter(fn func(filter.Config) error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.setFilter = fn
+}
+
+// SetFilter replaces the filters of a running job, the addresses already checked aren't
+// checked again.
+func (j *Job) SetFilter(cfg filter.Config) error {
+	j.mu.Lock()
+	fn, state := j.setFilter, j.status.State
+	j.mu.Unlock()
+	if state != StateRunning || fn == nil {
+		return errors.Wrapf(ErrNotRunning, "job is %s", state)
+	}
+	return fn(cfg)
+}
+
+// Status returns the current progress of the job.
+func (j *Job) Status() Status {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.status
+}
+
+// changes returns a channel closed at the next result or state change of the job.
+func (j *Job) changes() <-chan struct{} {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.changed
+}
+
+// notify wakes up the watchers of the job, must be called with mu held.
+func (j *Job) notify() {
+	close(j.changed)
+	j.changed = make(chan struct{})
+}
+
+// page returns up to limit results from offset.
+func (j *Job) page(offset, limit int) Results {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	offset = min(max(offset, 0), len(j.results))
+	end := min(offset+limit, len(j.results))
+	return Results{
+		Results: slices.Clone(j.results[offset:end]),
+		Next:    end,
+		Done:    j.status.State.Finished() && end == len(j.results),
+	}
+}
+
+// watch sends the results of the job from offset as they are found, along with its status,
+// until the job is finished or ctx is canceled.
+func (j *Job) watch(ctx context.Context, offset int, sendResult func(Result) error, sendStatus func(Status) error) error {
+	ticker := time.NewTicker(WatchStatusInterval)
+	defer ticker.Stop()
+
+	var last Status
+	tick := true
+	for {
+		// taken before reading, so that a change while sending isn't missed
+		changed := j.changes()
+		page := j.page(offset, MaxPageSize)
+		for _, r := range page.Results {
+			if err := sendResult(r); err != nil {
+				return err
+			}
+		}
+		offset = page.Next
+		if st := j.Status(); st.State != last.State || tick && st != last {
+			if err := sendStatus(st); err != nil {
+				return err
+			}
+			last, tick = st, false
+		}
+		if page.Done {
+			return nil
+		}
+		if len(page.Results) == MaxPageSize {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+		case <-ticker.C:
+			tick = true
+		}
+	}
+}
+
+// Server runs the submitted jobs and serves their progress and results.
+type Server struct {
+	config Config
+	slots  chan struct{}
+	ctx    context.Context
+	stop   context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu    sync.Mutex
+	jobs  map[string]*Job
+	order []string
+}
+
+// NewServer returns a new server.
+func NewServer(cfg Config) *Server {
+	if cfg.Parallel <= 0 {
+		cfg.Parallel = DefaultParallel
+	}
+	if cfg.Keep <= 0 {
+		cfg.Keep = DefaultKeep
+	}
+	if cfg.MaxResults <= 0 {
+		cfg.MaxResults = DefaultMaxResults
+	}
+	ctx, stop := context.WithCancel(context.Background())
+	return &Server{
+		config: cfg,
+		slots:  make(chan struct{}, cfg.Parallel),
+		ctx:    ctx,
+		stop:   stop,
+		jobs:   make(map[string]*Job),
+	}
+}
+
+// Handler returns the HTTP handler serving the API.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST "+JobsPath, func(w http.ResponseWriter, r *http.Request) {
+		var spec Spec
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, MaxRequestSize)).Decode(&spec); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid job: "+err.Error())
+			return
+		}
+		if err := spec.Validate(); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid job: "+err.Error())
+			return
+		}
+		job, err := s.Submit(spec)
+		if err != nil {
+			writeError(w, http.StatusServiceUnavailable, err.Error())
+			return
+		}
+		w.Header().Set("Location", JobsPath+"/"+job.status.ID)
+		writeJSON(w, http.StatusAccepted, job.Status())
+	})
+	mux.HandleFunc("GET "+JobsPath, func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, http.StatusOK, s.List())
+	})
+	mux.HandleFunc("GET "+JobsPath+"/{id}", s.withJob(func(w http.ResponseWriter, _ *http.Request, job *Job) {
+		writeJSON(w, http.StatusOK, job.Status())
+	}))
+	mux.HandleFunc("GET "+JobsPath+"/{id}/results", s.withJob(func(w http.ResponseWriter, r *http.Request, job *Job) {
+		offset, limit, err := pageParams(r)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, job.page(offset, limit))
+	}))
+	mux.HandleFunc("GET "+JobsPath+"/{id}/events", s.withJob(serveEvents))
+	mux.HandleFunc("PUT "+JobsPath+"/{id}/filter", s.withJob(func(w http.ResponseWriter, r *http.Request, job *Job) {
+		var cfg filter.Config
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, MaxRequestSize)).Decode(&cfg); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid filter: "+err.Error())
+			return
+		}
+		if err := cfg.Validate(); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid filter: "+err.Error())
+			return
+		}
+		if err := job.SetFilter(cfg); err != nil {
+			writeError(w, http.StatusConflict, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, job.Status())
+	}))
+	mux.HandleFunc("POST "+JobsPath+"/{id}/cancel", s.withJob(func(w http.ResponseWriter, _ *http.Request, job *Job) {
+		job.cancel(context.Canceled)
+		writeJSON(w, http.StatusOK, job.Status())
+	}))
+	mux.HandleFunc("DELETE "+JobsPath+"/{id}", s.withJob(func(w http.ResponseWriter, _ *http.Request, job *Job) {
+		s.Remove(job.status.ID)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	return s.authorize(mux)
+}
+
+// Submit queues a job, it is run once a slot is free.
+func (s *Server) Submit(spec Spec) (*Job, error) {
+	if s.ctx.Err() != nil {
+		return nil, errors.New("server is shutting down")
+	}
+	id, err := newJobID()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancelCause(s.ctx)
+	job := &Job{
+		spec:       spec,
+		maxResults: s.config.MaxResults,
+		cancel:     cancel,
+		done:       make(chan struct{}),
+		status:     Status{ID: id, Kind: spec.Kind, State: StateQueued, CreatedAt: time.Now()},
+		results:    make([]Result, 0),
+		changed:    make(chan struct{}),
+	}
+
+	s.mu.Lock()
+	s.jobs[id] = job
+	s.order = append(s.order, id)
+	s.prune()
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.run(ctx, job)
+	}()
+	return job, nil
+}
+
+// List returns the status of every job, oldest first.
+func (s *Server) List() []Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list := make([]Status, 0, len(s.order))
+	for _, id := range s.order {
+		list = append(list, s.jobs[id].Status())
+	}
+	return list
+}
+
+// Get returns a job, nil if it doesn't exist.
+func (s *Server) Get(id string) *Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.jobs[id]
+}
+
+// Remove cancels a job and forgets it along with its results.
+func (s *Server) Remove(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[id]
+	if !ok {
+		return
+	}
+	job.cancel(context.Canceled)
+	delete(s.jobs, id)
+	s.order = slices.DeleteFunc(s.order, func(v string) bool { return v == id })
+}
+
+// Close cancels every job and waits for the running ones to return.
+func (s *Server) Close() {
+	s.stop()
+	s.wg.Wait()
+}
+
+// run waits for a slot, then runs the job and records how it ended.
+func (s *Server) run(ctx context.Context, job *Job) {
+	defer close(job.done)
+	select {
+	case s.slots <- struct{}{}:
+		defer func() { <-s.slots }()
+	case <-ctx.Done():
+		s.finish(ctx, job, nil)
+		return
+	}
+
+	job.mu.Lock()
+	started := time.Now()
+	job.status.State, job.status.StartedAt = StateRunning, &started
+	job.notify()
+	job.mu.Unlock()
+
+	var err error
+	if ctx.Err() == nil {
+		err = s.config.Run(ctx, job.spec, job)
+	}
+	s.finish(ctx, job, err)
+}
+
+// finish sets the final state of a job.
+func (s *Server) finish(ctx context.Context, job *Job, err error) {
+	job.mu.Lock()
+	ended := time.Now()
+	job.status.EndedAt = &ended
+	switch cause := context.Cause(ctx); {
+	case errors.Is(cause, errResultLimit):
+		job.status.State, job.status.Truncated = StateDone, true
+	case cause != nil:
+		job.status.State = StateCanceled
+	case err != nil:
+		job.status.State, job.status.Error = StateFailed, err.Error()
+	default:
+		job.status.State = StateDone
+	}
+	job.notify()
+	job.mu.Unlock()
+	job.cancel(nil)
+}
+
+// prune drops the oldest finished jobs beyond the kept number, must be called with mu held.
+func (s *Server) prune() {
+	finished := 0
+	for _, id := range s.order {
+		if s.jobs[id].Status().State.Finished() {
+			finished++
+		}
+	}
+	s.order = slices.DeleteFunc(s.order, func(id string) bool {
+		if finished <= s.config.Keep || !s.jobs[id].Status().State.Finished() {
+			return false
+		}
+		finished--
+		delete(s.jobs, id)
+		return true
+	})
+}
+
+func (s *Server) withJob(fn func(w http.ResponseWriter, r *http.Request, job *Job)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		job := s.Get(r.PathValue("id"))
+		if job == nil {
+			writeError(w, http.StatusNotFound, "job not found")
+			return
+		}
+		fn(w, r, job)
+	}
+}
+
+// authorize checks the bearer token of the requests. Browsers can't set headers on a
+// WebSocket, which can pass it in the token query parameter instead.
+func (s *Server) authorize(next http.Handler) http.Handler {
+	want := []byte("Bearer " + s.config.Token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got := r.Header.Get("Authorization")
+		if got == "" && websocket.IsWebSocketUpgrade(r) {
+			got = "Bearer " + r.URL.Query().Get("token")
+		}
+		if subtle.ConstantTimeCompare([]byte(got), want) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeError(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// pageParams parses the offset and limit query parameters of a results page.
+func pageParams(r *http.Request) (offset, limit int, err error) {
+	limit = MaxPageSize
+	if v := r.URL.Query().Get("offset"); v != "" {
+		if offset, err = strconv.Atoi(v); err != nil || offset < 0 {
+			return 0, 0, errors.Errorf("invalid offset %q", v)
+		}
+	}
+	if v := r.URL.Query().Get("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil || limit <= 0 {
+			return 0, 0, errors.Errorf("invalid limit %q", v)
+		}
+		limit = min(limit, MaxPageSize)
+	}
+	return offset, limit, nil
+}
+
+func newJobID() (string, error) {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", errors.WithStack(err)
+	}
+	return hex.EncodeToString(b[:]), nil
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, code int, msg string) {
+	writeJSON(w, code, struct {
+		Error string `json:"error"`
+	}{msg})
+}
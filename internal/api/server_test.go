@@ -0,0 +1,205 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/planxnx/ethereum-wallet-generator/filter"
+)
+
+func TestServer(t *testing.T) {
+	s := NewServer(Config{
+		Token:      "secret",
+		MaxResults: 3,
+		Run: func(ctx context.Context, spec Spec, job *Job) error {
+			job.SetTotal(int64(len(spec.Seeds)))
+			for i := range spec.Seeds {
+				if ctx.Err() != nil {
+					break
+				}
+				job.Progress(1)
+				job.Match(Result{Line: i + 1, Address: "0x01"})
+			}
+			return nil
+		},
+	})
+	defer s.Close()
+	srv := httptest.NewServer(s.Handler())
+	defer srv.Close()
+
+	call := func(method, path, body string, out any) int {
+		req, _ := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer secret")
+		resp, err := srv.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if out != nil {
+			if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return resp.StatusCode
+	}
+
+	if resp, err := srv.Client().Get(srv.URL + JobsPath); err != nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("unauthenticated request: %v %v", resp.Status, err)
+	}
+	if code := call("POST", JobsPath, `{"kind":"scan"}`, nil); code != http.StatusBadRequest {
+		t.Errorf("invalid job code = %d", code)
+	}
+
+	var st Status
+	if code := call("POST", JobsPath, `{"kind":"scan","seeds":["a","b","c","d","e"]}`, &st); code != http.StatusAccepted {
+		t.Fatalf("submit code = %d", code)
+	}
+	<-s.Get(st.ID).done
+	call("GET", JobsPath+"/"+st.ID, "", &st)
+	if st.State != StateDone || !st.Truncated || st.Matches != 3 || st.Total != 5 {
+		t.Errorf("status = %+v", st)
+	}
+
+	var page Results
+	call("GET", JobsPath+"/"+st.ID+"/results?offset=1&limit=1", "", &page)
+	if len(page.Results) != 1 || page.Results[0].Line != 2 || page.Next != 2 || page.Done {
+		t.Errorf("page = %+v", page)
+	}
+	call("GET", JobsPath+"/"+st.ID+"/results?offset=2", "", &page)
+	if len(page.Results) != 1 || !page.Done {
+		t.Errorf("last page = %+v", page)
+	}
+
+	if code := call("DELETE", JobsPath+"/"+st.ID, "", nil); code != http.StatusNoContent {
+		t.Errorf("delete code = %d", code)
+	}
+	if code := call("GET", JobsPath+"/"+st.ID, "", nil); code != http.StatusNotFound {
+		t.Errorf("deleted job code = %d", code)
+	}
+}
+
+func TestServerCancel(t *testing.T) {
+	s := NewServer(Config{
+		Token: "secret",
+		Run: func(ctx context.Context, _ Spec, _ *Job) error {
+			<-ctx.Done()
+			return nil
+		},
+	})
+	defer s.Close()
+
+	running, _ := s.Submit(Spec{Kind: KindGenerate, Number: 1})
+	for running.Status().State != StateRunning {
+		time.Sleep(time.Millisecond)
+	}
+	queued, _ := s.Submit(Spec{Kind: KindGenerate, Number: 1})
+	if st := queued.Status(); st.State != StateQueued {
+		t.Errorf("second job state = %s, want queued", st.State)
+	}
+	running.cancel(context.Canceled)
+	<-running.done
+	if st := running.Status(); st.State != StateCanceled {
+		t.Errorf("canceled job state = %s", st.State)
+	}
+}
+
+func TestServerFilter(t *testing.T) {
+	s := NewServer(Config{
+		Token: "secret",
+		Run: func(ctx context.Context, _ Spec, job *Job) error {
+			job.OnFilter(func(cfg filter.Config) error {
+				job.Match(Result{Address: cfg.Prefix})
+				return nil
+			})
+			<-ctx.Done()
+			return nil
+		},
+	})
+	defer s.Close()
+	srv := httptest.NewServer(s.Handler())
+	defer srv.Close()
+
+	job, _ := s.Submit(Spec{Kind: KindGenerate, Number: 1})
+	for job.Status().State != StateRunning {
+		time.Sleep(time.Millisecond)
+	}
+	put := func(body string) int {
+		req, _ := http.NewRequest("PUT", srv.URL+JobsPath+"/"+job.status.ID+"/filter", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer secret")
+		resp, err := srv.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	if code := put(`{"regex":["[("]}`); code != http.StatusBadRequest {
+		t.Errorf("invalid filter code = %d", code)
+	}
+	if code := put(`{"prefix":"0x00"}`); code != http.StatusOK || job.page(0, 1).Results[0].Address != "0x00" {
+		t.Errorf("filter code = %d, results %+v", code, job.page(0, 1))
+	}
+	job.cancel(context.Canceled)
+	<-job.done
+	if code := put(`{"prefix":"0x00"}`); code != http.StatusConflict {
+		t.Errorf("finished job filter code = %d", code)
+	}
+}
+
+func TestServerEvents(t *testing.T) {
+	s := NewServer(Config{
+		Token: "secret",
+		Run: func(_ context.Context, spec Spec, job *Job) error {
+			for i := range spec.Seeds {
+				job.Progress(1)
+				job.Match(Result{Line: i + 1, Address: "0x01"})
+			}
+			return nil
+		},
+	})
+	defer s.Close()
+	srv := httptest.NewServer(s.Handler())
+	defer srv.Close()
+
+	job, _ := s.Submit(Spec{Kind: KindScan, Seeds: []string{"a", "b", "c"}})
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + JobsPath + "/" + job.Status().ID + "/events?offset=1"
+	if _, resp, err := websocket.DefaultDialer.Dial(url, nil); err == nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("unauthenticated dial: %v", err)
+	}
+	conn, _, err := websocket.DefaultDialer.Dial(url+"&token=secret", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	var lines []int
+	var last *Status
+	for {
+		var ev Event
+		if err := conn.ReadJSON(&ev); err != nil {
+			if !websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+				t.Fatal(err)
+			}
+			break
+		}
+		switch ev.Type {
+		case EventResult:
+			lines = append(lines, ev.Result.Line)
+		case EventStatus:
+			last = ev.Status
+		}
+	}
+	if len(lines) != 2 || lines[0] != 2 || lines[1] != 3 {
+		t.Errorf("streamed lines = %v", lines)
+	}
+	if last == nil || last.State != StateDone {
+		t.Errorf("last status = %+v", last)
+	}
+}
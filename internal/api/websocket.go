@@ -0,0 +1,75 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// writeTimeout is how long a WebSocket client has to receive an event.
+const writeTimeout = 10 * time.Second
+
+// Event types of the events WebSocket.
+const (
+	EventResult = "result"
+	EventStatus = "status"
+)
+
+// Event is a message of the events WebSocket of a job, it carries either a result or the
+// status of the job.
+type Event struct {
+	Type   string  `json:"type"`
+	Result *Result `json:"result,omitempty"`
+	Status *Status `json:"status,omitempty"`
+}
+
+// upgrader accepts any origin, a web UI served elsewhere still needs the token.
+var upgrader = websocket.Upgrader{CheckOrigin: func(*http.Request) bool { return true }}
+
+// serveEvents upgrades to a WebSocket sending the events of a job from the offset query
+// parameter, until the job is finished and the connection is closed.
+func serveEvents(w http.ResponseWriter, r *http.Request, job *Job) {
+	offset := 0
+	if v := r.URL.Query().Get("offset"); v != "" {
+		var err error
+		if offset, err = strconv.Atoi(v); err != nil || offset < 0 {
+			writeError(w, http.StatusBadRequest, "invalid offset "+strconv.Quote(v))
+			return
+		}
+	}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// the upgrader already replied
+		return
+	}
+	defer conn.Close()
+
+	// reading handles the control frames and notices the client going away
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	go func() {
+		defer cancel()
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	send := func(ev Event) error {
+		_ = conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+		return conn.WriteJSON(ev)
+	}
+	err = job.watch(ctx, offset, func(r Result) error {
+		return send(Event{Type: EventResult, Result: &r})
+	}, func(st Status) error {
+		return send(Event{Type: EventStatus, Status: &st})
+	})
+	if err != nil {
+		return
+	}
+	_ = conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, "job finished"), time.Now().Add(writeTimeout))
+}
@@ -0,0 +1,124 @@
+// Package audit reports the matches of a run grouped by the seed they were derived from: the
+// seed lines that produced hits, at which derivation paths and indexes, and the parameters of
+// the search, as a JSON or HTML document traceable back to the inputs without any secret.
+package audit
+
+import (
+	_ "embed"
+	"encoding/json"
+	"html/template"
+	"io"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/planxnx/ethereum-wallet-generator/store"
+	"github.com/planxnx/ethereum-wallet-generator/wallets"
+)
+
+// The formats of a report.
+const (
+	FormatJSON = "json"
+	FormatHTML = "html"
+)
+
+// Formats lists the supported formats.
+var Formats = []string{FormatJSON, FormatHTML}
+
+//go:embed report.html
+var htmlTemplate string
+
+var reportTemplate = template.Must(template.New("report").Parse(htmlTemplate))
+
+// Report is the audit report of a run.
+type Report struct {
+	Title     string    `json:"title,omitempty"`
+	Generated time.Time `json:"generated"`
+	Run       Run       `json:"run"`
+	// Parameters are the settings of the run shaping what was searched: the seeds, the
+	// derivation and the filters.
+	Parameters []Parameter `json:"parameters"`
+	Seeds      []Seed      `json:"seeds"`
+	Matches    int         `json:"matches"`
+}
+
+// Run describes the run of a report.
+type Run struct {
+	ID      string     `json:"id"`
+	Command string     `json:"command,omitempty"`
+	Version string     `json:"version,omitempty"`
+	Started time.Time  `json:"started"`
+	Ended   *time.Time `json:"ended,omitempty"`
+}
+
+// Parameter is a setting of the run.
+type Parameter struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// Seed is a seed line of the run with matches. The seed itself is only identified by the
+// SHA-256 hash of its mnemonic, the random wallets of generate have no seed.
+type Seed struct {
+	File    string  `json:"file,omitempty"`
+	Line    int     `json:"line,omitempty"`
+	Label   string  `json:"label,omitempty"`
+	Hash    string  `json:"seed_hash,omitempty"`
+	Matches []Match `json:"matches"`
+}
+
+// Match is a matched address of a seed.
+type Match struct {
+	Address      string `json:"address"`
+	HDPath       string `json:"hd_path,omitempty"`
+	Account      int    `json:"account"`
+	Index        int    `json:"index"`
+	AddressLabel string `json:"address_label,omitempty"`
+	// SignedMessage and Signature prove the control of the address, when stored.
+	SignedMessage string `json:"signed_message,omitempty"`
+	Signature     string `json:"signature,omitempty"`
+}
+
+// New returns the report of run, whose stored wallets are ws in the order of their seed file
+// and line.
+func New(title string, run store.Run, params []Parameter, ws []*wallets.Wallet) *Report {
+	r := &Report{
+		Title:      title,
+		Generated:  time.Now().UTC(),
+		Run:        Run{ID: run.RunID, Command: run.Command, Version: run.Version, Started: run.StartedAt.UTC(), Ended: run.EndedAt},
+		Parameters: params,
+		Seeds:      []Seed{},
+		Matches:    len(ws),
+	}
+	for _, w := range ws {
+		last := len(r.Seeds) - 1
+		if last < 0 || r.Seeds[last].File != w.SeedFile || r.Seeds[last].Line != w.SeedLine || r.Seeds[last].Hash != w.SeedHash {
+			r.Seeds = append(r.Seeds, Seed{File: w.SeedFile, Line: w.SeedLine, Label: w.SeedLabel, Hash: w.SeedHash})
+			last++
+		}
+		r.Seeds[last].Matches = append(r.Seeds[last].Matches, Match{
+			Address:       w.Address,
+			HDPath:        w.HDPath,
+			Account:       w.AccountIndex,
+			Index:         w.AddressIndex,
+			AddressLabel:  w.AddressLabel,
+			SignedMessage: w.SignedMessage,
+			Signature:     w.Signature,
+		})
+	}
+	return r
+}
+
+// Write writes the report to out in format.
+func (r *Report) Write(out io.Writer, format string) error {
+	switch format {
+	case FormatJSON:
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return errors.WithStack(enc.Encode(r))
+	case FormatHTML:
+		return errors.WithStack(reportTemplate.Execute(out, r))
+	default:
+		return errors.Errorf("unknown report format %q, must be one of %v", format, Formats)
+	}
+}
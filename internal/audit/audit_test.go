@@ -0,0 +1,47 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/planxnx/ethereum-wallet-generator/store"
+	"github.com/planxnx/ethereum-wallet-generator/wallets"
+)
+
+func TestNew(t *testing.T) {
+	ws := []*wallets.Wallet{
+		{Address: "0x01", SeedFile: "a.txt", SeedLine: 3, SeedHash: "h3", HDPath: "m/44'/60'/0'/0/0"},
+		{Address: "0x02", SeedFile: "a.txt", SeedLine: 3, SeedHash: "h3", HDPath: "m/44'/60'/0'/0/4", AddressIndex: 4},
+		{Address: "0x03", SeedFile: "a.txt", SeedLine: 9, SeedHash: "h9", SeedLabel: "<alice>"},
+		{Address: "0x04", SeedFile: "b.txt", SeedLine: 9, SeedHash: "h9b"},
+	}
+	r := New("Recovery", store.Run{RunID: "r1", Command: "scan"}, []Parameter{{Name: "prefix", Value: "0x0"}}, ws)
+	if len(r.Seeds) != 3 || r.Matches != 4 {
+		t.Fatalf("grouped into %d seeds, %d matches", len(r.Seeds), r.Matches)
+	}
+	if s := r.Seeds[0]; s.Line != 3 || len(s.Matches) != 2 || s.Matches[1].Index != 4 {
+		t.Errorf("first seed %+v", s)
+	}
+
+	var buf bytes.Buffer
+	if err := r.Write(&buf, FormatJSON); err != nil {
+		t.Fatal(err)
+	}
+	var decoded Report
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil || len(decoded.Seeds) != 3 {
+		t.Errorf("JSON report %s, err %v", buf.String(), err)
+	}
+
+	buf.Reset()
+	if err := r.Write(&buf, FormatHTML); err != nil {
+		t.Fatal(err)
+	}
+	if html := buf.String(); !strings.Contains(html, "&lt;alice&gt;") || !strings.Contains(html, "0x04") {
+		t.Errorf("HTML report misses the escaped label or a match:\n%s", html)
+	}
+	if err := r.Write(&buf, "pdf"); err == nil {
+		t.Error("unknown format must fail")
+	}
+}
@@ -0,0 +1,149 @@
+// Package backpressure holds back the writes of results while the disks they are written to
+// run out of free space or the DB is slow to take them.
+package backpressure
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultInterval is the default interval between free space checks.
+const DefaultInterval = time.Second
+
+// Config configures a gate.
+type Config struct {
+	// Paths are the files and directories the results are written to, the free space of
+	// the filesystem of each is checked.
+	Paths []string
+	// MinFree is the free space in bytes under which the writes are held back, 0 to not
+	// check it.
+	MinFree uint64
+	// MaxLatency is the duration of a DB write above which the writes are held back for
+	// as long as it took, 0 to not check it.
+	MaxLatency time.Duration
+	// Interval is the interval between free space checks, defaults to DefaultInterval.
+	Interval time.Duration
+}
+
+// Reason is why a gate holds the writes back.
+type Reason struct {
+	// Path is the path whose filesystem has Free bytes left, below MinFree, if the free
+	// space is low.
+	Path    string
+	Free    uint64
+	MinFree uint64
+	// Latency is the duration of the DB write above MaxLatency, if the DB is slow.
+	Latency    time.Duration
+	MaxLatency time.Duration
+}
+
+// Gate holds back the writes of results while the free space of their filesystems is
+// low or the DB is slow. A Gate is safe for concurrent use.
+type Gate struct {
+	config Config
+	// freeSpace returns the bytes available to the process on the filesystem of path.
+	freeSpace func(path string) (uint64, error)
+
+	mu sync.Mutex
+	// checked is the time of the last free space check and low its result, nil if every
+	// filesystem had enough space.
+	checked time.Time
+	low     *Reason
+	// slow is the last write slower than MaxLatency, held back until slowUntil.
+	slow      Reason
+	slowUntil time.Time
+}
+
+// New returns a gate checking the free space of the filesystems of cfg.Paths, it returns an
+// error if the free space of one of them can't be checked.
+func New(cfg Config) (*Gate, error) {
+	if cfg.Interval <= 0 {
+		cfg.Interval = DefaultInterval
+	}
+	cfg.Paths = slices.Clone(cfg.Paths)
+	if cfg.MinFree > 0 {
+		if !FreeSpaceSupported {
+			return nil, errors.New("free disk space checks are not supported on this platform")
+		}
+		for i, path := range cfg.Paths {
+			if abs, err := filepath.Abs(path); err == nil {
+				cfg.Paths[i] = abs
+			}
+			if _, err := freeSpace(cfg.Paths[i]); err != nil {
+				return nil, errors.Wrapf(err, "failed to check the free space of %s", path)
+			}
+		}
+	}
+	return &Gate{config: cfg, freeSpace: freeSpace}, nil
+}
+
+// ObserveDB records the duration of a DB write, a write slower than MaxLatency holds the
+// next ones back for as long as it took. op is ignored, ObserveDB matches the observe
+// function of store.NewInstrumentedRepository.
+func (g *Gate) ObserveDB(op string, d time.Duration) {
+	if g == nil || g.config.MaxLatency <= 0 || d <= g.config.MaxLatency {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if until := time.Now().Add(d); until.After(g.slowUntil) {
+		g.slow = Reason{Latency: d, MaxLatency: g.config.MaxLatency}
+		g.slowUntil = until
+	}
+}
+
+// Wait blocks while the writes are held back, or until ctx is done, after which it no longer
+// blocks. hold is called with the reason when it starts blocking, it may be nil. Wait reports
+// whether it blocked.
+func (g *Gate) Wait(ctx context.Context, hold func(Reason)) bool {
+	if g == nil {
+		return false
+	}
+	held := false
+	for {
+		reason, wait := g.check()
+		if reason == nil || ctx.Err() != nil {
+			return held
+		}
+		if !held && hold != nil {
+			hold(*reason)
+		}
+		held = true
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+		}
+	}
+}
+
+// check returns why the writes are held back and how long to wait before checking again,
+// or nil if they aren't.
+func (g *Gate) check() (*Reason, time.Duration) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	now := time.Now()
+	if g.config.MinFree > 0 && now.Sub(g.checked) >= g.config.Interval {
+		g.checked, g.low = now, nil
+		for _, path := range g.config.Paths {
+			free, err := g.freeSpace(path)
+			// a failing check doesn't stop the writes, they report their own errors
+			if err == nil && free < g.config.MinFree {
+				g.low = &Reason{Path: path, Free: free, MinFree: g.config.MinFree}
+				break
+			}
+		}
+	}
+	if g.low != nil {
+		return g.low, g.config.Interval
+	}
+	if now.Before(g.slowUntil) {
+		slow := g.slow
+		return &slow, g.slowUntil.Sub(now)
+	}
+	return nil, 0
+}
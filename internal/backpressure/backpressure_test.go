@@ -0,0 +1,65 @@
+package backpressure
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGateFreeSpace(t *testing.T) {
+	g, err := New(Config{Paths: []string{t.TempDir()}, MinFree: 100, Interval: time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var free atomic.Uint64
+	free.Store(10)
+	g.freeSpace = func(string) (uint64, error) { return free.Load(), nil }
+
+	var reasons []Reason
+	done := make(chan bool)
+	go func() {
+		done <- g.Wait(context.Background(), func(r Reason) { reasons = append(reasons, r) })
+	}()
+	select {
+	case <-done:
+		t.Fatal("Wait returned while the free space was low")
+	case <-time.After(20 * time.Millisecond):
+	}
+	free.Store(1000)
+	if held := <-done; !held || len(reasons) != 1 || reasons[0].Free != 10 || reasons[0].MinFree != 100 {
+		t.Errorf("held %v, reasons %+v", held, reasons)
+	}
+	if g.Wait(context.Background(), nil) {
+		t.Error("Wait blocked with enough free space")
+	}
+
+	free.Store(10)
+	time.Sleep(2 * time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if g.Wait(ctx, nil) {
+		t.Error("Wait blocked once ctx was done")
+	}
+}
+
+func TestGateLatency(t *testing.T) {
+	g, err := New(Config{MaxLatency: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.ObserveDB("insert", 5*time.Millisecond)
+	if g.Wait(context.Background(), nil) {
+		t.Error("a fast write must not hold the next ones back")
+	}
+
+	g.ObserveDB("commit", 30*time.Millisecond)
+	start := time.Now()
+	var reason Reason
+	if !g.Wait(context.Background(), func(r Reason) { reason = r }) || reason.Latency != 30*time.Millisecond {
+		t.Errorf("slow write not held back, reason %+v", reason)
+	}
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Errorf("held back for %v only", elapsed)
+	}
+}
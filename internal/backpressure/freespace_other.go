@@ -0,0 +1,12 @@
+//go:build !(linux || darwin || freebsd || dragonfly)
+
+package backpressure
+
+import "github.com/pkg/errors"
+
+// FreeSpaceSupported reports whether the free disk space can be checked on this platform.
+const FreeSpaceSupported = false
+
+func freeSpace(string) (uint64, error) {
+	return 0, errors.New("free disk space checks are not supported on this platform")
+}
@@ -0,0 +1,29 @@
+//go:build linux || darwin || freebsd || dragonfly
+
+package backpressure
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// FreeSpaceSupported reports whether the free disk space can be checked on this platform.
+const FreeSpaceSupported = true
+
+// freeSpace returns the bytes available to unprivileged users on the filesystem of path,
+// the one of its closest existing parent if it doesn't exist yet.
+func freeSpace(path string) (uint64, error) {
+	for {
+		var st syscall.Statfs_t
+		err := syscall.Statfs(path, &st)
+		if err == nil {
+			return uint64(st.Bavail) * uint64(st.Bsize), nil
+		}
+		parent := filepath.Dir(path)
+		if !os.IsNotExist(err) || parent == path {
+			return 0, err
+		}
+		path = parent
+	}
+}
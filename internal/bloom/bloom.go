@@ -0,0 +1,189 @@
+// Package bloom is a Bloom filter persisted to a file, remembering the addresses reported by
+// earlier runs in a fixed size whatever their number, at the cost of a configurable false
+// positive rate.
+package bloom
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/binary"
+	"io"
+	"math"
+	"os"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Magic starts every filter file, its last byte is the format version.
+const Magic = "EWGBLM\x00\x01"
+
+// Defaults of the filters created by Open.
+const (
+	DefaultCapacity = 1_000_000
+	DefaultFPRate   = 0.001
+)
+
+// header follows Magic in a filter file, the bits follow it.
+type header struct {
+	Bits     uint64
+	Hashes   uint32
+	Capacity uint64
+	FPRate   float64
+	Count    uint64
+}
+
+// Filter is a goroutine safe Bloom filter.
+type Filter struct {
+	mu    sync.Mutex
+	h     header
+	bits  []uint64
+	dirty bool
+}
+
+// New returns an empty filter sized for capacity keys at the false positive rate fpRate.
+func New(capacity uint64, fpRate float64) (*Filter, error) {
+	if capacity == 0 {
+		return nil, errors.New("the capacity of a Bloom filter must be positive")
+	}
+	if fpRate <= 0 || fpRate >= 1 {
+		return nil, errors.Errorf("invalid false positive rate %v, it must be between 0 and 1", fpRate)
+	}
+	bits := uint64(math.Ceil(-float64(capacity) * math.Log(fpRate) / (math.Ln2 * math.Ln2)))
+	bits = (bits + 63) / 64 * 64
+	hashes := uint32(max(1, math.Round(float64(bits)/float64(capacity)*math.Ln2)))
+	return &Filter{
+		h:     header{Bits: bits, Hashes: hashes, Capacity: capacity, FPRate: fpRate},
+		bits:  make([]uint64, bits/64),
+		dirty: true,
+	}, nil
+}
+
+// Open reads the filter file at name, or returns a new filter of the capacity and fpRate if
+// it doesn't exist yet. The capacity and rate of an existing file are kept.
+func Open(name string, capacity uint64, fpRate float64) (*Filter, error) {
+	f, err := os.Open(name)
+	if os.IsNotExist(err) {
+		return New(capacity, fpRate)
+	}
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer f.Close()
+	filter, err := read(bufio.NewReader(f))
+	return filter, errors.Wrapf(err, "invalid Bloom filter file %s", name)
+}
+
+func read(r io.Reader) (*Filter, error) {
+	magic := make([]byte, len(Magic))
+	if _, err := io.ReadFull(r, magic); err != nil || string(magic) != Magic {
+		return nil, errors.New("not a Bloom filter")
+	}
+	var h header
+	if err := binary.Read(r, binary.BigEndian, &h); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if h.Bits == 0 || h.Bits%64 != 0 || h.Hashes == 0 || h.Bits > 1<<40 {
+		return nil, errors.New("corrupted header")
+	}
+	f := &Filter{h: h, bits: make([]uint64, h.Bits/64)}
+	if err := binary.Read(r, binary.BigEndian, f.bits); err != nil {
+		return nil, errors.Wrap(err, "truncated bits")
+	}
+	return f, nil
+}
+
+// Save writes the filter to the file at name, replaced only once it is complete. It does
+// nothing if the filter read from the file is unchanged.
+func (f *Filter) Save(name string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.dirty {
+		return nil
+	}
+
+	tmp := name + ".tmp"
+	file, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer os.Remove(tmp)
+	w := bufio.NewWriter(file)
+	w.WriteString(Magic)
+	binary.Write(w, binary.BigEndian, f.h)
+	binary.Write(w, binary.BigEndian, f.bits)
+	if err := w.Flush(); err != nil {
+		file.Close()
+		return errors.WithStack(err)
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return errors.WithStack(err)
+	}
+	if err := file.Close(); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := os.Rename(tmp, name); err != nil {
+		return errors.WithStack(err)
+	}
+	f.dirty = false
+	return nil
+}
+
+// locations calls fn with the bit of every hash of key, derived from the two halves of its
+// SHA-256 by double hashing.
+func (f *Filter) locations(key string, fn func(bit uint64) bool) bool {
+	sum := sha256.Sum256([]byte(key))
+	h1, h2 := binary.BigEndian.Uint64(sum[:8]), binary.BigEndian.Uint64(sum[8:16])|1
+	for i := range uint64(f.h.Hashes) {
+		if !fn((h1 + i*h2) % f.h.Bits) {
+			return false
+		}
+	}
+	return true
+}
+
+// Test reports whether key may have been added, false if it certainly wasn't.
+func (f *Filter) Test(key string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.test(key)
+}
+
+func (f *Filter) test(key string) bool {
+	return f.locations(key, func(bit uint64) bool { return f.bits[bit/64]&(1<<(bit%64)) != 0 })
+}
+
+// Add adds key and reports whether it may have been added before.
+func (f *Filter) Add(key string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.test(key) {
+		return true
+	}
+	f.locations(key, func(bit uint64) bool {
+		f.bits[bit/64] |= 1 << (bit % 64)
+		return true
+	})
+	f.h.Count++
+	f.dirty = true
+	return false
+}
+
+// Count returns the number of distinct keys added, as far as the filter can tell.
+func (f *Filter) Count() uint64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.h.Count
+}
+
+// Capacity returns the number of keys the filter was sized for and its false positive rate
+// at that number, which grows once more keys are added.
+func (f *Filter) Capacity() (uint64, float64) {
+	return f.h.Capacity, f.h.FPRate
+}
+
+// Size returns the size of the bits of the filter in bytes.
+func (f *Filter) Size() int64 {
+	return int64(f.h.Bits / 8)
+}
@@ -0,0 +1,60 @@
+package bloom
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAddSaveOpen(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "seen.bloom")
+	f, err := Open(name, 1000, 0.01)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range 1000 {
+		if f.Add(fmt.Sprintf("0x%040x", i)) && i < 10 {
+			t.Errorf("key %d reported as added before", i)
+		}
+	}
+	if err := f.Save(name); err != nil {
+		t.Fatal(err)
+	}
+
+	g, err := Open(name, 5, 0.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if capacity, rate := g.Capacity(); capacity != 1000 || rate != 0.01 {
+		t.Errorf("reopened with capacity %d and rate %v", capacity, rate)
+	}
+	for i := range 1000 {
+		if !g.Test(fmt.Sprintf("0x%040x", i)) {
+			t.Fatalf("lost key %d", i)
+		}
+	}
+	positives := 0
+	for i := 1000; i < 11000; i++ {
+		if g.Test(fmt.Sprintf("0x%040x", i)) {
+			positives++
+		}
+	}
+	// the expected rate is 1%, this fails once in many millions runs
+	if positives > 250 {
+		t.Errorf("%d false positives in 10000 keys", positives)
+	}
+}
+
+func TestOpenInvalid(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "seen.bloom")
+	if err := os.WriteFile(name, []byte("EWGBLM\x00\x01short"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(name, DefaultCapacity, DefaultFPRate); err == nil {
+		t.Error("opened a truncated filter")
+	}
+	if _, err := New(10, 1); err == nil {
+		t.Error("accepted a false positive rate of 1")
+	}
+}
@@ -0,0 +1,137 @@
+// Package checkpoint persists the position reached by a run so it can be resumed later.
+package checkpoint
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultInterval is the default interval between checkpoint writes.
+const DefaultInterval = 30 * time.Second
+
+// Checkpoint is the position reached by a run.
+type Checkpoint struct {
+	// Line is the seed line currently being processed.
+	Line int `json:"line"`
+	// Index is the number of address indexes already processed for Line.
+	Index int `json:"index"`
+	// ConfigHash identifies the settings of the run, a checkpoint can only be resumed with the same settings.
+	ConfigHash string `json:"config_hash"`
+	// Done is true once the whole run has completed.
+	Done      bool      `json:"done"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Hash returns a stable hash of the given run settings.
+func Hash(settings any) (string, error) {
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Load reads a checkpoint file.
+func Load(path string) (*Checkpoint, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var cp Checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, errors.Wrapf(err, "invalid checkpoint file %s", path)
+	}
+	return &cp, nil
+}
+
+// Save atomically writes a checkpoint file, synced to disk before it replaces the previous one.
+func Save(path string, cp Checkpoint) error {
+	cp.UpdatedAt = time.Now().UTC()
+	data, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return errors.WithStack(err)
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return errors.WithStack(err)
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return errors.WithStack(err)
+	}
+	// the rename survives a crash once the directory is synced, which not every platform supports
+	if dir, err := os.Open(filepath.Dir(path)); err == nil {
+		_ = dir.Sync()
+		_ = dir.Close()
+	}
+	return nil
+}
+
+// Writer saves checkpoints to a file at most once per interval.
+type Writer struct {
+	path       string
+	configHash string
+	interval   time.Duration
+	lastSave   time.Time
+}
+
+// NewWriter returns a checkpoint writer for the given file.
+func NewWriter(path, configHash string, interval time.Duration) *Writer {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	return &Writer{
+		path:       path,
+		configHash: configHash,
+		interval:   interval,
+		lastSave:   time.Now(),
+	}
+}
+
+// Due reports whether the interval has elapsed since the previous save.
+func (w *Writer) Due() bool {
+	return w != nil && time.Since(w.lastSave) >= w.interval
+}
+
+// Update saves the position if the interval has elapsed since the previous save.
+func (w *Writer) Update(line, index int) error {
+	if !w.Due() {
+		return nil
+	}
+	return w.save(Checkpoint{Line: line, Index: index})
+}
+
+// Flush saves the position immediately, done marks the run as completed.
+func (w *Writer) Flush(line, index int, done bool) error {
+	if w == nil {
+		return nil
+	}
+	return w.save(Checkpoint{Line: line, Index: index, Done: done})
+}
+
+func (w *Writer) save(cp Checkpoint) error {
+	cp.ConfigHash = w.configHash
+	w.lastSave = time.Now()
+	return Save(w.path, cp)
+}
@@ -0,0 +1,91 @@
+// Package config loads flag values from YAML or TOML run files.
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// Load reads a YAML (.yaml, .yml) or TOML (.toml) file mapping flag names to values.
+// Keys may use underscores instead of dashes and lists are joined with commas.
+func Load(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	raw := make(map[string]any)
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &raw)
+	case ".toml":
+		err = toml.Unmarshal(data, &raw)
+	default:
+		return nil, errors.Errorf("unknown config file extension %q, must be .yaml, .yml or .toml", ext)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid config file %s", path)
+	}
+
+	values := make(map[string]string, len(raw))
+	for key, value := range raw {
+		s, err := flagValue(value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "config key %q", key)
+		}
+		values[strings.ReplaceAll(key, "_", "-")] = s
+	}
+	return values, nil
+}
+
+// Apply sets the flags of fs that were not given on the command line from values.
+// It is called after fs.Parse so command line flags override file values.
+func Apply(fs *flag.FlagSet, values map[string]string) error {
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+
+	for name, value := range values {
+		if fs.Lookup(name) == nil {
+			return errors.Errorf("unknown flag %q in config file for %s", name, fs.Name())
+		}
+		if set[name] {
+			continue
+		}
+		if err := fs.Set(name, value); err != nil {
+			return errors.Wrapf(err, "invalid value %q for flag %q", value, name)
+		}
+	}
+	return nil
+}
+
+func flagValue(v any) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case []any:
+		items := make([]string, len(v))
+		for i, item := range v {
+			s, err := flagValue(item)
+			if err != nil {
+				return "", err
+			}
+			items[i] = s
+		}
+		return strings.Join(items, ","), nil
+	case map[string]any:
+		return "", errors.New("nested tables are not supported, use flag names as keys")
+	default:
+		return fmt.Sprint(v), nil
+	}
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApply(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run.yaml")
+	content := "seeds: seeds.txt\ndepth: 5\nstrict: true\ncontains: [\"0x00\", \"777\"]\nmax_cpu: 50%\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	fs := flag.NewFlagSet("scan", flag.ContinueOnError)
+	seeds := fs.String("seeds", "", "")
+	depth := fs.Int("depth", 1, "")
+	strict := fs.Bool("strict", false, "")
+	contains := fs.String("contains", "", "")
+	maxCPU := fs.String("max-cpu", "100%", "")
+	if err := fs.Parse([]string{"-depth", "2"}); err != nil {
+		t.Fatal(err)
+	}
+
+	values, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(t, Apply(fs, values))
+	assert.Equal(t, "seeds.txt", *seeds)
+	assert.Equal(t, 2, *depth, "command line flags take precedence")
+	assert.True(t, *strict)
+	assert.Equal(t, "0x00,777", *contains)
+	assert.Equal(t, "50%", *maxCPU)
+
+	assert.Error(t, Apply(fs, map[string]string{"unknown": "1"}))
+}
+
+func TestApplyEnv(t *testing.T) {
+	t.Setenv("EWG_DB_KEY", "secret")
+	t.Setenv("EWG_DEPTH", "3")
+
+	fs := flag.NewFlagSet("scan", flag.ContinueOnError)
+	dbKey := fs.String("db-key", "", "")
+	depth := fs.Int("depth", 1, "")
+	if err := fs.Parse([]string{"-depth", "2"}); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.NoError(t, ApplyEnv(fs))
+	assert.Equal(t, "secret", *dbKey)
+	assert.Equal(t, 2, *depth, "command line flags take precedence")
+
+	// flags set from the environment are not overridden by the config file
+	assert.NoError(t, Apply(fs, map[string]string{"db-key": "from-file"}))
+	assert.Equal(t, "secret", *dbKey)
+}
@@ -0,0 +1,42 @@
+package config
+
+import (
+	"flag"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// EnvPrefix is the prefix of the environment variables setting flags.
+const EnvPrefix = "EWG_"
+
+// EnvName returns the environment variable of a flag, eg. EWG_DB_KEY for -db-key.
+func EnvName(flagName string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+// ApplyEnv sets the flags of fs that were not given on the command line from their
+// EWG_ environment variables. It is called after fs.Parse and before Apply, so values
+// are taken from the command line first, then the environment, then the config file.
+func ApplyEnv(fs *flag.FlagSet) error {
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+
+	var err error
+	fs.VisitAll(func(f *flag.Flag) {
+		if err != nil || set[f.Name] {
+			return
+		}
+		value, ok := os.LookupEnv(EnvName(f.Name))
+		if !ok {
+			return
+		}
+		if setErr := fs.Set(f.Name, value); setErr != nil {
+			err = errors.Wrapf(setErr, "invalid value of %s", EnvName(f.Name))
+		}
+	})
+	return err
+}
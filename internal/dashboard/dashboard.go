@@ -0,0 +1,283 @@
+// Package dashboard is an interactive terminal view of a running scan, with keybindings
+// to pause it, tighten its filters or stop it.
+package dashboard
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/pkg/errors"
+
+	"github.com/planxnx/ethereum-wallet-generator/filter"
+	"github.com/planxnx/ethereum-wallet-generator/pipeline"
+)
+
+const (
+	// refreshInterval is the delay between two renders.
+	refreshInterval = 500 * time.Millisecond
+	// recentMatches is the number of matches kept on screen.
+	recentMatches = 8
+	// recentLogs is the number of log lines kept on screen.
+	recentLogs = 5
+)
+
+// Controls are the actions triggered by the dashboard keybindings.
+type Controls struct {
+	Pause  func()
+	Resume func()
+	// Tighten adds a regex every further match must also satisfy.
+	Tighten func(regex string) error
+	// Stop ends the run cleanly, as an interrupt would.
+	Stop func()
+}
+
+// Config configures a Dashboard.
+type Config struct {
+	// Total is the number of addresses to derive, 0 or less when unknown.
+	Total   int
+	Workers int
+	// Filter is the validator of the run, whose hit rates are shown.
+	Filter   *filter.Dynamic
+	Controls Controls
+}
+
+// Dashboard collects the progress of a run and renders it until closed.
+// Its methods are safe for concurrent use.
+type Dashboard struct {
+	config  Config
+	start   time.Time
+	program *tea.Program
+	done    chan error
+
+	mu        sync.Mutex
+	processed int64
+	seeds     int64
+	matches   int64
+	failures  int64
+	busy      time.Duration
+	recent    []string
+	logs      []string
+	partial   []byte
+}
+
+// New returns a dashboard, call Start to show it.
+func New(cfg Config) *Dashboard {
+	return &Dashboard{config: cfg, start: time.Now(), done: make(chan error, 1)}
+}
+
+// Start takes over the terminal and renders the dashboard on stderr. Keys are read from
+// the terminal, so stdin can still be the seeds input.
+func (d *Dashboard) Start() {
+	d.program = tea.NewProgram(model{d: d}, tea.WithOutput(os.Stderr), tea.WithInputTTY(), tea.WithAltScreen())
+	go func() {
+		_, err := d.program.Run()
+		d.done <- errors.WithStack(err)
+	}()
+}
+
+// Close stops rendering and gives the terminal back.
+func (d *Dashboard) Close() error {
+	d.program.Quit()
+	return <-d.done
+}
+
+// Seed records a processed seed.
+func (d *Dashboard) Seed(st pipeline.SeedStats) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.seeds++
+	d.processed += int64(st.Processed)
+	d.failures += int64(st.Failures)
+	d.busy += st.Elapsed
+}
+
+// Match records a match, described by where it was derived from.
+func (d *Dashboard) Match(origin, address string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.matches++
+	d.recent = append(d.recent, fmt.Sprintf("%s  %s", address, origin))
+	if len(d.recent) > recentMatches {
+		d.recent = d.recent[len(d.recent)-recentMatches:]
+	}
+}
+
+// Write keeps the last log lines written to the dashboard, so the logger can write to it
+// instead of garbling the screen.
+func (d *Dashboard) Write(p []byte) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.partial = append(d.partial, p...)
+	for {
+		i := bytes.IndexByte(d.partial, '\n')
+		if i < 0 {
+			break
+		}
+		d.logs = append(d.logs, string(d.partial[:i]))
+		d.partial = d.partial[i+1:]
+	}
+	if len(d.logs) > recentLogs {
+		d.logs = d.logs[len(d.logs)-recentLogs:]
+	}
+	return len(p), nil
+}
+
+// view renders the current state, along with the key help or prompt line of the model.
+func (d *Dashboard) view(m model) string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var b strings.Builder
+	elapsed := time.Since(d.start)
+	rate := float64(d.processed) / max(elapsed.Seconds(), 0.001)
+
+	state := "running"
+	switch {
+	case m.stopping:
+		state = "stopping"
+	case m.paused:
+		state = "paused"
+	}
+	fmt.Fprintf(&b, " Wallet scan [%s]\n\n", state)
+
+	total, eta := "?", "?"
+	if d.config.Total > 0 {
+		total = fmt.Sprint(d.config.Total)
+		if rate > 0 {
+			eta = (time.Duration(float64(int64(d.config.Total)-d.processed)/rate) * time.Second).Round(time.Second).String()
+		}
+	}
+	fmt.Fprintf(&b, " Progress     %d/%s addresses, %d seeds | ETA %s\n", d.processed, total, d.seeds, eta)
+	fmt.Fprintf(&b, " Throughput   %.1f addr/s | elapsed %s\n", rate, elapsed.Round(time.Second))
+	utilization := 100 * d.busy.Seconds() / max(elapsed.Seconds()*float64(max(d.config.Workers, 1)), 0.001)
+	fmt.Fprintf(&b, " Workers      %d at %.0f%% utilization\n", max(d.config.Workers, 1), min(utilization, 100))
+	fmt.Fprintf(&b, " Results      %d matches, %d failures\n", d.matches, d.failures)
+
+	b.WriteString("\n Filters\n")
+	for _, st := range d.config.Filter.Stats() {
+		fmt.Fprintf(&b, "   %-40s %d/%d hits (%s)\n", st.Name, st.Hits, st.Checked, percent(st.Hits, st.Checked))
+	}
+
+	b.WriteString("\n Recent matches\n")
+	for _, r := range d.recent {
+		b.WriteString("   " + r + "\n")
+	}
+
+	if len(d.logs) > 0 {
+		b.WriteString("\n Log\n")
+		for _, l := range d.logs {
+			b.WriteString("   " + l + "\n")
+		}
+	}
+
+	b.WriteString("\n")
+	switch {
+	case m.editing:
+		fmt.Fprintf(&b, " Add regex filter: %s_  (enter to apply, esc to cancel)\n", m.input)
+	case m.message != "":
+		b.WriteString(" " + m.message + "\n")
+	default:
+		b.WriteString(" p pause/resume | r add regex filter | q stop\n")
+	}
+	return b.String()
+}
+
+// percent formats n/total as a percentage.
+func percent(n, total int64) string {
+	if total <= 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.4f%%", 100*float64(n)/float64(total))
+}
+
+// tickMsg triggers a render.
+type tickMsg time.Time
+
+func tick() tea.Cmd {
+	return tea.Tick(refreshInterval, func(t time.Time) tea.Msg { return tickMsg(t) })
+}
+
+// model is the bubbletea model of the dashboard, it only holds the interaction state.
+type model struct {
+	d        *Dashboard
+	paused   bool
+	stopping bool
+	editing  bool
+	input    string
+	message  string
+}
+
+func (m model) Init() tea.Cmd {
+	return tick()
+}
+
+func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tickMsg:
+		return m, tick()
+	case tea.KeyMsg:
+		if m.editing {
+			return m.updatePrompt(msg), nil
+		}
+		switch msg.String() {
+		case "p", " ":
+			if m.paused {
+				m.d.config.Controls.Resume()
+			} else {
+				m.d.config.Controls.Pause()
+			}
+			m.paused = !m.paused
+			m.message = ""
+		case "r":
+			m.editing, m.input = true, ""
+		case "q", "ctrl+c":
+			if !m.stopping {
+				m.stopping = true
+				m.d.config.Controls.Stop()
+			}
+		}
+	}
+	return m, nil
+}
+
+// updatePrompt edits the regex prompt, enter applies it.
+func (m model) updatePrompt(msg tea.KeyMsg) model {
+	switch msg.Type {
+	case tea.KeyEnter:
+		m.editing = false
+		if m.input == "" {
+			return m
+		}
+		if err := m.d.config.Controls.Tighten(m.input); err != nil {
+			m.message = "Error: " + err.Error()
+			return m
+		}
+		m.message = "Added regex filter " + m.input
+	case tea.KeyEsc:
+		m.editing = false
+	case tea.KeyBackspace:
+		if r := []rune(m.input); len(r) > 0 {
+			m.input = string(r[:len(r)-1])
+		}
+	case tea.KeySpace:
+		m.input += " "
+	case tea.KeyRunes:
+		m.input += string(msg.Runes)
+	case tea.KeyCtrlC:
+		m.editing = false
+		if !m.stopping {
+			m.stopping = true
+			m.d.config.Controls.Stop()
+		}
+	}
+	return m
+}
+
+func (m model) View() string {
+	return m.d.view(m)
+}
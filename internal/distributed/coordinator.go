@@ -0,0 +1,240 @@
+package distributed
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/planxnx/ethereum-wallet-generator/seeds"
+)
+
+const (
+	// DefaultUnitSize is the default number of seeds per work unit.
+	DefaultUnitSize = 1000
+	// DefaultLeaseTimeout is the default duration after which an unfinished unit is handed out again.
+	DefaultLeaseTimeout = 10 * time.Minute
+	// ShutdownGrace is how long a finished coordinator keeps serving, so polling workers learn the run is done.
+	ShutdownGrace = 2 * DefaultPollInterval
+)
+
+// CoordinatorConfig configures a Coordinator.
+type CoordinatorConfig struct {
+	Seeds        <-chan seeds.Seed
+	TotalSeeds   int
+	UnitSize     int
+	Job          Job
+	LeaseTimeout time.Duration
+	// Token must be sent by workers as a bearer token, every request is refused without one.
+	Token string
+	// OnMatch is called for every match reported by workers, calls are serialized.
+	OnMatch func(Match)
+}
+
+type leasedUnit struct {
+	unit     *Unit
+	deadline time.Time
+}
+
+// Coordinator splits a seed stream into work units and hands them out to workers.
+type Coordinator struct {
+	config CoordinatorConfig
+
+	// readMu serializes the reads of the seed stream, mu guards the state and is never held
+	// while reading it
+	readMu    sync.Mutex
+	mu        sync.Mutex
+	nextID    int
+	leased    map[int]*leasedUnit
+	retry     []*Unit
+	exhausted bool
+	status    Status
+
+	done     chan struct{}
+	doneOnce sync.Once
+}
+
+// NewCoordinator returns a new coordinator.
+func NewCoordinator(cfg CoordinatorConfig) *Coordinator {
+	if cfg.UnitSize <= 0 {
+		cfg.UnitSize = DefaultUnitSize
+	}
+	if cfg.LeaseTimeout <= 0 {
+		cfg.LeaseTimeout = DefaultLeaseTimeout
+	}
+	return &Coordinator{
+		config: cfg,
+		leased: make(map[int]*leasedUnit),
+		status: Status{TotalSeeds: cfg.TotalSeeds},
+		done:   make(chan struct{}),
+	}
+}
+
+// Done is closed once every unit has been completed.
+func (c *Coordinator) Done() <-chan struct{} {
+	return c.done
+}
+
+// Status returns the current progress.
+func (c *Coordinator) Status() Status {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.currentStatus()
+}
+
+// Handler returns the HTTP handler serving the coordinator API.
+func (c *Coordinator) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST "+LeasePath, func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, c.Lease())
+	})
+	mux.HandleFunc("POST "+CompletePath, func(w http.ResponseWriter, r *http.Request) {
+		var res UnitResult
+		if err := json.NewDecoder(r.Body).Decode(&res); err != nil {
+			http.Error(w, "invalid unit result: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, c.Complete(res))
+	})
+	mux.HandleFunc("GET "+StatusPath, func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, c.Status())
+	})
+	return c.authorize(mux)
+}
+
+// Lease hands out the next work unit. Reading a new unit from the seed stream, which may block,
+// only holds up the other leases, not the results and status.
+func (c *Coordinator) Lease() Lease {
+	c.mu.Lock()
+	now := time.Now()
+	var expired []*Unit
+	for id, l := range c.leased {
+		if now.After(l.deadline) {
+			delete(c.leased, id)
+			expired = append(expired, l.unit)
+		}
+	}
+	// the oldest units are handed out again first
+	slices.SortFunc(expired, func(a, b *Unit) int { return a.ID - b.ID })
+	c.retry = append(c.retry, expired...)
+	if len(c.retry) > 0 {
+		unit := c.retry[0]
+		c.retry = c.retry[1:]
+		c.leased[unit.ID] = &leasedUnit{unit: unit, deadline: now.Add(c.config.LeaseTimeout)}
+		c.mu.Unlock()
+		return Lease{Unit: unit}
+	}
+	exhausted := c.exhausted
+	c.mu.Unlock()
+	if exhausted {
+		return c.noUnit()
+	}
+
+	c.readMu.Lock()
+	defer c.readMu.Unlock()
+	batch, ok := c.readBatch()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !ok {
+		c.exhausted = true
+	}
+	if len(batch) == 0 {
+		return Lease{Done: c.isDone()}
+	}
+	c.nextID++
+	c.status.DispatchedSeeds += len(batch)
+	unit := &Unit{ID: c.nextID, Job: c.config.Job, Seeds: batch}
+	c.leased[unit.ID] = &leasedUnit{unit: unit, deadline: time.Now().Add(c.config.LeaseTimeout)}
+	return Lease{Unit: unit}
+}
+
+// noUnit is the lease of a worker asking for a unit once the stream is exhausted.
+func (c *Coordinator) noUnit() Lease {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return Lease{Done: c.isDone()}
+}
+
+// Complete records the result of a unit and returns the updated progress.
+// Results of unknown or already completed units are ignored.
+func (c *Coordinator) Complete(res UnitResult) Status {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.leased[res.ID]; ok {
+		delete(c.leased, res.ID)
+	} else if !c.removeRetry(res.ID) {
+		return c.currentStatus()
+	}
+
+	c.status.CompletedUnits++
+	c.status.Processed += int64(res.Processed)
+	c.status.Failed += int64(res.Failed)
+	c.status.Matches += int64(len(res.Matches))
+	if c.config.OnMatch != nil {
+		for _, m := range res.Matches {
+			c.config.OnMatch(m)
+		}
+	}
+
+	if c.isDone() {
+		c.doneOnce.Do(func() { close(c.done) })
+	}
+	return c.currentStatus()
+}
+
+// readBatch reads the seeds of the next unit from the stream, ok is false once it is
+// exhausted. It must be called with readMu held, and not mu.
+func (c *Coordinator) readBatch() (batch []seeds.Seed, ok bool) {
+	batch = make([]seeds.Seed, 0, c.config.UnitSize)
+	for len(batch) < c.config.UnitSize {
+		seed, ok := <-c.config.Seeds
+		if !ok {
+			return batch, false
+		}
+		batch = append(batch, seed)
+	}
+	return batch, true
+}
+
+func (c *Coordinator) removeRetry(id int) bool {
+	for i, u := range c.retry {
+		if u.ID == id {
+			c.retry = append(c.retry[:i], c.retry[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// isDone reports whether the stream is exhausted and every unit was completed, must be called with mu held.
+func (c *Coordinator) isDone() bool {
+	return c.exhausted && len(c.leased) == 0 && len(c.retry) == 0
+}
+
+func (c *Coordinator) currentStatus() Status {
+	status := c.status
+	status.LeasedUnits = len(c.leased)
+	status.Done = c.isDone()
+	return status
+}
+
+// authorize refuses the requests without the bearer token, units carrying mnemonics and
+// results private keys.
+func (c *Coordinator) authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if c.config.Token == "" || subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte("Bearer "+c.config.Token)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
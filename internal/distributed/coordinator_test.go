@@ -0,0 +1,159 @@
+package distributed
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/planxnx/ethereum-wallet-generator/seeds"
+)
+
+// newTestCoordinator returns a coordinator handing out n seeds one per unit.
+func newTestCoordinator(n int, leaseTimeout time.Duration, token string) *Coordinator {
+	ch := make(chan seeds.Seed, n)
+	for i := 1; i <= n; i++ {
+		ch <- seeds.Seed{Line: i, Phrase: "seed"}
+	}
+	close(ch)
+	return NewCoordinator(CoordinatorConfig{Seeds: ch, TotalSeeds: n, UnitSize: 1, LeaseTimeout: leaseTimeout, Token: token})
+}
+
+func TestCoordinatorLeaseExpiry(t *testing.T) {
+	c := newTestCoordinator(2, 10*time.Millisecond, "")
+	first, second := c.Lease(), c.Lease()
+	if first.Unit == nil || second.Unit == nil || first.Unit.ID == second.Unit.ID {
+		t.Fatalf("leased %+v and %+v", first, second)
+	}
+	if lease := c.Lease(); lease.Unit != nil || lease.Done {
+		t.Fatalf("leased %+v while every unit is out", lease)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	// both expired, the first is leased again and the second waits for retry
+	again := c.Lease()
+	if again.Unit == nil || again.Unit.ID != first.Unit.ID {
+		t.Fatalf("expired unit not leased again: %+v", again)
+	}
+	if st := c.Status(); st.LeasedUnits != 1 || st.Done {
+		t.Errorf("status %+v", st)
+	}
+}
+
+func TestCoordinatorComplete(t *testing.T) {
+	var matches []Match
+	c := newTestCoordinator(2, 10*time.Millisecond, "")
+	c.config.OnMatch = func(m Match) { matches = append(matches, m) }
+	first, second := c.Lease().Unit, c.Lease().Unit
+	time.Sleep(20 * time.Millisecond)
+	c.Lease()
+
+	// the first is leased again, the second is waiting for retry
+	st := c.Complete(UnitResult{ID: second.ID, Processed: 1, Matches: []Match{{Line: 2}}})
+	if st.CompletedUnits != 1 || st.Processed != 1 || st.Matches != 1 || st.Done {
+		t.Errorf("completing the unit in retry: %+v", st)
+	}
+	if lease := c.Lease(); lease.Unit != nil {
+		t.Errorf("completed unit leased again: %+v", lease)
+	}
+	st = c.Complete(UnitResult{ID: first.ID, Processed: 1, Failed: 1})
+	if st.CompletedUnits != 2 || st.Processed != 2 || st.Failed != 1 || !st.Done {
+		t.Errorf("completing the leased unit: %+v", st)
+	}
+	select {
+	case <-c.Done():
+	default:
+		t.Error("Done not closed once every unit is completed")
+	}
+
+	// a duplicate report, eg. of the worker whose lease expired, and an unknown unit are ignored
+	st = c.Complete(UnitResult{ID: first.ID, Processed: 1, Matches: []Match{{Line: 1}}})
+	if st.CompletedUnits != 2 || st.Processed != 2 || st.Matches != 1 {
+		t.Errorf("duplicate report counted: %+v", st)
+	}
+	if st := c.Complete(UnitResult{ID: 42, Processed: 1}); st.CompletedUnits != 2 {
+		t.Errorf("unknown unit counted: %+v", st)
+	}
+	if len(matches) != 1 || matches[0].Line != 2 {
+		t.Errorf("OnMatch called with %+v", matches)
+	}
+	if lease := c.Lease(); !lease.Done {
+		t.Errorf("lease after the run: %+v", lease)
+	}
+}
+
+func TestCoordinatorAuth(t *testing.T) {
+	srv := httptest.NewServer(newTestCoordinator(1, time.Minute, "secret").Handler())
+	defer srv.Close()
+
+	for auth, want := range map[string]int{
+		"":              http.StatusUnauthorized,
+		"Bearer":        http.StatusUnauthorized,
+		"Bearer secre":  http.StatusUnauthorized,
+		"Bearer secret": http.StatusOK,
+		"secret":        http.StatusUnauthorized,
+	} {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+StatusPath, nil)
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		resp, err := srv.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Errorf("Authorization %q: %s, want %d", auth, resp.Status, want)
+		}
+	}
+
+	w := NewWorker(WorkerConfig{CoordinatorURL: srv.URL, Token: "wrong", Client: srv.Client()})
+	if err := w.Run(t.Context()); err == nil {
+		t.Error("worker with a wrong token ran")
+	}
+
+	// a coordinator without token doesn't serve anyone
+	open := httptest.NewServer(newTestCoordinator(1, time.Minute, "").Handler())
+	defer open.Close()
+	req, _ := http.NewRequest(http.MethodPost, open.URL+LeasePath, nil)
+	req.Header.Set("Authorization", "Bearer ")
+	resp, err := open.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("lease without coordinator token: %s", resp.Status)
+	}
+}
+
+func TestCoordinatorLeaseBlocked(t *testing.T) {
+	ch := make(chan seeds.Seed)
+	c := NewCoordinator(CoordinatorConfig{Seeds: ch, UnitSize: 1, LeaseTimeout: time.Minute})
+	leased := make(chan Lease)
+	go func() { leased <- c.Lease() }()
+
+	// the lease waits on the seed stream, the results and status are still served
+	status := make(chan Status)
+	go func() {
+		c.Complete(UnitResult{ID: 42})
+		status <- c.Status()
+	}()
+	select {
+	case st := <-status:
+		if st.DispatchedSeeds != 0 || st.Done {
+			t.Errorf("status %+v", st)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("status blocked by the lease reading the seed stream")
+	}
+
+	ch <- seeds.Seed{Line: 1, Phrase: "seed"}
+	if lease := <-leased; lease.Unit == nil || len(lease.Unit.Seeds) != 1 {
+		t.Errorf("leased %+v", lease)
+	}
+	close(ch)
+	if lease := c.Lease(); lease.Unit != nil || lease.Done {
+		t.Errorf("leased %+v with a unit out", lease)
+	}
+}
@@ -0,0 +1,102 @@
+// Package distributed implements a coordinator that hands out seed work units over HTTP,
+// and the workers that lease, process and report them back.
+package distributed
+
+import (
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/pkg/errors"
+
+	"github.com/planxnx/ethereum-wallet-generator/coins"
+	"github.com/planxnx/ethereum-wallet-generator/filter"
+	"github.com/planxnx/ethereum-wallet-generator/seeds"
+	"github.com/planxnx/ethereum-wallet-generator/wallets"
+)
+
+const (
+	// LeasePath is the endpoint used by workers to lease a work unit.
+	LeasePath = "/v1/units/lease"
+	// CompletePath is the endpoint used by workers to report a finished work unit.
+	CompletePath = "/v1/units/complete"
+	// StatusPath is the endpoint returning the coordinator progress.
+	StatusPath = "/v1/status"
+)
+
+// Job is the derivation settings shared by every work unit of a run.
+type Job struct {
+	Depth  int           `json:"depth"`
+	Filter filter.Config `json:"filter"`
+	// Coin and AddressType name the chain whose addresses are derived, see coins.Lookup,
+	// and BasePath their derivation path, the ones of Ethereum if empty.
+	Coin        string `json:"coin,omitempty"`
+	AddressType string `json:"address_type,omitempty"`
+	BasePath    string `json:"base_path,omitempty"`
+}
+
+// NewJob returns the job deriving depth addresses of coin, along its default derivation
+// path, from every seed and keeping those passing filters.
+func NewJob(depth int, filters filter.Config, coin coins.Coin) Job {
+	return Job{Depth: depth, Filter: filters, Coin: coin.Name(), AddressType: coin.AddressType(), BasePath: coin.BasePath().String()}
+}
+
+// derivation returns the coin and base derivation path of the job. It fails if the coin is
+// not registered in this process.
+func (j Job) derivation() (coins.Coin, accounts.DerivationPath, error) {
+	coin := coins.ETH
+	if j.Coin != "" {
+		var err error
+		if coin, err = coins.Lookup(j.Coin, j.AddressType); err != nil {
+			return nil, nil, errors.WithStack(err)
+		}
+	}
+	if j.BasePath == "" {
+		return coin, coin.BasePath(), nil
+	}
+	path, err := accounts.ParseDerivationPath(j.BasePath)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "invalid base path %q", j.BasePath)
+	}
+	return coin, path, nil
+}
+
+// Unit is a batch of seeds leased to a single worker.
+type Unit struct {
+	ID    int          `json:"id"`
+	Job   Job          `json:"job"`
+	Seeds []seeds.Seed `json:"seeds"`
+}
+
+// Lease is the coordinator response to a lease request.
+// Unit is nil when no work is currently available, Done is true when the whole run is finished.
+type Lease struct {
+	Unit *Unit `json:"unit,omitempty"`
+	Done bool  `json:"done"`
+}
+
+// Match is a wallet that passed the job filters.
+type Match struct {
+	Line   int             `json:"line"`
+	Index  int             `json:"index"`
+	Phrase string          `json:"phrase"`
+	Label  string          `json:"label,omitempty"`
+	Wallet *wallets.Wallet `json:"wallet"`
+}
+
+// UnitResult is reported by a worker once it has processed a unit.
+type UnitResult struct {
+	ID        int     `json:"id"`
+	Processed int     `json:"processed"`
+	Failed    int     `json:"failed"`
+	Matches   []Match `json:"matches"`
+}
+
+// Status is the coordinator progress.
+type Status struct {
+	TotalSeeds      int   `json:"total_seeds"`
+	DispatchedSeeds int   `json:"dispatched_seeds"`
+	LeasedUnits     int   `json:"leased_units"`
+	CompletedUnits  int   `json:"completed_units"`
+	Processed       int64 `json:"processed"`
+	Failed          int64 `json:"failed"`
+	Matches         int64 `json:"matches"`
+	Done            bool  `json:"done"`
+}
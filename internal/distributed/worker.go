@@ -0,0 +1,166 @@
+package distributed
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/planxnx/ethereum-wallet-generator/filter"
+	"github.com/planxnx/ethereum-wallet-generator/pipeline"
+	"github.com/planxnx/ethereum-wallet-generator/seeds"
+)
+
+// DefaultPollInterval is the default delay between lease attempts when no work is available.
+const DefaultPollInterval = 5 * time.Second
+
+// WorkerConfig configures a Worker.
+type WorkerConfig struct {
+	CoordinatorURL string
+	Token          string
+	PollInterval   time.Duration
+	Client         *http.Client
+	// Concurrency is the number of derivation workers used per unit.
+	Concurrency int
+	// CPUPercent limits the CPU usage of unit processing, 0 or 100 disables throttling.
+	CPUPercent int
+}
+
+// Worker leases work units from a coordinator, processes them and reports the results.
+type Worker struct {
+	config WorkerConfig
+}
+
+// NewWorker returns a new worker.
+func NewWorker(cfg WorkerConfig) *Worker {
+	cfg.CoordinatorURL = strings.TrimRight(cfg.CoordinatorURL, "/")
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = DefaultPollInterval
+	}
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{Timeout: time.Minute}
+	}
+	return &Worker{config: cfg}
+}
+
+// Run processes units until the coordinator reports the run is done or ctx is canceled.
+func (w *Worker) Run(ctx context.Context) error {
+	for {
+		var lease Lease
+		if err := w.call(ctx, LeasePath, nil, &lease); err != nil {
+			return errors.WithStack(err)
+		}
+
+		if lease.Done {
+			return nil
+		}
+
+		if lease.Unit == nil {
+			select {
+			case <-ctx.Done():
+				return errors.WithStack(ctx.Err())
+			case <-time.After(w.config.PollInterval):
+			}
+			continue
+		}
+
+		res, err := Process(ctx, lease.Unit, w.config.Concurrency, w.config.CPUPercent)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		var status Status
+		if err := w.call(ctx, CompletePath, res, &status); err != nil {
+			return errors.WithStack(err)
+		}
+		slog.Info("Unit done", "unit", res.ID, "processed", res.Processed, "failed", res.Failed, "matches", len(res.Matches))
+
+		if status.Done {
+			return nil
+		}
+	}
+}
+
+// Process derives and filters every seed of the unit. It fails if the coin or the validators
+// of the unit filters are not registered in this worker.
+func Process(ctx context.Context, unit *Unit, concurrency, cpuPercent int) (UnitResult, error) {
+	res := UnitResult{ID: unit.ID, Matches: make([]Match, 0)}
+	validator, err := filter.NewValidators(unit.Job.Filter.Validators)
+	if err != nil {
+		return res, errors.WithStack(err)
+	}
+	coin, basePath, err := unit.Job.derivation()
+	if err != nil {
+		return res, err
+	}
+
+	in := make(chan seeds.Seed, len(unit.Seeds))
+	for _, seed := range unit.Seeds {
+		in <- seed
+	}
+	close(in)
+
+	pipeline.New(pipeline.Config{
+		Workers:          concurrency,
+		Depth:            unit.Job.Depth,
+		BasePath:         basePath,
+		Coin:             coin,
+		CPUPercent:       cpuPercent,
+		AddressValidator: filter.NewAddressValidator(unit.Job.Filter),
+		Validator:        validator,
+		OnMatch: func(m pipeline.Match) {
+			res.Matches = append(res.Matches, Match{Line: m.Line, Index: m.Index, Phrase: m.Phrase, Label: m.Label, Wallet: m.Wallet})
+		},
+		OnFailure: func(f pipeline.Failure) {
+			if f.Index < 0 {
+				res.Failed += unit.Job.Depth
+				return
+			}
+			res.Failed++
+		},
+		OnProgress: func(processed int) {
+			res.Processed += processed
+		},
+	}).Run(ctx, in)
+	return res, nil
+}
+
+func (w *Worker) call(ctx context.Context, path string, body, out any) error {
+	var reqBody io.Reader = http.NoBody
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		reqBody = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.config.CoordinatorURL+path, reqBody)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if w.config.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+w.config.Token)
+	}
+
+	resp, err := w.config.Client.Do(req)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return errors.Errorf("coordinator returned %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	if out != nil {
+		return errors.WithStack(json.NewDecoder(resp.Body).Decode(out))
+	}
+	return nil
+}
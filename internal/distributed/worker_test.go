@@ -0,0 +1,50 @@
+package distributed
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/planxnx/ethereum-wallet-generator/coins"
+	"github.com/planxnx/ethereum-wallet-generator/filter"
+	"github.com/planxnx/ethereum-wallet-generator/seeds"
+)
+
+const testPhrase = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+func TestProcessJobCoin(t *testing.T) {
+	btc, err := coins.Lookup("btc", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCases := map[string]struct {
+		job           Job
+		address, path string
+	}{
+		"default":   {job: Job{Depth: 1}, address: "0x9858effd232b4033e47d90003d41ec34ecaeda94", path: "m/44'/60'/0'/0/0"},
+		"base path": {job: Job{Depth: 1, Coin: "eth", BasePath: "m/44'/60'/1'/0"}, path: "m/44'/60'/1'/0/0"},
+		"coin":      {job: NewJob(1, filter.Config{}, btc), address: "bc1qcr8te4kr609gcawutmrza0j4xv80jy8z306fyu", path: "m/84'/0'/0'/0/0"},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			res, err := Process(context.Background(), &Unit{ID: 1, Job: tc.job, Seeds: []seeds.Seed{{Line: 1, Phrase: testPhrase}}}, 1, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(res.Matches) != 1 {
+				t.Fatalf("%d matches, failed %d", len(res.Matches), res.Failed)
+			}
+			w := res.Matches[0].Wallet
+			if tc.address != "" && strings.ToLower(w.Address) != tc.address {
+				t.Errorf("address %s, want %s", w.Address, tc.address)
+			}
+			if w.HDPath != tc.path {
+				t.Errorf("path %s, want %s", w.HDPath, tc.path)
+			}
+		})
+	}
+
+	if _, err := Process(context.Background(), &Unit{Job: Job{Depth: 1, Coin: "unknown"}}, 1, 0); err == nil {
+		t.Error("unit of an unknown coin processed")
+	}
+}
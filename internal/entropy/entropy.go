@@ -0,0 +1,186 @@
+// Package entropy provides the random sources of the generated wallets, crypto/rand or a
+// hardware RNG device, optionally mixed with user supplied entropy, and guards them with
+// the NIST SP 800-90B health tests: a source failing them stops the run rather than
+// producing weak keys.
+package entropy
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"io"
+	mathrand "math/rand/v2"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Sources lists the accepted source specs, device takes the path of the RNG device.
+var Sources = []string{"crypto", "device:/dev/hwrng"}
+
+// StartupSamples is the number of bytes checked by the startup health test, the 1024
+// samples SP 800-90B asks for.
+const StartupSamples = 1024
+
+// The health test cutoffs of SP 800-90B 4.4 for a false positive rate of 2^-20, assuming
+// a conservative min-entropy of 1 bit per byte: they only catch broken sources, stuck
+// on a value or heavily biased, never a healthy one.
+const (
+	repetitionCutoff = 21
+	proportionWindow = 512
+	proportionCutoff = 410
+)
+
+// ErrSourceFailed is returned once the source failed a health test or a read, every later
+// read fails.
+var ErrSourceFailed = errors.New("entropy source failed")
+
+// Reader is a goroutine safe random source whose raw bytes pass the health tests before
+// they are used.
+type Reader struct {
+	mu     sync.Mutex
+	source io.Reader
+	closer io.Closer
+	health health
+	// mixKey is the hash of the user entropy mixed in, nil without.
+	mixKey  []byte
+	counter uint64
+	buf     []byte
+}
+
+// Open opens the source of spec, crypto for crypto/rand or device:PATH, mixing the bytes
+// of mix in if it isn't empty, and runs the startup health test.
+func Open(spec string, mix []byte) (*Reader, error) {
+	r := &Reader{}
+	switch {
+	case spec == "" || spec == "crypto":
+		r.source = rand.Reader
+	case strings.HasPrefix(spec, "device:"):
+		f, err := os.Open(strings.TrimPrefix(spec, "device:"))
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		r.source, r.closer = f, f
+	default:
+		return nil, errors.Errorf("unknown entropy source %q, must be one of %v", spec, Sources)
+	}
+	return r.start(mix)
+}
+
+// Deterministic opens a source replaying the ChaCha8 stream keyed with the sha256 of seed,
+// the same bytes on every run with the same seed and mix. Anyone knowing the seed has the
+// keys generated from it: it is only meant for tests and benchmarks.
+func Deterministic(seed string, mix []byte) (*Reader, error) {
+	r := &Reader{source: mathrand.NewChaCha8(sha256.Sum256([]byte(seed)))}
+	return r.start(mix)
+}
+
+// start keys the mixing of mix and runs the startup health test.
+func (r *Reader) start(mix []byte) (*Reader, error) {
+	if len(mix) > 0 {
+		sum := sha256.Sum256(mix)
+		r.mixKey = sum[:]
+	}
+	startup := make([]byte, StartupSamples)
+	if err := r.readRaw(startup); err != nil {
+		r.Close()
+		return nil, errors.WithMessage(err, "startup health test")
+	}
+	clear(startup)
+	return r, nil
+}
+
+// Read fills p with random bytes.
+func (r *Reader) Read(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.mixKey == nil {
+		if err := r.readRaw(p); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+	// every 32 byte block is sha256(mix key || counter || raw block), as unpredictable
+	// as the source or the mixed in entropy, whichever is stronger
+	if r.buf == nil {
+		r.buf = make([]byte, sha256.Size)
+	}
+	for n := 0; n < len(p); {
+		if err := r.readRaw(r.buf); err != nil {
+			return n, err
+		}
+		h := sha256.New()
+		h.Write(r.mixKey)
+		h.Write(binary.BigEndian.AppendUint64(nil, r.counter))
+		h.Write(r.buf)
+		r.counter++
+		block := h.Sum(nil)
+		n += copy(p[n:], block)
+		clear(block)
+	}
+	clear(r.buf)
+	return len(p), nil
+}
+
+// readRaw fills p from the source, checking every byte.
+func (r *Reader) readRaw(p []byte) error {
+	if r.health.failed {
+		return ErrSourceFailed
+	}
+	if _, err := io.ReadFull(r.source, p); err != nil {
+		r.health.failed = true
+		return errors.Wrapf(ErrSourceFailed, "read: %v", err)
+	}
+	for _, b := range p {
+		if !r.health.sample(b) {
+			clear(p)
+			return errors.Wrap(ErrSourceFailed, "health test")
+		}
+	}
+	return nil
+}
+
+// Close closes the device of the source.
+func (r *Reader) Close() error {
+	if r.closer == nil {
+		return nil
+	}
+	return errors.WithStack(r.closer.Close())
+}
+
+// health runs the repetition count and adaptive proportion tests over the raw samples.
+type health struct {
+	// failed is set once a test or a read failed.
+	failed bool
+	last   byte
+	// repeats is the length of the run of last, 0 before the first sample.
+	repeats int
+	// first and count are the first sample of the adaptive proportion window and its
+	// occurrences, seen samples of it.
+	first       byte
+	count, seen int
+}
+
+// sample checks b, reporting false once a test failed.
+func (h *health) sample(b byte) bool {
+	if h.repeats > 0 && b == h.last {
+		h.repeats++
+	} else {
+		h.last, h.repeats = b, 1
+	}
+	if h.seen == 0 {
+		h.first, h.count = b, 0
+	}
+	if b == h.first {
+		h.count++
+	}
+	if h.seen++; h.seen == proportionWindow {
+		h.seen = 0
+	}
+	if h.repeats >= repetitionCutoff || h.count >= proportionCutoff {
+		h.failed = true
+	}
+	return !h.failed
+}
@@ -0,0 +1,110 @@
+package entropy
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHealth(t *testing.T) {
+	testCases := map[string]struct {
+		source []byte
+		ok     bool
+	}{
+		"random": {source: bytes.Repeat([]byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}, 200), ok: true},
+		"stuck":  {source: make([]byte, StartupSamples), ok: false},
+		// never repeats 21 times in a row, but 7 in 8 samples are zeros
+		"biased": {source: bytes.Repeat([]byte{0, 0, 0, 0, 0, 0, 0, 1}, 200), ok: false},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "rng")
+			if err := os.WriteFile(path, tc.source, 0o600); err != nil {
+				t.Fatal(err)
+			}
+			r, err := Open("device:"+path, nil)
+			if (err == nil) != tc.ok {
+				t.Fatalf("startup health test error %v, want ok %v", err, tc.ok)
+			}
+			if err != nil && !errors.Is(err, ErrSourceFailed) {
+				t.Errorf("error %v isn't ErrSourceFailed", err)
+			}
+			if r != nil {
+				r.Close()
+			}
+		})
+	}
+}
+
+func TestDeterministic(t *testing.T) {
+	read := func(seed string) []byte {
+		r, err := Deterministic(seed, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p := make([]byte, 64)
+		if _, err := r.Read(p); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	if a, b := read("42"), read("42"); !bytes.Equal(a, b) {
+		t.Errorf("same seed read %x and %x", a, b)
+	}
+	if a, b := read("42"), read("43"); bytes.Equal(a, b) {
+		t.Errorf("different seeds read the same %x", a)
+	}
+}
+
+func TestMix(t *testing.T) {
+	r, err := Open("crypto", []byte("dice rolls 3 1 4 1 5 9 2 6"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, b := make([]byte, 40), make([]byte, 40)
+	if _, err := r.Read(a); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Read(b); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(a, b) || bytes.Equal(a, make([]byte, 40)) {
+		t.Errorf("mixed reads %x and %x", a, b)
+	}
+}
+
+func TestExtract(t *testing.T) {
+	dice, err := ParseThrows(InputDice, "1 2, 6-5 3 3 4 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 1<2 gives 0, 6>5 gives 1, 3=3 is dropped, 4>1 gives 1
+	x, err := Extract(InputDice, append(dice, 2, 1, 1, 2, 1, 2, 1, 2, 1, 2), 8, DebiasVonNeumann)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x.Entropy[0] != 0b01110000 || x.Used != 18 || x.Discarded != 2 || x.Counts[0] != 7 {
+		t.Errorf("von Neumann extraction %+v", x)
+	}
+
+	// 4 gives 11, 6 gives 1, 1 gives 00, 5 gives 0, 2 gives 01
+	if x, err := Extract(InputDice, []int{4, 6, 1, 5, 2, 3}, 8, DebiasNone); err != nil || x.Entropy[0] != 0b11100001 || x.Used != 5 {
+		t.Errorf("fair dice extraction %+v, %v", x, err)
+	}
+
+	coins, err := ParseThrows(InputCoin, "HTHT TTHH htth")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Extract(InputCoin, coins, 8, DebiasVonNeumann); err == nil {
+		t.Error("too few throws must fail")
+	}
+	if x, err := Extract(InputCoin, coins[:8], 8, DebiasNone); err != nil || x.Entropy[0] != 0b10100011 {
+		t.Errorf("fair coin extraction %+v, %v", x, err)
+	}
+	if _, err := ParseThrows(InputDice, "1237"); err == nil {
+		t.Error("a 7 isn't a die throw")
+	}
+}
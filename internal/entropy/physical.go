@@ -0,0 +1,144 @@
+package entropy
+
+import (
+	"math"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// The physical inputs, throws of a six-sided die or of a coin.
+const (
+	InputDice = "dice"
+	InputCoin = "coin"
+)
+
+// Inputs lists the physical inputs.
+var Inputs = []string{InputDice, InputCoin}
+
+// The debiasing methods of physical inputs.
+const (
+	// DebiasVonNeumann only assumes the throws are independent, the die or coin may be
+	// loaded: of each pair of throws, a first one lower than the second gives a 0, higher a 1,
+	// and equal ones are dropped. A die yields 5/12 bit per throw, a coin 1/4.
+	DebiasVonNeumann = "vonneumann"
+	// DebiasNone assumes a fair die or coin: a die throw of 1 to 4 gives the 2 bits of 0 to 3,
+	// 5 and 6 the bit 0 and 1, 5/3 bits per throw, and a coin throw gives its bit.
+	DebiasNone = "none"
+)
+
+// DebiasMethods lists the debiasing methods.
+var DebiasMethods = []string{DebiasVonNeumann, DebiasNone}
+
+// Extraction is the entropy extracted from physical throws.
+type Extraction struct {
+	Entropy []byte
+	// Throws is the number of throws given, Used the ones read until the entropy was
+	// complete, the others being left over, and Discarded the used ones the debiasing
+	// dropped.
+	Throws, Used, Discarded int
+	// Counts is the number of throws of each value, the faces 1 to 6 of a die at index 0 to
+	// 5, tails (0) and heads (1) of a coin.
+	Counts []int
+}
+
+// ParseThrows parses the throws of input written down in s: the digits 1 to 6 of dice, or
+// H/T or 1/0 for the heads and tails of a coin. Spaces, commas and dashes between them are
+// ignored. Dice throws are returned as 1 to 6, coin ones as 1 for heads and 0 for tails.
+func ParseThrows(input, s string) ([]int, error) {
+	if input != InputDice && input != InputCoin {
+		return nil, errors.Errorf("unknown input %q, must be one of %v", input, Inputs)
+	}
+	var throws []int
+	for i, c := range s {
+		switch {
+		case strings.ContainsRune(" \t\r\n,-", c):
+			continue
+		case input == InputDice && c >= '1' && c <= '6':
+			throws = append(throws, int(c-'0'))
+		case input == InputCoin && strings.ContainsRune("Hh1", c):
+			throws = append(throws, 1)
+		case input == InputCoin && strings.ContainsRune("Tt0", c):
+			throws = append(throws, 0)
+		default:
+			return nil, errors.Errorf("invalid %s throw %q at offset %d", input, c, i)
+		}
+	}
+	return throws, nil
+}
+
+// Extract extracts bits bits of entropy from the throws of input with the debias method,
+// failing with the number of throws still missing when there aren't enough.
+func Extract(input string, throws []int, bits int, debias string) (*Extraction, error) {
+	yield, ok := map[string]float64{
+		InputDice + DebiasVonNeumann: 5.0 / 12,
+		InputDice + DebiasNone:       5.0 / 3,
+		InputCoin + DebiasVonNeumann: 1.0 / 4,
+		InputCoin + DebiasNone:       1,
+	}[input+debias]
+	if !ok {
+		return nil, errors.Errorf("unknown input %q or debias method %q, must be one of %v and %v", input, debias, Inputs, DebiasMethods)
+	}
+	if bits <= 0 || bits%8 != 0 {
+		return nil, errors.Errorf("invalid entropy size of %d bits", bits)
+	}
+
+	low, high := 0, 1
+	if input == InputDice {
+		low, high = 1, 6
+	}
+	for i, t := range throws {
+		if t < low || t > high {
+			return nil, errors.Errorf("invalid %s throw %d at %d", input, t, i+1)
+		}
+	}
+
+	x := &Extraction{Entropy: make([]byte, bits/8), Throws: len(throws), Counts: make([]int, high-low+1)}
+	n := 0
+	put := func(bit int) {
+		if n < bits {
+			x.Entropy[n/8] |= byte(bit) << (7 - n%8)
+			n++
+		}
+	}
+	for x.Used < len(throws) && n < bits {
+		switch {
+		case debias == DebiasVonNeumann:
+			if x.Used+1 >= len(throws) {
+				// a lone throw can't be paired
+				x.Used = len(throws)
+				x.Discarded++
+				continue
+			}
+			a, b := throws[x.Used], throws[x.Used+1]
+			x.Used += 2
+			switch {
+			case a < b:
+				put(0)
+			case a > b:
+				put(1)
+			default:
+				x.Discarded += 2
+			}
+		case input == InputDice && throws[x.Used] <= 4:
+			put((throws[x.Used] - 1) >> 1)
+			put((throws[x.Used] - 1) & 1)
+			x.Used++
+		case input == InputDice:
+			put(throws[x.Used] - 5)
+			x.Used++
+		default:
+			put(throws[x.Used])
+			x.Used++
+		}
+	}
+	for _, t := range throws[:x.Used] {
+		x.Counts[t-low]++
+	}
+	if n < bits {
+		clear(x.Entropy)
+		missing := int(math.Ceil(float64(bits-n) / yield))
+		return nil, errors.Errorf("not enough %s throws, %d bits of %d extracted: about %d more throws are needed", input, n, bits, missing)
+	}
+	return x, nil
+}
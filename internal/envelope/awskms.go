@@ -0,0 +1,98 @@
+package envelope
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/pkg/errors"
+)
+
+// awsKMS wraps data keys with an AWS KMS key, calling the KMS API signed with the
+// AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN credentials. The region is
+// the one of a key ARN, the region query parameter, or else AWS_REGION.
+type awsKMS struct {
+	client   *http.Client
+	endpoint string
+	region   string
+	keyID    string
+	creds    aws.Credentials
+	signer   *v4.Signer
+}
+
+func newAWSKMS(client *http.Client, spec string) (*awsKMS, error) {
+	keyID, query, _ := strings.Cut(spec, "?")
+	params, err := url.ParseQuery(query)
+	if err != nil {
+		return nil, errors.Errorf("invalid awskms parameters %q", query)
+	}
+	k := &awsKMS{
+		client: client,
+		keyID:  keyID,
+		region: params.Get("region"),
+		creds: aws.Credentials{
+			AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		},
+		signer: v4.NewSigner(),
+	}
+	// arn:aws:kms:<region>:<account>:key/<id>
+	if parts := strings.Split(keyID, ":"); len(parts) > 3 && parts[0] == "arn" && k.region == "" {
+		k.region = parts[3]
+	}
+	for _, env := range []string{"AWS_REGION", "AWS_DEFAULT_REGION"} {
+		if k.region == "" {
+			k.region = os.Getenv(env)
+		}
+	}
+	if k.region == "" {
+		return nil, errors.New("awskms needs a region, from the key ARN, ?region= or AWS_REGION")
+	}
+	if k.creds.AccessKeyID == "" || k.creds.SecretAccessKey == "" {
+		return nil, errors.New("awskms needs AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+	}
+	k.endpoint = params.Get("endpoint")
+	if k.endpoint == "" {
+		k.endpoint = "https://kms." + k.region + ".amazonaws.com"
+	}
+	return k, nil
+}
+
+func (k *awsKMS) Wrap(ctx context.Context, key []byte) ([]byte, error) {
+	var res struct {
+		CiphertextBlob []byte
+	}
+	err := k.call(ctx, "Encrypt", map[string]any{"KeyId": k.keyID, "Plaintext": key}, &res)
+	return res.CiphertextBlob, err
+}
+
+func (k *awsKMS) Unwrap(ctx context.Context, wrapped []byte) ([]byte, error) {
+	var res struct {
+		Plaintext []byte
+	}
+	err := k.call(ctx, "Decrypt", map[string]any{"KeyId": k.keyID, "CiphertextBlob": wrapped}, &res)
+	return res.Plaintext, err
+}
+
+// call sends a request of the KMS JSON protocol, whose binary fields are base64 as encoding/json does.
+func (k *awsKMS) call(ctx context.Context, action string, body, res any) error {
+	req, data, err := newJSONRequest(ctx, k.endpoint+"/", body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "TrentService."+action)
+	sum := sha256.Sum256(data)
+	if err := k.signer.SignHTTP(ctx, k.creds, req, hex.EncodeToString(sum[:]), "kms", k.region, time.Now()); err != nil {
+		return errors.WithStack(err)
+	}
+	return doJSON(k.client, "awskms", req, res)
+}
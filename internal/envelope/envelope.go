@@ -0,0 +1,208 @@
+// Package envelope encrypts secrets with a data key wrapped by a key management service,
+// AWS KMS, GCP Cloud KMS or the HashiCorp Vault transit engine, so that the stored private
+// keys can only be read back by whoever may use the service key.
+package envelope
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Prefix starts every sealed value, followed by the wrapped data key and the ciphertext.
+const Prefix = "ewgenv1."
+
+// Pattern matches the sealed values inside a text.
+var Pattern = regexp.MustCompile(`ewgenv1\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`)
+
+// Backends lists the supported key management services.
+var Backends = []string{"awskms", "gcpkms", "vault"}
+
+// KeyWrapper encrypts and decrypts data keys with a key held by a key management service.
+type KeyWrapper interface {
+	Wrap(ctx context.Context, key []byte) ([]byte, error)
+	Unwrap(ctx context.Context, wrapped []byte) ([]byte, error)
+}
+
+// Parse returns the key wrapper of a backend spec:
+//
+//	awskms://<key id, alias/name or ARN>[?region=us-east-1]
+//	gcpkms://projects/<project>/locations/<location>/keyRings/<ring>/cryptoKeys/<key>
+//	vault://[<mount>/]<key>
+//
+// Credentials come from the environment of each service, see the backends.
+func Parse(spec string) (KeyWrapper, error) {
+	backend, rest, ok := strings.Cut(spec, "://")
+	if !ok || rest == "" {
+		return nil, errors.Errorf("invalid key spec %q, expected backend://key", spec)
+	}
+	client := &http.Client{Timeout: 30 * time.Second}
+	switch backend {
+	case "awskms":
+		return newAWSKMS(client, rest)
+	case "gcpkms":
+		return newGCPKMS(client, rest)
+	case "vault":
+		return newVault(client, rest)
+	default:
+		return nil, errors.Errorf("unknown key backend %q, must be one of %v", backend, Backends)
+	}
+}
+
+// Sealer encrypts values with AES-256-GCM under a single data key, generated and wrapped
+// once. Every sealed value carries the wrapped key, so it can be opened on its own.
+type Sealer struct {
+	aead    cipher.AEAD
+	wrapped string
+}
+
+// NewSealer generates a data key and wraps it with w.
+func NewSealer(ctx context.Context, w KeyWrapper) (*Sealer, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	wrapped, err := w.Wrap(ctx, key)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to wrap the data key")
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	return &Sealer{aead: aead, wrapped: base64.RawURLEncoding.EncodeToString(wrapped)}, nil
+}
+
+// Seal returns the sealed form of a value, an empty value stays empty.
+func (s *Sealer) Seal(plaintext string) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+	nonce := make([]byte, s.aead.NonceSize(), s.aead.NonceSize()+len(plaintext)+s.aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", errors.WithStack(err)
+	}
+	sealed := s.aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return Prefix + s.wrapped + "." + base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// Opener decrypts sealed values, unwrapping every distinct data key once.
+type Opener struct {
+	w KeyWrapper
+
+	mu   sync.Mutex
+	keys map[string]cipher.AEAD
+}
+
+// NewOpener returns an opener unwrapping the data keys with w.
+func NewOpener(w KeyWrapper) *Opener {
+	return &Opener{w: w, keys: make(map[string]cipher.AEAD)}
+}
+
+// Open returns the plaintext of a sealed value.
+func (o *Opener) Open(ctx context.Context, sealed string) (string, error) {
+	wrapped, data, ok := strings.Cut(strings.TrimPrefix(sealed, Prefix), ".")
+	if !ok || !strings.HasPrefix(sealed, Prefix) {
+		return "", errors.New("not a sealed value")
+	}
+	aead, err := o.key(ctx, wrapped)
+	if err != nil {
+		return "", err
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(data)
+	if err != nil || len(raw) < aead.NonceSize() {
+		return "", errors.New("malformed sealed value")
+	}
+	plaintext, err := aead.Open(nil, raw[:aead.NonceSize()], raw[aead.NonceSize():], nil)
+	if err != nil {
+		return "", errors.New("sealed value failed authentication")
+	}
+	return string(plaintext), nil
+}
+
+// OpenAll replaces every sealed value of text with its plaintext.
+func (o *Opener) OpenAll(ctx context.Context, text string) (string, error) {
+	var err error
+	out := Pattern.ReplaceAllStringFunc(text, func(sealed string) string {
+		if err != nil {
+			return sealed
+		}
+		var plaintext string
+		plaintext, err = o.Open(ctx, sealed)
+		return plaintext
+	})
+	return out, err
+}
+
+// key returns the cipher of a wrapped data key, unwrapping it on first use.
+func (o *Opener) key(ctx context.Context, wrapped string) (cipher.AEAD, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if aead, ok := o.keys[wrapped]; ok {
+		return aead, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(wrapped)
+	if err != nil {
+		return nil, errors.New("malformed wrapped data key")
+	}
+	key, err := o.w.Unwrap(ctx, raw)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to unwrap the data key")
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	o.keys[wrapped] = aead
+	return aead, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	aead, err := cipher.NewGCM(block)
+	return aead, errors.WithStack(err)
+}
+
+// doJSON sends req with body as JSON, decoding the response into res. The service name
+// prefixes the errors, which never carry the credentials.
+func doJSON(client *http.Client, service string, req *http.Request, res any) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return errors.Errorf("%s request failed: %v", service, errors.Unwrap(err))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return errors.Errorf("%s rejected the request with %s: %s", service, resp.Status, bytes.TrimSpace(msg))
+	}
+	return errors.WithStack(json.NewDecoder(resp.Body).Decode(res))
+}
+
+// newJSONRequest returns a POST request of body as JSON, along with the encoded body.
+func newJSONRequest(ctx context.Context, url string, body any) (*http.Request, []byte, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, nil, errors.WithStack(err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, errors.WithStack(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return req, data, nil
+}
@@ -0,0 +1,110 @@
+package envelope
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// fakeTransit is a Vault transit engine "encrypting" by prefixing the base64 plaintext.
+func fakeTransit(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "root" {
+			http.Error(w, `{"errors":["permission denied"]}`, http.StatusForbidden)
+			return
+		}
+		var req map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+		}
+		switch r.URL.Path {
+		case "/v1/transit/encrypt/ewg":
+			_ = json.NewEncoder(w).Encode(map[string]any{"data": map[string]string{"ciphertext": "vault:v1:" + req["plaintext"]}})
+		case "/v1/transit/decrypt/ewg":
+			_ = json.NewEncoder(w).Encode(map[string]any{"data": map[string]string{"plaintext": strings.TrimPrefix(req["ciphertext"], "vault:v1:")}})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func TestVaultRoundTrip(t *testing.T) {
+	srv := fakeTransit(t)
+	defer srv.Close()
+	t.Setenv("VAULT_ADDR", srv.URL)
+	t.Setenv("VAULT_TOKEN", "root")
+
+	w, err := Parse("vault://ewg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	sealer, err := NewSealer(ctx, w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := "1ab42cc412b618bdea3a599e3c9bae199ebf030895b039e9db1e30dafb12b727"
+	sealed, err := sealer.Seal(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !Pattern.MatchString(sealed) || strings.Contains(sealed, key) {
+		t.Fatalf("sealed value %q", sealed)
+	}
+	if empty, _ := sealer.Seal(""); empty != "" {
+		t.Errorf("sealed empty value = %q", empty)
+	}
+
+	opener := NewOpener(w)
+	line := "0x9858effd232b4033e47d90003d41ec34ecaeda94," + sealed + ",m/44'/60'/0'/0/0"
+	opened, err := opener.OpenAll(ctx, line)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := strings.Replace(line, sealed, key, 1); opened != want {
+		t.Errorf("OpenAll = %q, want %q", opened, want)
+	}
+
+	// a tampered ciphertext fails authentication
+	raw, _ := base64.RawURLEncoding.DecodeString(sealed[strings.LastIndex(sealed, ".")+1:])
+	raw[len(raw)-1] ^= 1
+	tampered := sealed[:strings.LastIndex(sealed, ".")+1] + base64.RawURLEncoding.EncodeToString(raw)
+	if _, err := opener.Open(ctx, tampered); err == nil {
+		t.Error("opened a tampered value")
+	}
+
+	t.Setenv("VAULT_TOKEN", "wrong")
+	w, err = Parse("vault://transit/ewg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewOpener(w).Open(ctx, sealed); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("open with a wrong token: %v", err)
+	}
+}
+
+func TestParse(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKID")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_REGION", "")
+	t.Setenv("AWS_DEFAULT_REGION", "")
+	k, err := Parse("awskms://arn:aws:kms:eu-west-1:111122223333:key/1234abcd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if region := k.(*awsKMS).region; region != "eu-west-1" {
+		t.Errorf("region = %q", region)
+	}
+	if _, err := Parse("awskms://alias/ewg"); err == nil {
+		t.Error("parsed an AWS key without a region")
+	}
+	for _, spec := range []string{"", "vault", "kms://key", "vault://"} {
+		if _, err := Parse(spec); err == nil {
+			t.Errorf("parsed %q", spec)
+		}
+	}
+}
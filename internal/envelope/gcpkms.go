@@ -0,0 +1,56 @@
+package envelope
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/planxnx/ethereum-wallet-generator/internal/gcpauth"
+)
+
+// gcpKMS wraps data keys with a Cloud KMS key. The access token is GOOGLE_OAUTH_ACCESS_TOKEN
+// (eg. from gcloud auth print-access-token), or else the one of the instance service
+// account from the metadata server.
+type gcpKMS struct {
+	client   *http.Client
+	endpoint string
+	name     string
+	token    *gcpauth.Token
+}
+
+func newGCPKMS(client *http.Client, name string) (*gcpKMS, error) {
+	return &gcpKMS{
+		client:   client,
+		endpoint: "https://cloudkms.googleapis.com/v1/",
+		name:     name,
+		token:    gcpauth.NewToken(client),
+	}, nil
+}
+
+func (g *gcpKMS) Wrap(ctx context.Context, key []byte) ([]byte, error) {
+	var res struct {
+		Ciphertext []byte `json:"ciphertext"`
+	}
+	err := g.call(ctx, "encrypt", map[string][]byte{"plaintext": key}, &res)
+	return res.Ciphertext, err
+}
+
+func (g *gcpKMS) Unwrap(ctx context.Context, wrapped []byte) ([]byte, error) {
+	var res struct {
+		Plaintext []byte `json:"plaintext"`
+	}
+	err := g.call(ctx, "decrypt", map[string][]byte{"ciphertext": wrapped}, &res)
+	return res.Plaintext, err
+}
+
+func (g *gcpKMS) call(ctx context.Context, op string, body, res any) error {
+	token, err := g.token.Get(ctx)
+	if err != nil {
+		return err
+	}
+	req, _, err := newJSONRequest(ctx, g.endpoint+g.name+":"+op, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return doJSON(g.client, "gcpkms", req, res)
+}
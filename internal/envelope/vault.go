@@ -0,0 +1,79 @@
+package envelope
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// vault wraps data keys with the transit secrets engine of HashiCorp Vault, reached at
+// VAULT_ADDR with VAULT_TOKEN, and VAULT_NAMESPACE if set.
+type vault struct {
+	client         *http.Client
+	addr, token    string
+	namespace      string
+	mount, keyName string
+}
+
+func newVault(client *http.Client, spec string) (*vault, error) {
+	mount, key, ok := strings.Cut(strings.Trim(spec, "/"), "/")
+	if !ok {
+		mount, key = "transit", mount
+	}
+	v := &vault{
+		client:    client,
+		addr:      strings.TrimRight(os.Getenv("VAULT_ADDR"), "/"),
+		token:     os.Getenv("VAULT_TOKEN"),
+		namespace: os.Getenv("VAULT_NAMESPACE"),
+		mount:     mount,
+		keyName:   key,
+	}
+	if v.addr == "" {
+		v.addr = "https://127.0.0.1:8200"
+	}
+	if v.token == "" {
+		return nil, errors.New("vault needs a VAULT_TOKEN")
+	}
+	return v, nil
+}
+
+func (v *vault) Wrap(ctx context.Context, key []byte) ([]byte, error) {
+	var res struct {
+		Data struct {
+			Ciphertext string `json:"ciphertext"`
+		} `json:"data"`
+	}
+	if err := v.call(ctx, "encrypt", map[string]string{"plaintext": base64.StdEncoding.EncodeToString(key)}, &res); err != nil {
+		return nil, err
+	}
+	return []byte(res.Data.Ciphertext), nil
+}
+
+func (v *vault) Unwrap(ctx context.Context, wrapped []byte) ([]byte, error) {
+	var res struct {
+		Data struct {
+			Plaintext string `json:"plaintext"`
+		} `json:"data"`
+	}
+	if err := v.call(ctx, "decrypt", map[string]string{"ciphertext": string(wrapped)}, &res); err != nil {
+		return nil, err
+	}
+	key, err := base64.StdEncoding.DecodeString(res.Data.Plaintext)
+	return key, errors.WithStack(err)
+}
+
+func (v *vault) call(ctx context.Context, op string, body, res any) error {
+	req, _, err := newJSONRequest(ctx, v.addr+"/v1/"+v.mount+"/"+op+"/"+v.keyName, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Vault-Token", v.token)
+	if v.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", v.namespace)
+	}
+	return doJSON(v.client, "vault", req, res)
+}
@@ -0,0 +1,67 @@
+// Package gcpauth returns the OAuth access token of the Google Cloud API calls: the
+// GOOGLE_OAUTH_ACCESS_TOKEN environment variable (eg. from gcloud auth print-access-token),
+// or else the token of the instance service account from the metadata server.
+package gcpauth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// metadataTokenURL returns the access token of the service account of a GCP instance.
+const metadataTokenURL = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+
+// Token is an access token refreshed from the metadata server before it expires.
+type Token struct {
+	client *http.Client
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+// NewToken returns the access token of the environment, fetched with client.
+func NewToken(client *http.Client) *Token {
+	return &Token{client: client, token: os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN")}
+}
+
+// Get returns the configured token, or a token of the metadata server.
+func (t *Token) Get(ctx context.Context) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.token != "" && (t.expires.IsZero() || time.Now().Before(t.expires)) {
+		return t.token, nil
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, metadataTokenURL, nil)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return "", errors.Errorf("GOOGLE_OAUTH_ACCESS_TOKEN is needed off GCP, the metadata server request failed: %v", errors.Unwrap(err))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return "", errors.Errorf("gcp metadata server rejected the request with %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	var res struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return "", errors.WithStack(err)
+	}
+	t.token = res.AccessToken
+	t.expires = time.Now().Add(time.Duration(res.ExpiresIn)*time.Second - time.Minute)
+	return t.token, nil
+}
@@ -0,0 +1,52 @@
+// Package keychain keeps secrets in the keychain of the OS, the macOS Keychain, the Windows
+// Credential Manager or the Secret Service of Linux desktops, so passphrases and API
+// tokens needn't be given in flags or environment variables.
+package keychain
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/zalando/go-keyring"
+)
+
+// Service is the keychain service the secrets are stored under.
+const Service = "ethereum-wallet-generator"
+
+// Prefix marks a flag value naming a keychain secret, eg. keychain:db-passphrase.
+const Prefix = "keychain:"
+
+// Set stores the secret name.
+func Set(name, secret string) error {
+	if name == "" {
+		return errors.New("empty secret name")
+	}
+	return errors.Wrap(keyring.Set(Service, name, secret), "keychain")
+}
+
+// Get returns the secret name.
+func Get(name string) (string, error) {
+	secret, err := keyring.Get(Service, name)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return "", errors.Errorf("no secret %q in the keychain, add it with the keychain set command", name)
+	}
+	return secret, errors.Wrap(err, "keychain")
+}
+
+// Delete removes the secret name.
+func Delete(name string) error {
+	err := keyring.Delete(Service, name)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return errors.Errorf("no secret %q in the keychain", name)
+	}
+	return errors.Wrap(err, "keychain")
+}
+
+// Resolve returns the secret a value names with Prefix, or the value itself.
+func Resolve(value string) (string, error) {
+	name, ok := strings.CutPrefix(value, Prefix)
+	if !ok {
+		return value, nil
+	}
+	return Get(name)
+}
@@ -0,0 +1,36 @@
+package keychain
+
+import (
+	"testing"
+
+	"github.com/zalando/go-keyring"
+)
+
+func TestResolve(t *testing.T) {
+	keyring.MockInit()
+	if err := Set("db-passphrase", "correct horse"); err != nil {
+		t.Fatal(err)
+	}
+	testCases := map[string]struct {
+		value, expected string
+		isErr           bool
+	}{
+		"plain":   {value: "battery staple", expected: "battery staple"},
+		"secret":  {value: "keychain:db-passphrase", expected: "correct horse"},
+		"missing": {value: "keychain:api-token", isErr: true},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			actual, err := Resolve(tc.value)
+			if (err != nil) != tc.isErr || actual != tc.expected {
+				t.Errorf("Resolve(%q) = %q, %v", tc.value, actual, err)
+			}
+		})
+	}
+	if err := Delete("db-passphrase"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Get("db-passphrase"); err == nil {
+		t.Error("deleted secret still found")
+	}
+}
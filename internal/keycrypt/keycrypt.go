@@ -0,0 +1,166 @@
+// Package keycrypt encrypts the private keys stored in a DB with AES-256-GCM, under a key
+// derived with Argon2id from a passphrase, so a stolen DB is no instant loss of its keys.
+package keycrypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/argon2"
+)
+
+// The Argon2id parameters of the RFC 9106 second recommended option, 64 MiB of memory and
+// three passes, derived once per run.
+const (
+	argonTime    = 3
+	argonMemory  = 64 * 1024
+	argonThreads = 4
+	keyLength    = 32
+	saltLength   = 16
+)
+
+// Sealed is an encrypted private key, its fields hex encoded as they are stored.
+type Sealed struct {
+	// Salt is the Argon2id salt of the key encrypting it, one per run.
+	Salt       string
+	Nonce      string
+	Ciphertext string
+}
+
+// Encrypter encrypts private keys under the key of a passphrase and a random salt.
+type Encrypter struct {
+	aead cipher.AEAD
+	salt string
+}
+
+// NewEncrypter derives the key of passphrase with a new random salt.
+func NewEncrypter(passphrase string) (*Encrypter, error) {
+	if passphrase == "" {
+		return nil, errors.New("empty passphrase")
+	}
+	salt := make([]byte, saltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	aead, err := newAEAD(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	return &Encrypter{aead: aead, salt: hex.EncodeToString(salt)}, nil
+}
+
+// Encrypt returns the sealed form of a private key.
+func (e *Encrypter) Encrypt(plaintext string) (Sealed, error) {
+	nonce := make([]byte, e.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return Sealed{}, errors.WithStack(err)
+	}
+	ciphertext := e.aead.Seal(nil, nonce, []byte(plaintext), []byte(e.salt))
+	return Sealed{Salt: e.salt, Nonce: hex.EncodeToString(nonce), Ciphertext: hex.EncodeToString(ciphertext)}, nil
+}
+
+// Decrypter decrypts sealed private keys, deriving the key of every distinct salt once.
+type Decrypter struct {
+	passphrase string
+
+	mu   sync.Mutex
+	keys map[string]cipher.AEAD
+}
+
+// NewDecrypter returns a decrypter of the keys encrypted with passphrase.
+func NewDecrypter(passphrase string) *Decrypter {
+	return &Decrypter{passphrase: passphrase, keys: make(map[string]cipher.AEAD)}
+}
+
+// Decrypt returns the private key of s, failing if the passphrase isn't the one it was
+// encrypted with.
+func (d *Decrypter) Decrypt(s Sealed) (string, error) {
+	aead, err := d.key(s.Salt)
+	if err != nil {
+		return "", err
+	}
+	nonce, err := hex.DecodeString(s.Nonce)
+	if err != nil || len(nonce) != aead.NonceSize() {
+		return "", errors.New("malformed nonce")
+	}
+	ciphertext, err := hex.DecodeString(s.Ciphertext)
+	if err != nil {
+		return "", errors.New("malformed ciphertext")
+	}
+	plaintext, err := aead.Open(nil, nonce, ciphertext, []byte(s.Salt))
+	if err != nil {
+		return "", errors.New("wrong passphrase or tampered private key")
+	}
+	return string(plaintext), nil
+}
+
+// key returns the cipher of a salt, deriving it on first use.
+func (d *Decrypter) key(salt string) (cipher.AEAD, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if aead, ok := d.keys[salt]; ok {
+		return aead, nil
+	}
+	raw, err := hex.DecodeString(salt)
+	if err != nil || len(raw) == 0 {
+		return nil, errors.New("malformed salt")
+	}
+	aead, err := newAEAD(d.passphrase, raw)
+	if err != nil {
+		return nil, err
+	}
+	d.keys[salt] = aead
+	return aead, nil
+}
+
+// Hash returns the salted hash of a private key, as salt:sha256(salt || key) in hex, proving
+// the key of a wallet without storing it.
+func Hash(privateKey string) (string, error) {
+	salt := make([]byte, saltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", errors.WithStack(err)
+	}
+	return hex.EncodeToString(salt) + ":" + hex.EncodeToString(saltedHash(salt, privateKey)), nil
+}
+
+// VerifyHash reports whether privateKey is the key of a hash returned by Hash.
+func VerifyHash(hash, privateKey string) bool {
+	salt, sum, ok := strings.Cut(hash, ":")
+	if !ok {
+		return false
+	}
+	rawSalt, err := hex.DecodeString(salt)
+	if err != nil {
+		return false
+	}
+	rawSum, err := hex.DecodeString(sum)
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare(rawSum, saltedHash(rawSalt, privateKey)) == 1
+}
+
+func saltedHash(salt []byte, privateKey string) []byte {
+	h := sha256.New()
+	h.Write(salt)
+	h.Write([]byte(privateKey))
+	return h.Sum(nil)
+}
+
+func newAEAD(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key := argon2.IDKey([]byte(passphrase), salt, argonTime, argonMemory, argonThreads, keyLength)
+	defer clear(key)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	aead, err := cipher.NewGCM(block)
+	return aead, errors.WithStack(err)
+}
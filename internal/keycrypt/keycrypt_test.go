@@ -0,0 +1,42 @@
+package keycrypt
+
+import "testing"
+
+func TestRoundTrip(t *testing.T) {
+	e, err := NewEncrypter("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	const key = "1ab42cc412b618bdea3a599e3c9bae199ebf030895b039e9db1e30dafb12b727"
+	sealed, err := e.Encrypt(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := NewDecrypter("correct horse").Decrypt(sealed); err != nil || got != key {
+		t.Errorf("decrypted %q, %v", got, err)
+	}
+	if _, err := NewDecrypter("wrong horse").Decrypt(sealed); err == nil {
+		t.Error("decrypted with the wrong passphrase")
+	}
+	other, _ := NewEncrypter("correct horse")
+	if s, _ := other.Encrypt(key); s.Salt == sealed.Salt || s.Ciphertext == sealed.Ciphertext {
+		t.Error("reused a salt across encrypters")
+	}
+}
+
+func TestHash(t *testing.T) {
+	const key = "1ab42cc412b618bdea3a599e3c9bae199ebf030895b039e9db1e30dafb12b727"
+	hash, err := Hash(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !VerifyHash(hash, key) {
+		t.Error("the key doesn't verify its hash")
+	}
+	if VerifyHash(hash, key[1:]) || VerifyHash("", key) {
+		t.Error("verified the wrong key")
+	}
+	if other, _ := Hash(key); other == hash {
+		t.Error("reused a salt across hashes")
+	}
+}
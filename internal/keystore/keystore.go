@@ -0,0 +1,180 @@
+// Package keystore writes wallets as geth-compatible encrypted keystore V3 files, and reads them back.
+package keystore
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/planxnx/ethereum-wallet-generator/wallets"
+)
+
+const (
+	// StandardScryptN is the scrypt N parameter used by geth by default.
+	StandardScryptN = keystore.StandardScryptN
+	// StandardScryptP is the scrypt P parameter used by geth by default.
+	StandardScryptP = keystore.StandardScryptP
+)
+
+// Writer encrypts wallets into keystore files inside a directory, which geth and clef can use
+// as their --keystore: there is a single file per address, written atomically.
+type Writer struct {
+	dir      string
+	password string
+	scryptN  int
+	scryptP  int
+
+	mu sync.Mutex
+	// files are the keystore files of the directory by address.
+	files map[common.Address]string
+}
+
+// NewWriter creates dir if needed and returns a writer encrypting keys with the given password and scrypt parameters.
+// The keystore files already in dir are indexed, so their addresses aren't written again.
+func NewWriter(dir, password string, scryptN, scryptP int) (*Writer, error) {
+	if password == "" {
+		return nil, errors.New("keystore password is required")
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	files, err := indexFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	return &Writer{
+		dir:      dir,
+		password: password,
+		scryptN:  scryptN,
+		scryptP:  scryptP,
+		files:    files,
+	}, nil
+}
+
+// Write encrypts the wallet private key and writes it as a UTC--<time>--<address> file, returning its path.
+// A wallet whose address already has a file isn't written again, the existing path is returned.
+func (w *Writer) Write(wallet *wallets.Wallet) (string, error) {
+	privateKey, err := crypto.HexToECDSA(wallet.PrivateKey)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	address := crypto.PubkeyToAddress(privateKey.PublicKey)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if path, ok := w.files[address]; ok {
+		return path, nil
+	}
+
+	id, err := uuid.NewRandom()
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	key := &keystore.Key{
+		Id:         id,
+		Address:    address,
+		PrivateKey: privateKey,
+	}
+
+	data, err := keystore.EncryptKey(key, w.password, w.scryptN, w.scryptP)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	path := filepath.Join(w.dir, FileName(key))
+	if err := writeFileAtomic(path, data); err != nil {
+		return "", err
+	}
+	w.files[address] = path
+	return path, nil
+}
+
+// writeFileAtomic writes data to a hidden temporary file renamed to path, so a node watching
+// the directory never reads a partial file.
+func writeFileAtomic(path string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return errors.WithStack(err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(os.Rename(f.Name(), path))
+}
+
+// indexFiles returns the keystore files of dir by address, read from the address field of
+// each file like geth does. Files that aren't keystore files are ignored.
+func indexFiles(dir string) (map[common.Address]string, error) {
+	paths, err := Files(dir)
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[common.Address]string, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		var key struct {
+			Address string `json:"address"`
+		}
+		if json.Unmarshal(data, &key) != nil || !common.IsHexAddress(key.Address) {
+			continue
+		}
+		files[common.HexToAddress(key.Address)] = path
+	}
+	return files, nil
+}
+
+// FileName returns the geth file name of a key, eg. UTC--2016-03-22T12-57-55.920751759Z--7ef5a6135f1fd6a02593eedc869c6d41d934aef8.
+func FileName(key *keystore.Key) string {
+	ts := time.Now().UTC()
+	return fmt.Sprintf("UTC--%s--%x", ts.Format("2006-01-02T15-04-05.000000000Z"), key.Address[:])
+}
+
+// Files returns the sorted paths of the files of dir that may be keystore files, skipping
+// subdirectories and hidden files.
+func Files(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	var paths []string
+	for _, e := range entries {
+		if !e.Type().IsRegular() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		paths = append(paths, filepath.Join(dir, e.Name()))
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// Read decrypts the keystore file at path with password and returns its wallet.
+func Read(path, password string) (*wallets.Wallet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	key, err := keystore.DecryptKey(data, password)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to decrypt %s", filepath.Base(path))
+	}
+	return wallets.NewFromPrivatekey(key.PrivateKey)
+}
@@ -0,0 +1,118 @@
+package keystore
+
+import (
+	"encoding/hex"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/planxnx/ethereum-wallet-generator/wallets"
+)
+
+func TestWriterWrite(t *testing.T) {
+	wallet, err := wallets.NewWallet()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := NewWriter(t.TempDir(), "secret", keystore.LightScryptN, keystore.LightScryptP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path, err := w.Write(wallet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := keystore.DecryptKey(data, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, wallet.Address, strings.ToLower(key.Address.Hex()))
+	assert.Equal(t, wallet.PrivateKey, hex.EncodeToString(crypto.FromECDSA(key.PrivateKey)))
+}
+
+func TestRead(t *testing.T) {
+	wallet, err := wallets.NewWallet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	w, err := NewWriter(dir, "secret", keystore.LightScryptN, keystore.LightScryptP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(wallet); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(dir+"/.DS_Store", nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	paths, err := Files(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, paths, 1)
+	read, err := Read(paths[0], "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, wallet.Address, read.Address)
+	assert.Equal(t, wallet.PrivateKey, read.PrivateKey)
+
+	_, err = Read(paths[0], "wrong")
+	assert.Error(t, err)
+}
+
+func TestWriterGethKeystore(t *testing.T) {
+	wallet, err := wallets.NewWallet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	w, err := NewWriter(dir, "secret", keystore.LightScryptN, keystore.LightScryptP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, err := w.Write(wallet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := w.Write(wallet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, first, again)
+
+	// a new writer indexes the files already written
+	w, err = NewWriter(dir, "secret", keystore.LightScryptN, keystore.LightScryptP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err = w.Write(wallet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, first, again)
+	info, err := os.Stat(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	ks := keystore.NewKeyStore(dir, keystore.LightScryptN, keystore.LightScryptP)
+	accounts := ks.Accounts()
+	if assert.Len(t, accounts, 1) {
+		assert.Equal(t, wallet.Address, strings.ToLower(accounts[0].Address.Hex()))
+		assert.NoError(t, ks.Unlock(accounts[0], "secret"))
+	}
+}
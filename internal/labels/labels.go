@@ -0,0 +1,97 @@
+// Package labels names the known owners of addresses, exchanges, token and protocol contracts
+// and the like, so that a match on one of them is recognized at once.
+package labels
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Bundled is the name loading the dataset built into the binary, a small list of Ethereum
+// mainnet exchanges, tokens and contracts.
+const Bundled = "bundled"
+
+//go:embed known.csv
+var bundled string
+
+// Label is what an address is known for.
+type Label struct {
+	Name string
+	// Category is the kind of owner, eg. exchange, token or contract, it may be empty.
+	Category string
+}
+
+// String returns the name followed by the category in parentheses, if any.
+func (l Label) String() string {
+	if l.Category == "" {
+		return l.Name
+	}
+	return l.Name + " (" + l.Category + ")"
+}
+
+// Set maps addresses to their labels.
+type Set map[string]Label
+
+// Load adds the labels of the dataset file at name, or of the bundled one for Bundled.
+func (s Set) Load(name string) error {
+	if name == Bundled {
+		return s.Read(strings.NewReader(bundled))
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer f.Close()
+	return errors.Wrapf(s.Read(f), "labels %s", name)
+}
+
+// Read adds the labels of a CSV dataset of address, label and optional category lines. Lines
+// starting with # and a first address,label header line are skipped, a later line of an address
+// replaces its label.
+func (s Set) Read(r io.Reader) error {
+	cr := csv.NewReader(r)
+	cr.Comment = '#'
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	for first := true; ; first = false {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if len(record) < 2 || len(record) > 3 {
+			line, _ := cr.FieldPos(0)
+			return errors.Errorf("line %d: expected address,label[,category], got %d fields", line, len(record))
+		}
+		if first && strings.EqualFold(record[0], "address") {
+			continue
+		}
+		l := Label{Name: strings.TrimSpace(record[1])}
+		if len(record) == 3 {
+			l.Category = strings.TrimSpace(record[2])
+		}
+		s[key(record[0])] = l
+	}
+}
+
+// Lookup returns the label of address, hex addresses matching in any case.
+func (s Set) Lookup(address string) (Label, bool) {
+	l, ok := s[key(address)]
+	return l, ok
+}
+
+// key returns the lowercase form of 0x-prefixed hex addresses, and the other addresses as is.
+func key(address string) string {
+	address = strings.TrimSpace(address)
+	if strings.HasPrefix(address, "0x") || strings.HasPrefix(address, "0X") {
+		return strings.ToLower(address)
+	}
+	return address
+}
@@ -0,0 +1,46 @@
+package labels
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestRead(t *testing.T) {
+	s := Set{}
+	data := "address,label,category\n# comment\n0xABCDEF0000000000000000000000000000000001,Some Exchange 3,exchange\nbc1qexample,Cold wallet\n0xabcdef0000000000000000000000000000000001,Some Exchange 4,exchange\n"
+	if err := s.Read(strings.NewReader(data)); err != nil {
+		t.Fatal(err)
+	}
+	if len(s) != 2 {
+		t.Errorf("read %d labels, want 2", len(s))
+	}
+	if l, ok := s.Lookup("0xabcdef0000000000000000000000000000000001"); !ok || l.String() != "Some Exchange 4 (exchange)" {
+		t.Errorf("Lookup = %q, %v, want the later line of the address", l, ok)
+	}
+	if l, ok := s.Lookup("bc1qexample"); !ok || l.String() != "Cold wallet" {
+		t.Errorf("Lookup(bc1qexample) = %q, %v", l, ok)
+	}
+	if _, ok := s.Lookup("BC1QEXAMPLE"); ok {
+		t.Error("non hex addresses must match exactly")
+	}
+	if err := s.Read(strings.NewReader("0x01\n")); err == nil {
+		t.Error("a line without label must fail")
+	}
+}
+
+func TestBundled(t *testing.T) {
+	s := Set{}
+	if err := s.Load(Bundled); err != nil {
+		t.Fatal(err)
+	}
+	for address, l := range s {
+		if !common.IsHexAddress(address) || address != strings.ToLower(address) || l.Name == "" || l.Category == "" {
+			t.Errorf("bundled line %s,%s,%s", address, l.Name, l.Category)
+		}
+	}
+	if l, ok := s.Lookup("0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2"); !ok || l.Category != "token" {
+		t.Errorf("WETH label = %+v, %v", l, ok)
+	}
+}
@@ -0,0 +1,220 @@
+// Package manifest is the offline form of the distributed mode: a job split into work unit
+// manifests processed on machines without a connection to a coordinator, and the result files
+// they write, both signed with a secret shared by the team so that a modified or foreign file
+// is refused.
+package manifest
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/planxnx/ethereum-wallet-generator/internal/distributed"
+	"github.com/planxnx/ethereum-wallet-generator/seeds"
+	"github.com/planxnx/ethereum-wallet-generator/wallets"
+)
+
+// Version is the format version of the manifests and result files.
+const Version = 1
+
+// The kinds of work units.
+const (
+	// KindSeeds is a range of lines of seed files, copied along with the manifest.
+	KindSeeds = "seeds"
+	// KindKeyspace is a number of random private keys, drawn by the machine processing it.
+	KindKeyspace = "keyspace"
+)
+
+// ErrSignature is returned for a file whose signature doesn't match its content, modified
+// or signed with another key.
+var ErrSignature = errors.New("invalid signature, the file was modified or signed with another key")
+
+// File is a seed file of a manifest, the seed lines of the ranges are only processed from the
+// file of the same hash.
+type File struct {
+	Name   string `json:"name"`
+	SHA256 string `json:"sha256"`
+}
+
+// Manifest is a work unit of a plan.
+type Manifest struct {
+	Version int `json:"version"`
+	// Plan identifies the plan, Unit the manifest among its Units, numbered from 1.
+	Plan  string `json:"plan"`
+	Unit  int    `json:"unit"`
+	Units int    `json:"units"`
+	Kind  string `json:"kind"`
+
+	// Files, Format and Range are the seed lines of a KindSeeds unit, the lines of the range
+	// numbered across the files in turn.
+	Files  []File       `json:"files,omitempty"`
+	Format seeds.Format `json:"format,omitempty"`
+	Range  seeds.Range  `json:"range"`
+
+	// Keys is the number of random keys of a KindKeyspace unit, searched with Backend.
+	Keys    int    `json:"keys,omitempty"`
+	Backend string `json:"backend,omitempty"`
+
+	// Job is the depth and filters of the unit, the depth only applying to seeds.
+	Job       distributed.Job `json:"job"`
+	Created   time.Time       `json:"created"`
+	Signature string          `json:"signature"`
+}
+
+// Match is a matched wallet of a unit, with the seed file and line of a KindSeeds one.
+type Match struct {
+	File   string          `json:"file,omitempty"`
+	Line   int             `json:"line,omitempty"`
+	Index  int             `json:"index"`
+	Phrase string          `json:"phrase,omitempty"`
+	Label  string          `json:"label,omitempty"`
+	Wallet *wallets.Wallet `json:"wallet"`
+}
+
+// Result is the result file of a processed unit.
+type Result struct {
+	Version   int       `json:"version"`
+	Plan      string    `json:"plan"`
+	Unit      int       `json:"unit"`
+	Units     int       `json:"units"`
+	Processed int       `json:"processed"`
+	Failed    int       `json:"failed"`
+	Matches   []Match   `json:"matches"`
+	Started   time.Time `json:"started"`
+	Finished  time.Time `json:"finished"`
+	Signature string    `json:"signature"`
+}
+
+// NewPlanID returns a random plan identifier.
+func NewPlanID() (string, error) {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return "", errors.WithStack(err)
+	}
+	return hex.EncodeToString(id), nil
+}
+
+// HashFile returns the hex SHA-256 of the file at name.
+func HashFile(name string) (string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", errors.WithStack(err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// WriteManifest signs m with key and writes it to the file at name.
+func WriteManifest(name string, m Manifest, key string) error {
+	m.Signature = ""
+	sig, err := sign(m, key)
+	if err != nil {
+		return err
+	}
+	m.Signature = sig
+	return write(name, m)
+}
+
+// ReadManifest reads the manifest file at name and checks its signature with key.
+func ReadManifest(name, key string) (Manifest, error) {
+	var m Manifest
+	if err := read(name, &m); err != nil {
+		return m, err
+	}
+	sig := m.Signature
+	m.Signature = ""
+	if err := verify(m, sig, key); err != nil {
+		return m, errors.Wrapf(err, "manifest %s", name)
+	}
+	m.Signature = sig
+	if m.Version != Version {
+		return m, errors.Errorf("manifest %s has version %d, this build reads version %d", name, m.Version, Version)
+	}
+	return m, nil
+}
+
+// WriteResult signs r with key and writes it to the file at name.
+func WriteResult(name string, r Result, key string) error {
+	r.Signature = ""
+	sig, err := sign(r, key)
+	if err != nil {
+		return err
+	}
+	r.Signature = sig
+	return write(name, r)
+}
+
+// ReadResult reads the result file at name and checks its signature with key.
+func ReadResult(name, key string) (Result, error) {
+	var r Result
+	if err := read(name, &r); err != nil {
+		return r, err
+	}
+	sig := r.Signature
+	r.Signature = ""
+	if err := verify(r, sig, key); err != nil {
+		return r, errors.Wrapf(err, "result %s", name)
+	}
+	r.Signature = sig
+	if r.Version != Version {
+		return r, errors.Errorf("result %s has version %d, this build reads version %d", name, r.Version, Version)
+	}
+	return r, nil
+}
+
+// sign returns the hex HMAC-SHA256 of the JSON encoding of v.
+func sign(v any, key string) (string, error) {
+	if key == "" {
+		return "", errors.New("a signing key is required")
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+func verify(v any, sig, key string) error {
+	expected, err := sign(v, key)
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal([]byte(sig), []byte(expected)) {
+		return ErrSignature
+	}
+	return nil
+}
+
+// write writes v to the file at name, replaced only once it is complete.
+func write(name string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	tmp := name + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o600); err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(os.Rename(tmp, name))
+}
+
+func read(name string, v any) error {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.Wrapf(json.Unmarshal(data, v), "invalid file %s", name)
+}
@@ -0,0 +1,44 @@
+package manifest
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/planxnx/ethereum-wallet-generator/seeds"
+)
+
+func TestSignature(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "unit-0001.json")
+	m := Manifest{Version: Version, Plan: "p", Unit: 1, Units: 2, Kind: KindSeeds, Range: seeds.Range{Skip: 10, Take: 5}}
+	if err := WriteManifest(name, m, "secret"); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadManifest(name, "secret")
+	if err != nil || got.Range != m.Range || got.Unit != 1 {
+		t.Fatalf("read %+v, %v", got, err)
+	}
+	if _, err := ReadManifest(name, "other"); !errors.Is(err, ErrSignature) {
+		t.Errorf("read with another key: %v", err)
+	}
+
+	data, _ := os.ReadFile(name)
+	data = []byte(string(data[:len(data)-2]) + ",\"keys\":1}\n")
+	_ = os.WriteFile(name, data, 0o600)
+	if _, err := ReadManifest(name, "secret"); !errors.Is(err, ErrSignature) {
+		t.Errorf("read a modified manifest: %v", err)
+	}
+
+	result := filepath.Join(dir, "unit-0001.result.json")
+	if err := WriteResult(result, Result{Version: Version, Plan: "p", Unit: 1, Processed: 5}, "secret"); err != nil {
+		t.Fatal(err)
+	}
+	if r, err := ReadResult(result, "secret"); err != nil || r.Processed != 5 {
+		t.Errorf("read %+v, %v", r, err)
+	}
+	if _, err := ReadResult(result, "other"); !errors.Is(err, ErrSignature) {
+		t.Errorf("read with another key: %v", err)
+	}
+}
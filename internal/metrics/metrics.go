@@ -0,0 +1,191 @@
+// Package metrics exposes the counters of a run on a Prometheus endpoint. Every method
+// is a no-op on a nil *Metrics, so call sites don't check whether metrics are enabled.
+package metrics
+
+import (
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// DefaultPath is the path metrics are served on when the listen address has none.
+const DefaultPath = "/metrics"
+
+// Metrics are the collectors of a run.
+type Metrics struct {
+	registry  *prometheus.Registry
+	seeds     prometheus.Counter
+	addresses prometheus.Counter
+	matches   prometheus.Counter
+	errors    *prometheus.CounterVec
+	dbWrites  *prometheus.HistogramVec
+	busy      prometheus.Counter
+	workers   prometheus.Gauge
+
+	// the counts read back by Snapshot, which the collectors don't expose cheaply
+	started                               time.Time
+	nSeeds, nAddresses, nMatches, nErrors atomic.Int64
+	total                                 atomic.Int64
+}
+
+// Snapshot are the counts of a run so far.
+type Snapshot struct {
+	Started   time.Time
+	Uptime    time.Duration
+	Seeds     int64
+	Addresses int64
+	Matches   int64
+	Errors    int64
+	// Total is the number of addresses of the run, 0 when unknown.
+	Total int64
+}
+
+// New returns the metrics of a command, labeled with its name.
+func New(command string) *Metrics {
+	labels := prometheus.Labels{"command": command}
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		started:  time.Now(),
+		seeds: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "ewg_seeds_processed_total", Help: "Seeds whose addresses were derived.", ConstLabels: labels,
+		}),
+		addresses: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "ewg_addresses_derived_total", Help: "Addresses derived and checked against the filters.", ConstLabels: labels,
+		}),
+		matches: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "ewg_matches_total", Help: "Wallets that passed the filters.", ConstLabels: labels,
+		}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "ewg_errors_total", Help: "Errors by kind: derivation, seeds, db, output, keystore, qr, paper.", ConstLabels: labels,
+		}, []string{"kind"}),
+		dbWrites: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name: "ewg_db_write_seconds", Help: "Latency of DB inserts and commits.", ConstLabels: labels,
+			Buckets: prometheus.ExponentialBuckets(0.0001, 4, 10),
+		}, []string{"op"}),
+		busy: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "ewg_worker_busy_seconds_total", Help: "Time spent deriving by all workers, divide its rate by ewg_workers for the utilization.", ConstLabels: labels,
+		}),
+		workers: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "ewg_workers", Help: "Number of derivation workers.", ConstLabels: labels,
+		}),
+	}
+	m.registry.MustRegister(m.seeds, m.addresses, m.matches, m.errors, m.dbWrites, m.busy, m.workers,
+		collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+	return m
+}
+
+// Gatherer returns the registry of the metrics, eg. to export them elsewhere.
+func (m *Metrics) Gatherer() prometheus.Gatherer {
+	return m.registry
+}
+
+// Serve serves the metrics on listen, host:port optionally followed by the path, eg.
+// ":9090/metrics". It returns once the listener is open.
+func (m *Metrics) Serve(listen string) (*http.Server, error) {
+	addr, path := listen, DefaultPath
+	if i := strings.IndexByte(listen, '/'); i >= 0 {
+		addr, path = listen[:i], listen[i:]
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	mux := http.NewServeMux()
+	mux.Handle(path, promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{}))
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() { _ = srv.Serve(ln) }()
+	return srv, nil
+}
+
+// Seed counts a seed and its derived addresses.
+func (m *Metrics) Seed(addresses int) {
+	if m == nil {
+		return
+	}
+	m.seeds.Inc()
+	m.addresses.Add(float64(addresses))
+	m.nSeeds.Add(1)
+	m.nAddresses.Add(int64(addresses))
+}
+
+// Addresses counts derived addresses that are not part of a seed.
+func (m *Metrics) Addresses(n int) {
+	if m == nil {
+		return
+	}
+	m.addresses.Add(float64(n))
+	m.nAddresses.Add(int64(n))
+}
+
+// Match counts a match.
+func (m *Metrics) Match() {
+	if m == nil {
+		return
+	}
+	m.matches.Inc()
+	m.nMatches.Add(1)
+}
+
+// Error counts an error of the given kind.
+func (m *Metrics) Error(kind string) {
+	if m == nil {
+		return
+	}
+	m.errors.WithLabelValues(kind).Inc()
+	m.nErrors.Add(1)
+}
+
+// DBWrite records the latency of a DB operation, insert or commit.
+func (m *Metrics) DBWrite(op string, d time.Duration) {
+	if m == nil {
+		return
+	}
+	m.dbWrites.WithLabelValues(op).Observe(d.Seconds())
+}
+
+// Busy adds time a worker spent deriving.
+func (m *Metrics) Busy(d time.Duration) {
+	if m == nil {
+		return
+	}
+	m.busy.Add(d.Seconds())
+}
+
+// SetWorkers sets the number of derivation workers.
+func (m *Metrics) SetWorkers(n int) {
+	if m == nil {
+		return
+	}
+	m.workers.Set(float64(n))
+}
+
+// SetTotal sets the number of addresses of the run, the progress of Snapshot.
+func (m *Metrics) SetTotal(n int) {
+	if m == nil {
+		return
+	}
+	m.total.Store(int64(n))
+}
+
+// Snapshot returns the counts of the run so far.
+func (m *Metrics) Snapshot() Snapshot {
+	if m == nil {
+		return Snapshot{}
+	}
+	return Snapshot{
+		Started:   m.started,
+		Uptime:    time.Since(m.started),
+		Seeds:     m.nSeeds.Load(),
+		Addresses: m.nAddresses.Load(),
+		Matches:   m.nMatches.Load(),
+		Errors:    m.nErrors.Load(),
+		Total:     m.total.Load(),
+	}
+}
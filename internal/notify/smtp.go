@@ -0,0 +1,185 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	secrets "github.com/planxnx/ethereum-wallet-generator/internal/redact"
+)
+
+// email sends the first matches of a run and a summary when it ends, rather than a message per
+// batch, for the long unattended runs someone checks the mailbox of.
+type email struct {
+	// addr is the host:port of the server, implicit TLS with smtps, STARTTLS when offered with smtp.
+	addr     string
+	implicit bool
+	auth     smtp.Auth
+	from     string
+	to       []string
+	redactor *secrets.Redactor
+
+	// deliver sends a message, replaced by the tests.
+	deliver func(ctx context.Context, msg []byte) error
+
+	mu         sync.Mutex
+	firstMatch bool
+}
+
+// parseEmail returns the email notifier of smtp://[user:pass@]host[:port]?from=FROM&to=TO[,TO...],
+// or smtps:// for a server expecting TLS from the start. The port defaults to 587, 465 with smtps.
+func parseEmail(spec string) (*email, error) {
+	u, err := url.Parse(spec)
+	if err != nil || u.Hostname() == "" {
+		return nil, errors.Errorf("invalid notifier %q, expected %s://[user:pass@]host[:port]?from=FROM&to=TO", redact(spec), strings.SplitN(spec, "://", 2)[0])
+	}
+	e := &email{implicit: u.Scheme == "smtps", redactor: secrets.New()}
+	port := u.Port()
+	if port == "" {
+		port = "587"
+		if e.implicit {
+			port = "465"
+		}
+	}
+	e.addr = net.JoinHostPort(u.Hostname(), port)
+
+	query := u.Query()
+	from, err := mail.ParseAddress(query.Get("from"))
+	if err != nil {
+		return nil, errors.Errorf("invalid from address %q of notifier %s", query.Get("from"), redact(spec))
+	}
+	e.from = from.Address
+	for _, to := range strings.Split(query.Get("to"), ",") {
+		addr, err := mail.ParseAddress(strings.TrimSpace(to))
+		if err != nil {
+			return nil, errors.Errorf("invalid to address %q of notifier %s", to, redact(spec))
+		}
+		e.to = append(e.to, addr.Address)
+	}
+	if u.User != nil {
+		pass, _ := u.User.Password()
+		e.auth = smtp.PlainAuth("", u.User.Username(), pass, u.Hostname())
+	}
+	e.deliver = e.send
+	return e, nil
+}
+
+func (e *email) Notify(ctx context.Context, text string) error {
+	return e.mail(ctx, "Notification", text)
+}
+
+// NotifyEvent mails the first batch of matches and the final summary, the digests are left to
+// the chat notifiers.
+func (e *email) NotifyEvent(ctx context.Context, ev Event) error {
+	switch ev.Kind {
+	case EventMatches:
+		e.mu.Lock()
+		first := !e.firstMatch
+		e.firstMatch = true
+		e.mu.Unlock()
+		if !first {
+			return nil
+		}
+		return e.mail(ctx, "First match found", ev.Text+"\n\nThe run goes on, a summary is sent when it ends.")
+	case EventFinal:
+		var b strings.Builder
+		if ev.Text != "" {
+			b.WriteString(ev.Text + "\n\n")
+		}
+		if ev.Total == 0 {
+			b.WriteString("No matches.")
+		} else {
+			fmt.Fprintf(&b, "%d matches:\n", ev.Total)
+			for _, m := range ev.Matches {
+				b.WriteString(m + "\n")
+			}
+			if more := ev.Total - len(ev.Matches); more > 0 {
+				fmt.Fprintf(&b, "... and %d more\n", more)
+			}
+		}
+		return e.mail(ctx, fmt.Sprintf("Run finished, %d matches", ev.Total), b.String())
+	default:
+		return nil
+	}
+}
+
+// mail sends a plain text message, its body scrubbed of anything looking like a secret.
+func (e *email) mail(ctx context.Context, subject, body string) error {
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", e.from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(e.to, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", "ethereum-wallet-generator: "+subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(e.redactor.String(body), "\n", "\r\n"))
+	return e.deliver(ctx, msg.Bytes())
+}
+
+// send delivers a message to the server, dialed within the deadline of ctx.
+func (e *email) send(ctx context.Context, msg []byte) error {
+	host, _, _ := net.SplitHostPort(e.addr)
+	tlsConfig := &tls.Config{ServerName: host}
+	var (
+		conn net.Conn
+		err  error
+	)
+	if e.implicit {
+		conn, err = (&tls.Dialer{Config: tlsConfig}).DialContext(ctx, "tcp", e.addr)
+	} else {
+		conn, err = (&net.Dialer{}).DialContext(ctx, "tcp", e.addr)
+	}
+	if err != nil {
+		return errors.Errorf("smtp: %v", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return errors.Errorf("smtp: %v", err)
+	}
+	defer c.Close()
+	if !e.implicit {
+		if ok, _ := c.Extension("STARTTLS"); ok {
+			if err := c.StartTLS(tlsConfig); err != nil {
+				return errors.Errorf("smtp: %v", err)
+			}
+		}
+	}
+	if e.auth != nil {
+		if err := c.Auth(e.auth); err != nil {
+			return errors.Errorf("smtp: %v", err)
+		}
+	}
+	if err := c.Mail(e.from); err != nil {
+		return errors.Errorf("smtp: %v", err)
+	}
+	for _, to := range e.to {
+		if err := c.Rcpt(to); err != nil {
+			return errors.Errorf("smtp: %s: %v", to, err)
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return errors.Errorf("smtp: %v", err)
+	}
+	if _, err := w.Write(msg); err != nil {
+		return errors.Errorf("smtp: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		return errors.Errorf("smtp: %v", err)
+	}
+	return errors.WithStack(c.Quit())
+}
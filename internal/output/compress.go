@@ -0,0 +1,62 @@
+package output
+
+import (
+	"compress/gzip"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pkg/errors"
+)
+
+// Compression algorithms of result files.
+const (
+	CompressNone = "none"
+	CompressGzip = "gzip"
+	CompressZstd = "zstd"
+)
+
+// Compressions lists the supported compression algorithms.
+var Compressions = []string{CompressNone, CompressGzip, CompressZstd}
+
+// CompressExt returns the file extension of a compression algorithm.
+func CompressExt(algo string) string {
+	switch algo {
+	case CompressGzip:
+		return ".gz"
+	case CompressZstd:
+		return ".zst"
+	default:
+		return ""
+	}
+}
+
+// ValidateCompression returns an error if algo is not one of Compressions.
+func ValidateCompression(algo string) error {
+	for _, c := range Compressions {
+		if c == algo {
+			return nil
+		}
+	}
+	return errors.Errorf("unknown compression %q, must be one of %v", algo, Compressions)
+}
+
+// Compress wraps w into a compressed stream. Closing the returned writer
+// finalizes the stream and then closes closer, if not nil.
+func Compress(w io.Writer, closer io.Closer, algo string) (io.WriteCloser, error) {
+	var (
+		zw  io.WriteCloser
+		err error
+	)
+	switch algo {
+	case CompressGzip:
+		zw = gzip.NewWriter(w)
+	case CompressZstd:
+		zw, err = zstd.NewWriter(w)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+	default:
+		return nil, ValidateCompression(algo)
+	}
+	return &chainedWriter{WriteCloser: zw, closer: closer}, nil
+}
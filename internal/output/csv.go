@@ -0,0 +1,152 @@
+package output
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+
+	"github.com/planxnx/ethereum-wallet-generator/wallets"
+)
+
+// Column names of a record.
+const (
+	ColumnAddress         = "address"
+	ColumnChecksumAddress = "checksum_address"
+	ColumnPrivateKey      = "private_key"
+	ColumnPublicKey       = "public_key"
+	ColumnCompressedKey   = "compressed_public_key"
+	ColumnMnemonic        = "mnemonic"
+	ColumnSeedFile        = "seed_file"
+	ColumnSeedLabel       = "seed_label"
+	ColumnSeedLine        = "seed_line"
+	ColumnHDPath          = "hd_path"
+	ColumnIndex           = "index"
+	ColumnSignedMessage   = "signed_message"
+	ColumnSignature       = "signature"
+	ColumnAvaxXAddress    = "avax_x_address"
+	ColumnAvaxPAddress    = "avax_p_address"
+	ColumnPrivateKeyHash  = "private_key_hash"
+	ColumnICAP            = "icap"
+	ColumnAddressLabel    = "address_label"
+)
+
+// Columns lists every supported column.
+var Columns = []string{ColumnAddress, ColumnChecksumAddress, ColumnPrivateKey, ColumnPublicKey, ColumnCompressedKey, ColumnMnemonic, ColumnSeedFile, ColumnSeedLine, ColumnSeedLabel, ColumnHDPath, ColumnIndex, ColumnSignedMessage, ColumnSignature, ColumnAvaxXAddress, ColumnAvaxPAddress, ColumnPrivateKeyHash, ColumnICAP, ColumnAddressLabel}
+
+// DefaultColumns is the default column selection of column based formats.
+var DefaultColumns = []string{ColumnAddress, ColumnChecksumAddress, ColumnPrivateKey, ColumnMnemonic, ColumnSeedLine, ColumnHDPath, ColumnIndex}
+
+// columnValue returns the string value of a record column.
+func columnValue(r Record, column string) string {
+	switch column {
+	case ColumnAddress:
+		return r.Wallet.Address
+	case ColumnChecksumAddress:
+		return r.Wallet.ChecksumAddress
+	case ColumnPrivateKey:
+		return r.Wallet.PrivateKey
+	case ColumnPublicKey:
+		return r.Wallet.PublicKey
+	case ColumnCompressedKey:
+		return r.Wallet.CompressedPublicKey
+	case ColumnMnemonic:
+		return r.Mnemonic
+	case ColumnSeedFile:
+		return r.SeedFile
+	case ColumnSeedLine:
+		return strconv.Itoa(r.Line)
+	case ColumnSeedLabel:
+		return r.SeedLabel
+	case ColumnHDPath:
+		return r.Wallet.HDPath
+	case ColumnIndex:
+		return strconv.Itoa(r.Index)
+	case ColumnSignedMessage:
+		return r.Wallet.SignedMessage
+	case ColumnSignature:
+		return r.Wallet.Signature
+	case ColumnAvaxXAddress:
+		return r.Wallet.AvaxXAddress
+	case ColumnAvaxPAddress:
+		return r.Wallet.AvaxPAddress
+	case ColumnPrivateKeyHash:
+		return r.Wallet.PrivateKeyHash
+	case ColumnICAP:
+		return r.Wallet.ICAP
+	case ColumnAddressLabel:
+		return r.Wallet.AddressLabel
+	default:
+		return ""
+	}
+}
+
+// ChecksumAddress returns the EIP-55 address of the wallet, computed from Address
+// for wallets that don't carry it, eg. the rows of older DBs.
+func ChecksumAddress(w *wallets.Wallet) string {
+	if w.ChecksumAddress != "" {
+		return w.ChecksumAddress
+	}
+	return common.HexToAddress(w.Address).Hex()
+}
+
+// ValidateColumns returns an error if any of the given columns is unknown.
+func ValidateColumns(columns []string) error {
+	for _, c := range columns {
+		if !isColumn(c) {
+			return errors.Errorf("unknown column %q, must be one of %v", c, Columns)
+		}
+	}
+	return nil
+}
+
+func isColumn(name string) bool {
+	for _, c := range Columns {
+		if c == name {
+			return true
+		}
+	}
+	return false
+}
+
+type csvEncoder struct {
+	w         *csv.Writer
+	columns   []string
+	wroteHead bool
+	row       []string
+}
+
+func newCSVEncoder(w io.Writer, columns []string, appended bool) (Encoder, error) {
+	if len(columns) == 0 {
+		columns = DefaultColumns
+	}
+	if err := ValidateColumns(columns); err != nil {
+		return nil, err
+	}
+	return &csvEncoder{
+		w:         csv.NewWriter(w),
+		columns:   columns,
+		wroteHead: appended,
+		row:       make([]string, len(columns)),
+	}, nil
+}
+
+func (e *csvEncoder) Encode(r Record) error {
+	if !e.wroteHead {
+		if err := e.w.Write(e.columns); err != nil {
+			return errors.WithStack(err)
+		}
+		e.wroteHead = true
+	}
+	for i, c := range e.columns {
+		e.row[i] = columnValue(r, c)
+	}
+	return errors.WithStack(e.w.Write(e.row))
+}
+
+func (e *csvEncoder) Flush() error {
+	e.w.Flush()
+	return errors.WithStack(e.w.Error())
+}
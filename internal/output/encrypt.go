@@ -0,0 +1,94 @@
+package output
+
+import (
+	"io"
+	"strings"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"github.com/pkg/errors"
+)
+
+// ParseRecipient parses an age public key (age1...) or, for any other value,
+// returns a scrypt recipient encrypting with the value as a passphrase.
+func ParseRecipient(s string) (age.Recipient, error) {
+	if strings.HasPrefix(s, "age1") {
+		r, err := age.ParseX25519Recipient(s)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		return r, nil
+	}
+	if s == "" {
+		return nil, errors.New("encryption passphrase is empty")
+	}
+	r, err := age.NewScryptRecipient(s)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return r, nil
+}
+
+// Encrypter encrypts the result streams, to age recipients or OpenPGP keys.
+type Encrypter interface {
+	// Encrypt wraps w into an encrypted stream. Closing the returned writer finalizes the
+	// stream and then closes closer, if not nil.
+	Encrypt(w io.Writer, closer io.Closer) (io.WriteCloser, error)
+}
+
+// AgeEncrypter encrypts the result streams to an age recipient.
+type AgeEncrypter struct {
+	Recipient age.Recipient
+	// Armor writes the streams ASCII armored, eg. to stdout.
+	Armor bool
+}
+
+func (e AgeEncrypter) Encrypt(w io.Writer, closer io.Closer) (io.WriteCloser, error) {
+	if e.Armor {
+		a := armor.NewWriter(w)
+		return Encrypt(a, &chainedWriter{WriteCloser: a, closer: closer}, e.Recipient)
+	}
+	return Encrypt(w, closer, e.Recipient)
+}
+
+// Encrypt wraps w into an age encrypted stream for recipient. Closing the returned
+// writer finalizes the stream and then closes closer, if not nil.
+func Encrypt(w io.Writer, closer io.Closer, recipient age.Recipient) (io.WriteCloser, error) {
+	enc, err := age.Encrypt(w, recipient)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &chainedWriter{WriteCloser: enc, closer: closer}, nil
+}
+
+// chainedWriter is a stream layered on top of another one, closing both in order.
+type chainedWriter struct {
+	io.WriteCloser
+	closer io.Closer
+}
+
+func (w *chainedWriter) Close() error {
+	if err := w.WriteCloser.Close(); err != nil {
+		return errors.WithStack(err)
+	}
+	if w.closer != nil {
+		return errors.WithStack(w.closer.Close())
+	}
+	return nil
+}
+
+// Flush flushes both streams when they support it, age streams can only be finalized by Close.
+func (w *chainedWriter) Flush() error {
+	for _, s := range []any{w.WriteCloser, w.closer} {
+		if f, ok := s.(flusher); ok {
+			if err := f.Flush(); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+	}
+	return nil
+}
+
+type flusher interface {
+	Flush() error
+}
@@ -0,0 +1,175 @@
+package output
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// fieldAliases are the short field names accepted along with the column names.
+var fieldAliases = map[string]string{
+	"addr":      ColumnAddress,
+	"checksum":  ColumnChecksumAddress,
+	"pk":        ColumnPrivateKey,
+	"pubkey":    ColumnPublicKey,
+	"cpubkey":   ColumnCompressedKey,
+	"seedfile":  ColumnSeedFile,
+	"seedline":  ColumnSeedLine,
+	"label":     ColumnSeedLabel,
+	"hdpath":    ColumnHDPath,
+	"idx":       ColumnIndex,
+	"sig":       ColumnSignature,
+	"sigmsg":    ColumnSignedMessage,
+	"xaddr":     ColumnAvaxXAddress,
+	"paddr":     ColumnAvaxPAddress,
+	"pkhash":    ColumnPrivateKeyHash,
+	"addrlabel": ColumnAddressLabel,
+}
+
+// SecretFields are the columns leaking the private key of a wallet.
+var SecretFields = []string{ColumnPrivateKey, ColumnMnemonic}
+
+// ParseFields parses a comma separated list of column names or their short
+// aliases (addr, checksum, pk, pubkey, cpubkey, seedfile, seedline, label, hdpath, idx, sig, sigmsg, xaddr, paddr, pkhash, addrlabel) into column names.
+func ParseFields(s string) ([]string, error) {
+	var fields []string
+	for _, f := range strings.Split(s, ",") {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+		if c, ok := fieldAliases[f]; ok {
+			f = c
+		}
+		if !isColumn(f) {
+			return nil, errors.Errorf("unknown field %q, must be one of %v", f, Columns)
+		}
+		fields = append(fields, f)
+	}
+	if len(fields) == 0 {
+		return nil, errors.New("no fields selected")
+	}
+	return fields, nil
+}
+
+// WithoutFields returns fields, or every column if nil, minus the excluded ones.
+func WithoutFields(fields []string, excluded ...string) []string {
+	if fields == nil {
+		fields = Columns
+	}
+	kept := make([]string, 0, len(fields))
+	for _, f := range fields {
+		if !hasField(excluded, f) {
+			kept = append(kept, f)
+		}
+	}
+	return kept
+}
+
+// Redact returns a copy of the record with every column that isn't part of fields
+// cleared, along with the seed hash unless the seed line is kept and the account index
+// unless the derivation path is. A nil fields keeps the record untouched.
+func (r Record) Redact(fields []string) Record {
+	if fields == nil {
+		return r
+	}
+	w := *r.Wallet
+	if !hasField(fields, ColumnAddress) {
+		w.Address = ""
+	}
+	if !hasField(fields, ColumnChecksumAddress) {
+		w.ChecksumAddress = ""
+	}
+	if !hasField(fields, ColumnPrivateKey) {
+		w.PrivateKey = ""
+	}
+	if !hasField(fields, ColumnMnemonic) {
+		w.Mnemonic = ""
+		r.Mnemonic = ""
+	}
+	if !hasField(fields, ColumnPublicKey) {
+		w.PublicKey = ""
+	}
+	if !hasField(fields, ColumnCompressedKey) {
+		w.CompressedPublicKey = ""
+	}
+	if !hasField(fields, ColumnSeedFile) {
+		w.SeedFile = ""
+		r.SeedFile = ""
+	}
+	if !hasField(fields, ColumnSeedLine) {
+		w.SeedLine = 0
+		w.SeedHash = ""
+		r.Line = 0
+	}
+	if !hasField(fields, ColumnSeedLabel) {
+		w.SeedLabel = ""
+		r.SeedLabel = ""
+	}
+	if !hasField(fields, ColumnHDPath) {
+		w.HDPath = ""
+		w.AccountIndex = 0
+	}
+	if !hasField(fields, ColumnIndex) {
+		w.AddressIndex = 0
+		r.Index = 0
+	}
+	if !hasField(fields, ColumnSignedMessage) {
+		w.SignedMessage = ""
+	}
+	if !hasField(fields, ColumnSignature) {
+		w.Signature = ""
+	}
+	if !hasField(fields, ColumnAvaxXAddress) {
+		w.AvaxXAddress = ""
+	}
+	if !hasField(fields, ColumnAvaxPAddress) {
+		w.AvaxPAddress = ""
+	}
+	if !hasField(fields, ColumnPrivateKeyHash) {
+		w.PrivateKeyHash = ""
+	}
+	if !hasField(fields, ColumnICAP) {
+		w.ICAP = ""
+	}
+	if !hasField(fields, ColumnAddressLabel) {
+		w.AddressLabel = ""
+	}
+	r.Wallet = &w
+	return r
+}
+
+// HasField reports whether column is part of fields, a nil fields has every column.
+func HasField(fields []string, column string) bool {
+	return hasField(fields, column)
+}
+
+// hasField reports whether column is part of fields, a nil fields has every column.
+func hasField(fields []string, column string) bool {
+	if fields == nil {
+		return true
+	}
+	for _, f := range fields {
+		if f == column {
+			return true
+		}
+	}
+	return false
+}
+
+// selectColumns returns the columns, or DefaultColumns if empty, that are part of fields.
+func selectColumns(columns, fields []string) []string {
+	if len(columns) == 0 {
+		columns = DefaultColumns
+	}
+	if fields == nil {
+		return columns
+	}
+	selected := make([]string, 0, len(columns))
+	for _, c := range columns {
+		if hasField(fields, c) {
+			selected = append(selected, c)
+		}
+	}
+	return selected
+}
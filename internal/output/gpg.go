@@ -0,0 +1,108 @@
+package output
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"os/exec"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/pkg/errors"
+)
+
+// PGPExt is appended to the names of the files encrypted with OpenPGP.
+const PGPExt = ".gpg"
+
+// PGPEncrypter encrypts the result streams and files to OpenPGP public keys, so a box running
+// the search writes results only the holder of a private key, eg. an offline machine, reads.
+type PGPEncrypter struct {
+	keys  openpgp.EntityList
+	armor bool
+}
+
+// NewPGPEncrypter returns the encrypter to the public key of every recipient: a public key
+// file, armored or not, or else a key ID, fingerprint or e-mail exported from the gpg keyring.
+func NewPGPEncrypter(recipients []string) (*PGPEncrypter, error) {
+	e := &PGPEncrypter{}
+	for _, recipient := range recipients {
+		keys, err := readPGPKeys(recipient)
+		if err != nil {
+			return nil, err
+		}
+		e.keys = append(e.keys, keys...)
+	}
+	if len(e.keys) == 0 {
+		return nil, errors.New("no OpenPGP recipient")
+	}
+	// a key without an encryption subkey, or expired, only fails once a message is written
+	w, err := openpgp.Encrypt(io.Discard, e.keys, nil, nil, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "can't encrypt to the OpenPGP recipients")
+	}
+	return e, errors.WithStack(w.Close())
+}
+
+// readPGPKeys reads the public keys of a recipient.
+func readPGPKeys(recipient string) (openpgp.EntityList, error) {
+	data, err := os.ReadFile(recipient)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, errors.WithStack(err)
+		}
+		if data, err = exec.Command("gpg", "--batch", "--armor", "--export", recipient).Output(); err != nil {
+			return nil, errors.Wrapf(err, "failed to export the OpenPGP key %q from gpg, give its public key file instead", recipient)
+		}
+		if len(data) == 0 {
+			return nil, errors.Errorf("no OpenPGP public key %q in the gpg keyring", recipient)
+		}
+	}
+	var keys openpgp.EntityList
+	if bytes.Contains(data, []byte("-----BEGIN PGP PUBLIC KEY BLOCK-----")) {
+		keys, err = openpgp.ReadArmoredKeyRing(bytes.NewReader(data))
+	} else {
+		keys, err = openpgp.ReadKeyRing(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid OpenPGP public key %q", recipient)
+	}
+	return keys, nil
+}
+
+// Armored returns an encrypter of the same keys writing ASCII armored messages, eg. to stdout.
+func (e *PGPEncrypter) Armored() *PGPEncrypter {
+	return &PGPEncrypter{keys: e.keys, armor: true}
+}
+
+// Encrypt wraps w into an OpenPGP message to the keys. Closing the returned writer finalizes
+// the message and then closes closer, if not nil.
+func (e *PGPEncrypter) Encrypt(w io.Writer, closer io.Closer) (io.WriteCloser, error) {
+	if e.armor {
+		armored, err := armor.Encode(w, "PGP MESSAGE", nil)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		w, closer = armored, &chainedWriter{WriteCloser: armored, closer: closer}
+	}
+	enc, err := openpgp.Encrypt(w, e.keys, nil, nil, nil)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &chainedWriter{WriteCloser: enc, closer: closer}, nil
+}
+
+// EncryptBytes returns data encrypted to the keys, for the files written at once.
+func (e *PGPEncrypter) EncryptBytes(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w, err := e.Encrypt(&buf, nil)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,255 @@
+// Package output encodes matched wallets to result streams in the supported formats.
+package output
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/pkg/errors"
+
+	"github.com/planxnx/ethereum-wallet-generator/internal/style"
+	"github.com/planxnx/ethereum-wallet-generator/wallets"
+)
+
+const (
+	// FormatText is the human readable `MATCH:` line format.
+	FormatText = "text"
+	// FormatJSONL is one JSON object per line.
+	FormatJSONL = "jsonl"
+	// FormatCSV is comma separated values with a header row.
+	FormatCSV = "csv"
+	// FormatTemplate is one line per record rendered with the Options.Template text/template.
+	FormatTemplate = "template"
+	// FormatParquet is a Parquet file with a typed wallet schema, it can't be streamed to stdout.
+	FormatParquet = "parquet"
+	// FormatTree is one JSON object per seed, its derived addresses nested by account.
+	FormatTree = "tree"
+)
+
+// Formats lists the supported output formats.
+var Formats = []string{FormatText, FormatJSONL, FormatCSV, FormatTemplate, FormatParquet, FormatTree}
+
+// Options configures an encoder.
+type Options struct {
+	// Columns selects and orders the fields of column based formats, defaults to DefaultColumns.
+	Columns []string
+	// Fields restricts every format to the given columns, nil keeps every field.
+	Fields []string
+	// Template is the text/template source of the template format, eg. {{.Address}},{{.HDPath}}.
+	Template string
+	// Color styles the MATCH: prefix of the text format for a terminal.
+	Color bool
+	// Append continues a stream already holding records, the csv header isn't written again.
+	Append bool
+}
+
+// Record is a matched wallet along with where it was derived from. SeedFile is only
+// set when the seeds were read from several files, Line is then the line within it.
+// SeedLabel is the label of the seed line in structured seeds files.
+type Record struct {
+	SeedFile  string
+	Line      int
+	SeedLabel string
+	Index     int
+	Mnemonic  string
+	Wallet    *wallets.Wallet
+}
+
+// Encoder encodes records to an underlying stream.
+type Encoder interface {
+	Encode(r Record) error
+	Flush() error
+}
+
+// NewEncoder returns an encoder for the given format.
+func NewEncoder(format string, w io.Writer, opts Options) (Encoder, error) {
+	bw := bufio.NewWriter(w)
+	switch format {
+	case FormatText, "":
+		return &textEncoder{w: bw, fields: opts.Fields, color: opts.Color}, nil
+	case FormatJSONL:
+		return &jsonlEncoder{w: bw, fields: opts.Fields}, nil
+	case FormatCSV:
+		columns := selectColumns(opts.Columns, opts.Fields)
+		if len(columns) == 0 {
+			return nil, errors.New("none of the csv columns is part of the selected fields")
+		}
+		return newCSVEncoder(w, columns, opts.Append)
+	case FormatTemplate:
+		return newTemplateEncoder(bw, opts.Template)
+	case FormatParquet:
+		return newParquetEncoder(w), nil
+	case FormatTree:
+		return &treeEncoder{w: bw, fields: opts.Fields}, nil
+	default:
+		return nil, errors.Errorf("unknown output format %q, must be one of %v", format, Formats)
+	}
+}
+
+// Writer encodes records to a stream and closes it when done.
+type Writer struct {
+	enc    Encoder
+	closer io.Closer
+
+	// rotation state, only set by NewRotatingWriter
+	format   string
+	opts     Options
+	open     Opener
+	rotation Rotation
+	part     int
+	rows     int
+	written  *countingWriter
+}
+
+// NewWriter returns a writer encoding records in the given format to w.
+// closer may be nil if w must not be closed (eg. stdout), such writers are
+// flushed after every record so results show up live.
+func NewWriter(format string, w io.Writer, closer io.Closer, opts Options) (*Writer, error) {
+	enc, err := NewEncoder(format, w, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &Writer{enc: enc, closer: closer}, nil
+}
+
+// Write encodes a record.
+func (w *Writer) Write(r Record) error {
+	if w.rotation.due(w.rows, w.written) {
+		if err := w.rotate(); err != nil {
+			return err
+		}
+	}
+	if err := w.enc.Encode(r); err != nil {
+		return err
+	}
+	w.rows++
+	// size based rotation needs every record counted as it is written
+	if w.closer == nil || w.rotation.Bytes > 0 {
+		return w.enc.Flush()
+	}
+	return nil
+}
+
+// Flush writes any buffered data to the underlying stream, including its compression buffers.
+func (w *Writer) Flush() error {
+	if err := w.enc.Flush(); err != nil {
+		return err
+	}
+	if f, ok := w.closer.(flusher); ok {
+		return errors.WithStack(f.Flush())
+	}
+	return nil
+}
+
+// Close flushes and closes the underlying stream.
+func (w *Writer) Close() error {
+	if err := w.enc.Flush(); err != nil {
+		return err
+	}
+	if c, ok := w.enc.(io.Closer); ok {
+		if err := c.Close(); err != nil {
+			return err
+		}
+	}
+	if w.closer != nil {
+		return errors.WithStack(w.closer.Close())
+	}
+	return nil
+}
+
+type textEncoder struct {
+	w      *bufio.Writer
+	fields []string
+	color  bool
+}
+
+// textKeys are the columns of the text format and their names.
+var textKeys = []struct{ column, key string }{
+	{ColumnSeedFile, "seed_file"},
+	{ColumnSeedLine, "seed_line"},
+	{ColumnSeedLabel, "seed_label"},
+	{ColumnIndex, "idx"},
+	{ColumnAddress, "addr"},
+	{ColumnPrivateKey, "pk"},
+	{ColumnHDPath, "hdpath"},
+	{ColumnSignature, "sig"},
+	{ColumnAvaxXAddress, "xaddr"},
+	{ColumnAvaxPAddress, "paddr"},
+	{ColumnPrivateKeyHash, "pkhash"},
+	{ColumnICAP, "icap"},
+	{ColumnAddressLabel, "addrlabel"},
+}
+
+func (e *textEncoder) Encode(r Record) error {
+	e.w.WriteString(style.Paint(e.color, style.Match, "MATCH:"))
+	for _, k := range textKeys {
+		if (k.column == ColumnSeedFile || k.column == ColumnSeedLabel || k.column == ColumnSignature || k.column == ColumnAvaxXAddress || k.column == ColumnAvaxPAddress || k.column == ColumnPrivateKeyHash || k.column == ColumnICAP || k.column == ColumnAddressLabel) && columnValue(r, k.column) == "" {
+			continue
+		}
+		if hasField(e.fields, k.column) {
+			e.w.WriteString(" " + k.key + "=" + columnValue(r, k.column))
+		}
+	}
+	return errors.WithStack(e.w.WriteByte('\n'))
+}
+
+func (e *textEncoder) Flush() error {
+	return errors.WithStack(e.w.Flush())
+}
+
+type jsonlEncoder struct {
+	w      *bufio.Writer
+	fields []string
+}
+
+// Encode writes the selected columns of the record as a JSON object, in Columns order.
+func (e *jsonlEncoder) Encode(r Record) error {
+	e.w.WriteByte('{')
+	if _, err := writeJSONColumns(e.w, r, e.fields, Columns); err != nil {
+		return err
+	}
+	_, err := e.w.WriteString("}\n")
+	return errors.WithStack(err)
+}
+
+func (e *jsonlEncoder) Flush() error {
+	return errors.WithStack(e.w.Flush())
+}
+
+// writeJSONColumns writes the selected columns of the record as comma separated JSON object
+// members, in columns order, and reports whether it wrote any. The seed line and index are
+// numbers, empty public keys, mnemonic, seed file, label, signature, Avalanche addresses,
+// private key hash, ICAP and address label are omitted.
+func writeJSONColumns(w *bufio.Writer, r Record, fields, columns []string) (bool, error) {
+	first := true
+	for _, c := range columns {
+		if !hasField(fields, c) {
+			continue
+		}
+		var value any = columnValue(r, c)
+		if value == "" && (c == ColumnMnemonic || c == ColumnPublicKey || c == ColumnCompressedKey || c == ColumnSeedFile || c == ColumnSeedLabel || c == ColumnSignedMessage || c == ColumnSignature || c == ColumnAvaxXAddress || c == ColumnAvaxPAddress || c == ColumnPrivateKeyHash || c == ColumnICAP || c == ColumnAddressLabel) {
+			continue
+		}
+
+		switch c {
+		case ColumnSeedLine:
+			value = r.Line
+		case ColumnIndex:
+			value = r.Index
+		}
+		b, err := json.Marshal(value)
+		if err != nil {
+			return false, errors.WithStack(err)
+		}
+
+		if !first {
+			w.WriteByte(',')
+		}
+		first = false
+		fmt.Fprintf(w, "%q:", c)
+		w.Write(b)
+	}
+	return !first, nil
+}
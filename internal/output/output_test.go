@@ -0,0 +1,322 @@
+package output
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"filippo.io/age"
+	agearmor "filippo.io/age/armor"
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/parquet-go/parquet-go"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/planxnx/ethereum-wallet-generator/wallets"
+)
+
+func testRecord() Record {
+	return Record{
+		Line:     7,
+		Index:    1,
+		Mnemonic: "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about",
+		Wallet: &wallets.Wallet{
+			Address:         "0x6fac4d18c912343bf86fa7049364dd4e424ab9c0",
+			ChecksumAddress: "0x6Fac4D18c912343BF86fa7049364Dd4E424Ab9C0",
+			PrivateKey:      "9a983cb3d832fbde5ab49d692b7a8bf5b5d232479c99333d0fc8e1d21f1b55b6",
+			HDPath:          "m/44'/60'/0'/0/1",
+		},
+	}
+}
+
+func TestWriterFormats(t *testing.T) {
+	testCases := map[string]struct {
+		format   string
+		opts     Options
+		expected string
+	}{
+		"text": {
+			format:   FormatText,
+			expected: "MATCH: seed_line=7 idx=1 addr=0x6fac4d18c912343bf86fa7049364dd4e424ab9c0 pk=9a983cb3d832fbde5ab49d692b7a8bf5b5d232479c99333d0fc8e1d21f1b55b6 hdpath=m/44'/60'/0'/0/1\n",
+		},
+		"csv": {
+			format:   FormatCSV,
+			opts:     Options{Columns: []string{ColumnSeedLine, ColumnChecksumAddress, ColumnHDPath}},
+			expected: "seed_line,checksum_address,hd_path\n7,0x6Fac4D18c912343BF86fa7049364Dd4E424Ab9C0,m/44'/60'/0'/0/1\n7,0x6Fac4D18c912343BF86fa7049364Dd4E424Ab9C0,m/44'/60'/0'/0/1\n",
+		},
+		"jsonl fields": {
+			format:   FormatJSONL,
+			opts:     Options{Fields: WithoutFields(nil, SecretFields...)},
+			expected: `{"address":"0x6fac4d18c912343bf86fa7049364dd4e424ab9c0","checksum_address":"0x6Fac4D18c912343BF86fa7049364Dd4E424Ab9C0","seed_line":7,"hd_path":"m/44'/60'/0'/0/1","index":1}` + "\n",
+		},
+		"template": {
+			format:   FormatTemplate,
+			opts:     Options{Template: "{{.SeedLine}}:{{.Index}} {{.ChecksumAddress}} {{.HDPath}}"},
+			expected: "7:1 0x6Fac4D18c912343BF86fa7049364Dd4E424Ab9C0 m/44'/60'/0'/0/1\n",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			w, err := NewWriter(tc.format, &buf, nil, tc.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rows := 1
+			if tc.format == FormatCSV {
+				rows = 2
+			}
+			for i := 0; i < rows; i++ {
+				assert.NoError(t, w.Write(testRecord()))
+			}
+			assert.NoError(t, w.Close())
+			assert.Equal(t, tc.expected, buf.String())
+		})
+	}
+}
+
+func TestUnknownColumn(t *testing.T) {
+	_, err := NewEncoder(FormatCSV, &bytes.Buffer{}, Options{Columns: []string{"balance"}})
+	assert.Error(t, err)
+}
+
+func TestEncryptedWriter(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	recipient, err := ParseRecipient(identity.Recipient().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	enc, err := Encrypt(&buf, nil, recipient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := NewWriter(FormatText, enc, enc, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(t, w.Write(testRecord()))
+	assert.NoError(t, w.Close())
+	assert.NotContains(t, buf.String(), testRecord().Wallet.PrivateKey)
+
+	r, err := age.Decrypt(&buf, identity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, string(plain), "pk="+testRecord().Wallet.PrivateKey)
+
+	// armored, as written to stdout
+	buf.Reset()
+	enc, err = AgeEncrypter{Recipient: recipient, Armor: true}.Encrypt(&buf, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err = NewWriter(FormatText, enc, enc, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(t, w.Write(testRecord()))
+	assert.NoError(t, w.Close())
+	assert.Contains(t, buf.String(), "-----END AGE ENCRYPTED FILE-----")
+	r, err = age.Decrypt(agearmor.NewReader(&buf), identity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain, err = io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, string(plain), "pk="+testRecord().Wallet.PrivateKey)
+}
+
+func TestPGPEncryptedWriter(t *testing.T) {
+	entity, err := openpgp.NewEntity("Ops", "", "ops@example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyFile := filepath.Join(t.TempDir(), "ops.asc")
+	var key bytes.Buffer
+	armored, err := armor.Encode(&key, openpgp.PublicKeyType, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(t, entity.Serialize(armored))
+	assert.NoError(t, armored.Close())
+	assert.NoError(t, os.WriteFile(keyFile, key.Bytes(), 0o600))
+
+	pgp, err := NewPGPEncrypter([]string{keyFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	enc, err := pgp.Armored().Encrypt(&buf, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := NewWriter(FormatText, enc, enc, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(t, w.Write(testRecord()))
+	assert.NoError(t, w.Close())
+	assert.NotContains(t, buf.String(), testRecord().Wallet.PrivateKey)
+
+	block, err := armor.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	md, err := openpgp.ReadMessage(block.Body, openpgp.EntityList{entity}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain, err := io.ReadAll(md.UnverifiedBody)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, string(plain), "pk="+testRecord().Wallet.PrivateKey)
+}
+
+type nopBuffer struct{ bytes.Buffer }
+
+func (*nopBuffer) Close() error { return nil }
+
+func TestRotatingWriter(t *testing.T) {
+	var parts []*nopBuffer
+	w, err := NewRotatingWriter(FormatCSV, func(part int) (io.WriteCloser, error) {
+		parts = append(parts, &nopBuffer{})
+		return parts[part-1], nil
+	}, Rotation{Rows: 2}, Options{Columns: []string{ColumnSeedLine}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, w.Write(testRecord()))
+	}
+	assert.NoError(t, w.Close())
+
+	if assert.Len(t, parts, 3) {
+		assert.Equal(t, "seed_line\n7\n7\n", parts[0].String())
+		assert.Equal(t, "seed_line\n7\n", parts[2].String())
+	}
+	assert.Equal(t, "wallets.00002.jsonl", PartName("wallets.jsonl", 2))
+}
+
+func TestRecordRedact(t *testing.T) {
+	full := func() Record {
+		return Record{
+			SeedFile:  "seeds.txt",
+			Line:      7,
+			SeedLabel: "cold",
+			Index:     1,
+			Mnemonic:  "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about",
+			Wallet: &wallets.Wallet{
+				Address:             "0x6fac4d18c912343bf86fa7049364dd4e424ab9c0",
+				ChecksumAddress:     "0x6Fac4D18c912343BF86fa7049364Dd4E424Ab9C0",
+				PrivateKey:          "9a983cb3d832fbde5ab49d692b7a8bf5b5d232479c99333d0fc8e1d21f1b55b6",
+				PublicKey:           "04aa",
+				CompressedPublicKey: "02aa",
+				Mnemonic:            "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about",
+				HDPath:              "m/44'/60'/0'/0/1",
+				SeedFile:            "seeds.txt",
+				SeedLine:            7,
+				SeedLabel:           "cold",
+				SeedHash:            "5eed",
+				AccountIndex:        2,
+				AddressIndex:        1,
+				SignedMessage:       "msg",
+				Signature:           "0xsig",
+				AvaxXAddress:        "X-avax1",
+				AvaxPAddress:        "P-avax1",
+				PrivateKeyHash:      "pkhash",
+				ICAP:                "XE00",
+				AddressLabel:        "exchange",
+			},
+		}
+	}
+	for _, column := range Columns {
+		t.Run(column, func(t *testing.T) {
+			r := full()
+			redacted := r.Redact([]string{column})
+			for _, c := range Columns {
+				value := columnValue(redacted, c)
+				if c == column {
+					assert.Equal(t, columnValue(r, c), value, "selected column %s", c)
+				} else if value != "" && value != "0" {
+					assert.Failf(t, "unselected column kept", "%s = %q", c, value)
+				}
+			}
+			assert.Equal(t, full(), r, "Redact changed the record")
+		})
+	}
+
+	redacted := full().Redact([]string{ColumnAddress, ColumnHDPath})
+	assert.Equal(t, Record{Wallet: &wallets.Wallet{
+		Address:      "0x6fac4d18c912343bf86fa7049364dd4e424ab9c0",
+		HDPath:       "m/44'/60'/0'/0/1",
+		AccountIndex: 2,
+	}}, redacted)
+	assert.Equal(t, full(), full().Redact(nil))
+}
+
+func TestParquetWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(FormatParquet, &buf, io.NopCloser(nil), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	record := testRecord().Redact(WithoutFields(nil, SecretFields...))
+	assert.NoError(t, w.Write(record))
+	assert.NoError(t, w.Close())
+
+	rows, err := parquet.Read[parquetRow](bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []parquetRow{{
+		Address:         "0x6fac4d18c912343bf86fa7049364dd4e424ab9c0",
+		ChecksumAddress: "0x6Fac4D18c912343BF86fa7049364Dd4E424Ab9C0",
+		SeedLine:        7,
+		HDPath:          "m/44'/60'/0'/0/1",
+		Index:           1,
+	}}, rows)
+}
+
+func TestTreeWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(FormatTree, &buf, nil, Options{Fields: []string{ColumnSeedLine, ColumnAddress, ColumnHDPath, ColumnIndex}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, second, account, next := testRecord(), testRecord(), testRecord(), testRecord()
+	second.Index = 2
+	second.Wallet = &wallets.Wallet{Address: "0x02", HDPath: "m/44'/60'/0'/0/2"}
+	account.Index = 0
+	account.Wallet = &wallets.Wallet{Address: "0x03", HDPath: "m/44'/60'/1'/0/0"}
+	next.Line = 8
+	next.Wallet = &wallets.Wallet{Address: "0x04"}
+	for _, r := range []Record{first, second, account} {
+		assert.NoError(t, w.Write(r))
+	}
+	// the seed is only complete once the next one starts
+	assert.Empty(t, buf.String())
+	assert.NoError(t, w.Write(next))
+	assert.NoError(t, w.Close())
+
+	assert.Equal(t, `{"seed_line":7,"accounts":[`+
+		`{"account":0,"path":"m/44'/60'/0'","addresses":[{"address":"0x6fac4d18c912343bf86fa7049364dd4e424ab9c0","hd_path":"m/44'/60'/0'/0/1","index":1},{"address":"0x02","hd_path":"m/44'/60'/0'/0/2","index":2}]},`+
+		`{"account":1,"path":"m/44'/60'/1'","addresses":[{"address":"0x03","hd_path":"m/44'/60'/1'/0/0","index":0}]}]}`+"\n"+
+		`{"seed_line":8,"accounts":[{"addresses":[{"address":"0x04","hd_path":"","index":1}]}]}`+"\n", buf.String())
+}
@@ -0,0 +1,78 @@
+package output
+
+import (
+	"io"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/pkg/errors"
+)
+
+// parquetRow is the Parquet schema of a record. Redacted secrets are stored as nulls.
+type parquetRow struct {
+	Address             string `parquet:"address"`
+	ChecksumAddress     string `parquet:"checksum_address"`
+	PrivateKey          string `parquet:"private_key,optional"`
+	PublicKey           string `parquet:"public_key,optional"`
+	CompressedPublicKey string `parquet:"compressed_public_key,optional"`
+	Mnemonic            string `parquet:"mnemonic,optional"`
+	SeedFile            string `parquet:"seed_file,optional"`
+	SeedLine            int64  `parquet:"seed_line"`
+	SeedLabel           string `parquet:"seed_label,optional"`
+	HDPath              string `parquet:"hd_path,optional"`
+	Index               int32  `parquet:"index"`
+	SignedMessage       string `parquet:"signed_message,optional"`
+	Signature           string `parquet:"signature,optional"`
+	AvaxXAddress        string `parquet:"avax_x_address,optional"`
+	AvaxPAddress        string `parquet:"avax_p_address,optional"`
+	PrivateKeyHash      string `parquet:"private_key_hash,optional"`
+	ICAP                string `parquet:"icap,optional"`
+	AddressLabel        string `parquet:"address_label,optional"`
+}
+
+// parquetEncoder writes records as a snappy compressed Parquet file. Every Flush ends a
+// row group, and the file is only readable once the encoder is closed.
+type parquetEncoder struct {
+	w   *parquet.GenericWriter[parquetRow]
+	row []parquetRow
+}
+
+func newParquetEncoder(w io.Writer) *parquetEncoder {
+	return &parquetEncoder{
+		w:   parquet.NewGenericWriter[parquetRow](w, parquet.Compression(&parquet.Snappy)),
+		row: make([]parquetRow, 1),
+	}
+}
+
+func (e *parquetEncoder) Encode(r Record) error {
+	e.row[0] = parquetRow{
+		Address:             r.Wallet.Address,
+		ChecksumAddress:     r.Wallet.ChecksumAddress,
+		PrivateKey:          r.Wallet.PrivateKey,
+		PublicKey:           r.Wallet.PublicKey,
+		CompressedPublicKey: r.Wallet.CompressedPublicKey,
+		Mnemonic:            r.Mnemonic,
+		SeedFile:            r.SeedFile,
+		SeedLine:            int64(r.Line),
+		SeedLabel:           r.SeedLabel,
+		HDPath:              r.Wallet.HDPath,
+		Index:               int32(r.Index),
+		SignedMessage:       r.Wallet.SignedMessage,
+		Signature:           r.Wallet.Signature,
+		AvaxXAddress:        r.Wallet.AvaxXAddress,
+		AvaxPAddress:        r.Wallet.AvaxPAddress,
+		PrivateKeyHash:      r.Wallet.PrivateKeyHash,
+		ICAP:                r.Wallet.ICAP,
+		AddressLabel:        r.Wallet.AddressLabel,
+	}
+	_, err := e.w.Write(e.row)
+	return errors.WithStack(err)
+}
+
+func (e *parquetEncoder) Flush() error {
+	return errors.WithStack(e.w.Flush())
+}
+
+// Close writes the file footer.
+func (e *parquetEncoder) Close() error {
+	return errors.WithStack(e.w.Close())
+}
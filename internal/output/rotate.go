@@ -0,0 +1,92 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Rotation splits a file output into numbered parts. A part is closed and the next one
+// opened before writing a record once it holds Rows records or Bytes bytes. Zero values
+// disable the corresponding limit. Sizes are counted before any compression or encryption
+// layer of the opener, and a part may exceed Bytes by one record.
+type Rotation struct {
+	Rows  int
+	Bytes int64
+}
+
+// Enabled reports whether any limit is set.
+func (r Rotation) Enabled() bool {
+	return r.Rows > 0 || r.Bytes > 0
+}
+
+func (r Rotation) due(rows int, written *countingWriter) bool {
+	if rows == 0 {
+		return false
+	}
+	return (r.Rows > 0 && rows >= r.Rows) || (r.Bytes > 0 && written != nil && written.n >= r.Bytes)
+}
+
+// Opener opens the stream of a numbered part, starting at 1.
+type Opener func(part int) (io.WriteCloser, error)
+
+// PartName returns the file name of a numbered part of path, eg. wallets.jsonl is
+// split into wallets.00001.jsonl, wallets.00002.jsonl and so on.
+func PartName(path string, part int) string {
+	ext := filepath.Ext(path)
+	return fmt.Sprintf("%s.%05d%s", strings.TrimSuffix(path, ext), part, ext)
+}
+
+// NewRotatingWriter returns a writer encoding records in the given format to the parts
+// returned by open, switching to the next part according to rotation.
+func NewRotatingWriter(format string, open Opener, rotation Rotation, opts Options) (*Writer, error) {
+	w := &Writer{
+		format:   format,
+		opts:     opts,
+		open:     open,
+		rotation: rotation,
+	}
+	if err := w.openPart(1); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// rotate closes the current part and opens the next one.
+func (w *Writer) rotate() error {
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return w.openPart(w.part + 1)
+}
+
+func (w *Writer) openPart(part int) error {
+	f, err := w.open(part)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	counter := &countingWriter{w: f}
+	enc, err := NewEncoder(w.format, counter, w.opts)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	w.enc, w.closer, w.written = enc, f, counter
+	w.part, w.rows = part, 0
+	return nil
+}
+
+// countingWriter counts the bytes written to w.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
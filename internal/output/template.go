@@ -0,0 +1,60 @@
+package output
+
+import (
+	"bufio"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+
+	"github.com/planxnx/ethereum-wallet-generator/wallets"
+)
+
+// TemplateData is the data available to the template format. Every Wallet field is
+// promoted, Mnemonic, SeedFile and SeedLabel are filled in when the wallet does not carry them.
+type TemplateData struct {
+	wallets.Wallet
+	SeedLine int
+	Index    int
+}
+
+type templateEncoder struct {
+	w    *bufio.Writer
+	tmpl *template.Template
+}
+
+func newTemplateEncoder(w *bufio.Writer, src string) (*templateEncoder, error) {
+	if src == "" {
+		return nil, errors.New("template format requires a template")
+	}
+	if !strings.HasSuffix(src, "\n") {
+		src += "\n"
+	}
+	tmpl, err := template.New("output").Option("missingkey=error").Parse(src)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid output template")
+	}
+	return &templateEncoder{w: w, tmpl: tmpl}, nil
+}
+
+func (e *templateEncoder) Encode(r Record) error {
+	data := TemplateData{
+		Wallet:   *r.Wallet,
+		SeedLine: r.Line,
+		Index:    r.Index,
+	}
+	if data.Mnemonic == "" {
+		data.Mnemonic = r.Mnemonic
+	}
+	if data.SeedFile == "" {
+		data.SeedFile = r.SeedFile
+	}
+	if data.SeedLabel == "" {
+		data.SeedLabel = r.SeedLabel
+	}
+	return errors.WithStack(e.tmpl.Execute(e.w, data))
+}
+
+func (e *templateEncoder) Flush() error {
+	return errors.WithStack(e.w.Flush())
+}
@@ -0,0 +1,118 @@
+package output
+
+import (
+	"bufio"
+	"fmt"
+	"slices"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/pkg/errors"
+)
+
+// seedColumns are the columns of the tree format describing the seed itself, the others
+// describe each address.
+var seedColumns = []string{ColumnSeedFile, ColumnSeedLine, ColumnSeedLabel, ColumnMnemonic}
+
+// treeEncoder groups the consecutive records of a seed into one JSON object per line: the
+// seed columns, then its accounts in the order they were first derived, each with its
+// derived addresses. A seed is written once a record of the next seed arrives or the
+// encoder is closed, as there is no telling before whether more of its addresses follow.
+type treeEncoder struct {
+	w      *bufio.Writer
+	fields []string
+	seed   []Record
+}
+
+// treeAccount is the account level of a seed in the tree format, the addresses derived
+// below the m/purpose'/coin'/account' path.
+type treeAccount struct {
+	account   int
+	path      string
+	addresses []Record
+}
+
+func (e *treeEncoder) Encode(r Record) error {
+	if len(e.seed) > 0 && !sameSeed(e.seed[0], r) {
+		if err := e.writeSeed(); err != nil {
+			return err
+		}
+	}
+	e.seed = append(e.seed, r)
+	return nil
+}
+
+// sameSeed reports whether a and b are derived from the same seed.
+func sameSeed(a, b Record) bool {
+	return a.SeedFile == b.SeedFile && a.Line == b.Line && a.SeedLabel == b.SeedLabel && a.Mnemonic == b.Mnemonic
+}
+
+// writeSeed writes the records of the current seed and resets it.
+func (e *treeEncoder) writeSeed() error {
+	var accs []*treeAccount
+	for _, r := range e.seed {
+		acc := &treeAccount{account: -1}
+		// wallets without an account level, eg. derived from a private key, share an
+		// account without account number nor path
+		if path, err := accounts.ParseDerivationPath(r.Wallet.HDPath); err == nil && len(path) > 2 {
+			acc = &treeAccount{account: int(path[2] &^ 0x80000000), path: accounts.DerivationPath(path[:3]).String()}
+		}
+		i := slices.IndexFunc(accs, func(a *treeAccount) bool { return a.account == acc.account && a.path == acc.path })
+		if i < 0 {
+			accs = append(accs, acc)
+			i = len(accs) - 1
+		}
+		accs[i].addresses = append(accs[i].addresses, r)
+	}
+
+	e.w.WriteByte('{')
+	wrote, err := writeJSONColumns(e.w, e.seed[0], e.fields, seedColumns)
+	if err != nil {
+		return err
+	}
+	if wrote {
+		e.w.WriteByte(',')
+	}
+	e.w.WriteString(`"accounts":[`)
+	for i, acc := range accs {
+		if i > 0 {
+			e.w.WriteByte(',')
+		}
+		e.w.WriteByte('{')
+		if acc.account >= 0 {
+			fmt.Fprintf(e.w, `"account":%d,"path":%q,`, acc.account, acc.path)
+		}
+		e.w.WriteString(`"addresses":[`)
+		for j, r := range acc.addresses {
+			if j > 0 {
+				e.w.WriteByte(',')
+			}
+			e.w.WriteByte('{')
+			if _, err := writeJSONColumns(e.w, r, e.fields, addressColumns); err != nil {
+				return err
+			}
+			e.w.WriteByte('}')
+		}
+		e.w.WriteString("]}")
+	}
+	e.seed = e.seed[:0]
+	_, err = e.w.WriteString("]}\n")
+	return errors.WithStack(err)
+}
+
+// addressColumns are the Columns that aren't seedColumns.
+var addressColumns = slices.DeleteFunc(slices.Clone(Columns), func(c string) bool { return slices.Contains(seedColumns, c) })
+
+// Flush writes the buffered seeds, the current one is kept until it is complete.
+func (e *treeEncoder) Flush() error {
+	return errors.WithStack(e.w.Flush())
+}
+
+// Close writes the current seed.
+func (e *treeEncoder) Close() error {
+	if len(e.seed) > 0 {
+		if err := e.writeSeed(); err != nil {
+			return err
+		}
+	}
+	return e.Flush()
+}
@@ -0,0 +1,104 @@
+// Package paperwallet renders a printable HTML sheet per wallet, with its address,
+// QR codes, mnemonic and derivation path. Sheets can be printed or saved as PDF from a browser.
+package paperwallet
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+
+	"github.com/planxnx/ethereum-wallet-generator/internal/output"
+	"github.com/planxnx/ethereum-wallet-generator/internal/qrcode"
+)
+
+// DefaultTemplate is the built-in sheet template.
+//
+//go:embed template.html
+var DefaultTemplate string
+
+// Sheet is the data available to sheet templates.
+type Sheet struct {
+	Address         string
+	ChecksumAddress string
+	PrivateKey      string
+	Mnemonic        string
+	HDPath          string
+	SeedLine        int
+	Index           int
+	AddressQR       template.HTML
+	PrivateKeyQR    template.HTML
+}
+
+// Writer renders sheets into a directory.
+type Writer struct {
+	dir  string
+	tmpl *template.Template
+
+	// Encrypt, if set, encrypts the sheets, named with EncryptedExt appended.
+	Encrypt      func(data []byte) ([]byte, error)
+	EncryptedExt string
+}
+
+// NewWriter creates dir if needed and returns a writer rendering sheets with the given
+// html/template source, or DefaultTemplate if it is empty.
+func NewWriter(dir, tmpl string) (*Writer, error) {
+	if tmpl == "" {
+		tmpl = DefaultTemplate
+	}
+	t, err := template.New("paperwallet").Parse(tmpl)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid paper wallet template")
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &Writer{dir: dir, tmpl: t}, nil
+}
+
+// Write renders the sheet of the record to <address>.html and returns its path.
+func (w *Writer) Write(r output.Record) (string, error) {
+	sheet := Sheet{
+		Address:         r.Wallet.Address,
+		ChecksumAddress: output.ChecksumAddress(r.Wallet),
+		PrivateKey:      r.Wallet.PrivateKey,
+		Mnemonic:        r.Mnemonic,
+		HDPath:          r.Wallet.HDPath,
+		SeedLine:        r.Line,
+		Index:           r.Index,
+	}
+
+	qr, err := qrcode.Encode(sheet.ChecksumAddress, qrcode.FormatSVG, 0)
+	if err != nil {
+		return "", err
+	}
+	sheet.AddressQR = template.HTML(qr)
+	if sheet.PrivateKey != "" {
+		qr, err := qrcode.Encode(sheet.PrivateKey, qrcode.FormatSVG, 0)
+		if err != nil {
+			return "", err
+		}
+		sheet.PrivateKeyQR = template.HTML(qr)
+	}
+
+	var buf bytes.Buffer
+	if err := w.tmpl.Execute(&buf, sheet); err != nil {
+		return "", errors.WithStack(err)
+	}
+	path := filepath.Join(w.dir, fmt.Sprintf("%s.html", r.Wallet.Address))
+	data := buf.Bytes()
+	if w.Encrypt != nil {
+		if data, err = w.Encrypt(data); err != nil {
+			return "", err
+		}
+		path += w.EncryptedExt
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return "", errors.WithStack(err)
+	}
+	return path, nil
+}
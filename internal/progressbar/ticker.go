@@ -0,0 +1,349 @@
+package progressbar
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+const (
+	// DefaultTickerInterval is the default refresh interval of the ticker progress bar.
+	DefaultTickerInterval = time.Second
+	// MinRedirectedInterval is the shortest interval between the status lines appended to an
+	// output that isn't a terminal, eg. a log file.
+	MinRedirectedInterval = 10 * time.Second
+
+	// meterWidth is the number of cells of a bar.
+	meterWidth = 24
+	// partNameWidth is the widest part name shown, longer ones keep their end.
+	partNameWidth = 32
+
+	// rateSmoothing is the weight of the newest sample in the rolling average rate.
+	rateSmoothing = 0.3
+)
+
+// Event is a progress update of the JSON ticker, one JSON line per interval.
+type Event struct {
+	Time      time.Time `json:"time"`
+	Processed int64     `json:"processed"`
+	// Total is the number of addresses of the run, 0 when unknown.
+	Total int64 `json:"total"`
+	// Rate is the rolling throughput in addresses per second.
+	Rate    float64 `json:"rate"`
+	Matches int64   `json:"matches"`
+	// Elapsed and ETA are in seconds, ETA is null while unknown.
+	Elapsed float64  `json:"elapsed"`
+	ETA     *float64 `json:"eta"`
+	// Done is set on the last event, written by Finish.
+	Done bool `json:"done,omitempty"`
+	// Parts is the progress of every part of the run, eg. of every seeds file.
+	Parts []PartEvent `json:"parts,omitempty"`
+}
+
+// PartEvent is the progress of a part of the run in an Event.
+type PartEvent struct {
+	Name      string `json:"name"`
+	Processed int64  `json:"processed"`
+	Total     int64  `json:"total"`
+}
+
+// PartsProgressBar is a progress bar also tracking the parts of a run, eg. its seeds files,
+// rendered below the overall bar.
+type PartsProgressBar interface {
+	ProgressBar
+	// AddPart adds a part of total increments.
+	AddPart(name string, total int)
+	// IncrementPart adds n increments to the part of the name, unknown names are ignored.
+	IncrementPart(name string, n int)
+}
+
+// part is a part of the run and its progress.
+type part struct {
+	name      string
+	total     int64
+	processed atomic.Int64
+}
+
+// tickerProgressBar renders a status line with rolling throughput, elapsed time, ETA and matches,
+// refreshed on a fixed interval instead of on every increment. On a terminal it is redrawn in
+// place along with a bar of the run and of every part, other outputs get a plain line per
+// interval.
+type tickerProgressBar struct {
+	out      io.Writer
+	total    int64
+	start    time.Time
+	interval time.Duration
+	// json writes an Event per interval instead of the status line.
+	json     bool
+	terminal bool
+
+	processed atomic.Int64
+	resolved  atomic.Int64
+
+	partsMu sync.Mutex
+	parts   []*part
+	byName  map[string]*part
+	// drawn is the number of lines of the last terminal render.
+	drawn int
+
+	rate         float64
+	lastCount    int64
+	lastTickTime time.Time
+
+	stop     chan struct{}
+	stopped  chan struct{}
+	stopOnce sync.Once
+}
+
+// NewTickerProgressBar returns a progress bar writing to out every interval, or every
+// MinRedirectedInterval at most when out isn't a terminal. total <= 0 means unknown.
+func NewTickerProgressBar(out io.Writer, total int, interval time.Duration) PartsProgressBar {
+	return newTickerProgressBar(out, total, interval, false)
+}
+
+// NewJSONTickerProgressBar returns a progress bar writing an Event as a JSON line to out every
+// interval, for the programs tracking a run. total <= 0 means unknown.
+func NewJSONTickerProgressBar(out io.Writer, total int, interval time.Duration) PartsProgressBar {
+	return newTickerProgressBar(out, total, interval, true)
+}
+
+func newTickerProgressBar(out io.Writer, total int, interval time.Duration, json bool) *tickerProgressBar {
+	if interval <= 0 {
+		interval = DefaultTickerInterval
+	}
+	terminal := !json && isTerminal(out)
+	if !json && !terminal {
+		interval = max(interval, MinRedirectedInterval)
+	}
+	now := time.Now()
+	bar := &tickerProgressBar{
+		out:          out,
+		total:        int64(total),
+		start:        now,
+		interval:     interval,
+		json:         json,
+		terminal:     terminal,
+		byName:       make(map[string]*part),
+		lastTickTime: now,
+		stop:         make(chan struct{}),
+		stopped:      make(chan struct{}),
+	}
+	go bar.run()
+	return bar
+}
+
+// Increment increment progress
+func (bar *tickerProgressBar) Increment() error {
+	bar.processed.Add(1)
+	return nil
+}
+
+// SetResolved set resolved wallet number
+func (bar *tickerProgressBar) SetResolved(resolved int) error {
+	bar.resolved.Store(int64(resolved))
+	return nil
+}
+
+func (bar *tickerProgressBar) AddPart(name string, total int) {
+	bar.partsMu.Lock()
+	defer bar.partsMu.Unlock()
+	p := &part{name: name, total: int64(total)}
+	bar.parts = append(bar.parts, p)
+	bar.byName[name] = p
+}
+
+func (bar *tickerProgressBar) IncrementPart(name string, n int) {
+	bar.partsMu.Lock()
+	p := bar.byName[name]
+	bar.partsMu.Unlock()
+	if p != nil {
+		p.processed.Add(int64(n))
+	}
+}
+
+// Finish close progress bar
+func (bar *tickerProgressBar) Finish() error {
+	bar.stopOnce.Do(func() {
+		close(bar.stop)
+		<-bar.stopped
+		if bar.json {
+			bar.renderJSON(time.Now(), true)
+			return
+		}
+		bar.render(time.Now())
+		if bar.terminal {
+			fmt.Fprintln(bar.out)
+		}
+	})
+	return nil
+}
+
+func (bar *tickerProgressBar) run() {
+	defer close(bar.stopped)
+	ticker := time.NewTicker(bar.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-bar.stop:
+			return
+		case now := <-ticker.C:
+			if bar.json {
+				bar.renderJSON(now, false)
+			} else {
+				bar.render(now)
+			}
+		}
+	}
+}
+
+// tick updates the rolling rate with the processed count at now and returns the count.
+func (bar *tickerProgressBar) tick(now time.Time) int64 {
+	processed := bar.processed.Load()
+	if dt := now.Sub(bar.lastTickTime).Seconds(); dt > 0 {
+		sample := float64(processed-bar.lastCount) / dt
+		if bar.lastCount == 0 && bar.rate == 0 {
+			bar.rate = sample
+		} else {
+			bar.rate = rateSmoothing*sample + (1-rateSmoothing)*bar.rate
+		}
+		bar.lastCount, bar.lastTickTime = processed, now
+	}
+	return processed
+}
+
+func (bar *tickerProgressBar) renderJSON(now time.Time, done bool) {
+	processed := bar.tick(now)
+	e := Event{
+		Time:      now.UTC(),
+		Processed: processed,
+		Total:     max(bar.total, 0),
+		Rate:      bar.rate,
+		Matches:   bar.resolved.Load(),
+		Elapsed:   now.Sub(bar.start).Seconds(),
+		Done:      done,
+	}
+	bar.partsMu.Lock()
+	for _, p := range bar.parts {
+		e.Parts = append(e.Parts, PartEvent{Name: p.name, Processed: p.processed.Load(), Total: p.total})
+	}
+	bar.partsMu.Unlock()
+	if done {
+		eta := 0.0
+		e.ETA = &eta
+	} else if bar.total > 0 && bar.rate > 0 {
+		eta := max(float64(bar.total-processed)/bar.rate, 0)
+		e.ETA = &eta
+	}
+	line, _ := json.Marshal(e)
+	_, _ = bar.out.Write(append(line, '\n'))
+}
+
+func (bar *tickerProgressBar) render(now time.Time) {
+	processed := bar.tick(now)
+
+	elapsed := now.Sub(bar.start).Round(time.Second)
+	eta := "?"
+	if bar.total > 0 && bar.rate > 0 {
+		remaining := float64(bar.total-processed) / bar.rate
+		eta = time.Duration(remaining * float64(time.Second)).Round(time.Second).String()
+	}
+
+	total := "?"
+	if bar.total > 0 {
+		total = fmt.Sprint(bar.total)
+	}
+	status := fmt.Sprintf("Processed %d/%s | %.1f addr/s | elapsed %s | ETA %s | matches %d",
+		processed, total, bar.rate, elapsed, eta, bar.resolved.Load())
+	if !bar.terminal {
+		fmt.Fprintln(bar.out, status)
+		return
+	}
+
+	lines := []string{status}
+	if bar.total > 0 {
+		lines[0] = meter(processed, bar.total) + " " + status
+	}
+	bar.partsMu.Lock()
+	nameWidth := 0
+	for _, p := range bar.parts {
+		nameWidth = max(nameWidth, min(utf8.RuneCountInString(p.name), partNameWidth))
+	}
+	for _, p := range bar.parts {
+		done := p.processed.Load()
+		lines = append(lines, fmt.Sprintf("  %-*s %s %d/%d", nameWidth, shortName(p.name), meter(done, p.total), done, p.total))
+	}
+	bar.partsMu.Unlock()
+
+	// the cursor is left at the end of the last line, lines wider than the terminal would wrap
+	// and throw the redraw off
+	width := terminalWidth(bar.out)
+	var b strings.Builder
+	b.WriteByte('\r')
+	if bar.drawn > 1 {
+		fmt.Fprintf(&b, "\033[%dA", bar.drawn-1)
+	}
+	for i, line := range lines {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString(truncate(line, width-1))
+		b.WriteString("\033[K")
+	}
+	bar.drawn = len(lines)
+	_, _ = io.WriteString(bar.out, b.String())
+}
+
+// meter returns the bar and percentage of done out of total.
+func meter(done, total int64) string {
+	ratio := 0.0
+	if total > 0 {
+		ratio = min(max(float64(done)/float64(total), 0), 1)
+	}
+	filled := int(ratio * meterWidth)
+	return "[" + strings.Repeat("█", filled) + strings.Repeat("░", meterWidth-filled) + fmt.Sprintf("] %5.1f%%", ratio*100)
+}
+
+// shortName returns the part name cut to partNameWidth, keeping its end.
+func shortName(name string) string {
+	runes := []rune(name)
+	if len(runes) <= partNameWidth {
+		return name
+	}
+	return "…" + string(runes[len(runes)-partNameWidth+1:])
+}
+
+// truncate cuts line to width runes, not at all if width isn't positive.
+func truncate(line string, width int) string {
+	if width <= 0 || utf8.RuneCountInString(line) <= width {
+		return line
+	}
+	return string([]rune(line)[:width])
+}
+
+// isTerminal reports whether out is a terminal able to redraw the bars in place.
+func isTerminal(out io.Writer) bool {
+	f, ok := out.(*os.File)
+	return ok && term.IsTerminal(int(f.Fd())) && os.Getenv("TERM") != "dumb"
+}
+
+// terminalWidth returns the width of the terminal of out, 0 if unknown.
+func terminalWidth(out io.Writer) int {
+	f, ok := out.(*os.File)
+	if !ok {
+		return 0
+	}
+	width, _, err := term.GetSize(int(f.Fd()))
+	if err != nil {
+		return 0
+	}
+	return width
+}
@@ -0,0 +1,122 @@
+// Package qrcode renders QR codes of wallet addresses and private keys as PNG or SVG images.
+package qrcode
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	qr "github.com/skip2/go-qrcode"
+
+	"github.com/planxnx/ethereum-wallet-generator/wallets"
+)
+
+// Image formats.
+const (
+	FormatPNG = "png"
+	FormatSVG = "svg"
+)
+
+// QR code contents.
+const (
+	ContentAddress    = "address"
+	ContentPrivateKey = "private-key"
+	ContentBoth       = "both"
+)
+
+// DefaultSize is the default width and height of PNG images in pixels.
+const DefaultSize = 256
+
+// Encode renders content as a QR code image of the given format. size is the PNG width in pixels
+// and is ignored for SVG images.
+func Encode(content, format string, size int) ([]byte, error) {
+	code, err := qr.New(content, qr.Medium)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	switch format {
+	case FormatPNG:
+		png, err := code.PNG(size)
+		return png, errors.WithStack(err)
+	case FormatSVG:
+		return svg(code.Bitmap()), nil
+	default:
+		return nil, errors.Errorf("unknown QR code format %q, must be %s or %s", format, FormatPNG, FormatSVG)
+	}
+}
+
+// svg renders the QR code bitmap as a scalable image with one unit per module.
+func svg(bitmap [][]bool) []byte {
+	var buf bytes.Buffer
+	n := len(bitmap)
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" shape-rendering="crispEdges">`, n, n)
+	fmt.Fprintf(&buf, `<rect width="%d" height="%d" fill="#fff"/><path fill="#000" d="`, n, n)
+	for y, row := range bitmap {
+		for x, dark := range row {
+			if dark {
+				fmt.Fprintf(&buf, "M%d %dh1v1h-1z", x, y)
+			}
+		}
+	}
+	buf.WriteString(`"/></svg>`)
+	return buf.Bytes()
+}
+
+// Writer renders the QR codes of wallets into a directory.
+type Writer struct {
+	dir     string
+	format  string
+	content string
+	size    int
+
+	// Encrypt, if set, encrypts the images, named with EncryptedExt appended.
+	Encrypt      func(data []byte) ([]byte, error)
+	EncryptedExt string
+}
+
+// NewWriter creates dir if needed and returns a writer of QR codes with the given format and content.
+func NewWriter(dir, format, content string, size int) (*Writer, error) {
+	if format != FormatPNG && format != FormatSVG {
+		return nil, errors.Errorf("unknown QR code format %q, must be %s or %s", format, FormatPNG, FormatSVG)
+	}
+	if content != ContentAddress && content != ContentPrivateKey && content != ContentBoth {
+		return nil, errors.Errorf("unknown QR code content %q, must be %s, %s or %s", content, ContentAddress, ContentPrivateKey, ContentBoth)
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &Writer{dir: dir, format: format, content: content, size: size}, nil
+}
+
+// Write renders the QR codes of the wallet, named <address>-<content>.<format>.
+func (w *Writer) Write(wallet *wallets.Wallet) error {
+	if w.content != ContentPrivateKey {
+		if err := w.write(wallet.Address, ContentAddress, wallet.Address); err != nil {
+			return err
+		}
+	}
+	if w.content != ContentAddress && wallet.PrivateKey != "" {
+		if err := w.write(wallet.Address, ContentPrivateKey, wallet.PrivateKey); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *Writer) write(address, kind, content string) error {
+	img, err := Encode(content, w.format, w.size)
+	if err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%s.%s", address, kind, w.format)
+	if w.Encrypt != nil {
+		if img, err = w.Encrypt(img); err != nil {
+			return err
+		}
+		name += w.EncryptedExt
+	}
+	return errors.WithStack(os.WriteFile(filepath.Join(w.dir, name), img, 0o600))
+}
@@ -0,0 +1,89 @@
+package queue
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/sasl/plain"
+)
+
+// kafkaConsumer consumes a Kafka topic in a consumer group, committing the offsets of the
+// acknowledged records only. The records of a worker that stops before committing are read
+// again by the group.
+type kafkaConsumer struct {
+	client   *kgo.Client
+	buffered []*kgo.Record
+}
+
+func openKafka(u *url.URL, topic string, query url.Values) (*kafkaConsumer, error) {
+	group := query.Get("group")
+	if group == "" {
+		group = DefaultGroup
+	}
+	opts := []kgo.Opt{
+		kgo.SeedBrokers(strings.Split(u.Host, ",")...),
+		kgo.ConsumerGroup(group),
+		kgo.ConsumeTopics(topic),
+		kgo.DisableAutoCommit(),
+	}
+	if query.Get("tls") == "true" {
+		opts = append(opts, kgo.DialTLSConfig(&tls.Config{MinVersion: tls.VersionTLS12}))
+	}
+	if u.User != nil {
+		password, _ := u.User.Password()
+		opts = append(opts, kgo.SASL(plain.Auth{User: u.User.Username(), Pass: password}.AsMechanism()))
+	}
+	client, err := kgo.NewClient(opts...)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid kafka URL")
+	}
+	return &kafkaConsumer{client: client}, nil
+}
+
+func (c *kafkaConsumer) Receive(ctx context.Context, wait time.Duration) (*Message, error) {
+	if len(c.buffered) == 0 {
+		pollCtx, cancel := context.WithTimeout(ctx, wait)
+		fetches := c.client.PollFetches(pollCtx)
+		cancel()
+		if fetches.IsClientClosed() {
+			return nil, errors.New("kafka client closed")
+		}
+		for _, fe := range fetches.Errors() {
+			if !errors.Is(fe.Err, context.DeadlineExceeded) && !errors.Is(fe.Err, context.Canceled) {
+				return nil, errors.Wrapf(fe.Err, "failed to fetch %s partition %d", fe.Topic, fe.Partition)
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		c.buffered = fetches.Records()
+	}
+	if len(c.buffered) == 0 {
+		return nil, nil
+	}
+	r := c.buffered[0]
+	c.buffered = c.buffered[1:]
+	return &Message{ID: fmt.Sprintf("%d/%d", r.Partition, r.Offset), Body: r.Value, handle: r}, nil
+}
+
+func (c *kafkaConsumer) Ack(ctx context.Context, msgs []*Message) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+	records := make([]*kgo.Record, len(msgs))
+	for i, m := range msgs {
+		records[i] = m.handle.(*kgo.Record)
+	}
+	return errors.WithStack(c.client.CommitRecords(ctx, records...))
+}
+
+func (c *kafkaConsumer) Close() error {
+	c.client.Close()
+	return nil
+}
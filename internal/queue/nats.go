@@ -0,0 +1,84 @@
+package queue
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/pkg/errors"
+)
+
+// natsConsumer pulls the messages of a JetStream stream with a durable consumer shared by
+// the workers. A message not acknowledged within ack-wait is delivered again.
+type natsConsumer struct {
+	conn     *nats.Conn
+	consumer jetstream.Consumer
+}
+
+func openNATS(ctx context.Context, u *url.URL, stream string, query url.Values) (*natsConsumer, error) {
+	ackWait, err := duration(query, "ack-wait", 10*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	natsURL := url.URL{Scheme: u.Scheme, User: u.User, Host: u.Host}
+	conn, err := nats.Connect(natsURL.String())
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to connect to %s", natsURL.Redacted())
+	}
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, errors.WithStack(err)
+	}
+	durable := query.Get("durable")
+	if durable == "" {
+		durable = DefaultGroup
+	}
+	consumer, err := js.CreateOrUpdateConsumer(ctx, stream, jetstream.ConsumerConfig{
+		Durable:       durable,
+		FilterSubject: query.Get("subject"),
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		AckWait:       ackWait,
+	})
+	if err != nil {
+		conn.Close()
+		return nil, errors.Wrapf(err, "failed to create the consumer of stream %s", stream)
+	}
+	return &natsConsumer{conn: conn, consumer: consumer}, nil
+}
+
+func (c *natsConsumer) Receive(ctx context.Context, wait time.Duration) (*Message, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	msg, err := c.consumer.Next(jetstream.FetchMaxWait(max(wait, time.Second)))
+	if errors.Is(err, nats.ErrTimeout) || errors.Is(err, jetstream.ErrNoMessages) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	id := ""
+	if meta, err := msg.Metadata(); err == nil {
+		id = strconv.FormatUint(meta.Sequence.Stream, 10)
+	}
+	return &Message{ID: id, Body: msg.Data(), handle: msg}, nil
+}
+
+func (c *natsConsumer) Ack(ctx context.Context, msgs []*Message) error {
+	for _, m := range msgs {
+		// wait for the server to confirm, an ack lost on the way redelivers the message
+		if err := m.handle.(jetstream.Msg).DoubleAck(ctx); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+func (c *natsConsumer) Close() error {
+	c.conn.Close()
+	return nil
+}
@@ -0,0 +1,86 @@
+// Package queue consumes the seeds or work units of a run from a message queue: a Redis
+// stream, a NATS JetStream stream or a Kafka topic. Messages are acknowledged only once
+// their results are durable, so the ones of a crashed worker are delivered again.
+package queue
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultGroup is the consumer group, or durable consumer, shared by the workers of a fleet.
+const DefaultGroup = "ethereum-wallet-generator"
+
+// Schemes lists the supported queue URL schemes.
+var Schemes = []string{"redis", "rediss", "nats", "kafka"}
+
+// Message is a message consumed from a queue.
+type Message struct {
+	// ID locates the message in its queue, eg. the stream entry ID or partition and offset.
+	ID   string
+	Body []byte
+
+	handle any
+}
+
+// Consumer receives the messages of a queue as a member of a consumer group.
+type Consumer interface {
+	// Receive returns the next message, or nil if none came within wait.
+	Receive(ctx context.Context, wait time.Duration) (*Message, error)
+	// Ack acknowledges messages whose results are durable, they won't be delivered again.
+	Ack(ctx context.Context, msgs []*Message) error
+	Close() error
+}
+
+// Open connects to the queue of a URL:
+//
+//	redis://[user:pass@]host:6379/STREAM[?group=G&consumer=C&db=0&claim-idle=10m]
+//	nats://[user:pass@]host:4222/STREAM[?durable=D&subject=S&ack-wait=10m]
+//	kafka://[user:pass@]broker1:9092,broker2:9092/TOPIC[?group=G&tls=true]
+//
+// rediss:// connects to Redis over TLS, a Kafka user and password authenticate with SASL PLAIN.
+func Open(ctx context.Context, spec string) (Consumer, error) {
+	u, err := url.Parse(spec)
+	if err != nil {
+		return nil, errors.Errorf("invalid queue URL %q", spec)
+	}
+	name := strings.TrimPrefix(u.Path, "/")
+	if name == "" || u.Host == "" {
+		return nil, errors.Errorf("queue URL %s lacks its host or stream/topic name", u.Redacted())
+	}
+	query := u.Query()
+	switch u.Scheme {
+	case "redis", "rediss":
+		return openRedis(ctx, u, name, query)
+	case "nats":
+		return openNATS(ctx, u, name, query)
+	case "kafka":
+		return openKafka(u, name, query)
+	}
+	return nil, errors.Errorf("unknown queue scheme %q, must be one of %v", u.Scheme, Schemes)
+}
+
+// consumerName returns the name of this process in its consumer group.
+func consumerName() string {
+	host, _ := os.Hostname()
+	return host + "-" + strconv.Itoa(os.Getpid())
+}
+
+// duration returns the duration of a query parameter, def if it's missing.
+func duration(query url.Values, key string, def time.Duration) (time.Duration, error) {
+	v := query.Get(key)
+	if v == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, errors.Errorf("invalid %s %q", key, v)
+	}
+	return d, nil
+}
@@ -0,0 +1,141 @@
+package queue
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+)
+
+// redisFetch is the number of stream entries read at once.
+const redisFetch = 100
+
+// redisConsumer reads a Redis stream in a consumer group. It first reads the entries it was
+// delivered but didn't acknowledge before a restart, then claims the ones other consumers
+// left pending for claim-idle, then reads new entries.
+type redisConsumer struct {
+	client    *redis.Client
+	stream    string
+	group     string
+	consumer  string
+	claimIdle time.Duration
+
+	// pendingFrom is the ID after which the pending entries are read, empty once they are.
+	pendingFrom string
+	claimAt     time.Time
+	buffered    []*Message
+}
+
+func openRedis(ctx context.Context, u *url.URL, stream string, query url.Values) (*redisConsumer, error) {
+	claimIdle, err := duration(query, "claim-idle", 10*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	// the stream is the path of the URL, the DB a parameter
+	redisURL := url.URL{Scheme: u.Scheme, User: u.User, Host: u.Host, Path: "/" + query.Get("db")}
+	opts, err := redis.ParseURL(redisURL.String())
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid redis URL")
+	}
+	c := &redisConsumer{
+		client:      redis.NewClient(opts),
+		stream:      stream,
+		group:       query.Get("group"),
+		consumer:    query.Get("consumer"),
+		claimIdle:   claimIdle,
+		pendingFrom: "0",
+	}
+	if c.group == "" {
+		c.group = DefaultGroup
+	}
+	if c.consumer == "" {
+		c.consumer = consumerName()
+	}
+	err = c.client.XGroupCreateMkStream(ctx, stream, c.group, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		c.client.Close()
+		return nil, errors.Wrapf(err, "failed to create the consumer group of stream %s", stream)
+	}
+	return c, nil
+}
+
+func (c *redisConsumer) Receive(ctx context.Context, wait time.Duration) (*Message, error) {
+	if len(c.buffered) == 0 {
+		if err := c.fetch(ctx, wait); err != nil {
+			return nil, err
+		}
+	}
+	if len(c.buffered) == 0 {
+		return nil, nil
+	}
+	m := c.buffered[0]
+	c.buffered = c.buffered[1:]
+	return m, nil
+}
+
+func (c *redisConsumer) fetch(ctx context.Context, wait time.Duration) error {
+	if c.pendingFrom != "" {
+		// entries delivered to this consumer before it restarted
+		streams, err := c.client.XReadGroup(ctx, &redis.XReadGroupArgs{Group: c.group, Consumer: c.consumer, Streams: []string{c.stream, c.pendingFrom}, Count: redisFetch, Block: -1}).Result()
+		if err != nil && err != redis.Nil {
+			return errors.WithStack(err)
+		}
+		c.add(streams)
+		if len(c.buffered) > 0 {
+			c.pendingFrom = c.buffered[len(c.buffered)-1].ID
+			return nil
+		}
+		c.pendingFrom = ""
+	}
+	if c.claimIdle > 0 && time.Now().After(c.claimAt) {
+		c.claimAt = time.Now().Add(c.claimIdle / 2)
+		entries, _, err := c.client.XAutoClaim(ctx, &redis.XAutoClaimArgs{Stream: c.stream, Group: c.group, Consumer: c.consumer, MinIdle: c.claimIdle, Start: "0", Count: redisFetch}).Result()
+		if err != nil && err != redis.Nil {
+			return errors.WithStack(err)
+		}
+		c.add([]redis.XStream{{Messages: entries}})
+		if len(c.buffered) > 0 {
+			return nil
+		}
+	}
+	streams, err := c.client.XReadGroup(ctx, &redis.XReadGroupArgs{Group: c.group, Consumer: c.consumer, Streams: []string{c.stream, ">"}, Count: redisFetch, Block: max(wait, time.Millisecond)}).Result()
+	if err != nil && err != redis.Nil {
+		return errors.WithStack(err)
+	}
+	c.add(streams)
+	return nil
+}
+
+// add buffers the entries of streams, their body the data field or else their only field.
+func (c *redisConsumer) add(streams []redis.XStream) {
+	for _, s := range streams {
+		for _, entry := range s.Messages {
+			body, ok := entry.Values["data"]
+			if !ok && len(entry.Values) == 1 {
+				for _, v := range entry.Values {
+					body = v
+				}
+			}
+			data, _ := body.(string)
+			c.buffered = append(c.buffered, &Message{ID: entry.ID, Body: []byte(data)})
+		}
+	}
+}
+
+func (c *redisConsumer) Ack(ctx context.Context, msgs []*Message) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+	ids := make([]string, len(msgs))
+	for i, m := range msgs {
+		ids[i] = m.ID
+	}
+	return errors.WithStack(c.client.XAck(ctx, c.stream, c.group, ids...).Err())
+}
+
+func (c *redisConsumer) Close() error {
+	return errors.WithStack(c.client.Close())
+}
@@ -0,0 +1,98 @@
+// Package redact scrubs what looks like a secret out of log lines and error messages:
+// BIP39 mnemonics, hex, WIF and base58 private keys, extended and age secret keys, and
+// the passphrases given to the program.
+package redact
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/planxnx/ethereum-wallet-generator/bip39"
+)
+
+// Replacement is what a redacted secret is replaced by.
+const Replacement = "[REDACTED]"
+
+// minMnemonicWords is the length of the shortest BIP39 mnemonic, fewer words in a row are kept.
+const minMnemonicWords = 12
+
+// minSecretLength is the length of the shortest passphrase scrubbed, shorter ones would
+// redact common words.
+const minSecretLength = 4
+
+// keyPatterns match the encodings of private keys: 64 hex digits, base58 strings of at least
+// 50 characters (WIF keys, Solana keypairs, extended keys) and the known secret key prefixes.
+var keyPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`\b(0x)?[0-9a-fA-F]{64}\b`),
+	regexp.MustCompile(`\b[1-9A-HJ-NP-Za-km-z]{50,}\b`),
+	regexp.MustCompile(`\b(xprv|tprv|yprv|zprv|addr_xsk|ed25519e?_sk|AGE-SECRET-KEY-)[0-9A-Za-z]+`),
+}
+
+// keyValue matches the key=value pairs naming a secret and userinfo the password of a
+// URL, of DSNs eg.
+var (
+	keyValue = regexp.MustCompile(`(?i)\b(passphrase|password|passwd|secret|token|mnemonic|private_key|privatekey)=("[^"]*"|[^\s&;]+)`)
+	userinfo = regexp.MustCompile(`(://[^:/@\s]+:)[^@\s]+@`)
+)
+
+// Redactor scrubs the secrets out of strings.
+type Redactor struct {
+	// secrets are the literal values scrubbed, longest first.
+	secrets []string
+}
+
+// New returns a redactor scrubbing the patterns of secrets, and the literal secrets given.
+func New(secrets ...string) *Redactor {
+	r := &Redactor{}
+	for _, s := range secrets {
+		if len(s) >= minSecretLength {
+			r.secrets = append(r.secrets, s)
+		}
+	}
+	slices.SortFunc(r.secrets, func(a, b string) int { return len(b) - len(a) })
+	return r
+}
+
+// String returns s with its secrets replaced by Replacement.
+func (r *Redactor) String(s string) string {
+	for _, secret := range r.secrets {
+		s = strings.ReplaceAll(s, secret, Replacement)
+	}
+	for _, p := range keyPatterns {
+		s = p.ReplaceAllString(s, Replacement)
+	}
+	s = keyValue.ReplaceAllString(s, "${1}="+Replacement)
+	s = userinfo.ReplaceAllString(s, "${1}"+Replacement+"@")
+	return redactMnemonics(s)
+}
+
+// wordRuns match the runs of at least minMnemonicWords whitespace separated words, and words
+// the words of a run.
+var (
+	wordRuns = regexp.MustCompile(`[A-Za-z]+(?:\s+[A-Za-z]+){11,}`)
+	words    = regexp.MustCompile(`[A-Za-z]+`)
+)
+
+// redactMnemonics replaces the runs of at least minMnemonicWords BIP39 words of s.
+func redactMnemonics(s string) string {
+	return wordRuns.ReplaceAllStringFunc(s, func(run string) string {
+		idx := words.FindAllStringIndex(run, -1)
+		var out strings.Builder
+		written := 0
+		for i := 0; i < len(idx); {
+			j := i
+			for j < len(idx) && bip39.IsWord(strings.ToLower(run[idx[j][0]:idx[j][1]])) {
+				j++
+			}
+			if j-i >= minMnemonicWords {
+				out.WriteString(run[written:idx[i][0]])
+				out.WriteString(Replacement)
+				written = idx[j-1][1]
+			}
+			i = max(j, i+1)
+		}
+		out.WriteString(run[written:])
+		return out.String()
+	})
+}
@@ -0,0 +1,58 @@
+package redact
+
+import "testing"
+
+func TestString(t *testing.T) {
+	r := New("correct horse battery", "pw")
+	testCases := map[string]struct {
+		input, expected string
+	}{
+		"mnemonic": {
+			input:    `failed to derive "legal winner thank year wave sausage worth useful legal winner thank yellow": invalid path`,
+			expected: `failed to derive "[REDACTED]": invalid path`,
+		},
+		"short word run": {
+			input:    "the seed file has one mnemonic per line and no header at all here",
+			expected: "the seed file has one mnemonic per line and no header at all here",
+		},
+		"hex key": {
+			input:    "UNIQUE constraint failed: 0x1ab42cc412b618bdea3a599e3c9bae199ebf030895b039e9db1e30dafb12b727",
+			expected: "UNIQUE constraint failed: [REDACTED]",
+		},
+		"address kept": {
+			input:    "DB save failed address=0x6fac4d18c912343bf86fa7049364dd4e424ab9c0",
+			expected: "DB save failed address=0x6fac4d18c912343bf86fa7049364dd4e424ab9c0",
+		},
+		"wif": {
+			input:    "stored KwdMAjGmerYanjeui5SHS7JkmpZvVipYvB2LJGU1ZxJwYvP98617",
+			expected: "stored [REDACTED]",
+		},
+		"passphrase": {
+			input:    "open wallets.db: wrong key correct horse battery",
+			expected: "open wallets.db: wrong key [REDACTED]",
+		},
+		"short passphrase kept": {
+			input:    "pw",
+			expected: "pw",
+		},
+		"key value": {
+			input:    `dial: password=hunter22 host=db`,
+			expected: `dial: password=[REDACTED] host=db`,
+		},
+		"dsn": {
+			input:    "failed to connect to postgres://ewg:s3cr3t@db:5432/wallets",
+			expected: "failed to connect to postgres://ewg:[REDACTED]@db:5432/wallets",
+		},
+		"age key": {
+			input:    "AGE-SECRET-KEY-1QQPVUF8CCZ4K4QZ0X9NQMZ2Q7JCZ8N6Y7SXN0YH3DRKR2QXQVS5Q0C2ZCZ",
+			expected: "[REDACTED]",
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if actual := r.String(tc.input); actual != tc.expected {
+				t.Errorf("String(%q) = %q, want %q", tc.input, actual, tc.expected)
+			}
+		})
+	}
+}
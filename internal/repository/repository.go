@@ -1,9 +0,0 @@
-package repository
-
-import "github.com/planxnx/ethereum-wallet-generator/wallets"
-
-type Repository interface {
-	Insert(wallet *wallets.Wallet) error
-	Result() []*wallets.Wallet
-	Close() error
-}
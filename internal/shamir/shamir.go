@@ -0,0 +1,126 @@
+// Package shamir splits secrets into k-of-n Shamir shares over GF(2^8), so any k shares
+// rebuild the secret and fewer tell nothing about it.
+package shamir
+
+import (
+	"crypto/rand"
+
+	"github.com/pkg/errors"
+)
+
+// MaxShares is the most shares of a secret, their x coordinates being the non zero bytes.
+const MaxShares = 255
+
+// Split splits secret into n shares, any k of which rebuild it. Each share holds one byte
+// per byte of the secret followed by its x coordinate.
+func Split(secret []byte, k, n int) ([][]byte, error) {
+	if k < 2 || k > n || n > MaxShares {
+		return nil, errors.Errorf("invalid %d-of-%d split, want 2 <= k <= n <= %d", k, n, MaxShares)
+	}
+	if len(secret) == 0 {
+		return nil, errors.New("empty secret")
+	}
+	shares := make([][]byte, n)
+	for i := range shares {
+		shares[i] = make([]byte, len(secret)+1)
+		shares[i][len(secret)] = byte(i + 1)
+	}
+	// one random polynomial of degree k-1 per byte, its constant term the byte
+	coeffs := make([]byte, k)
+	for b, s := range secret {
+		if _, err := rand.Read(coeffs[1:]); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		coeffs[0] = s
+		for _, share := range shares {
+			share[b] = evaluate(coeffs, share[len(secret)])
+		}
+	}
+	clear(coeffs)
+	return shares, nil
+}
+
+// Combine rebuilds the secret of at least k of its shares. Fewer shares return garbage
+// rather than an error, nothing in them tells the threshold.
+func Combine(shares [][]byte) ([]byte, error) {
+	if len(shares) < 2 {
+		return nil, errors.New("at least 2 shares required")
+	}
+	size := len(shares[0])
+	if size < 2 {
+		return nil, errors.New("invalid share")
+	}
+	xs := make([]byte, len(shares))
+	seen := map[byte]bool{}
+	for i, share := range shares {
+		if len(share) != size {
+			return nil, errors.New("the shares differ in length")
+		}
+		x := share[size-1]
+		if x == 0 || seen[x] {
+			return nil, errors.Errorf("invalid or duplicate share %d", x)
+		}
+		seen[x] = true
+		xs[i] = x
+	}
+	secret := make([]byte, size-1)
+	ys := make([]byte, len(shares))
+	for b := range secret {
+		for i, share := range shares {
+			ys[i] = share[b]
+		}
+		secret[b] = interpolate(xs, ys)
+	}
+	return secret, nil
+}
+
+// evaluate returns the polynomial of coeffs, lowest degree first, at x.
+func evaluate(coeffs []byte, x byte) byte {
+	var y byte
+	for i := len(coeffs) - 1; i >= 0; i-- {
+		y = mul(y, x) ^ coeffs[i]
+	}
+	return y
+}
+
+// interpolate returns the value at 0 of the Lagrange polynomial through the points xs, ys.
+func interpolate(xs, ys []byte) byte {
+	var y byte
+	for i := range xs {
+		basis := byte(1)
+		for j := range xs {
+			if i != j {
+				// x_j / (x_j - x_i), subtraction being xor
+				basis = mul(basis, div(xs[j], xs[i]^xs[j]))
+			}
+		}
+		y ^= mul(ys[i], basis)
+	}
+	return y
+}
+
+// mul multiplies in GF(2^8) modulo the AES polynomial x^8 + x^4 + x^3 + x + 1.
+func mul(a, b byte) byte {
+	var p byte
+	for b != 0 {
+		if b&1 != 0 {
+			p ^= a
+		}
+		carry := a & 0x80
+		a <<= 1
+		if carry != 0 {
+			a ^= 0x1b
+		}
+		b >>= 1
+	}
+	return p
+}
+
+// div divides a by the non zero b, multiplying by its inverse b^254.
+func div(a, b byte) byte {
+	inv := byte(1)
+	for range 254 {
+		inv = mul(inv, b)
+	}
+	return mul(a, inv)
+}
@@ -0,0 +1,36 @@
+package shamir
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSplitCombine(t *testing.T) {
+	secret := []byte("4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318")
+	shares, err := Split(secret, 3, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, subset := range [][]int{{0, 1, 2}, {4, 2, 0}, {1, 3, 4}, {0, 1, 2, 3, 4}} {
+		var picked [][]byte
+		for _, i := range subset {
+			picked = append(picked, shares[i])
+		}
+		got, err := Combine(picked)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, secret) {
+			t.Errorf("shares %v rebuilt %q", subset, got)
+		}
+	}
+	if got, _ := Combine(shares[:2]); bytes.Equal(got, secret) {
+		t.Error("2 shares of a 3-of-5 split rebuilt the secret")
+	}
+	if _, err := Combine([][]byte{shares[0], shares[0]}); err == nil {
+		t.Error("duplicate shares combined")
+	}
+	if _, err := Split(secret, 4, 3); err == nil {
+		t.Error("4-of-3 split accepted")
+	}
+}
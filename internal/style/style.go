@@ -0,0 +1,54 @@
+// Package style colors the output shown on a terminal with ANSI escapes: matches in green,
+// warnings in yellow and errors in red. Redirected output and NO_COLOR environments stay plain.
+package style
+
+import (
+	"os"
+
+	"github.com/pkg/errors"
+	"golang.org/x/term"
+)
+
+// Style is the SGR parameters of a span of text.
+type Style string
+
+// Styles of the output.
+const (
+	Match   Style = "1;32"
+	Warning Style = "33"
+	Error   Style = "1;31"
+	Heading Style = "1"
+)
+
+// Modes of the -color flag.
+const (
+	ModeAuto   = "auto"
+	ModeAlways = "always"
+	ModeNever  = "never"
+)
+
+// Enabled reports whether the output to f is colored in mode. Auto colors it when f is a
+// terminal, NO_COLOR is unset or empty and TERM isn't dumb.
+func Enabled(mode string, f *os.File) (bool, error) {
+	switch mode {
+	case ModeAlways:
+		return true, nil
+	case ModeNever:
+		return false, nil
+	case ModeAuto, "":
+		if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+			return false, nil
+		}
+		return term.IsTerminal(int(f.Fd())), nil
+	default:
+		return false, errors.Errorf("invalid --color %q, must be %s, %s or %s", mode, ModeAuto, ModeAlways, ModeNever)
+	}
+}
+
+// Paint returns text in the style s, text itself when on is false.
+func Paint(on bool, s Style, text string) string {
+	if !on || text == "" {
+		return text
+	}
+	return "\x1b[" + string(s) + "m" + text + "\x1b[0m"
+}
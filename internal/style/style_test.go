@@ -0,0 +1,34 @@
+package style
+
+import (
+	"os"
+	"testing"
+)
+
+func TestEnabled(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("TERM", "xterm")
+	for mode, expected := range map[string]bool{ModeAuto: false, ModeAlways: true, ModeNever: false} {
+		if on, err := Enabled(mode, f); err != nil || on != expected {
+			t.Errorf("Enabled(%q) = %t, %v, expected %t", mode, on, err, expected)
+		}
+	}
+	if _, err := Enabled("sometimes", f); err == nil {
+		t.Error("expected an error for an unknown mode")
+	}
+}
+
+func TestPaint(t *testing.T) {
+	if got := Paint(true, Match, "MATCH:"); got != "\x1b[1;32mMATCH:\x1b[0m" {
+		t.Errorf("Paint() = %q", got)
+	}
+	if got := Paint(false, Match, "MATCH:"); got != "MATCH:" {
+		t.Errorf("Paint(false) = %q", got)
+	}
+}
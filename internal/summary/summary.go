@@ -0,0 +1,132 @@
+// Package summary reports the totals of a run once it ends.
+package summary
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/planxnx/ethereum-wallet-generator/internal/style"
+)
+
+// Summary is the end of run report.
+type Summary struct {
+	// RunID is the ID of the run recorded in the DB, if any.
+	RunID     string `json:"run_id,omitempty"`
+	Seeds     int64  `json:"seeds_processed"`
+	Addresses int64  `json:"addresses_derived"`
+	// Skipped is the number of addresses skipped as already stored, counted in Addresses.
+	Skipped   int64   `json:"addresses_skipped,omitempty"`
+	Matches   int64   `json:"matches"`
+	MatchRate float64 `json:"match_rate"`
+	Failures  int64   `json:"failures"`
+	// SlowSeeds is the number of seeds flagged as slow to derive.
+	SlowSeeds int64 `json:"slow_seeds,omitempty"`
+	// Patterns are the matches of every filter pattern on its own, when tallied.
+	Patterns []PatternMatches `json:"pattern_matches,omitempty"`
+	// Duplicates is the number of input seeds repeating an earlier one, when looked for.
+	Duplicates int64 `json:"duplicate_seeds,omitempty"`
+	// DuplicatesSkipped is set when the duplicates were not processed.
+	DuplicatesSkipped bool    `json:"duplicates_skipped,omitempty"`
+	Elapsed           string  `json:"elapsed"`
+	Seconds           float64 `json:"elapsed_seconds"`
+	Throughput        float64 `json:"addresses_per_second"`
+	Completed         bool    `json:"completed"`
+	Config            any     `json:"config"`
+
+	start time.Time
+}
+
+// PatternMatches is the number of addresses a filter pattern matched.
+type PatternMatches struct {
+	Pattern string `json:"pattern"`
+	Matches int64  `json:"matches"`
+}
+
+// New starts the clock of a run using the given effective configuration.
+func New(config any) *Summary {
+	return &Summary{Config: config, start: time.Now()}
+}
+
+// Finish stops the clock and computes the derived rates.
+func (s *Summary) Finish(completed bool) {
+	elapsed := time.Since(s.start)
+	s.Elapsed = elapsed.Round(time.Millisecond).String()
+	s.Seconds = elapsed.Seconds()
+	s.Completed = completed
+	if s.Addresses > 0 {
+		s.MatchRate = float64(s.Matches) / float64(s.Addresses)
+	}
+	if s.Seconds > 0 {
+		s.Throughput = float64(s.Addresses) / s.Seconds
+	}
+}
+
+// Print writes the human readable report to w, its matches, failures and slow seeds styled
+// for a terminal when color is set.
+func (s *Summary) Print(w io.Writer, color bool) error {
+	config, err := json.Marshal(s.Config)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	var runID string
+	if s.RunID != "" {
+		runID = fmt.Sprintf("  Run ID:            %s\n", s.RunID)
+	}
+	var skipped string
+	if s.Skipped > 0 {
+		skipped = fmt.Sprintf("  Already stored:    %d\n", s.Skipped)
+	}
+	var patterns strings.Builder
+	for _, p := range s.Patterns {
+		rate := 0.0
+		if s.Addresses > 0 {
+			rate = float64(p.Matches) / float64(s.Addresses)
+		}
+		fmt.Fprintf(&patterns, "    %-22s %d (%.6f%%)\n", p.Pattern, p.Matches, rate*100)
+	}
+	var slow string
+	if s.SlowSeeds > 0 {
+		slow = style.Paint(color, style.Warning, fmt.Sprintf("  Slow seeds:        %d", s.SlowSeeds)) + "\n"
+	}
+	var duplicates string
+	if s.Duplicates > 0 {
+		duplicates = fmt.Sprintf("  Duplicate seeds:   %d\n", s.Duplicates)
+		if s.DuplicatesSkipped {
+			duplicates = fmt.Sprintf("  Duplicate seeds:   %d (skipped)\n", s.Duplicates)
+		}
+	}
+	matches := fmt.Sprintf("  Matches:           %d (%.6f%%)", s.Matches, s.MatchRate*100)
+	if s.Matches > 0 {
+		matches = style.Paint(color, style.Match, matches)
+	}
+	failures := fmt.Sprintf("  Failures:          %d", s.Failures)
+	if s.Failures > 0 {
+		failures = style.Paint(color, style.Error, failures)
+	}
+	_, err = fmt.Fprintf(w, `%s
+%s  Seeds processed:   %d
+  Addresses derived: %d
+%s%s
+%s%s
+%s%s  Elapsed:           %s
+  Throughput:        %.1f addr/s
+  Completed:         %t
+  Config:            %s
+`, style.Paint(color, style.Heading, "Summary:"), runID, s.Seeds, s.Addresses, skipped, matches, patterns.String(), failures, slow, duplicates, s.Elapsed, s.Throughput, s.Completed, config)
+	return errors.WithStack(err)
+}
+
+// WriteJSON writes the report as JSON to the given file.
+func (s *Summary) WriteJSON(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(os.WriteFile(path, append(data, '\n'), 0o600))
+}
@@ -0,0 +1,143 @@
+// Package telemetry exports the traces and metrics of a run to an OpenTelemetry collector over
+// OTLP/HTTP. The instrumented code uses the global OpenTelemetry API, a no-op until Start
+// installs the providers, so nothing is recorded without a collector.
+package telemetry
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	promBridge "go.opentelemetry.io/contrib/bridges/prometheus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// DefaultInterval is the default interval between metric exports.
+const DefaultInterval = 30 * time.Second
+
+// Config configures the exporters.
+type Config struct {
+	// Endpoint is the base URL of the OTLP/HTTP receiver of the collector, eg.
+	// http://localhost:4318. The OTEL_EXPORTER_OTLP_HEADERS environment variable adds
+	// headers, eg. of authentication, to the exports.
+	Endpoint string
+	// Service and Command name the resource of the exported telemetry.
+	Service string
+	Command string
+	// SampleRatio is the fraction of the SampledSpans root spans sampled, the other root spans
+	// are always sampled. The children follow their parent.
+	SampleRatio  float64
+	SampledSpans []string
+	// Interval is the interval between metric exports, DefaultInterval if zero.
+	Interval time.Duration
+	// Gatherer has Prometheus metrics exported along with the OpenTelemetry ones, it may be nil.
+	Gatherer prometheus.Gatherer
+}
+
+// Telemetry are the installed trace and meter providers. Shutdown is a no-op on a nil
+// *Telemetry.
+type Telemetry struct {
+	tracer *sdktrace.TracerProvider
+	meter  *sdkmetric.MeterProvider
+}
+
+// Start installs the global trace and meter providers exporting to the collector.
+func Start(ctx context.Context, cfg Config) (*Telemetry, error) {
+	u, err := url.Parse(cfg.Endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, errors.Errorf("invalid OTLP endpoint %q, expected http(s)://HOST:PORT", cfg.Endpoint)
+	}
+	base := strings.TrimRight(u.Path, "/")
+
+	traceOpts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(u.Host), otlptracehttp.WithURLPath(base + "/v1/traces")}
+	metricOpts := []otlpmetrichttp.Option{otlpmetrichttp.WithEndpoint(u.Host), otlpmetrichttp.WithURLPath(base + "/v1/metrics")}
+	if u.Scheme == "http" {
+		traceOpts = append(traceOpts, otlptracehttp.WithInsecure())
+		metricOpts = append(metricOpts, otlpmetrichttp.WithInsecure())
+	}
+	traceExporter, err := otlptracehttp.New(ctx, traceOpts...)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	metricExporter, err := otlpmetrichttp.New(ctx, metricOpts...)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	res := resource.NewSchemaless(
+		attribute.String("service.name", cfg.Service),
+		attribute.String("ewg.command", cfg.Command),
+	)
+	interval := cfg.Interval
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	readerOpts := []sdkmetric.PeriodicReaderOption{sdkmetric.WithInterval(interval)}
+	if cfg.Gatherer != nil {
+		readerOpts = append(readerOpts, sdkmetric.WithProducer(promBridge.NewMetricProducer(promBridge.WithGatherer(cfg.Gatherer))))
+	}
+
+	t := &Telemetry{
+		tracer: sdktrace.NewTracerProvider(
+			sdktrace.WithBatcher(traceExporter),
+			sdktrace.WithResource(res),
+			sdktrace.WithSampler(sdktrace.ParentBased(newSampler(cfg.SampleRatio, cfg.SampledSpans))),
+		),
+		meter: sdkmetric.NewMeterProvider(
+			sdkmetric.WithReader(sdkmetric.NewPeriodicReader(metricExporter, readerOpts...)),
+			sdkmetric.WithResource(res),
+		),
+	}
+	otel.SetTracerProvider(t.tracer)
+	otel.SetMeterProvider(t.meter)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return t, nil
+}
+
+// Shutdown exports what is left and stops the providers.
+func (t *Telemetry) Shutdown(ctx context.Context) error {
+	if t == nil {
+		return nil
+	}
+	err := t.tracer.Shutdown(ctx)
+	if merr := t.meter.Shutdown(ctx); err == nil {
+		err = merr
+	}
+	return errors.WithStack(err)
+}
+
+// sampler samples the root spans of the names at ratio, every other root span, eg. of a run,
+// is sampled.
+type sampler struct {
+	names map[string]bool
+	ratio sdktrace.Sampler
+}
+
+func newSampler(ratio float64, names []string) sdktrace.Sampler {
+	s := &sampler{names: make(map[string]bool), ratio: sdktrace.TraceIDRatioBased(ratio)}
+	for _, name := range names {
+		s.names[name] = true
+	}
+	return s
+}
+
+func (s *sampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	if s.names[p.Name] {
+		return s.ratio.ShouldSample(p)
+	}
+	return sdktrace.AlwaysSample().ShouldSample(p)
+}
+
+func (s *sampler) Description() string {
+	return "SampledSpans{" + s.ratio.Description() + "}"
+}
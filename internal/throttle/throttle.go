@@ -0,0 +1,64 @@
+// Package throttle limits CPU usage of a busy loop with a sleep duty cycle.
+package throttle
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// minSleep is the smallest accumulated sleep worth handing to the scheduler.
+const minSleep = 10 * time.Millisecond
+
+// Throttle keeps a worker busy for at most a given percentage of wall-clock time.
+// A Throttle is not safe for concurrent use, each worker goroutine should own one.
+type Throttle struct {
+	percent int
+	start   time.Time
+	debt    time.Duration
+}
+
+// New returns a throttle allowing percent (1-100) of CPU time. 100 or more disables throttling.
+func New(percent int) *Throttle {
+	return &Throttle{percent: percent, start: time.Now()}
+}
+
+// ParsePercent parses a CPU percentage such as "50%" or "50".
+func ParsePercent(s string) (int, error) {
+	s = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(s), "%"))
+	if s == "" {
+		return 100, nil
+	}
+	percent, err := strconv.Atoi(s)
+	if err != nil || percent < 1 || percent > 100 {
+		return 0, errors.Errorf("invalid cpu percentage %q, must be between 1%% and 100%%", s)
+	}
+	return percent, nil
+}
+
+// Start should be called before each unit of work, the time since the previous Wait, eg.
+// spent blocked waiting for the next unit or handing over the previous result, isn't busy.
+func (t *Throttle) Start() {
+	if t == nil {
+		return
+	}
+	t.start = time.Now()
+}
+
+// Wait should be called after each unit of work, it sleeps long enough
+// for the busy time since the previous Start or Wait to stay within the allowed percentage.
+func (t *Throttle) Wait() {
+	if t == nil || t.percent >= 100 {
+		return
+	}
+
+	busy := time.Since(t.start)
+	t.debt += busy * time.Duration(100-t.percent) / time.Duration(t.percent)
+	if t.debt >= minSleep {
+		time.Sleep(t.debt)
+		t.debt = 0
+	}
+	t.start = time.Now()
+}
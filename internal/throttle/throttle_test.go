@@ -0,0 +1,26 @@
+package throttle
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWaitIgnoresIdleTime(t *testing.T) {
+	th := New(50)
+	// blocked waiting for work, eg. on a full channel
+	time.Sleep(30 * time.Millisecond)
+	th.Start()
+	start := time.Now()
+	th.Wait()
+	if elapsed := time.Since(start); elapsed >= 20*time.Millisecond {
+		t.Errorf("slept %v for the idle time", elapsed)
+	}
+
+	th.Start()
+	time.Sleep(20 * time.Millisecond)
+	start = time.Now()
+	th.Wait()
+	if elapsed := time.Since(start); elapsed < 15*time.Millisecond {
+		t.Errorf("slept %v only after 20ms of work at 50%%", elapsed)
+	}
+}
@@ -0,0 +1,52 @@
+package upload
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/pkg/errors"
+
+	"github.com/planxnx/ethereum-wallet-generator/internal/gcpauth"
+)
+
+// gcs puts objects with the media upload of the Cloud Storage JSON API, authorized with the
+// access token of gcpauth.
+type gcs struct {
+	client   *http.Client
+	endpoint string
+	bucket   string
+	kmsKey   string
+	token    *gcpauth.Token
+}
+
+func newGCS(client *http.Client, bucket, kmsKey string) *gcs {
+	return &gcs{
+		client:   client,
+		endpoint: "https://storage.googleapis.com/upload/storage/v1/b/",
+		bucket:   bucket,
+		kmsKey:   kmsKey,
+		token:    gcpauth.NewToken(client),
+	}
+}
+
+func (g *gcs) put(ctx context.Context, key string, f *os.File, size int64) error {
+	token, err := g.token.Get(ctx)
+	if err != nil {
+		return err
+	}
+	query := url.Values{"uploadType": {"media"}, "name": {key}}
+	if g.kmsKey != "" {
+		query.Set("kmsKeyName", g.kmsKey)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.endpoint+url.PathEscape(g.bucket)+"/o?"+query.Encode(), io.NopCloser(f))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Authorization", "Bearer "+token)
+	return send(g.client, "gcs", req)
+}
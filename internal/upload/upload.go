@@ -0,0 +1,112 @@
+// Package upload copies the result artifacts of a run, output files, sqlite DBs, checkpoints
+// and summaries, to an S3 or GCS bucket where the server keeps them encrypted at rest.
+package upload
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Schemes lists the supported destination schemes.
+var Schemes = []string{"s3", "gs"}
+
+// The server-side encryptions of S3 objects.
+const (
+	SSES3  = "AES256"
+	SSEKMS = "aws:kms"
+)
+
+// objectStore puts the objects of a bucket.
+type objectStore interface {
+	put(ctx context.Context, key string, f *os.File, size int64) error
+}
+
+// Uploader uploads files under the prefix of a bucket.
+type Uploader struct {
+	store  objectStore
+	prefix string
+	dest   string
+}
+
+// New returns the uploader to a destination, s3://BUCKET/PREFIX (with optional region and
+// endpoint parameters, eg. ?region=eu-west-1) or gs://BUCKET/PREFIX. S3 objects are encrypted
+// with sse, AES256 or aws:kms under kmsKey or else the AWS managed key. GCS objects are
+// encrypted with the Cloud KMS key name kmsKey if set, the Google managed key otherwise.
+func New(dest, sse, kmsKey string) (*Uploader, error) {
+	u, err := url.Parse(dest)
+	if err != nil || u.Host == "" {
+		return nil, errors.Errorf("invalid upload destination %q, expected s3://BUCKET/PREFIX or gs://BUCKET/PREFIX", dest)
+	}
+	client := &http.Client{Timeout: 30 * time.Minute}
+	up := &Uploader{prefix: strings.Trim(u.Path, "/"), dest: u.Scheme + "://" + u.Host + u.Path}
+	switch u.Scheme {
+	case "s3":
+		if sse != SSES3 && sse != SSEKMS {
+			return nil, errors.Errorf("unknown S3 server-side encryption %q, must be %s or %s", sse, SSES3, SSEKMS)
+		}
+		if kmsKey != "" && sse != SSEKMS {
+			return nil, errors.Errorf("a KMS key needs the %s server-side encryption", SSEKMS)
+		}
+		up.store, err = newS3(client, u.Host, u.Query(), sse, kmsKey)
+	case "gs":
+		up.store = newGCS(client, u.Host, kmsKey)
+	default:
+		return nil, errors.Errorf("unknown upload scheme %q, must be one of %v", u.Scheme, Schemes)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return up, nil
+}
+
+// Upload uploads the file at name under the prefix, keeping its base name. A nil uploader
+// uploads nothing.
+func (u *Uploader) Upload(ctx context.Context, name string) error {
+	if u == nil {
+		return nil
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	key := path.Join(u.prefix, filepath.Base(name))
+	if err := u.store.put(ctx, key, f, info.Size()); err != nil {
+		return errors.Wrapf(err, "failed to upload %s", name)
+	}
+	return nil
+}
+
+// Destination returns the URL of the uploaded object of the file at name.
+func (u *Uploader) Destination(name string) string {
+	return strings.TrimRight(u.dest, "/") + "/" + filepath.Base(name)
+}
+
+// send sends a request, failing on a non 2xx status.
+func send(client *http.Client, service string, req *http.Request) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return errors.Errorf("%s request failed: %v", service, errors.Unwrap(err))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return errors.Errorf("%s rejected the upload with %s: %s", service, resp.Status, bytes.TrimSpace(msg))
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
@@ -0,0 +1,51 @@
+package upload
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestS3Upload(t *testing.T) {
+	var got struct {
+		path, sse, key, auth, body string
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got.path, got.body = r.URL.Path, string(body)
+		got.sse, got.key = r.Header.Get("X-Amz-Server-Side-Encryption"), r.Header.Get("X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id")
+		got.auth = r.Header.Get("Authorization")
+	}))
+	defer srv.Close()
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+
+	if _, err := New("s3://results/runs?region=us-east-1", "none", ""); err == nil {
+		t.Error("unknown server-side encryption accepted")
+	}
+	u, err := New("s3://results/runs/?region=us-east-1&endpoint="+srv.URL, SSEKMS, "alias/ewg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := filepath.Join(t.TempDir(), "found.csv")
+	if err := os.WriteFile(name, []byte("address\n0x00\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := u.Upload(context.Background(), name); err != nil {
+		t.Fatal(err)
+	}
+	if got.path != "/results/runs/found.csv" || got.body != "address\n0x00\n" || got.sse != SSEKMS || got.key != "alias/ewg" {
+		t.Errorf("uploaded %+v", got)
+	}
+	if !strings.HasPrefix(got.auth, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/") {
+		t.Errorf("authorization %q", got.auth)
+	}
+	if dest := u.Destination(name); dest != "s3://results/runs/found.csv" {
+		t.Errorf("destination %q", dest)
+	}
+}
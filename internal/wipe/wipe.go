@@ -0,0 +1,30 @@
+// Package wipe zeroes secrets once they are used, so process memory dumps and swap don't
+// retain every seed and key a run derived. Go strings can't be wiped, so secrets are kept as
+// byte slices and keys for as long as they can.
+package wipe
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+)
+
+// Bytes zeroes b.
+func Bytes(b []byte) {
+	clear(b)
+}
+
+// Int zeroes the words of n, leaving it 0.
+func Int(n *big.Int) {
+	if n == nil {
+		return
+	}
+	clear(n.Bits())
+	n.SetInt64(0)
+}
+
+// Key zeroes the private scalar of key.
+func Key(key *ecdsa.PrivateKey) {
+	if key != nil {
+		Int(key.D)
+	}
+}
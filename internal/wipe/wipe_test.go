@@ -0,0 +1,24 @@
+package wipe
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestKey(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	words := key.D.Bits()
+	Key(key)
+	if key.D.Sign() != 0 {
+		t.Errorf("wiped key = %v", key.D)
+	}
+	for _, w := range words {
+		if w != 0 {
+			t.Fatal("the words of the key were left in memory")
+		}
+	}
+}
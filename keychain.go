@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+
+	"github.com/planxnx/ethereum-wallet-generator/internal/keychain"
+)
+
+// runKeychain stores, prints or deletes a secret of the OS keychain, given to the secret
+// flags as keychain:NAME.
+func runKeychain(args []string) {
+	fs := flag.NewFlagSet("keychain", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s keychain set|get|delete NAME\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "set reads the secret from the terminal, or from stdin when it isn't one. Secret flags such as\n-encrypt-output, -db-key or -token then take keychain:NAME as value.\n\n")
+		fs.PrintDefaults()
+	}
+	parseFlags(fs, args)
+	if fs.NArg() != 2 {
+		fs.Usage()
+		os.Exit(exitUsage)
+	}
+
+	action, name := fs.Arg(0), fs.Arg(1)
+	var err error
+	switch action {
+	case "set":
+		var secret string
+		if secret, err = readSecret(fmt.Sprintf("Secret %s: ", name)); err == nil {
+			err = keychain.Set(name, secret)
+		}
+	case "get":
+		var secret string
+		if secret, err = keychain.Get(name); err == nil {
+			fmt.Println(secret)
+		}
+	case "delete":
+		err = keychain.Delete(name)
+	default:
+		fmt.Fprintf(os.Stderr, "Error: unknown keychain action %q, must be set, get or delete\n", action)
+		os.Exit(exitUsage)
+	}
+	if err != nil {
+		fatal("Keychain "+action+" failed", "name", name, "err", err)
+	}
+}
+
+// readSecret asks a secret on the terminal, or reads the first line of stdin.
+func readSecret(prompt string) (string, error) {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return "", fmt.Errorf("failed to read the secret from stdin: %w", err)
+		}
+		return strings.TrimRight(line, "\r\n"), nil
+	}
+	return promptPassphrase(prompt)
+}
+
+// resolveSecretFlags replaces the keychain:NAME values of the secret flags of fs by the
+// keychain secrets they name, each value of the repeated ones.
+func resolveSecretFlags(fs *flag.FlagSet) error {
+	var err error
+	fs.VisitAll(func(f *flag.Flag) {
+		if err != nil || !secretFlags[f.Name] {
+			return
+		}
+		if values, ok := f.Value.(*stringsFlag); ok {
+			for i, value := range *values {
+				if (*values)[i], err = keychain.Resolve(value); err != nil {
+					err = fmt.Errorf("--%s: %w", f.Name, err)
+					return
+				}
+			}
+			return
+		}
+		if value := f.Value.String(); strings.HasPrefix(value, keychain.Prefix) {
+			var secret string
+			if secret, err = keychain.Resolve(value); err != nil {
+				err = fmt.Errorf("--%s: %w", f.Name, err)
+				return
+			}
+			err = f.Value.Set(secret)
+		}
+	})
+	return err
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/planxnx/ethereum-wallet-generator/internal/redact"
+	"github.com/planxnx/ethereum-wallet-generator/internal/style"
+)
+
+// Log formats.
+const (
+	logFormatText = "text"
+	logFormatJSON = "json"
+)
+
+// quiet is set by -quiet, hiding the progress bar along with every log message but errors.
+var quiet bool
+
+// colorStdout and colorStderr are set by -color when the matches and summary, and the logs,
+// are colored for a terminal.
+var colorStdout, colorStderr bool
+
+// stderrLog is where logs go without -log-file, the dashboard takes it over while shown.
+var stderrLog = &switchWriter{w: os.Stderr}
+
+// switchWriter is a writer whose destination can be changed while in use.
+type switchWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (s *switchWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.w.Write(p)
+}
+
+// Set changes the destination of the writes.
+func (s *switchWriter) Set(w io.Writer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.w = w
+}
+
+// addLogFlags registers the logging flags and returns a function installing the
+// configured logger as the slog default, to call once the flags are parsed.
+func addLogFlags(fs *flag.FlagSet) func() error {
+	level := fs.String("log-level", "info", "minimum level of the logged messages: debug, info, warn or error")
+	format := fs.String("log-format", logFormatText, "log format: text or json")
+	file := fs.String("log-file", "", "append logs to this file instead of stderr")
+	quietFlag := fs.Bool("quiet", false, "only print errors, the final summary and matches, same as --log-level error without the progress bar")
+	verbose := fs.Bool("verbose", false, "also log the derivation details and timing of every seed, same as --log-level debug")
+	color := fs.String("color", style.ModeAuto, "color the matches, summary and log levels: auto (when writing to a terminal and NO_COLOR is unset), always or never")
+	logSecrets := fs.Bool("log-secrets", false, "don't scrub what looks like mnemonics, private keys and the given passphrases out of the logged messages and errors")
+
+	return func() error {
+		var lvl slog.Level
+		if err := lvl.UnmarshalText([]byte(*level)); err != nil {
+			return errors.Errorf("invalid --log-level %q, must be debug, info, warn or error", *level)
+		}
+		switch {
+		case *quietFlag && *verbose:
+			return errors.New("--quiet and --verbose are mutually exclusive")
+		case *quietFlag:
+			lvl, quiet = slog.LevelError, true
+		case *verbose:
+			lvl = slog.LevelDebug
+		}
+
+		var err error
+		if colorStdout, err = style.Enabled(*color, os.Stdout); err != nil {
+			return err
+		}
+		if colorStderr, err = style.Enabled(*color, os.Stderr); err != nil {
+			return err
+		}
+
+		var w io.Writer = stderrLog
+		if *file != "" {
+			// every record is a single write, the file is left open until exit
+			f, err := os.OpenFile(*file, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+			if err != nil {
+				return errors.Wrap(err, "failed to open log file")
+			}
+			w = f
+		}
+		if colorStderr && *file == "" && *format == logFormatText {
+			w = levelColorWriter{w}
+		}
+
+		var redactor *redact.Redactor
+		if !*logSecrets {
+			redactor = redact.New(secretValues(fs)...)
+		}
+		// errors are logged by message, their pkg/errors stack trace only at the debug level
+		opts := &slog.HandlerOptions{Level: lvl, ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+			if err, ok := a.Value.Any().(error); ok {
+				if lvl <= slog.LevelDebug {
+					a.Value = slog.StringValue(fmt.Sprintf("%+v", err))
+				} else {
+					a.Value = slog.StringValue(err.Error())
+				}
+			}
+			if redactor != nil && a.Value.Kind() == slog.KindString {
+				a.Value = slog.StringValue(redactor.String(a.Value.String()))
+			}
+			return a
+		}}
+		var handler slog.Handler
+		switch *format {
+		case logFormatText:
+			handler = slog.NewTextHandler(w, opts)
+		case logFormatJSON:
+			handler = slog.NewJSONHandler(w, opts)
+		default:
+			return errors.Errorf("invalid --log-format %q, must be text or json", *format)
+		}
+		slog.SetDefault(slog.New(errorRecorder{Handler: handler, redactor: redactor}))
+		return nil
+	}
+}
+
+// coloredLevels are the levels of the text records styled apart on a terminal. The handler
+// would quote escapes in an attribute, so they are added to the formatted record.
+var coloredLevels = [][2][]byte{
+	{[]byte(" level=WARN "), []byte(" level=" + style.Paint(true, style.Warning, "WARN") + " ")},
+	{[]byte(" level=ERROR "), []byte(" level=" + style.Paint(true, style.Error, "ERROR") + " ")},
+}
+
+// levelColorWriter colors the warning and error levels of the text log records written to it.
+type levelColorWriter struct {
+	w io.Writer
+}
+
+// Write writes a record, the handler writes each one at once.
+func (l levelColorWriter) Write(p []byte) (int, error) {
+	for _, c := range coloredLevels {
+		if i := bytes.Index(p, c[0]); i >= 0 {
+			colored := append(append(append([]byte{}, p[:i]...), c[1]...), p[i+len(c[0]):]...)
+			if _, err := l.w.Write(colored); err != nil {
+				return 0, err
+			}
+			return len(p), nil
+		}
+	}
+	return l.w.Write(p)
+}
+
+// secretValues returns the values given to the secret flags of fs.
+func secretValues(fs *flag.FlagSet) []string {
+	var values []string
+	fs.VisitAll(func(f *flag.Flag) {
+		if !secretFlags[f.Name] {
+			return
+		}
+		if repeated, ok := f.Value.(*stringsFlag); ok {
+			values = append(values, *repeated...)
+		} else {
+			values = append(values, f.Value.String())
+		}
+	})
+	return values
+}
+
+// progressOutput returns where progress bars are written, discarding them in quiet mode.
+func progressOutput() io.Writer {
+	if quiet {
+		return io.Discard
+	}
+	return os.Stderr
+}
+
+// fatal logs msg at the error level, exports the telemetry left and exits.
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	shutdownTelemetry()
+	os.Exit(exitFailure)
+}
+
+// seedAttrs returns the log attributes locating a seed line, along with its file when
+// the seeds were read from several files.
+func seedAttrs(file string, line int) []any {
+	if file == "" {
+		return []any{"line", line}
+	}
+	return []any{"file", file, "line", line}
+}
@@ -1,177 +1,905 @@
 package main
 
 import (
-	"bufio"
+	"context"
+	"errors"
 	"flag"
 	"fmt"
-	"log"
+	"log/slog"
+	"math/rand/v2"
 	"os"
-	"regexp"
+	"path/filepath"
+	"slices"
 	"strings"
+	"sync/atomic"
+	"time"
 
-	"github.com/glebarez/sqlite"
-	"github.com/ethereum/go-ethereum/accounts"
-	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/term"
 
-	"github.com/planxnx/ethereum-wallet-generator/bip39"
-	"github.com/planxnx/ethereum-wallet-generator/utils"
-	"github.com/planxnx/ethereum-wallet-generator/wallets"
+	"github.com/planxnx/ethereum-wallet-generator/coins"
+	"github.com/planxnx/ethereum-wallet-generator/filter"
+	"github.com/planxnx/ethereum-wallet-generator/internal/checkpoint"
+	"github.com/planxnx/ethereum-wallet-generator/internal/config"
+	"github.com/planxnx/ethereum-wallet-generator/internal/dashboard"
+	"github.com/planxnx/ethereum-wallet-generator/internal/output"
+	"github.com/planxnx/ethereum-wallet-generator/internal/summary"
+	"github.com/planxnx/ethereum-wallet-generator/internal/throttle"
+	"github.com/planxnx/ethereum-wallet-generator/pipeline"
+	"github.com/planxnx/ethereum-wallet-generator/seeds"
 )
 
-// ReadSeeds reads a file containing one mnemonic per line and returns as a slice.
-func ReadSeeds(filename string) ([]string, error) {
-	f, err := os.Open(filename)
-	if err != nil {
-		return nil, err
+// command is a subcommand of the binary.
+type command struct {
+	name  string
+	usage string
+	run   func(args []string)
+}
+
+// commands lists the subcommands, scan is run when none is given.
+var commands = []command{
+	{"scan", "derive addresses from a file of mnemonics and keep the matching ones", runScan},
+	{"generate", "generate random wallets and keep the matching ones", runGenerate},
+	{"dice", "create a mnemonic from dice rolls or coin flips", runDice},
+	{"derive", "derive the addresses of a single mnemonic", runDerive},
+	{"recover", "rebuild the wallet details of a private key or keystore files", runRecover},
+	{"verify", "check that mnemonic -> address lines derive their expected address", runVerify},
+	{"build-index", "derive the addresses of a seed corpus once into an index for lookup", runBuildIndex},
+	{"lookup", "find target addresses in an index built by build-index", runLookup},
+	{"rebuild-seen", "write a -seen-filter from the addresses of result DBs and lists", runRebuildSeen},
+	{"seed-quality", "report the entropy quality signals of a batch of mnemonics", runSeedQuality},
+	{"find-path", "search the derivation paths of mnemonics for known addresses", runFindPath},
+	{"verify-hw", "compare the addresses of a Ledger or Trezor with a mnemonic", runVerifyHW},
+	{"export", "dump the wallets stored in a DB", runExport},
+	{"decrypt", "open the private keys and mnemonics sealed with -kms or -db-encrypt-keys", runDecrypt},
+	{"prove", "check a private key against the salted hash stored with -hash-only", runProve},
+	{"combine", "rebuild a private key or mnemonic from the Shamir shares of export -shamir", runCombine},
+	{"keychain", "store the secrets given to flags as keychain:NAME in the OS keychain", runKeychain},
+	{"bench", "measure the derivation throughput of this machine", runBench},
+	{"serve", "serve seed work units to remote workers (alias serve-coordinator)", runCoordinator},
+	{"worker", "process work units leased from a coordinator", runWorker},
+	{"consume", "process the seeds or work units of a Redis, NATS or Kafka queue", runConsume},
+	{"plan", "split a job into signed work unit manifests, or merge their results", runPlan},
+	{"run", "process a work unit manifest offline into a signed result file", runManifest},
+	{"api", "serve a REST API running scan and generate jobs", runAPI},
+	{"query", "print the stored wallets matching the scan filters", runQuery},
+	{"stats", "report the rows, runs and size of a result DB", runStats},
+	{"report", "write the audit report of a run, its matches grouped by seed", runReport},
+	{"migrate", "upgrade the schema of a result DB", runMigrate},
+	{"completion", "print the bash, zsh or fish completion script", runCompletion},
+}
+
+func main() {
+	args := os.Args[1:]
+	if len(args) > 0 {
+		name := args[0]
+		if name == "serve-coordinator" {
+			name = "serve"
+		}
+		for _, cmd := range commands {
+			if cmd.name == name {
+				cmd.run(args[1:])
+				shutdownTelemetry()
+				os.Exit(exitCode)
+			}
+		}
+		if name == completeCommand {
+			runComplete(args[1:])
+			return
+		}
+		if name == "help" || name == "-h" || name == "--help" {
+			printUsage()
+			return
+		}
+		if !strings.HasPrefix(name, "-") {
+			fmt.Fprintf(os.Stderr, "Error: unknown command %q\n", name)
+			printUsage()
+			os.Exit(exitUsage)
+		}
 	}
-	defer f.Close()
 
-	var seeds []string
-	scanner := bufio.NewScanner(f)
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line != "" {
-			seeds = append(seeds, line)
+	// flags without a subcommand keep running a scan
+	runScan(args)
+	shutdownTelemetry()
+	os.Exit(exitCode)
+}
+
+// printUsage lists the subcommands on stderr.
+func printUsage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s <command> [flags]\n\nCommands:\n", os.Args[0])
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-12s %s\n", cmd.name, cmd.usage)
+	}
+	fmt.Fprintf(os.Stderr, "\nRun '%s <command> -h' for the flags of a command.\n", os.Args[0])
+}
+
+// parseFlags parses args into fs, then fills the flags that were not given from their
+// EWG_ environment variables (eg. EWG_DB_KEY for -db-key), then from the -config file,
+// and installs the logger of the -log-* flags.
+func parseFlags(fs *flag.FlagSet, args []string) {
+	configPath := fs.String("config", "", "read flag values from this YAML or TOML file, flags given on the command line or environment take precedence")
+	setupLogging := addLogFlags(fs)
+	if completeFlags != nil {
+		completeFlags(fs)
+	}
+	_ = fs.Parse(args)
+
+	err := config.ApplyEnv(fs)
+	explicitFlags = make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { explicitFlags[f.Name] = true })
+	if err == nil && *configPath != "" {
+		var values map[string]string
+		if values, err = config.Load(*configPath); err == nil {
+			err = config.Apply(fs, values)
 		}
 	}
-	return seeds, scanner.Err()
+	if err == nil {
+		err = resolveSecretFlags(fs)
+	}
+	if err == nil {
+		err = checkEphemeral(fs)
+	}
+	if err == nil {
+		err = setupLogging()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitUsage)
+	}
 }
 
-func main() {
+// runScan derives the addresses of every mnemonic of a seeds file and keeps the matching ones.
+func runScan(args []string) {
+	fs := flag.NewFlagSet("scan", flag.ExitOnError)
 	// Flags
-	filePath := flag.String("seeds", "", "file containing list of BIP39 mnemonics (one per line)")
-	depth := flag.Int("depth", 1, "number of addresses to derive per seed/mnemonic (default 1, >=1)")
-	dbPath := flag.String("db", "", "set sqlite output name eg. wallets.db (db file will create in /db)")
-	strict := flag.Bool("strict", false, "strict contains mode")
-	contain := flag.String("contains", "", "show only result that contained with the given letters (support for multiple characters)")
-	prefix := flag.String("prefix", "", "show only result that prefix was matched")
-	suffix := flag.String("suffix", "", "show only result that suffix was matched")
-	regEx := flag.String("regex", "", "show only result that was matched with given regex (eg. ^0x99 or ^0x00)")
-	flag.Parse()
-
-	if *filePath == "" {
+	var seedPatterns seeds.Patterns
+	fs.Var(&seedPatterns, "seeds", "file containing list of BIP39 mnemonics (one per line), - to read them from stdin. Repeat it or use glob patterns (eg. \"dumps/*.txt\") to read several files in order")
+	seedsFormat := fs.String("seeds-format", string(seeds.FormatText), "seeds file line format: text (one mnemonic per line), or tsv/csv lines of mnemonic, passphrase, hdpath and label fields")
+	readAhead := fs.Int("read-ahead", seeds.DefaultReadAhead, "number of seeds to buffer ahead of derivation")
+	seedRangeConfig := addSeedRangeFlags(fs)
+	seedKeyConfig := addSeedKeyFlags(fs)
+	dedupConfig := addDuplicatesFlag(fs)
+	shuffle := fs.Bool("shuffle", false, "process the selected seeds in a random order, held in memory, so early progress is representative of sorted inputs")
+	shuffleSeed := fs.Uint64("shuffle-seed", 0, "seed of the --shuffle order, reported in the summary to reproduce a run (0 for a random one)")
+	depth := fs.Int("depth", 1, "number of addresses to derive per seed/mnemonic (default 1, >=1)")
+	sinksConfig := addSinkFlags(fs)
+	checkpointPath := fs.String("checkpoint", "", "periodically save the position reached to this file")
+	checkpointInterval := fs.Duration("checkpoint-interval", checkpoint.DefaultInterval, "interval between checkpoint writes")
+	resume := fs.Bool("resume", false, "continue from the position saved in the -checkpoint file")
+	skipStored := fs.Bool("skip-stored", false, "skip the address indexes whose seed line and hd path are already stored in -db from the same mnemonic, so a rerun after a crash is idempotent without a checkpoint")
+	timeout := fs.Duration("timeout", 0, "stop the run cleanly after this duration (eg. 2h, 0 for no deadline)")
+	concurrency := fs.Int("c", 1, "set concurrency value (number of derivation workers)")
+	maxCPU := fs.String("max-cpu", "100%", "limit CPU usage of the derivation loop to the given percentage (eg. 50%)")
+	filterConfig := addFilterFlags(fs)
+	coinConfig := addCoinFlags(fs)
+	summaryPath := fs.String("summary-json", "", "also write the end of run summary as JSON to this file")
+	errorsPath := fs.String("errors-file", "", "write every seed line that failed, with its file, line, address index, category (invalid, seed or address) and reason, as JSON lines to this file")
+	slowSeed := fs.Duration("slow-seed", 0, fmt.Sprintf("warn about the seeds whose derivation takes longer than this (0 for %d times the average seed time)", slowSeedFactor))
+	checkMnemonics := fs.Bool("check-mnemonics", false, "skip the seeds that aren't valid BIP39 mnemonics (unknown word, length or checksum), reporting them as failures of the invalid category")
+	onError := fs.String("on-error", onErrorContinue, "what a derivation or sink (DB, output, keystore...) error does: continue logs it and goes on, fail stops the run with exit code 3 and skip-seed also skips the remaining address indexes of a seed after one failed to derive")
+	refuseWeak := fs.Bool("refuse-weak", false, "skip the weak phrases (test vectors, development tool mnemonics, published brainwallets, repeated or sequential words) as failures of the invalid category, instead of deriving them with a warning")
+	dryRunMode := fs.Bool("dry-run", false, "check the seeds and filters, then estimate the work, runtime and matches without deriving or writing anything")
+	countOnly := fs.Bool("count-only", false, "apply the filters without storing or printing any wallet, only tally the matches of every pattern in the summary")
+	metricsConfig := addMetricsFlags(fs)
+	tui := fs.Bool("tui", false, "show an interactive dashboard instead of the progress bar, write matches to -out or -db to keep them off the screen")
+	progressConfig := addProgressFlags(fs)
+	uploadConfig := addUploadFlags(fs)
+	offlineConfig := addOfflineFlag(fs)
+	parseFlags(fs, args)
+	offlineConfig()
+	uploadConfig()
+
+	if len(seedPatterns) == 0 {
 		fmt.Fprintln(os.Stderr, "Error: --seeds parameter required, pointing to a file containing mnemonics")
-		os.Exit(1)
+		os.Exit(exitUsage)
+	}
+	input, err := seeds.NewInput(seedPatterns, seeds.Format(*seedsFormat))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitUsage)
+	}
+	if err := seedKeyConfig(input); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitUsage)
 	}
 	if *depth < 1 {
 		*depth = 1
 	}
 
-	seeds, err := ReadSeeds(*filePath)
+	seedRange, err := seedRangeConfig()
 	if err != nil {
-		log.Fatalf("Failed to open seeds file: %v", err)
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitUsage)
 	}
-	if len(seeds) == 0 {
-		fmt.Fprintln(os.Stderr, "No seeds/mnemonics found in the file.")
-		return
+	duplicatesMode, err := dedupConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitUsage)
+	}
+	cpuPercent, err := throttle.ParsePercent(*maxCPU)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitUsage)
 	}
-	totalToGenerate := len(seeds) * (*depth)
 
-	// Prepare DB if requested
-	var gdb *gorm.DB
-	if *dbPath != "" {
-		db, err := gorm.Open(sqlite.Open("./db/"+*dbPath), &gorm.Config{
-			Logger: logger.Default.LogMode(logger.Silent),
-		})
+	// Prepare address validator
+	coin, err := coinConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitUsage)
+	}
+	filters, err := coins.ApplyFilters(coin, filterConfig())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitUsage)
+	}
+	// the filters are replaced by a SIGHUP reloading the -config file
+	liveFilter, err := filter.NewLive(filters)
+	if err != nil {
+		fatal("Failed to prepare filters", "err", err)
+	}
+	validateAddress := liveFilter.ValidAddress
+
+	// Prepare checkpoint, the resumed position is applied on top of the selected range
+	var (
+		checkpoints *checkpoint.Writer
+		resumeAt    checkpoint.Checkpoint
+	)
+	if *shuffle && *checkpointPath != "" {
+		fmt.Fprintln(os.Stderr, "Error: --shuffle can't be combined with --checkpoint, positions are tracked in input order")
+		os.Exit(exitUsage)
+	}
+	if *shuffle && *shuffleSeed == 0 {
+		*shuffleSeed = rand.Uint64() | 1
+	}
+	if !*shuffle {
+		*shuffleSeed = 0
+	}
+
+	// a dry run leaves the filesystem untouched
+	if *checkpointPath != "" && !*dryRunMode {
+		prepareOutputDir("checkpoint", filepath.Dir(*checkpointPath))
+	}
+	if *summaryPath != "" && !*dryRunMode {
+		prepareOutputDir("summary-json", filepath.Dir(*summaryPath))
+	}
+	if *resume && *checkpointPath == "" {
+		fmt.Fprintln(os.Stderr, "Error: --resume requires --checkpoint")
+		os.Exit(exitUsage)
+	}
+	if *checkpointPath != "" {
+		// the text format is left out so checkpoints of plain seeds files stay valid
+		format := input.Format
+		if format == seeds.FormatText {
+			format = ""
+		}
+		configHash, err := checkpoint.Hash(struct {
+			Seeds  string
+			Format seeds.Format `json:",omitempty"`
+			Range  seeds.Range
+			Depth  int
+			Filter filter.Config
+			Coin   string `json:",omitempty"`
+		}{input.String(), format, seedRange, *depth, filters, coinID(coin)})
 		if err != nil {
-			log.Fatalf("Failed to open sqlite DB: %v", err)
+			fatal("Failed to hash run settings", "err", err)
+		}
+
+		if *resume {
+			cp, err := checkpoint.Load(*checkpointPath)
+			if err != nil {
+				fatal("Failed to load checkpoint", "err", err)
+			}
+			if cp.ConfigHash != configHash {
+				fmt.Fprintln(os.Stderr, "Error: checkpoint was created with different seeds, range, depth or filters")
+				os.Exit(exitUsage)
+			}
+			if cp.Done {
+				fmt.Fprintln(os.Stderr, "Checkpoint run already completed, nothing to resume.")
+				return
+			}
+			resumeAt = *cp
+			seedRange = seedRange.From(cp.Line)
 		}
-		// Auto migrate wallets.Wallet
-		if err := db.AutoMigrate(&wallets.Wallet{}); err != nil {
-			log.Fatalf("AutoMigrate failed: %v", err)
+		checkpoints = checkpoint.NewWriter(*checkpointPath, configHash, *checkpointInterval)
+	}
+
+	if *dryRunMode {
+		ctx, stop := withSignals(context.Background())
+		defer stop()
+		estimate := dryRun{input: input, rng: seedRange, depth: *depth, skipped: resumeAt.Index, workers: max(*concurrency, 1), cpuPercent: cpuPercent, filters: filters}
+		if err := estimate.run(ctx, os.Stderr); err != nil {
+			fatal("Dry run failed", "err", err)
 		}
-		gdb = db
+		return
 	}
 
-	// Prepare address validator
-	r := regexp.MustCompile(*regEx)
-	containsList := strings.Split(*contain, ",")
-	*prefix = utils.Add0xPrefix(*prefix)
-
-	validateAddress := func(address string) bool {
-		isValid := true
-		// contains logic
-		if len(containsList) > 0 && containsList[0] != "" {
-			found := false
-			for _, c := range containsList {
-				if strings.Contains(address, c) {
-					found = true
-					break
+	// stdin can only be read once, its progress total stays unknown
+	totalToGenerate := 0
+	var fileCounts []int
+	if !input.IsStdin() {
+		if fileCounts, err = input.CountFileLines(seedRange); err != nil {
+			fatal("Failed to open seeds file", "err", err)
+		}
+		seedCount := 0
+		for _, n := range fileCounts {
+			seedCount += n
+		}
+		if seedCount == 0 {
+			fmt.Fprintln(os.Stderr, "No seeds/mnemonics found in the selected range of the file.")
+			return
+		}
+		totalToGenerate = seedCount*(*depth) - resumeAt.Index
+	}
+
+	metricsConfig()
+	runMetrics.SetWorkers(max(*concurrency, 1))
+	runMetrics.SetTotal(totalToGenerate)
+	newProgressBar := progressConfig()
+	if *countOnly && *tui {
+		fmt.Fprintln(os.Stderr, "Error: --count-only can't be combined with --tui")
+		os.Exit(exitUsage)
+	}
+	if *onError != onErrorContinue && *onError != onErrorFail && *onError != onErrorSkipSeed {
+		fmt.Fprintf(os.Stderr, "Error: invalid --on-error %q, must be %s, %s or %s\n", *onError, onErrorContinue, onErrorFail, onErrorSkipSeed)
+		os.Exit(exitUsage)
+	}
+	if *checkpointPath != "" && fs.Lookup("top").Value.String() != "0" {
+		// the ranked matches are only written at the end, a checkpoint would move past them
+		fmt.Fprintln(os.Stderr, "Error: --top can't be combined with --checkpoint")
+		os.Exit(exitUsage)
+	}
+
+	// Prepare DB, output and keystore sinks, a count only run has none
+	sinks := &resultSinks{}
+	if !*countOnly {
+		sinks = sinksConfig()
+	}
+	if err := sinks.checkCoin(coin); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitUsage)
+	}
+	var failures *failureReport
+	if *errorsPath != "" {
+		if failures, err = openFailureReport(*errorsPath, *resume); err != nil {
+			fatal("Failed to open errors file", "err", err)
+		}
+	}
+	failuresLost := false
+	slow := &slowSeeds{threshold: *slowSeed}
+	check := func(seed seeds.Seed) error {
+		if *checkMnemonics {
+			if err := checkMnemonic(seed); err != nil {
+				return err
+			}
+		}
+		err := checkWeak(seed)
+		if err != nil && !*refuseWeak {
+			file, line := input.Locate(seed.Line)
+			slog.Warn("Weak mnemonic", append(seedAttrs(file, line), "err", err)...)
+			return nil
+		}
+		return err
+	}
+
+	var skip func(seeds.Seed, int) bool
+	if *skipStored {
+		if sinks.repo == nil {
+			fmt.Fprintln(os.Stderr, "Error: --skip-stored requires --db")
+			os.Exit(exitUsage)
+		}
+		if skip, err = sinks.skipStored(input, coin); err != nil {
+			fatal("Failed to read stored wallets", "err", err)
+		}
+	}
+
+	ctx, stop := withSignals(context.Background())
+	defer stop()
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+	ctx, stopRun := context.WithCancelCause(ctx)
+	defer stopRun(nil)
+	if *onError == onErrorFail {
+		sinks.onFail = func() { stopRun(errOnErrorFail) }
+	}
+	ctx, span := tracer.Start(ctx, "scan", trace.WithAttributes(attribute.String("ewg.seeds", input.String()), attribute.Int("ewg.depth", *depth)))
+
+	var addressesDone, matchesDone atomic.Int64
+	started := time.Now()
+	sinks.setProgress(func() string {
+		done := addressesDone.Load()
+		return fmt.Sprintf("Scanned %d/%d addresses of %s, %d matches, %.1f addr/s", done, totalToGenerate, input, matchesDone.Load(), float64(done)/time.Since(started).Seconds())
+	})
+
+	report := summary.New(struct {
+		Seeds      string        `json:"seeds"`
+		Range      seeds.Range   `json:"range"`
+		ResumeLine int           `json:"resume_line,omitempty"`
+		Depth      int           `json:"depth"`
+		Coin       string        `json:"coin,omitempty"`
+		BasePath   string        `json:"base_path"`
+		Filter     filter.Config `json:"filter"`
+		Workers    int           `json:"workers"`
+		MaxCPU     int           `json:"max_cpu_percent"`
+		Timeout    string        `json:"timeout,omitempty"`
+		Shuffle    uint64        `json:"shuffle_seed,omitempty"`
+	}{input.String(), seedRange, resumeAt.Line, *depth, coinID(coin), coin.BasePath().String(), filters, *concurrency, cpuPercent, durationString(*timeout), *shuffleSeed})
+
+	matches := 0
+	bar := newProgressBar(totalToGenerate, *tui)
+	if len(input.Files) > 1 {
+		// the resumed indexes are done in the first file left
+		resumed := resumeAt.Index
+		for i, file := range input.Files {
+			total := fileCounts[i] * *depth
+			if total > 0 {
+				total, resumed = total-resumed, 0
+			}
+			bar.AddPart(file, total)
+		}
+	}
+	committedLine := resumeAt.Line
+	committedIndex := resumeAt.Index
+	// durableLine and durableIndex are the last committed position whose results were all
+	// flushed before a sink failed, a checkpoint never moves past a lost result
+	durableLine, durableIndex := committedLine, committedIndex
+	flushCommitted := func() error {
+		if err := sinks.Flush(); err != nil {
+			return err
+		}
+		if !sinks.Failed() {
+			durableLine, durableIndex = committedLine, committedIndex
+		}
+		return nil
+	}
+	sinks.setBackpressure(ctx, func() {
+		// the run can be stopped and resumed where it waits
+		if err := flushCommitted(); err != nil {
+			slog.Error("Failed to flush results", "err", err)
+			return
+		}
+		if err := checkpoints.Flush(durableLine, durableIndex, false); err != nil {
+			slog.Error("Failed to save checkpoint", "err", err)
+		} else if *checkpointPath != "" {
+			slog.Info("Checkpoint saved, the run can be stopped and resumed with --resume", "checkpoint", *checkpointPath)
+		}
+	})
+	seedCh, seedErrCh := input.Stream(ctx, seedRange, *readAhead)
+	var duplicates, skippedDuplicates atomic.Int64
+	seedCh = dedupSeeds(ctx, seedCh, duplicatesMode, input, func(seed seeds.Seed, skipped bool) {
+		duplicates.Add(1)
+		if skipped {
+			skippedDuplicates.Add(1)
+			// skipped seeds still count towards the progress total
+			for i := 0; i < *depth; i++ {
+				_ = bar.Increment()
+			}
+			file, _ := input.Locate(seed.Line)
+			bar.IncrementPart(file, *depth)
+		}
+	})
+	if *shuffle {
+		slog.Info("Shuffling seeds", "shuffle_seed", *shuffleSeed)
+		seedCh = seeds.Shuffle(ctx, seedCh, *shuffleSeed)
+	}
+
+	// the dashboard validator counts the hits of every filter and can be tightened mid-run
+	var (
+		scan          *pipeline.Pipeline
+		dash          *dashboard.Dashboard
+		dynamicFilter *filter.Dynamic
+	)
+	if *tui {
+		dynamicFilter = filter.NewDynamic(filters)
+		validateAddress = dynamicFilter.Validate
+		dash = dashboard.New(dashboard.Config{
+			Total:   totalToGenerate,
+			Workers: *concurrency,
+			Filter:  dynamicFilter,
+			Controls: dashboard.Controls{
+				Pause:  func() { scan.Pause() },
+				Resume: func() { scan.Resume() },
+				Tighten: func(regex string) error {
+					if err := dynamicFilter.AddRegex(regex); err != nil {
+						return err
+					}
+					slog.Info("Filter tightened", "regex", regex)
+					return nil
+				},
+				Stop: func() { stopRun(errDashboardStop) },
+			},
+		})
+	}
+
+	// a count only run matches nothing, the tally counts what would have matched
+	var (
+		validator filter.Validator = liveFilter
+		tally     *filter.Tally
+	)
+	if *countOnly {
+		if tally, err = filter.NewTally(filters); err != nil {
+			fatal("Failed to prepare filters", "err", err)
+		}
+		validateAddress, validator = nil, tally
+	} else {
+		watchReload(ctx, fs, func(r reloadedFlags) error {
+			cfg, err := coins.ApplyFilters(coin, r.filters)
+			if err == nil {
+				err = liveFilter.Set(cfg)
+			}
+			if err == nil && dynamicFilter != nil {
+				dynamicFilter.Reset(cfg)
+			}
+			return err
+		})
+	}
+
+	// with -matches-out the other sinks record every derived wallet
+	var onMiss func(pipeline.Match)
+	if sinks.recordsAll() {
+		onMiss = func(m pipeline.Match) {
+			file, line := input.Locate(m.Line)
+			sinks.SaveMiss(output.Record{SeedFile: file, Line: line, SeedLabel: m.Label, Index: m.Index, Mnemonic: m.Phrase, Wallet: m.Wallet})
+		}
+	}
+	scan = pipeline.New(pipeline.Config{
+		Workers:          *concurrency,
+		Depth:            *depth,
+		BasePath:         coin.BasePath(),
+		Coin:             coin,
+		CPUPercent:       cpuPercent,
+		ResumeLine:       resumeAt.Line,
+		ResumeIndex:      resumeAt.Index,
+		Skip:             skip,
+		Check:            check,
+		SkipSeedOnError:  *onError == onErrorSkipSeed,
+		AddressValidator: validateAddress,
+		Validator:        validator,
+		OnMiss:           onMiss,
+		OnMatch: func(m pipeline.Match) {
+			file, line := input.Locate(m.Line)
+			sinks.Save(output.Record{SeedFile: file, Line: line, SeedLabel: m.Label, Index: m.Index, Mnemonic: m.Phrase, Wallet: m.Wallet})
+			matches++
+			matchesDone.Add(1)
+			runMetrics.Match()
+			report.Matches++
+			_ = bar.SetResolved(matches)
+			if dash != nil {
+				dash.Match(fmt.Sprintf("%sline %d idx %d", filePrefix(file), line, m.Index), m.Wallet.Address)
+			}
+		},
+		OnFailure: func(f pipeline.Failure) {
+			report.Failures++
+			runMetrics.Error("derivation")
+			file, line := input.Locate(f.Line)
+			if failures != nil && !failuresLost {
+				if err := failures.Add(file, line, f); err != nil {
+					slog.Error("Failed to write errors file", "err", err)
+					failuresLost = true
+				}
+			}
+			if f.Category == pipeline.CategoryInvalid {
+				slog.Warn("Seed skipped", append(seedAttrs(file, line), "err", f.Err)...)
+				return
+			}
+			if *onError == onErrorFail {
+				stopRun(errOnErrorFail)
+			}
+			if f.Index < 0 {
+				slog.Warn("Seed derivation failed", append(seedAttrs(file, line), "err", f.Err)...)
+				return
+			}
+			if *onError == onErrorSkipSeed {
+				slog.Warn("Wallet derivation failed, skipping the next indexes of the seed", append(seedAttrs(file, line), "index", f.Index, "err", f.Err)...)
+				return
+			}
+			slog.Warn("Wallet derivation failed", append(seedAttrs(file, line), "index", f.Index, "err", f.Err)...)
+		},
+		OnProgress: func(processed int) {
+			report.Seeds++
+			addressesDone.Add(int64(processed))
+			runMetrics.Seed(processed)
+			for i := 0; i < processed; i++ {
+				_ = bar.Increment()
+			}
+		},
+		OnSeed: func(st pipeline.SeedStats) {
+			report.Addresses += int64(st.Derived)
+			report.Skipped += int64(st.Skipped)
+			runMetrics.Busy(st.Elapsed)
+			file, line := input.Locate(st.Line)
+			bar.IncrementPart(file, st.Processed)
+			slog.Debug("Seed done", append(seedAttrs(file, line), "addresses", st.Processed, "matches", st.Matches, "failures", st.Failures, "elapsed", st.Elapsed)...)
+			if st.Processed > st.Skipped {
+				if threshold := slow.check(st.Elapsed); threshold > 0 {
+					report.SlowSeeds = slow.flagged
+					slog.Warn("Slow seed", append(seedAttrs(file, line), "elapsed", st.Elapsed, "threshold", threshold, "addresses", st.Processed)...)
 				}
 			}
-			if *strict && !found {
-				isValid = false
+			if dash != nil {
+				dash.Seed(st)
+			}
+		},
+		OnCommit: func(line int) {
+			committedLine, committedIndex = line, *depth
+			if !checkpoints.Due() {
+				return
 			}
-			if !*strict && !found {
-				isValid = false
+			// matches must be durable before the checkpoint moves past them
+			if err := flushCommitted(); err != nil {
+				slog.Error("Failed to flush results", "err", err)
+				return
 			}
+			if err := checkpoints.Update(durableLine, durableIndex); err != nil {
+				slog.Error("Failed to save checkpoint", "err", err)
+			}
+		},
+	})
+	if dash != nil {
+		stderrLog.Set(dash)
+		dash.Start()
+	}
+	scan.Run(ctx, seedCh)
+	if dash != nil {
+		if err := dash.Close(); err != nil {
+			slog.Error("Dashboard failed", "err", err)
 		}
-		if *prefix != "" && !strings.HasPrefix(address, *prefix) {
-			isValid = false
+		stderrLog.Set(os.Stderr)
+	}
+
+	seedErr := <-seedErrCh
+	if seedErr != nil {
+		slog.Error("Failed to read seeds file", "err", seedErr)
+		runMetrics.Error("seeds")
+	}
+	sinks.Close()
+	if failures != nil {
+		if err := failures.Close(); err != nil {
+			slog.Error("Failed to write errors file", "err", err)
+			failuresLost = true
 		}
-		if *suffix != "" && !strings.HasSuffix(address, *suffix) {
-			isValid = false
+	}
+	if seedErr != nil {
+		span.RecordError(seedErr)
+	}
+	span.SetAttributes(attribute.Int64("ewg.addresses", addressesDone.Load()), attribute.Int("ewg.matches", matches))
+	span.End()
+	if !sinks.Failed() {
+		durableLine, durableIndex = committedLine, committedIndex
+	}
+	if err := checkpoints.Flush(durableLine, durableIndex, seedErr == nil && ctx.Err() == nil && !sinks.Failed()); err != nil {
+		slog.Error("Failed to save checkpoint", "err", err)
+	}
+
+	_ = bar.Finish()
+	file, line := input.Locate(committedLine)
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		fmt.Fprintf(os.Stderr, "Deadline of %v reached, stopped after seed %sline %d\n", *timeout, filePrefix(file), line)
+	} else if sig := interruptSignal(ctx); sig != nil {
+		fmt.Fprintf(os.Stderr, "Interrupted by %v, stopped after seed %sline %d\n", sig, filePrefix(file), line)
+	} else if errors.Is(context.Cause(ctx), errDashboardStop) {
+		fmt.Fprintf(os.Stderr, "Stopped from the dashboard after seed %sline %d\n", filePrefix(file), line)
+	} else if errors.Is(context.Cause(ctx), errOnErrorFail) {
+		fmt.Fprintf(os.Stderr, "Stopped on the first error (--on-error fail) after seed %sline %d\n", filePrefix(file), line)
+	}
+
+	report.RunID = sinks.runID()
+	if tally != nil {
+		report.Matches = tally.Matches()
+		for _, c := range tally.Counts() {
+			report.Patterns = append(report.Patterns, summary.PatternMatches{Pattern: c.Pattern, Matches: c.Matches})
 		}
-		if *regEx != "" && !r.MatchString(address) {
-			isValid = false
+	}
+	report.Duplicates = duplicates.Load()
+	report.DuplicatesSkipped = skippedDuplicates.Load() > 0
+	report.Finish(seedErr == nil && ctx.Err() == nil)
+	exitCode = runExitCode(int64(report.Matches), seedErr != nil || sinks.Failed() || failuresLost || errors.Is(context.Cause(ctx), errOnErrorFail), ctx.Err() != nil)
+	if err := report.Print(os.Stderr, colorStderr); err != nil {
+		slog.Error("Failed to print summary", "err", err)
+	}
+	if *summaryPath != "" {
+		if err := report.WriteJSON(*summaryPath); err != nil {
+			slog.Error("Failed to write summary", "err", err)
 		}
-		return isValid
 	}
+	uploadArtifacts(sinks.dbFile(), *checkpointPath, *summaryPath)
+}
 
-	// Base derivation path from wallets package (m/44'/60'/0'/0)
-	basePath := wallets.DefaultBaseDerivationPath
-	basePathStr := wallets.DefaultBaseDerivationPathString
+// errDashboardStop is the cancellation cause of a run stopped from the dashboard.
+var errDashboardStop = errors.New("stopped from the dashboard")
+
+// filePrefix returns "<file> " to prefix seed line messages with, or nothing for a single file input.
+func filePrefix(file string) string {
+	if file == "" {
+		return ""
+	}
+	return file + " "
+}
 
-	count := 0
-	for si, seedOrMnemonic := range seeds {
-		seedBytes := bip39.NewSeed(seedOrMnemonic, "")
+// durationString formats d, or returns an empty string if it is not set.
+func durationString(d time.Duration) string {
+	if d <= 0 {
+		return ""
+	}
+	return d.String()
+}
 
-		for i := 0; i < *depth; i++ {
-			// build path base + index i
-			path := make(accounts.DerivationPath, len(basePath)+1)
-			copy(path, basePath)
-			path[len(basePath)] = uint32(i)
+// addSeedRangeFlags registers the seed range flags on fs and returns a function
+// building the selected range once the flags have been parsed.
+func addSeedRangeFlags(fs *flag.FlagSet) func() (seeds.Range, error) {
+	skip := fs.Int("skip", 0, "skip the first N lines of the seeds file")
+	take := fs.Int("take", 0, "process only M lines after the skipped ones (0 for all remaining lines)")
+	lines := fs.String("lines", "", "process only the given inclusive line range of the seeds file (eg. 1000-2000), overrides -skip/-take")
 
-			privKey, err := wallets.DeriveWallet(seedBytes, path)
-			if err != nil {
-				log.Printf("Seed line %d index %d: Failed to derive wallet: %v", si+1, i, err)
-				count++
-				if count%50 == 0 {
-					fmt.Printf("\rProcessed %d/%d", count, totalToGenerate)
-				}
-				continue
-			}
+	return func() (seeds.Range, error) {
+		if *lines != "" {
+			return seeds.ParseLineRange(*lines)
+		}
+		return seeds.Range{Skip: max(*skip, 0), Take: *take}, nil
+	}
+}
+
+// addSeedKeyFlags registers the flags decrypting encrypted seeds files on fs and returns a
+// function giving their keys to an input once the flags have been parsed, checking they
+// decrypt it.
+func addSeedKeyFlags(fs *flag.FlagSet) func(input *seeds.Input) error {
+	passphrase := fs.String("seeds-passphrase", "", "passphrase of the age, gpg --symmetric or openssl enc -aes-256-cbc -pbkdf2 encrypted seeds files, password of the MetaMask vaults, or of the -seeds-identity OpenPGP key, asked on the terminal if empty")
+	identity := fs.String("seeds-identity", "", "age identity file or armored OpenPGP private key of the seeds files encrypted to a recipient")
 
-			w, err := wallets.NewFromPrivatekey(privKey)
+	return func(input *seeds.Input) error {
+		keys, err := seeds.NewKeys(*identity, func() (string, error) {
+			if *passphrase != "" {
+				return *passphrase, nil
+			}
+			passphrase, err := promptPassphrase("Seeds file passphrase: ")
 			if err != nil {
-				log.Printf("Seed line %d index %d: NewFromPrivatekey failed: %v", si+1, i, err)
-				count++
-				if count%50 == 0 {
-					fmt.Printf("\rProcessed %d/%d", count, totalToGenerate)
-				}
-				continue
+				return "", fmt.Errorf("%w, give --seeds-passphrase", err)
 			}
-			w.HDPath = fmt.Sprintf("%s/%d", basePathStr, i)
+			return passphrase, nil
+		})
+		if err != nil {
+			return err
+		}
+		input.Keys = keys
+		return input.Unlock()
+	}
+}
 
-			if validateAddress(w.Address) {
-				if gdb != nil {
-					if err := gdb.Create(w).Error; err != nil {
-						log.Printf("DB save failed for seed %d idx %d: %v", si+1, i, err)
-					}
-				} else {
-					// print a compact representation when no DB configured
-					fmt.Printf("MATCH: seed_line=%d idx=%d addr=%s pk=%s hdpath=%s\n", si+1, i, w.Address, w.PrivateKey, w.HDPath)
-				}
+// promptPassphrase reads a passphrase from the terminal without echoing it.
+func promptPassphrase(prompt string) (string, error) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return "", errors.New("no terminal to ask the passphrase on")
+	}
+	defer tty.Close()
+	fmt.Fprint(tty, prompt)
+	passphrase, err := term.ReadPassword(int(tty.Fd()))
+	fmt.Fprintln(tty)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the passphrase: %w", err)
+	}
+	return string(passphrase), nil
+}
+
+// addFilterFlags registers the address filter flags on fs and returns a function
+// building the filter config once the flags have been parsed.
+func addFilterFlags(fs *flag.FlagSet) func() filter.Config {
+	build := filterFlags(fs)
+	return func() filter.Config {
+		cfg, err := build()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitUsage)
+		}
+		return cfg
+	}
+}
+
+// filterFlags is addFilterFlags returning the invalid filters as an error, eg. to reload them.
+func filterFlags(fs *flag.FlagSet) func() (filter.Config, error) {
+	strict := fs.Bool("strict", false, "strict contains mode")
+	contain := fs.String("contains", "", "show only result that contained with the given letters (support for multiple characters)")
+	prefix := fs.String("prefix", "", "show only result that prefix was matched")
+	suffix := fs.String("suffix", "", "show only result that suffix was matched")
+	var regexes, validators, plugins stringsFlag
+	fs.Var(&regexes, "regex", "show only result that was matched with given regex (eg. ^0x99 or ^0x00), can be repeated to match any of them")
+	fs.Var(&validators, "validator", "also require the registered validator name[:arg] to accept the wallet (eg. leading-zeros:4 or zero-bytes:2), can be repeated")
+	fs.Var(&plugins, "validator-plugin", "load a Go plugin registering more validators, can be repeated")
+
+	return func() (filter.Config, error) {
+		for _, path := range plugins {
+			if err := filter.LoadPlugin(path); err != nil {
+				return filter.Config{}, err
 			}
+		}
+		cfg := filter.Config{
+			Contains:   strings.Split(*contain, ","),
+			Strict:     *strict,
+			Prefix:     *prefix,
+			Suffix:     *suffix,
+			Regex:      regexes,
+			Validators: validators,
+		}
+		return cfg, cfg.Validate()
+	}
+}
+
+// newValidators returns the validators of the filters, which addFilterFlags already checked.
+func newValidators(cfg filter.Config) filter.Validator {
+	v, err := filter.NewValidators(cfg.Validators)
+	if err != nil {
+		fatal("Failed to create validators", "err", err)
+	}
+	return v
+}
+
+// stringsFlag is a flag that can be repeated, keeping every value.
+type stringsFlag []string
+
+func (s *stringsFlag) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringsFlag) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+// addDuplicatesFlag registers the -duplicates flag on fs and returns a function checking
+// the selected mode once the flags have been parsed.
+func addDuplicatesFlag(fs *flag.FlagSet) func() (string, error) {
+	mode := fs.String("duplicates", seeds.DuplicatesKeep, fmt.Sprintf("what to do with phrases repeated in the input, compared with normalized whitespace and case %v", seeds.DuplicatesModes))
+
+	return func() (string, error) {
+		if !slices.Contains(seeds.DuplicatesModes, *mode) {
+			return "", fmt.Errorf("unknown --duplicates %q, must be one of %v", *mode, seeds.DuplicatesModes)
+		}
+		return *mode, nil
+	}
+}
 
-			count++
-			if count%50 == 0 {
-				fmt.Printf("\rProcessed %d/%d", count, totalToGenerate)
+// addCoinFlags registers the -coin and -address-type flags on fs and returns a function
+// looking the coin up once the flags have been parsed.
+func addCoinFlags(fs *flag.FlagSet) func() (coins.Coin, error) {
+	name := fs.String("coin", "eth", fmt.Sprintf("chain whose addresses are derived from the mnemonics %v", coins.Names()))
+	addressType := fs.String("address-type", "", "address type of the --coin (eg. p2wpkh, p2sh-p2wpkh, p2pkh or p2tr for btc, the bech32 prefix of the chain such as osmo for cosmos), empty for its default one")
+	var plugins stringsFlag
+	fs.Var(&plugins, "coin-plugin", "load a Go plugin registering more coins, can be repeated")
+
+	return func() (coins.Coin, error) {
+		for _, path := range plugins {
+			if err := coins.LoadPlugin(path); err != nil {
+				return nil, err
 			}
 		}
+		return coins.Lookup(*name, *addressType)
+	}
+}
+
+// coinID identifies the coin and address type in checkpoints and summaries, eth ones are
+// left empty so earlier checkpoints stay valid.
+func coinID(coin coins.Coin) string {
+	if coin == coins.ETH {
+		return ""
 	}
+	return coin.Name() + "/" + coin.AddressType()
+}
 
-	// final progress newline
-	fmt.Printf("\rProcessed %d/%d\n", count, totalToGenerate)
+// dedupSeeds wraps the seeds of input with the handling of the duplicates mode. onDuplicate
+// is called from another goroutine for every duplicate found, with the seed and whether it was skipped.
+func dedupSeeds(ctx context.Context, in <-chan seeds.Seed, mode string, input *seeds.Input, onDuplicate func(seed seeds.Seed, skipped bool)) <-chan seeds.Seed {
+	if mode == seeds.DuplicatesKeep {
+		return in
+	}
+	skip := mode == seeds.DuplicatesSkip
+	return seeds.Dedup(ctx, in, skip, func(seed seeds.Seed, first int) {
+		file, line := input.Locate(seed.Line)
+		firstFile, firstLine := input.Locate(first)
+		slog.Debug("Duplicate seed", append(seedAttrs(file, line), "first", fmt.Sprintf("%sline %d", filePrefix(firstFile), firstLine), "skipped", skip)...)
+		onDuplicate(seed, skip)
+	})
 }
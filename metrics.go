@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"go.opentelemetry.io/otel"
+
+	"github.com/planxnx/ethereum-wallet-generator/internal/metrics"
+	"github.com/planxnx/ethereum-wallet-generator/internal/telemetry"
+	"github.com/planxnx/ethereum-wallet-generator/pipeline"
+)
+
+// runMetrics are the metrics of the command, nil unless -metrics or -otel is set.
+var runMetrics *metrics.Metrics
+
+// runTelemetry exports the traces and metrics of the command, nil unless -otel is set.
+var runTelemetry *telemetry.Telemetry
+
+// tracer traces the runs of the commands, through the provider -otel installs.
+var tracer = otel.Tracer("github.com/planxnx/ethereum-wallet-generator")
+
+// addMetricsFlags registers the -metrics, -status and -otel flags on fs and returns a function
+// starting the metrics and status endpoints and the OpenTelemetry exporters once the flags have
+// been parsed, setting runMetrics and runTelemetry.
+func addMetricsFlags(fs *flag.FlagSet) func() {
+	listen := fs.String("metrics", "", "serve Prometheus metrics on this address and path, eg. :9090/metrics")
+	status := fs.String("status", "", "serve the JSON status of the run (uptime, progress, rate, matches, last error) on this address and path for health checks, eg. :8081")
+	endpoint := fs.String("otel", "", "export traces and metrics over OTLP/HTTP to the collector at this URL, eg. http://localhost:4318")
+	sample := fs.Float64("otel-sample", 1, "fraction of the seeds whose pipeline stages are traced, runs and API requests always are")
+
+	return func() {
+		if *listen != "" {
+			m := metrics.New(fs.Name())
+			if _, err := m.Serve(*listen); err != nil {
+				fatal("Failed to serve metrics", "err", err)
+			}
+			slog.Info("Serving metrics", "addr", *listen)
+			runMetrics = m
+		}
+		if *status != "" {
+			// the status is read from the counters of -metrics
+			if runMetrics == nil {
+				runMetrics = metrics.New(fs.Name())
+			}
+			if err := serveStatus(*status, fs.Name()); err != nil {
+				fatal("Failed to serve status", "err", err)
+			}
+			slog.Info("Serving status", "addr", *status)
+		}
+		if *endpoint == "" {
+			return
+		}
+		if *sample < 0 || *sample > 1 {
+			fmt.Fprintln(os.Stderr, "Error: --otel-sample must be between 0 and 1")
+			os.Exit(exitUsage)
+		}
+		// the counters of -metrics are exported along with the spans
+		if runMetrics == nil {
+			runMetrics = metrics.New(fs.Name())
+		}
+		t, err := telemetry.Start(context.Background(), telemetry.Config{
+			Endpoint:     *endpoint,
+			Service:      "ethereum-wallet-generator",
+			Command:      fs.Name(),
+			SampleRatio:  *sample,
+			SampledSpans: []string{pipeline.SeedSpan},
+			Gatherer:     runMetrics.Gatherer(),
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --otel: %v\n", err)
+			os.Exit(exitUsage)
+		}
+		slog.Info("Exporting telemetry", "endpoint", *endpoint)
+		runTelemetry = t
+	}
+}
+
+// shutdownTelemetry exports the spans and metrics not exported yet, before the program exits.
+func shutdownTelemetry() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := runTelemetry.Shutdown(ctx); err != nil {
+		slog.Warn("Failed to export telemetry", "err", err)
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/planxnx/ethereum-wallet-generator/store"
+)
+
+// runMigrate applies the pending schema migrations to a result DB, or lists them.
+func runMigrate(args []string) {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	dbPath := fs.String("db", "", "sqlite DB file to migrate eg. wallets.db (a bare file name is read from ./db) or out/wallets.db, or a postgres:// or mysql:// DSN")
+	dbKey := fs.String("db-key", "", "SQLCipher passphrase of an encrypted DB")
+	status := fs.Bool("status", false, "only list the applied and pending migrations")
+	parseFlags(fs, args)
+
+	if *dbPath == "" {
+		fmt.Fprintln(os.Stderr, "Error: --db parameter required")
+		os.Exit(exitUsage)
+	}
+	if !isServerDSN(*dbPath) {
+		if _, err := os.Stat(sqlitePath(*dbPath)); err != nil {
+			fatal("Failed to open sqlite DB", "err", err)
+		}
+	}
+	db := openGorm(*dbPath, *dbKey)
+
+	if !*status {
+		migrations, err := store.Migrate(db)
+		if err != nil {
+			fatal("DB migration failed", "err", err)
+		}
+		fmt.Fprintf(os.Stderr, "Applied %d migrations\n", len(migrations))
+	}
+
+	applied, err := store.AppliedMigrations(db)
+	if err != nil {
+		fatal("Failed to read DB migrations", "err", err)
+	}
+	appliedAt := make(map[int]time.Time, len(applied))
+	version := 0
+	for _, m := range applied {
+		appliedAt[m.Version] = m.AppliedAt
+		version = max(version, m.Version)
+	}
+	fmt.Printf("Schema version %d of %d\n", version, store.LatestSchemaVersion)
+	for _, m := range store.Migrations {
+		state := "pending"
+		if t, ok := appliedAt[m.Version]; ok {
+			state = "applied " + t.Format(time.RFC3339)
+		}
+		fmt.Printf("  %3d  %-30s %s\n", m.Version, m.Name, state)
+	}
+	if version > store.LatestSchemaVersion {
+		fmt.Fprintf(os.Stderr, "Warning: the DB was migrated by a newer build, upgrade the program before writing to it\n")
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// errOffline is the error of every connection attempted by an -offline run.
+var errOffline = errors.New("network access is disabled by --offline")
+
+// networkFlags are the flags whose features use the network, with whether a value does.
+var networkFlags = []struct {
+	name string
+	uses func(value string) bool
+}{
+	{"db", isServerDSN},
+	{"kms", isSet},
+	{"notify", isSet},
+	{"metrics", isSet},
+	{"status", offLocalhost},
+	{"otel", isSet},
+	{"upload", isSet},
+}
+
+// addOfflineFlag registers the -offline flag on fs and returns a function that, once the
+// flags have been parsed, refuses to start if a network using feature is configured and
+// blocks the network of the process.
+func addOfflineFlag(fs *flag.FlagSet) func() {
+	offline := fs.Bool("offline", false, "air-gapped run: refuse to start if a feature using the network is configured (a postgres or mysql -db, -kms, -notify, -metrics, -otel, -upload, a -status address off localhost) and make any connection attempt fail")
+
+	return func() {
+		if !*offline {
+			return
+		}
+		if used := networkFlagsUsed(fs); len(used) > 0 {
+			fmt.Fprintf(os.Stderr, "Error: --offline can't be combined with %s, they use the network\n", strings.Join(used, ", "))
+			os.Exit(exitUsage)
+		}
+		blockNetwork()
+	}
+}
+
+// networkFlagsUsed returns the flags of fs whose value uses the network.
+func networkFlagsUsed(fs *flag.FlagSet) []string {
+	var used []string
+	for _, nf := range networkFlags {
+		if f := fs.Lookup(nf.name); f != nil && nf.uses(f.Value.String()) {
+			used = append(used, "--"+nf.name)
+		}
+	}
+	return used
+}
+
+// blockNetwork makes the DNS lookups and the connections of the default resolver and HTTP
+// transport fail, along with the HTTP clients built from them, so no code path reaches the
+// network by accident.
+func blockNetwork() {
+	dial := func(context.Context, string, string) (net.Conn, error) {
+		return nil, errOffline
+	}
+	net.DefaultResolver = &net.Resolver{PreferGo: true, Dial: dial}
+	http.DefaultTransport = &http.Transport{DialContext: dial}
+}
+
+// isSet reports whether a flag value is set.
+func isSet(value string) bool {
+	return value != ""
+}
+
+// offLocalhost reports whether the host:port/path listen address of a flag is set and accepts
+// connections from other machines.
+func offLocalhost(listen string) bool {
+	addr, _, _ := strings.Cut(listen, "/")
+	return listen != "" && !isLoopback(addr)
+}
+
+// isLoopback reports whether the host or host:port addr only accepts local connections. An
+// address without host listens on every interface.
+func isLoopback(addr string) bool {
+	host := addr
+	if h, _, err := net.SplitHostPort(addr); err == nil {
+		host = h
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(strings.Trim(host, "[]"))
+	return ip != nil && ip.IsLoopback()
+}
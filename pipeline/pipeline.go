@@ -0,0 +1,473 @@
+// Package pipeline connects the seed reader, derivation workers, address filter and result writer
+// with bounded channels, so a slow stage applies backpressure on the previous ones. Seeds are
+// traced and the stages timed through the global OpenTelemetry providers.
+package pipeline
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/planxnx/ethereum-wallet-generator/coins"
+	"github.com/planxnx/ethereum-wallet-generator/filter"
+	"github.com/planxnx/ethereum-wallet-generator/internal/throttle"
+	"github.com/planxnx/ethereum-wallet-generator/scanner"
+	"github.com/planxnx/ethereum-wallet-generator/seeds"
+	"github.com/planxnx/ethereum-wallet-generator/wallets"
+)
+
+// DefaultQueueSize is the default capacity of the channels between stages.
+const DefaultQueueSize = 256
+
+// SeedSpan is the name of the root span of a seed, the parent of its read, derive, filter and
+// write spans. It links to the span of the context of Run.
+const SeedSpan = "seed"
+
+// The stages of the pipeline.
+var stageNames = []string{"read", "derive", "filter", "write"}
+
+var (
+	tracer = otel.Tracer("github.com/planxnx/ethereum-wallet-generator/pipeline")
+	meter  = otel.Meter("github.com/planxnx/ethereum-wallet-generator/pipeline")
+)
+
+// Match is a derived wallet that passed the address validator.
+type Match struct {
+	Line   int
+	Index  int
+	Phrase string
+	Label  string
+	Wallet *wallets.Wallet
+}
+
+// The categories of the failures.
+const (
+	// CategoryInvalid is a seed rejected by Config.Check.
+	CategoryInvalid = "invalid"
+	// CategorySeed is a seed whose base key couldn't be derived, eg. of an invalid path.
+	CategorySeed = "seed"
+	// CategoryAddress is an address index of a seed that couldn't be derived.
+	CategoryAddress = "address"
+)
+
+// Failure is an error raised while checking or deriving a seed. Index is -1 when the whole seed
+// failed.
+type Failure struct {
+	Line     int
+	Index    int
+	Category string
+	Err      error
+}
+
+// SeedStats is the outcome of a single seed.
+type SeedStats struct {
+	Line int
+	// Processed is the number of address indexes of the seed done, Derived the number of
+	// them derived: none for a seed rejected or failing to derive, whose indexes are done all
+	// the same.
+	Processed int
+	Derived   int
+	Matches   int
+	Failures  int
+	// Skipped is the number of address indexes Config.Skip skipped, they count as processed.
+	Skipped int
+	// Elapsed is the time spent deriving the wallets of the seed.
+	Elapsed time.Duration
+}
+
+// Config configures a Pipeline. Every callback is called from the single writer goroutine.
+type Config struct {
+	Workers    int
+	QueueSize  int
+	Depth      int
+	BasePath   accounts.DerivationPath
+	CPUPercent int
+	// Coin is the chain whose addresses are derived, nil derives Ethereum ones.
+	Coin coins.Coin
+
+	// ResumeLine and ResumeIndex skip the first ResumeIndex address indexes of the seed at ResumeLine.
+	ResumeLine  int
+	ResumeIndex int
+
+	// Skip reports whether an address index of a seed was already handled, by an earlier run.
+	// Skipped indexes are neither derived nor matched, a seed is only derived if some of its
+	// indexes are not skipped. It is called from the workers and may be nil.
+	Skip func(seed seeds.Seed, index int) bool
+	// Check rejects a seed before it is derived, eg. an invalid mnemonic, reported as a failure
+	// of the CategoryInvalid category. It is called from the workers and may be nil.
+	Check func(seed seeds.Seed) error
+	// SkipSeedOnError drops the address indexes of a seed following the first one that failed,
+	// they are neither matched nor reported.
+	SkipSeedOnError bool
+
+	// AddressValidator reports whether a derived address is a match, nil matches everything.
+	AddressValidator func(address string) bool
+	// Validator further selects the wallets of the addresses AddressValidator accepted, it may be nil.
+	Validator filter.Validator
+
+	// OnMatch is called for every match.
+	OnMatch func(Match)
+	// OnMiss is called for every derived wallet that isn't a match, before the matches of its
+	// seed. It may be nil, the wallets that aren't matches are then dropped as they are filtered.
+	OnMiss func(Match)
+	// OnFailure is called for every derivation error.
+	OnFailure func(Failure)
+	// OnProgress is called with the number of address indexes processed for a seed.
+	OnProgress func(processed int)
+	// OnSeed is called once per seed, after its matches and failures.
+	OnSeed func(SeedStats)
+	// OnCommit is called with the line of the last seed such that it and every seed before it are done.
+	OnCommit func(line int)
+}
+
+// Pipeline derives, filters and writes wallets for a stream of seeds.
+type Pipeline struct {
+	config Config
+
+	mu sync.Mutex
+	// resumed is closed by Resume, it is nil while the pipeline isn't paused.
+	resumed chan struct{}
+
+	// stages records the time a seed spent in a stage, with the options of its stage attribute.
+	stages     metric.Float64Histogram
+	stageAttrs map[string]metric.RecordOption
+}
+
+// derivedSeed is a seed derived by a worker, waiting to be filtered.
+type derivedSeed struct {
+	seq     int
+	line    int
+	phrase  string
+	label   string
+	span    trace.Span
+	results []scanner.Result
+	err     error
+	// invalid is set when err is the rejection of Config.Check.
+	invalid bool
+	skipped int
+	// stored is the number of indexes dropped by Config.Skip.
+	stored  int
+	elapsed time.Duration
+}
+
+// filteredSeed is a seed whose derived wallets were filtered, waiting to be written.
+type filteredSeed struct {
+	seq       int
+	line      int
+	span      trace.Span
+	processed int
+	derived   int
+	stored    int
+	matches   []Match
+	misses    []Match
+	failures  []Failure
+	elapsed   time.Duration
+}
+
+// sequencedSeed is a seed with its position in the input stream.
+type sequencedSeed struct {
+	seq  int
+	seed seeds.Seed
+	span trace.Span
+}
+
+// New returns a new pipeline.
+func New(cfg Config) *Pipeline {
+	if cfg.Workers < 1 {
+		cfg.Workers = 1
+	}
+	if cfg.QueueSize < 1 {
+		cfg.QueueSize = DefaultQueueSize
+	}
+	if cfg.Depth < 1 {
+		cfg.Depth = 1
+	}
+	p := &Pipeline{config: cfg, stageAttrs: make(map[string]metric.RecordOption)}
+	var err error
+	p.stages, err = meter.Float64Histogram("ewg.pipeline.stage.duration", metric.WithUnit("s"),
+		metric.WithDescription("Time a seed spent in a stage of the pipeline: read, derive, filter or write."))
+	if err != nil {
+		otel.Handle(err)
+	}
+	for _, name := range stageNames {
+		p.stageAttrs[name] = metric.WithAttributeSet(attribute.NewSet(attribute.String("stage", name)))
+	}
+	return p
+}
+
+// stage is a seed going through a stage of the pipeline.
+type stage struct {
+	name  string
+	start time.Time
+	span  trace.Span
+}
+
+// startStage starts the span of a stage, a child of the span of the seed.
+func startStage(seed trace.Span, name string, start time.Time) stage {
+	_, span := tracer.Start(trace.ContextWithSpan(context.Background(), seed), name, trace.WithTimestamp(start))
+	return stage{name: name, start: start, span: span}
+}
+
+// endStage ends the span of a stage and records its duration.
+func (p *Pipeline) endStage(s stage, attrs ...attribute.KeyValue) {
+	now := time.Now()
+	s.span.SetAttributes(attrs...)
+	s.span.End(trace.WithTimestamp(now))
+	p.stages.Record(context.Background(), now.Sub(s.start).Seconds(), p.stageAttrs[s.name])
+}
+
+// Run processes every seed of in until it's closed or ctx is canceled.
+// On cancellation, queued seeds are dropped and the seeds already derived
+// are drained through the writer before Run returns.
+func (p *Pipeline) Run(ctx context.Context, in <-chan seeds.Seed) {
+	sequenced := p.sequence(ctx, in)
+	derived := p.derive(ctx, sequenced)
+	filtered := p.filter(derived)
+	p.write(filtered)
+}
+
+// sequence numbers the incoming seeds and stops forwarding them once ctx is canceled.
+func (p *Pipeline) sequence(ctx context.Context, in <-chan seeds.Seed) <-chan sequencedSeed {
+	out := make(chan sequencedSeed, p.config.QueueSize)
+	go func() {
+		defer close(out)
+		for seq := 0; ; seq++ {
+			start := time.Now()
+			select {
+			case <-ctx.Done():
+				return
+			case seed, ok := <-in:
+				if !ok {
+					return
+				}
+				_, span := tracer.Start(ctx, SeedSpan, trace.WithNewRoot(), trace.WithLinks(trace.LinkFromContext(ctx)),
+					trace.WithTimestamp(start), trace.WithAttributes(attribute.Int("ewg.seed.line", seed.Line)))
+				p.endStage(startStage(span, "read", start))
+				select {
+				case <-ctx.Done():
+					span.End()
+					return
+				case out <- sequencedSeed{seq: seq, seed: seed, span: span}:
+				}
+			}
+		}
+	}()
+	return out
+}
+
+// Pause stops the workers from starting new seeds until Resume is called. The seeds
+// being derived are finished and written.
+func (p *Pipeline) Pause() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.resumed == nil {
+		p.resumed = make(chan struct{})
+	}
+}
+
+// Resume lets the workers continue after Pause.
+func (p *Pipeline) Resume() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.resumed != nil {
+		close(p.resumed)
+		p.resumed = nil
+	}
+}
+
+// waitResumed blocks while the pipeline is paused, or until ctx is canceled.
+func (p *Pipeline) waitResumed(ctx context.Context) {
+	p.mu.Lock()
+	resumed := p.resumed
+	p.mu.Unlock()
+	if resumed != nil {
+		select {
+		case <-resumed:
+		case <-ctx.Done():
+		}
+	}
+}
+
+// derive runs the derivation workers.
+func (p *Pipeline) derive(ctx context.Context, in <-chan sequencedSeed) <-chan derivedSeed {
+	out := make(chan derivedSeed, p.config.QueueSize)
+	done := make(chan struct{})
+	for i := 0; i < p.config.Workers; i++ {
+		go func() {
+			defer func() { done <- struct{}{} }()
+
+			var cpu *throttle.Throttle
+			if p.config.CPUPercent > 0 {
+				cpu = throttle.New(p.config.CPUPercent)
+			}
+
+			for s := range in {
+				p.waitResumed(ctx)
+				if ctx.Err() != nil {
+					s.span.End()
+					continue
+				}
+				cpu.Start()
+
+				from := 0
+				if s.seed.Line == p.config.ResumeLine {
+					from = min(p.config.ResumeIndex, p.config.Depth)
+				}
+
+				d := derivedSeed{seq: s.seq, line: s.seed.Line, phrase: s.seed.Phrase, label: s.seed.Label, span: s.span, skipped: from}
+				if p.config.Check != nil {
+					if d.err = p.config.Check(s.seed); d.err != nil {
+						d.invalid = true
+						out <- d
+						continue
+					}
+				}
+				var stored []bool
+				if p.config.Skip != nil {
+					stored = make([]bool, p.config.Depth)
+					for i := from; i < p.config.Depth; i++ {
+						if p.config.Skip(s.seed, i) {
+							stored[i] = true
+							d.stored++
+						}
+					}
+				}
+				if d.stored == p.config.Depth-from {
+					out <- d
+					continue
+				}
+
+				d.results = make([]scanner.Result, 0, p.config.Depth-from-d.stored)
+				st := startStage(s.span, "derive", time.Now())
+				failed := false
+				d.err = scanner.DeriveCoin(s.seed, p.coin(), p.config.BasePath, from, p.config.Depth, func(r scanner.Result) {
+					if failed || (stored != nil && stored[r.Index]) {
+						return
+					}
+					d.results = append(d.results, r)
+					failed = r.Err != nil && p.config.SkipSeedOnError
+				})
+				d.elapsed = time.Since(st.start)
+				if d.err != nil {
+					st.span.RecordError(d.err)
+				}
+				p.endStage(st, attribute.Int("ewg.addresses", len(d.results)))
+				cpu.Wait()
+				out <- d
+			}
+		}()
+	}
+	go func() {
+		for i := 0; i < p.config.Workers; i++ {
+			<-done
+		}
+		close(out)
+	}()
+	return out
+}
+
+// filter applies the address validator to derived wallets.
+func (p *Pipeline) filter(in <-chan derivedSeed) <-chan filteredSeed {
+	out := make(chan filteredSeed, p.config.QueueSize)
+	go func() {
+		defer close(out)
+		for d := range in {
+			f := filteredSeed{seq: d.seq, line: d.line, span: d.span, processed: p.config.Depth - d.skipped, stored: d.stored, elapsed: d.elapsed}
+			st := startStage(d.span, "filter", time.Now())
+			if d.err != nil {
+				p.endStage(st)
+				category := CategorySeed
+				if d.invalid {
+					category = CategoryInvalid
+				}
+				f.failures = append(f.failures, Failure{Line: d.line, Index: -1, Category: category, Err: d.err})
+				out <- f
+				continue
+			}
+			f.derived = f.processed
+
+			for _, r := range d.results {
+				if r.Err != nil {
+					f.failures = append(f.failures, Failure{Line: d.line, Index: r.Index, Category: CategoryAddress, Err: r.Err})
+					continue
+				}
+				m := Match{Line: d.line, Index: r.Index, Phrase: d.phrase, Label: d.label, Wallet: r.Wallet}
+				if p.valid(r.Wallet) {
+					f.matches = append(f.matches, m)
+				} else if p.config.OnMiss != nil {
+					f.misses = append(f.misses, m)
+				}
+			}
+			p.endStage(st, attribute.Int("ewg.matches", len(f.matches)))
+			out <- f
+		}
+	}()
+	return out
+}
+
+// valid reports whether the wallet passes the address validator and the validator.
+func (p *Pipeline) valid(w *wallets.Wallet) bool {
+	if p.config.AddressValidator != nil && !p.config.AddressValidator(w.Address) {
+		return false
+	}
+	return p.config.Validator == nil || p.config.Validator.Valid(common.HexToAddress(w.Address), w)
+}
+
+// coin returns the coin whose addresses are derived.
+func (p *Pipeline) coin() coins.Coin {
+	if p.config.Coin == nil {
+		return coins.ETH
+	}
+	return p.config.Coin
+}
+
+// write hands results to the callbacks and tracks the contiguous completed prefix of the input.
+func (p *Pipeline) write(in <-chan filteredSeed) {
+	var (
+		next    int
+		pending = make(map[int]int)
+	)
+	for f := range in {
+		st := startStage(f.span, "write", time.Now())
+		for _, failure := range f.failures {
+			if p.config.OnFailure != nil {
+				p.config.OnFailure(failure)
+			}
+		}
+		for _, m := range f.misses {
+			p.config.OnMiss(m)
+		}
+		for _, m := range f.matches {
+			if p.config.OnMatch != nil {
+				p.config.OnMatch(m)
+			}
+		}
+		if p.config.OnProgress != nil {
+			p.config.OnProgress(f.processed)
+		}
+		if p.config.OnSeed != nil {
+			p.config.OnSeed(SeedStats{Line: f.line, Processed: f.processed, Derived: f.derived, Skipped: f.stored, Matches: len(f.matches), Failures: len(f.failures), Elapsed: f.elapsed})
+		}
+		p.endStage(st)
+		f.span.SetAttributes(attribute.Int("ewg.matches", len(f.matches)), attribute.Int("ewg.failures", len(f.failures)))
+		f.span.End()
+
+		pending[f.seq] = f.line
+		committed := 0
+		for line, ok := pending[next]; ok; line, ok = pending[next] {
+			delete(pending, next)
+			committed = line
+			next++
+		}
+		if committed > 0 && p.config.OnCommit != nil {
+			p.config.OnCommit(committed)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/planxnx/ethereum-wallet-generator/coins"
+	"github.com/planxnx/ethereum-wallet-generator/seeds"
+	"github.com/planxnx/ethereum-wallet-generator/wallets"
+)
+
+func TestPipelineRun(t *testing.T) {
+	const (
+		numSeeds = 20
+		depth    = 3
+	)
+
+	in := make(chan seeds.Seed, numSeeds)
+	for i := 1; i <= numSeeds; i++ {
+		in <- seeds.Seed{Line: i, Phrase: "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"}
+	}
+	close(in)
+
+	var (
+		matches   []Match
+		processed int
+		seedStats int
+		commits   []int
+	)
+	New(Config{
+		Workers:     4,
+		Depth:       depth,
+		BasePath:    wallets.DefaultBaseDerivationPath,
+		ResumeLine:  1,
+		ResumeIndex: 1,
+		AddressValidator: func(address string) bool {
+			return address == "0x9858effd232b4033e47d90003d41ec34ecaeda94"
+		},
+		OnMatch:    func(m Match) { matches = append(matches, m) },
+		OnFailure:  func(f Failure) { t.Errorf("unexpected failure: %+v", f) },
+		OnProgress: func(n int) { processed += n },
+		OnSeed:     func(st SeedStats) { seedStats += st.Processed },
+		OnCommit:   func(line int) { commits = append(commits, line) },
+	}).Run(context.Background(), in)
+
+	assert.Equal(t, numSeeds*depth-1, processed)
+	assert.Equal(t, processed, seedStats)
+	assert.Len(t, matches, numSeeds-1, "index 0 of the resumed line must be skipped")
+	assert.IsIncreasing(t, commits)
+	assert.Equal(t, numSeeds, commits[len(commits)-1])
+}
+
+func TestPipelineSkip(t *testing.T) {
+	in := make(chan seeds.Seed, 2)
+	in <- seeds.Seed{Line: 1, Phrase: "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"}
+	in <- seeds.Seed{Line: 2, Phrase: "legal winner thank year wave sausage worth useful legal winner thank yellow"}
+	close(in)
+
+	var (
+		matches  []Match
+		skipped  int
+		progress int
+	)
+	New(Config{
+		Depth:    2,
+		BasePath: wallets.DefaultBaseDerivationPath,
+		// every index of line 1 and index 0 of line 2 are done
+		Skip:       func(seed seeds.Seed, index int) bool { return seed.Line == 1 || index == 0 },
+		OnMatch:    func(m Match) { matches = append(matches, m) },
+		OnProgress: func(n int) { progress += n },
+		OnSeed:     func(st SeedStats) { skipped += st.Skipped },
+	}).Run(context.Background(), in)
+
+	if assert.Len(t, matches, 1) {
+		assert.Equal(t, 2, matches[0].Line)
+		assert.Equal(t, 1, matches[0].Index)
+	}
+	assert.Equal(t, 3, skipped)
+	assert.Equal(t, 4, progress)
+}
+
+func TestPipelineFailures(t *testing.T) {
+	in := make(chan seeds.Seed, 3)
+	in <- seeds.Seed{Line: 1, Phrase: "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"}
+	in <- seeds.Seed{Line: 2, Phrase: "not a mnemonic"}
+	in <- seeds.Seed{Line: 3, Phrase: "legal winner thank year wave sausage worth useful legal winner thank yellow", Path: "m/x"}
+	close(in)
+
+	failures := make(map[int]Failure)
+	var progress, derived int
+	New(Config{
+		Depth:    2,
+		BasePath: wallets.DefaultBaseDerivationPath,
+		Check: func(seed seeds.Seed) error {
+			if seed.Line == 2 {
+				return errors.New("not a BIP39 mnemonic")
+			}
+			return nil
+		},
+		OnFailure:  func(f Failure) { failures[f.Line] = f },
+		OnProgress: func(n int) { progress += n },
+		OnSeed:     func(st SeedStats) { derived += st.Derived },
+	}).Run(context.Background(), in)
+
+	assert.Len(t, failures, 2)
+	assert.Equal(t, CategoryInvalid, failures[2].Category)
+	assert.Equal(t, -1, failures[2].Index)
+	assert.Equal(t, CategorySeed, failures[3].Category)
+	assert.Equal(t, 6, progress, "failed seeds count as processed")
+	assert.Equal(t, 2, derived, "failed seeds derive no address")
+}
+
+// failingCoin derives Ethereum wallets, failing at index 1.
+type failingCoin struct{ coins.Coin }
+
+func (c failingCoin) NewDeriver(mnemonic, passphrase string, basePath accounts.DerivationPath) (coins.Deriver, error) {
+	d, err := c.Coin.NewDeriver(mnemonic, passphrase, basePath)
+	return failingDeriver{d}, err
+}
+
+type failingDeriver struct{ coins.Deriver }
+
+func (d failingDeriver) Derive(index uint32) (*wallets.Wallet, error) {
+	if index == 1 {
+		return nil, errors.New("invalid child key")
+	}
+	return d.Deriver.Derive(index)
+}
+
+func TestPipelineSkipSeedOnError(t *testing.T) {
+	for _, skip := range []bool{false, true} {
+		in := make(chan seeds.Seed, 1)
+		in <- seeds.Seed{Line: 1, Phrase: "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"}
+		close(in)
+
+		var matches, failures int
+		New(Config{
+			Depth:           4,
+			BasePath:        wallets.DefaultBaseDerivationPath,
+			Coin:            failingCoin{coins.ETH},
+			SkipSeedOnError: skip,
+			OnMatch:         func(Match) { matches++ },
+			OnFailure:       func(Failure) { failures++ },
+		}).Run(context.Background(), in)
+
+		assert.Equal(t, 1, failures)
+		if skip {
+			assert.Equal(t, 1, matches, "the indexes after the failed one are skipped")
+		} else {
+			assert.Equal(t, 3, matches)
+		}
+	}
+}
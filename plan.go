@@ -0,0 +1,412 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/planxnx/ethereum-wallet-generator/coins"
+	"github.com/planxnx/ethereum-wallet-generator/filter"
+	"github.com/planxnx/ethereum-wallet-generator/generator"
+	"github.com/planxnx/ethereum-wallet-generator/internal/distributed"
+	"github.com/planxnx/ethereum-wallet-generator/internal/manifest"
+	"github.com/planxnx/ethereum-wallet-generator/internal/output"
+	"github.com/planxnx/ethereum-wallet-generator/internal/progressbar"
+	"github.com/planxnx/ethereum-wallet-generator/internal/throttle"
+	"github.com/planxnx/ethereum-wallet-generator/seeds"
+	"github.com/planxnx/ethereum-wallet-generator/store"
+	"github.com/planxnx/ethereum-wallet-generator/wallets"
+)
+
+// runPlan splits a job into signed work unit manifests processed apart by run -manifest,
+// or merges their result files with plan merge.
+func runPlan(args []string) {
+	if len(args) > 0 && args[0] == "merge" {
+		runPlanMerge(args[1:])
+		return
+	}
+	fs := flag.NewFlagSet("plan", flag.ExitOnError)
+	var seedPatterns seeds.Patterns
+	fs.Var(&seedPatterns, "seeds", "file containing list of BIP39 mnemonics (one per line) split into seed units, repeat it or use glob patterns to split several files in order")
+	seedsFormat := fs.String("seeds-format", string(seeds.FormatText), "seeds file line format: text (one mnemonic per line), or tsv/csv lines of mnemonic, passphrase, hdpath and label fields")
+	seedRangeConfig := addSeedRangeFlags(fs)
+	seedKeyConfig := addSeedKeyFlags(fs)
+	depth := fs.Int("depth", 1, "number of addresses to derive per seed/mnemonic (default 1, >=1)")
+	coinConfig := addCoinFlags(fs)
+	keys := fs.Int("keys", 0, "split a search of this many random private keys into keyspace units instead of seed files")
+	keyspaceBackend := fs.String("keyspace-backend", "random", "key search of the keyspace units [random, incremental], see generate -keyspace-backend")
+	unitSize := fs.Int("unit-size", distributed.DefaultUnitSize, "number of seeds, or keys with -keys, per work unit")
+	units := fs.Int("units", 0, "split the job into this many work units instead, overrides -unit-size")
+	dir := fs.String("dir", "manifests", "directory the unit manifests are written to")
+	key := fs.String("manifest-key", "", "secret signing the manifests, which run -manifest and plan merge need to check them and the results")
+	filterConfig := addFilterFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s plan -seeds FILE|-keys N -manifest-key KEY [flags]\n       %s plan merge -manifest-key KEY [sink flags] RESULT...\n\n", os.Args[0], os.Args[0])
+		fmt.Fprintf(os.Stderr, "Each manifest is processed with run -manifest on any machine holding the seed files, and the\nresult files are merged back into the sinks with plan merge.\n\n")
+		fs.PrintDefaults()
+	}
+	parseFlags(fs, args)
+
+	if *key == "" {
+		fmt.Fprintln(os.Stderr, "Error: --manifest-key parameter required, signing the manifests")
+		os.Exit(exitUsage)
+	}
+	if (len(seedPatterns) == 0) == (*keys <= 0) {
+		fmt.Fprintln(os.Stderr, "Error: give either --seeds or --keys to split")
+		os.Exit(exitUsage)
+	}
+	if *keyspaceBackend != "random" && *keyspaceBackend != "incremental" {
+		fmt.Fprintf(os.Stderr, "Error: unknown --keyspace-backend %q, must be random or incremental\n", *keyspaceBackend)
+		os.Exit(exitUsage)
+	}
+	if *unitSize <= 0 && *units <= 0 {
+		fmt.Fprintln(os.Stderr, "Error: --unit-size must be positive")
+		os.Exit(exitUsage)
+	}
+	job := distributedJob(*depth, coinConfig, filterConfig())
+	if *keys > 0 && job.Coin != coins.ETH.Name() {
+		fmt.Fprintln(os.Stderr, "Error: --keys only searches Ethereum keys, --coin is for --seeds")
+		os.Exit(exitUsage)
+	}
+	id, err := manifest.NewPlanID()
+	if err != nil {
+		fatal("Failed to create plan id", "err", err)
+	}
+	base := manifest.Manifest{
+		Version: manifest.Version,
+		Plan:    id,
+		Job:     job,
+		Created: time.Now().UTC(),
+	}
+
+	var plan []manifest.Manifest
+	if *keys > 0 {
+		size := *unitSize
+		if *units > 0 {
+			size = (*keys + *units - 1) / *units
+		}
+		base.Kind, base.Backend = manifest.KindKeyspace, *keyspaceBackend
+		for done := 0; done < *keys; done += size {
+			m := base
+			m.Keys = min(size, *keys-done)
+			plan = append(plan, m)
+		}
+	} else {
+		plan = planSeeds(base, seedPatterns, seeds.Format(*seedsFormat), seedRangeConfig, seedKeyConfig, *unitSize, *units)
+	}
+
+	if err := os.MkdirAll(*dir, 0o700); err != nil {
+		fatal("Failed to create manifests directory", "dir", *dir, "err", err)
+	}
+	for i := range plan {
+		plan[i].Unit, plan[i].Units = i+1, len(plan)
+		name := filepath.Join(*dir, fmt.Sprintf("unit-%04d.json", i+1))
+		if err := manifest.WriteManifest(name, plan[i], *key); err != nil {
+			fatal("Failed to write manifest", "file", name, "err", err)
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Planned %d %s units of plan %s in %s\n", len(plan), plan[0].Kind, id, *dir)
+}
+
+// planSeeds returns the seed unit manifests of the lines of the seed files, unitSize non-blank
+// lines each or split into units.
+func planSeeds(base manifest.Manifest, patterns seeds.Patterns, format seeds.Format, rangeConfig func() (seeds.Range, error), keyConfig func(*seeds.Input) error, unitSize, units int) []manifest.Manifest {
+	input, err := seeds.NewInput(patterns, format)
+	if err == nil && input.IsStdin() {
+		err = fmt.Errorf("the seeds of a plan can't be read from stdin, the units must be read again")
+	}
+	if err == nil {
+		err = keyConfig(input)
+	}
+	var rng seeds.Range
+	if err == nil {
+		rng, err = rangeConfig()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitUsage)
+	}
+	if units > 0 {
+		count, err := input.CountLines(rng)
+		if err != nil {
+			fatal("Failed to open seeds file", "err", err)
+		}
+		unitSize = max((count+units-1)/units, 1)
+	}
+
+	base.Kind, base.Format = manifest.KindSeeds, format
+	for _, name := range input.Files {
+		hash, err := manifest.HashFile(name)
+		if err != nil {
+			fatal("Failed to read seeds file", "file", name, "err", err)
+		}
+		base.Files = append(base.Files, manifest.File{Name: name, SHA256: hash})
+	}
+
+	// the units are cut at the stream line of every unitSize-th seed, so that the blank lines
+	// don't count
+	var (
+		plan    []manifest.Manifest
+		start   = rng.Skip
+		last, n int
+	)
+	cut := func() {
+		m := base
+		m.Range = seeds.Range{Skip: start, Take: last - start}
+		plan = append(plan, m)
+		start, n = last, 0
+	}
+	seedCh, errCh := input.Stream(context.Background(), rng, seeds.DefaultReadAhead)
+	for seed := range seedCh {
+		last = seed.Line
+		if n++; n == unitSize {
+			cut()
+		}
+	}
+	if err := <-errCh; err != nil {
+		fatal("Failed to read seeds file", "err", err)
+	}
+	if n > 0 {
+		cut()
+	}
+	if len(plan) == 0 {
+		fatal("No seeds to plan", "seeds", input.String())
+	}
+	return plan
+}
+
+// runManifest processes the work unit of a manifest, writing its signed result file.
+func runManifest(args []string) {
+	fs := flag.NewFlagSet("run", flag.ExitOnError)
+	path := fs.String("manifest", "", "work unit manifest written by plan")
+	key := fs.String("manifest-key", "", "secret the manifest was signed with, also signing the result")
+	seedsDir := fs.String("seeds-dir", "", "directory holding the seed files of the manifest, found by base name (default the recorded paths, then the manifest directory)")
+	seedKeyConfig := addSeedKeyFlags(fs)
+	concurrency := fs.Int("c", 1, "set concurrency value (number of derivation workers)")
+	maxCPU := fs.String("max-cpu", "100%", "limit CPU usage of seed units to the given percentage (eg. 50%)")
+	out := fs.String("out", "", "result file (default the manifest name with a .result.json extension)")
+	progressConfig := addProgressFlags(fs)
+	var plugins stringsFlag
+	fs.Var(&plugins, "validator-plugin", "load a Go plugin registering validators used by the unit, can be repeated")
+	parseFlags(fs, args)
+
+	if *path == "" || *key == "" {
+		fmt.Fprintln(os.Stderr, "Error: --manifest and --manifest-key parameters required")
+		os.Exit(exitUsage)
+	}
+	for _, plugin := range plugins {
+		if err := filter.LoadPlugin(plugin); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitUsage)
+		}
+	}
+	cpuPercent, err := throttle.ParsePercent(*maxCPU)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitUsage)
+	}
+	m, err := manifest.ReadManifest(*path, *key)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitUsage)
+	}
+	if err := m.Job.Filter.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitUsage)
+	}
+	if *out == "" {
+		*out = strings.TrimSuffix(*path, filepath.Ext(*path)) + ".result.json"
+	}
+
+	ctx, stop := withSignals(context.Background())
+	defer stop()
+	res := manifest.Result{Version: manifest.Version, Plan: m.Plan, Unit: m.Unit, Units: m.Units, Matches: make([]manifest.Match, 0), Started: time.Now().UTC()}
+	switch m.Kind {
+	case manifest.KindSeeds:
+		runSeedUnit(ctx, m, &res, manifestSeeds(m, *path, *seedsDir), seedKeyConfig, max(*concurrency, 1), cpuPercent)
+	case manifest.KindKeyspace:
+		runKeyspaceUnit(ctx, m, &res, max(*concurrency, 1), progressConfig())
+	default:
+		fmt.Fprintf(os.Stderr, "Error: unknown work unit kind %q\n", m.Kind)
+		os.Exit(exitUsage)
+	}
+	if sig := interruptSignal(ctx); sig != nil {
+		// a partial result would merge as a complete unit
+		fmt.Fprintf(os.Stderr, "Interrupted by %v, no result written, run the unit again\n", sig)
+		exitCode = exitStopped
+		return
+	}
+	res.Finished = time.Now().UTC()
+	if err := manifest.WriteResult(*out, res, *key); err != nil {
+		fatal("Failed to write result", "file", *out, "err", err)
+	}
+	fmt.Fprintf(os.Stderr, "Unit %d/%d: processed %d, failed %d, matches %d, result written to %s\n", m.Unit, m.Units, res.Processed, res.Failed, len(res.Matches), *out)
+	exitCode = runExitCode(int64(len(res.Matches)), false, false)
+}
+
+// manifestSeeds returns the paths of the seed files of m, checking they are the planned ones.
+func manifestSeeds(m manifest.Manifest, path, seedsDir string) []string {
+	files := make([]string, len(m.Files))
+	for i, f := range m.Files {
+		candidates := []string{filepath.Join(seedsDir, filepath.Base(f.Name))}
+		if seedsDir == "" {
+			candidates = []string{f.Name, filepath.Join(filepath.Dir(path), filepath.Base(f.Name))}
+		}
+		for _, name := range candidates {
+			hash, err := manifest.HashFile(name)
+			if err != nil {
+				continue
+			}
+			if hash != f.SHA256 {
+				fatal("Seed file differs from the planned one", "file", name, "sha256", hash, "planned", f.SHA256)
+			}
+			files[i] = name
+			break
+		}
+		if files[i] == "" {
+			fmt.Fprintf(os.Stderr, "Error: seed file %s of the manifest not found, give its directory with --seeds-dir\n", f.Name)
+			os.Exit(exitUsage)
+		}
+	}
+	return files
+}
+
+// runSeedUnit derives the seeds of the range of a seed unit into res.
+func runSeedUnit(ctx context.Context, m manifest.Manifest, res *manifest.Result, files []string, seedKeyConfig func(*seeds.Input) error, concurrency, cpuPercent int) {
+	input, err := seeds.NewInput(files, m.Format)
+	if err == nil {
+		err = seedKeyConfig(input)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitUsage)
+	}
+	unit := &distributed.Unit{ID: m.Unit, Job: m.Job}
+	seedCh, errCh := input.Stream(ctx, m.Range, seeds.DefaultReadAhead)
+	for seed := range seedCh {
+		unit.Seeds = append(unit.Seeds, seed)
+	}
+	if err := <-errCh; err != nil && ctx.Err() == nil {
+		fatal("Failed to read seeds file", "err", err)
+	}
+
+	processed, err := distributed.Process(ctx, unit, concurrency, cpuPercent)
+	if err != nil {
+		fatal("Failed to process unit", "err", err)
+	}
+	res.Processed, res.Failed = processed.Processed, processed.Failed
+	for _, match := range processed.Matches {
+		file, line := input.Locate(match.Line)
+		// the result names the files as planned, wherever they were read from
+		name := m.Files[0].Name
+		for i := range files {
+			if files[i] == file {
+				name = m.Files[i].Name
+			}
+		}
+		res.Matches = append(res.Matches, manifest.Match{File: name, Line: line, Index: match.Index, Phrase: match.Phrase, Label: match.Label, Wallet: match.Wallet})
+	}
+}
+
+// runKeyspaceUnit searches the random keys of a keyspace unit into res. The keys are drawn
+// from the system random source of the machine running the unit, the manifest holding no
+// key material.
+func runKeyspaceUnit(ctx context.Context, m manifest.Manifest, res *manifest.Result, concurrency int, newProgressBar func(int, bool) progressbar.PartsProgressBar) {
+	validAddress := filter.NewAddressValidator(m.Job.Filter)
+	walletGen := wallets.NewGeneratorKeyspaceFrom(rand.Reader, validAddress)
+	if m.Backend == "incremental" {
+		walletGen = wallets.NewGeneratorIncrementalFrom(rand.Reader, validAddress)
+	}
+	repo := store.NewInMemoryRepository()
+	gen := generator.New(walletGen, repo, generator.Config{
+		AddresValidator: validAddress,
+		Validator:       newValidators(m.Job.Filter),
+		ProgressBar:     meteredProgress{newProgressBar(m.Keys, false)},
+		Concurrency:     concurrency,
+		Number:          m.Keys,
+		Limit:           -1,
+	})
+	go func() {
+		<-ctx.Done()
+		if interruptSignal(ctx) != nil {
+			_ = gen.Shutdown()
+		}
+	}()
+	if _, err := gen.Start(); err != nil {
+		fatal("Generator failed", "err", err)
+	}
+	res.Processed = m.Keys
+	for _, w := range repo.Result() {
+		res.Matches = append(res.Matches, manifest.Match{Wallet: w})
+	}
+}
+
+// runPlanMerge checks the result files of a plan and saves their matches to the sinks.
+func runPlanMerge(args []string) {
+	fs := flag.NewFlagSet("plan merge", flag.ExitOnError)
+	key := fs.String("manifest-key", "", "secret the results were signed with")
+	sinksConfig := addSinkFlags(fs)
+	parseFlags(fs, args)
+
+	if *key == "" || fs.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "Error: --manifest-key and the result files to merge required")
+		os.Exit(exitUsage)
+	}
+	var (
+		results []manifest.Result
+		units   = make(map[int]bool)
+	)
+	for _, name := range fs.Args() {
+		res, err := manifest.ReadResult(name, *key)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitUsage)
+		}
+		if len(results) > 0 && (res.Plan != results[0].Plan || res.Units != results[0].Units) {
+			fmt.Fprintf(os.Stderr, "Error: %s is a result of plan %s, not %s\n", name, res.Plan, results[0].Plan)
+			os.Exit(exitUsage)
+		}
+		if units[res.Unit] {
+			slog.Warn("Unit merged twice, skipping the result", "unit", res.Unit, "file", name)
+			continue
+		}
+		units[res.Unit] = true
+		results = append(results, res)
+	}
+
+	sinks := sinksConfig()
+	if sinks.recordsAll() {
+		fmt.Fprintln(os.Stderr, "Error: --matches-out isn't supported by plan merge, the results only hold their matches")
+		os.Exit(exitUsage)
+	}
+	var processed, failed, matches int
+	for _, res := range results {
+		processed += res.Processed
+		failed += res.Failed
+		for _, m := range res.Matches {
+			sinks.Save(output.Record{SeedFile: m.File, Line: m.Line, SeedLabel: m.Label, Index: m.Index, Mnemonic: m.Phrase, Wallet: m.Wallet})
+			matches++
+		}
+	}
+	sinks.Close()
+
+	missing := results[0].Units - len(results)
+	fmt.Fprintf(os.Stderr, "Merged %d of %d units of plan %s: processed %d, failed %d, matches %d\n", len(results), results[0].Units, results[0].Plan, processed, failed, matches)
+	if missing > 0 {
+		var list []string
+		for unit := 1; unit <= results[0].Units; unit++ {
+			if !units[unit] {
+				list = append(list, fmt.Sprint(unit))
+			}
+		}
+		slog.Warn("Units of the plan not merged", "count", missing, "units", strings.Join(list, ","))
+	}
+	// an incomplete plan exits as a stopped run
+	exitCode = runExitCode(int64(matches), sinks.Failed(), missing > 0)
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/planxnx/ethereum-wallet-generator/internal/progressbar"
+)
+
+// Progress formats.
+const (
+	progressFormatText = "text"
+	progressFormatJSON = "json"
+)
+
+// addProgressFlags registers the progress flags on fs and returns a function, to call once the
+// flags are parsed, returning the constructor of the progress bar of a run of total addresses.
+// A hidden bar writes nothing to stderr, eg. while the dashboard is shown.
+func addProgressFlags(fs *flag.FlagSet) func() func(total int, hidden bool) progressbar.PartsProgressBar {
+	format := fs.String("progress-format", progressFormatText, "progress format: text, a status line refreshed in place, or json, a line of {processed, total, rate, matches, elapsed, eta} per -progress-interval for GUIs and scripts")
+	file := fs.String("progress-file", "", "write the progress to this file or named pipe instead of stderr, named pipes block the run until a reader opens them")
+	interval := fs.Duration("progress-interval", progressbar.DefaultTickerInterval, "interval between the progress updates")
+
+	return func() func(int, bool) progressbar.PartsProgressBar {
+		if *format != progressFormatText && *format != progressFormatJSON {
+			fmt.Fprintf(os.Stderr, "Error: invalid --progress-format %q, must be text or json\n", *format)
+			os.Exit(exitUsage)
+		}
+		if *interval <= 0 {
+			*interval = progressbar.DefaultTickerInterval
+		}
+		// -quiet hides the status line, not the events a program asked for
+		out := progressOutput()
+		if *format == progressFormatJSON {
+			out = os.Stderr
+		}
+		toFile := *file != ""
+		if toFile {
+			// a named pipe is opened as is, the file of the updates is left open until exit
+			f, err := os.OpenFile(*file, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+			if err != nil {
+				fatal("Failed to open progress file", "err", err)
+			}
+			out = f
+		}
+		return func(total int, hidden bool) progressbar.PartsProgressBar {
+			w := out
+			if hidden && !toFile {
+				w = io.Discard
+			}
+			if *format == progressFormatJSON {
+				return progressbar.NewJSONTickerProgressBar(w, total, *interval)
+			}
+			return progressbar.NewTickerProgressBar(w, total, *interval)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/planxnx/ethereum-wallet-generator/internal/keycrypt"
+	"github.com/planxnx/ethereum-wallet-generator/wallets"
+)
+
+// runProve checks a private key against the salted hash stored by -hash-only for an address,
+// proving the match without the DB ever holding the key. It exits with status 1 if they differ.
+func runProve(args []string) {
+	fs := flag.NewFlagSet("prove", flag.ExitOnError)
+	dbPath := fs.String("db", "", "sqlite DB file written with -hash-only eg. wallets.db (a bare file name is read from ./db) or out/wallets.db, or a postgres:// or mysql:// DSN")
+	dbKey := fs.String("db-key", "", "SQLCipher passphrase of an encrypted DB")
+	address := fs.String("address", "", "stored address to prove")
+	privateKey := fs.String("private-key", "", "private key of the address, as the wallet output encodes it, read from stdin if empty")
+	parseFlags(fs, args)
+
+	if *dbPath == "" || *address == "" {
+		fmt.Fprintln(os.Stderr, "Error: --db and --address parameters required")
+		os.Exit(exitUsage)
+	}
+	if !isServerDSN(*dbPath) {
+		if _, err := os.Stat(sqlitePath(*dbPath)); err != nil {
+			fatal("Failed to open sqlite DB", "err", err)
+		}
+	}
+	key := strings.TrimSpace(*privateKey)
+	if key == "" {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			fmt.Fprintln(os.Stderr, "Error: --private-key parameter or a private key on stdin required")
+			os.Exit(exitUsage)
+		}
+		key = strings.TrimSpace(line)
+	}
+
+	var wallet wallets.Wallet
+	result := openDB(*dbPath, *dbKey).Where("address = ? OR checksum_address = ?", *address, *address).Limit(1).Find(&wallet)
+	if result.Error != nil {
+		fatal("Failed to query DB", "err", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		fmt.Fprintf(os.Stderr, "%s isn't stored in the DB\n", *address)
+		os.Exit(exitFailure)
+	}
+	if wallet.PrivateKeyHash == "" {
+		fmt.Fprintf(os.Stderr, "%s was stored without -hash-only, it has no private key hash\n", *address)
+		os.Exit(exitFailure)
+	}
+	if !keycrypt.VerifyHash(wallet.PrivateKeyHash, key) {
+		fmt.Printf("MISMATCH: the private key isn't the one of %s\n", wallet.Address)
+		os.Exit(exitNoMatch)
+	}
+	fmt.Printf("PROVEN: the private key is the one of %s, stored from %sline %d at %s\n", wallet.Address, filePrefix(wallet.SeedFile), wallet.SeedLine, wallet.HDPath)
+}
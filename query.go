@@ -0,0 +1,116 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/planxnx/ethereum-wallet-generator/coins"
+	"github.com/planxnx/ethereum-wallet-generator/filter"
+	"github.com/planxnx/ethereum-wallet-generator/internal/output"
+	"github.com/planxnx/ethereum-wallet-generator/wallets"
+)
+
+// runQuery prints the wallets stored in a DB that match the scan filters.
+func runQuery(args []string) {
+	fs := flag.NewFlagSet("query", flag.ExitOnError)
+	dbPath := fs.String("db", "", "sqlite DB file to search eg. wallets.db (a bare file name is read from ./db) or out/wallets.db, or a postgres:// or mysql:// DSN")
+	dbKey := fs.String("db-key", "", "SQLCipher passphrase of an encrypted DB")
+	filterConfig := addFilterFlags(fs)
+	coinConfig := addCoinFlags(fs)
+	hdPath := fs.String("hd-path", "", "only wallets whose derivation path starts with this prefix (eg. m/44'/60'/0'/0)")
+	runID := fs.String("run", "", "only wallets stored by the run with this ID")
+	limit := fs.Int("limit", 50, "print at most this many wallets (0 for no limit)")
+	format := fs.String("format", output.FormatText, fmt.Sprintf("output format %v", output.Formats))
+	columns := fs.String("columns", strings.Join(output.DefaultColumns, ","), fmt.Sprintf("comma separated columns of the csv format %v", output.Columns))
+	fieldList := fs.String("fields", "addr,seedfile,seedline,hdpath,idx", "comma separated fields to print (eg. addr,hdpath,seedline), empty for all")
+	parseFlags(fs, args)
+
+	if *dbPath == "" {
+		fmt.Fprintln(os.Stderr, "Error: --db parameter required")
+		os.Exit(exitUsage)
+	}
+	if !isServerDSN(*dbPath) {
+		if _, err := os.Stat(sqlitePath(*dbPath)); err != nil {
+			fatal("Failed to open sqlite DB", "err", err)
+		}
+	}
+	if *format == output.FormatParquet {
+		fmt.Fprintln(os.Stderr, "Error: --format parquet requires a file, use export")
+		os.Exit(exitUsage)
+	}
+	var fields []string
+	if *fieldList != "" {
+		var err error
+		if fields, err = output.ParseFields(*fieldList); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitUsage)
+		}
+	}
+	coin, err := coinConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitUsage)
+	}
+	filters, err := coins.ApplyFilters(coin, filterConfig())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitUsage)
+	}
+	validateAddress := filter.NewAddressValidator(filters)
+	validator := newValidators(filters)
+
+	query := openDB(*dbPath, *dbKey).Model(&wallets.Wallet{}).Order("id")
+	// the prefix narrows the rows read, every filter is still applied to them
+	if prefix := filters.FullPrefix(); prefix != "" {
+		// eth addresses are stored lowercase, the base58 ones of other coins are case sensitive
+		if filters.Coin == "" {
+			prefix = strings.ToLower(prefix)
+		}
+		query = query.Where("address LIKE ?", prefix+"%")
+	}
+	if *hdPath != "" {
+		query = query.Where("hd_path LIKE ?", *hdPath+"%")
+	}
+	if *runID != "" {
+		query = query.Where("run_id = ?", *runID)
+	}
+
+	out := openOutput(*format, "", true, output.CompressNone, nil, output.Rotation{}, output.Options{
+		Columns: strings.Split(*columns, ","),
+		Fields:  fields,
+	}, false)
+	rows, err := query.Rows()
+	if err != nil {
+		fatal("Failed to query DB", "err", err)
+	}
+	defer rows.Close()
+
+	found := 0
+	for rows.Next() && (*limit <= 0 || found < *limit) {
+		var wallet wallets.Wallet
+		if err := query.ScanRows(rows, &wallet); err != nil {
+			fatal("Failed to read DB", "err", err)
+		}
+		if !validateAddress(wallet.Address) {
+			continue
+		}
+		if validator != nil && !validator.Valid(common.HexToAddress(wallet.Address), &wallet) {
+			continue
+		}
+		if err := out.Write(storedRecord(&wallet).Redact(fields)); err != nil {
+			fatal("Failed to write results", "err", err)
+		}
+		found++
+	}
+	if err := rows.Err(); err != nil {
+		fatal("Failed to read DB", "err", err)
+	}
+	if err := out.Close(); err != nil {
+		fatal("Failed to close output", "err", err)
+	}
+	fmt.Fprintf(os.Stderr, "Found %d wallets\n", found)
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+
+	"github.com/planxnx/ethereum-wallet-generator/filter"
+	"github.com/planxnx/ethereum-wallet-generator/internal/config"
+)
+
+// reloadFlags are the flags a SIGHUP re-reads from the -config file of a running scan or
+// generate: the address filters and validators, and the -limit of generate.
+var reloadFlags = []string{"contains", "strict", "prefix", "suffix", "regex", "validator", "limit"}
+
+// explicitFlags are the flags given on the command line or in the environment, which keep
+// their value when the -config file is reloaded.
+var explicitFlags map[string]bool
+
+// reloadedFlags are the values of the reloadFlags after a reload.
+type reloadedFlags struct {
+	filters filter.Config
+	// limit is the -limit of the commands having one.
+	limit int
+}
+
+// watchReload calls apply with the reloadFlags of fs re-read from its -config file at every
+// SIGHUP until ctx is done, as a new run of the same command line would take them. A file
+// that fails to load or that apply refuses is logged and the run keeps its filters. Nothing is
+// watched without -config, SIGHUP then keeps its default handling.
+func watchReload(ctx context.Context, fs *flag.FlagSet, apply func(reloadedFlags) error) {
+	path := fs.Lookup("config").Value.String()
+	if path == "" {
+		return
+	}
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGHUP)
+	go func() {
+		defer signal.Stop(sigCh)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-sigCh:
+			}
+			flags, err := reloadConfig(fs, path)
+			if err == nil {
+				err = apply(flags)
+			}
+			if err != nil {
+				slog.Error("Failed to reload the config file, the filters are unchanged", "file", path, "err", err)
+				continue
+			}
+			slog.Info("Reloaded the config file", "file", path, "filters", flags.filters.String(), "validators", flags.filters.Validators)
+		}
+	}()
+}
+
+// reloadConfig reads the reloadFlags of fs from the config file at path, the explicitFlags
+// keeping their current value.
+func reloadConfig(fs *flag.FlagSet, path string) (reloadedFlags, error) {
+	values, err := config.Load(path)
+	if err != nil {
+		return reloadedFlags{}, err
+	}
+	fresh := flag.NewFlagSet(fs.Name(), flag.ContinueOnError)
+	fresh.SetOutput(io.Discard)
+	filterConfig := filterFlags(fresh)
+	limit := new(int)
+	if f := fs.Lookup("limit"); f != nil {
+		def, _ := strconv.Atoi(f.DefValue)
+		limit = fresh.Int("limit", def, f.Usage)
+	}
+	for _, name := range reloadFlags {
+		f := fs.Lookup(name)
+		if f == nil || !explicitFlags[name] {
+			continue
+		}
+		if repeated, ok := f.Value.(*stringsFlag); ok {
+			for _, value := range *repeated {
+				_ = fresh.Set(name, value)
+			}
+			continue
+		}
+		_ = fresh.Set(name, f.Value.String())
+	}
+
+	reloaded := make(map[string]string)
+	for _, name := range reloadFlags {
+		if value, ok := values[name]; ok && fresh.Lookup(name) != nil && !explicitFlags[name] {
+			reloaded[name] = value
+		}
+	}
+	if err := config.Apply(fresh, reloaded); err != nil {
+		return reloadedFlags{}, err
+	}
+	filters, err := filterConfig()
+	return reloadedFlags{filters: filters, limit: *limit}, err
+}
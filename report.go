@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/planxnx/ethereum-wallet-generator/internal/audit"
+	"github.com/planxnx/ethereum-wallet-generator/store"
+	"github.com/planxnx/ethereum-wallet-generator/wallets"
+)
+
+// reportFlags are the flags of a run listed in the parameters of its audit report, in order:
+// what was searched, how it was derived and the filters.
+var reportFlags = []string{
+	"coin", "address-type", "seeds", "seeds-format", "lines", "skip", "take", "duplicates", "check-mnemonics", "refuse-weak",
+	"path", "depth", "mode", "bit", "n", "limit", "keyspace-backend",
+	"contains", "strict", "prefix", "suffix", "regex", "validator", "validator-plugin", "count-only",
+}
+
+// runReport writes the audit report of a run stored in a DB, its matches grouped by the seed
+// they were derived from.
+func runReport(args []string) {
+	fs := flag.NewFlagSet("report", flag.ExitOnError)
+	dbPath := fs.String("db", "", "sqlite DB file holding the run eg. wallets.db (a bare file name is read from ./db) or out/wallets.db, or a postgres:// or mysql:// DSN")
+	dbKey := fs.String("db-key", "", "SQLCipher passphrase of an encrypted DB")
+	runID := fs.String("run", "", "ID of the run to report on, listed by the stats command (default the last run that stored wallets)")
+	format := fs.String("format", audit.FormatHTML, fmt.Sprintf("report format %v", audit.Formats))
+	outPath := fs.String("out", "", "write the report to this file instead of stdout")
+	title := fs.String("title", "", "title of the report, eg. the engagement it is handed over for")
+	parseFlags(fs, args)
+
+	if *dbPath == "" {
+		fmt.Fprintln(os.Stderr, "Error: --db parameter required")
+		os.Exit(exitUsage)
+	}
+	if *format != audit.FormatJSON && *format != audit.FormatHTML {
+		fmt.Fprintf(os.Stderr, "Error: unknown --format %q, must be one of %v\n", *format, audit.Formats)
+		os.Exit(exitUsage)
+	}
+
+	db := openDB(*dbPath, *dbKey)
+	var run store.Run
+	query := db.Where("run_id IN (?)", db.Model(&wallets.Wallet{}).Distinct("run_id")).Order("started_at DESC").Limit(1)
+	if *runID != "" {
+		query = db.Where("run_id = ?", *runID)
+	}
+	if err := query.Find(&run).Error; err != nil {
+		fatal("Failed to query DB", "err", err)
+	}
+	if run.RunID == "" {
+		if *runID == "" {
+			fatal("The DB holds no recorded run")
+		}
+		// wallets of a run missing from the runs table are still reported
+		run.RunID = *runID
+	}
+
+	var ws []*wallets.Wallet
+	if err := db.Where("run_id = ?", run.RunID).Order("seed_file, seed_line, account_index, address_index, id").Find(&ws).Error; err != nil {
+		fatal("Failed to query DB", "err", err)
+	}
+	if len(ws) == 0 && run.Command == "" {
+		fatal("No run with this ID in the DB", "run", run.RunID)
+	}
+	report := audit.New(*title, run, runParameters(run), ws)
+
+	var out io.WriteCloser = os.Stdout
+	if *outPath != "" {
+		prepareOutputDir("out", filepath.Dir(*outPath))
+		f, err := os.OpenFile(*outPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+		if err != nil {
+			fatal("Failed to create report", "err", err)
+		}
+		out = f
+	}
+	err := report.Write(out, *format)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		fatal("Failed to write report", "err", err)
+	}
+}
+
+// runParameters returns the reportFlags set in the recorded config of run.
+func runParameters(run store.Run) []audit.Parameter {
+	var config map[string]string
+	if json.Unmarshal([]byte(run.Config), &config) != nil {
+		return nil
+	}
+	var params []audit.Parameter
+	for _, name := range reportFlags {
+		if value, ok := config[name]; ok && value != "" && value != "false" && value != "0" {
+			params = append(params, audit.Parameter{Name: name, Value: value})
+		}
+	}
+	return params
+}
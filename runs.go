@@ -0,0 +1,180 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/planxnx/ethereum-wallet-generator/store"
+	"github.com/planxnx/ethereum-wallet-generator/wallets"
+)
+
+// secretFlags are the flags whose value is left out of the recorded run configuration.
+var secretFlags = map[string]bool{
+	"db-key":            true,
+	"keystore-password": true,
+	"encrypt-output":    true,
+	"token":             true,
+	"notify":            true,
+	"mnemonic":          true,
+	"passphrase":        true,
+	"private-key":       true,
+	"db-encrypt-keys":   true,
+	"seeds-passphrase":  true,
+	"manifest-key":      true,
+}
+
+// resultFlags are the flags shaping the wallets stored in the DB, a run appending to a DB must
+// use the values of the run that stored its last wallets. The secret ones only compare as set
+// or not.
+var resultFlags = []string{
+	"coin",
+	"address-type",
+	"fields",
+	"no-secrets",
+	"hash-only",
+	"db-mnemonic",
+	"db-encrypt-keys",
+	"kms",
+	"icap",
+	"checksum-chainid",
+	"sign",
+}
+
+// buildVersion returns the module version the binary was built from, or the VCS revision of
+// a development build.
+func buildVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	if v := info.Main.Version; v != "" && v != "(devel)" {
+		return v
+	}
+	version, modified := "devel", ""
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			version = s.Value
+		case "vcs.modified":
+			if s.Value == "true" {
+				modified = "-dirty"
+			}
+		}
+	}
+	return version + modified
+}
+
+// flagConfig returns the value of every flag of fs as JSON, secrets redacted.
+func flagConfig(fs *flag.FlagSet) string {
+	config := make(map[string]string)
+	fs.VisitAll(func(f *flag.Flag) {
+		value := f.Value.String()
+		if secretFlags[f.Name] && value != "" {
+			value = "<redacted>"
+		}
+		config[f.Name] = value
+	})
+	data, _ := json.Marshal(config)
+	return string(data)
+}
+
+// checkAppend refuses to append the results of the command configured by fs to a DB whose last
+// stored wallets were shaped by other resultFlags values, unless allowMixed is set. Runs that
+// didn't record one of the flags, eg. of an older build, aren't compared on it.
+func (s *resultSinks) checkAppend(fs *flag.FlagSet, allowMixed bool) error {
+	if _, ok := s.repo.(store.RunRecorder); !ok || s.dbPath == memoryDB {
+		return nil
+	}
+	db := openGorm(s.dbPath, s.dbKey)
+	defer closeGorm(db)
+	var last store.Run
+	err := db.Where("run_id IN (?)", db.Model(&wallets.Wallet{}).Distinct("run_id")).Order("started_at DESC").Limit(1).Find(&last).Error
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if last.RunID == "" {
+		return nil
+	}
+	var stored map[string]string
+	if err := json.Unmarshal([]byte(last.Config), &stored); err != nil {
+		slog.Warn("Failed to read the config of the last run of the DB, appending without checking it", "run_id", last.RunID, "err", err)
+		return nil
+	}
+
+	var diffs []string
+	current := make(map[string]string)
+	if err := json.Unmarshal([]byte(flagConfig(fs)), &current); err != nil {
+		return errors.WithStack(err)
+	}
+	for _, name := range resultFlags {
+		was, ok := stored[name]
+		now, known := current[name]
+		if !ok || !known || was == now {
+			continue
+		}
+		diffs = append(diffs, fmt.Sprintf("--%s %q (this run %q)", name, was, now))
+	}
+	if len(diffs) == 0 {
+		return nil
+	}
+	if allowMixed {
+		slog.Warn("Appending results incompatible with the last run of the DB", "run_id", last.RunID, "differences", strings.Join(diffs, ", "))
+		return nil
+	}
+	return errors.Errorf("--db holds the wallets of run %s (%s, %s) stored with %s: use the same flags, another --db, or --db-allow-mixed to append anyway",
+		last.RunID, last.Command, last.StartedAt.Format(time.DateTime), strings.Join(diffs, ", "))
+}
+
+// startRun records a new run of the command configured by fs if the DB records runs, the saved
+// wallets are then tagged with its ID.
+func (s *resultSinks) startRun(fs *flag.FlagSet) {
+	recorder, ok := s.repo.(store.RunRecorder)
+	if !ok {
+		return
+	}
+	run := &store.Run{
+		RunID:     store.NewRunID(),
+		Command:   fs.Name(),
+		StartedAt: time.Now(),
+		Config:    flagConfig(fs),
+		Version:   buildVersion(),
+	}
+	if err := recorder.StartRun(run); err != nil {
+		fatal("Failed to record run in DB", "err", err)
+	}
+	s.run = run
+	slog.Info("Run started", "run_id", run.RunID)
+}
+
+// finishRun stores the end time and matches of the recorded run.
+func (s *resultSinks) finishRun() {
+	if s.run == nil {
+		return
+	}
+	// the matches lost by the background DB writes are only known once they are done
+	if err := s.repo.Commit(); err != nil {
+		slog.Error("Failed to save wallets in DB", "run_id", s.run.RunID, "err", err)
+		s.fail("db")
+	}
+	s.run.Matches -= s.lostMatches.Load()
+	now := time.Now()
+	s.run.EndedAt = &now
+	if err := s.repo.(store.RunRecorder).FinishRun(s.run); err != nil {
+		slog.Error("Failed to record run end in DB", "run_id", s.run.RunID, "err", err)
+	}
+}
+
+// runID returns the ID of the recorded run, if any.
+func (s *resultSinks) runID() string {
+	if s.run == nil {
+		return ""
+	}
+	return s.run.RunID
+}
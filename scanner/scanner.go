@@ -0,0 +1,58 @@
+// Package scanner derives the wallets of a mnemonic seed along a BIP44 base path.
+package scanner
+
+import (
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/pkg/errors"
+
+	"github.com/planxnx/ethereum-wallet-generator/coins"
+	"github.com/planxnx/ethereum-wallet-generator/seeds"
+	"github.com/planxnx/ethereum-wallet-generator/wallets"
+)
+
+// Result is a wallet derived from a seed at a given address index, or the error that prevented it.
+type Result struct {
+	Index  int
+	Wallet *wallets.Wallet
+	Err    error
+}
+
+// Derive derives depth wallets under basePath from the seed and calls fn for each of them.
+// It returns an error only if the base key of the seed itself could not be derived.
+func Derive(seed seeds.Seed, basePath accounts.DerivationPath, depth int, fn func(Result)) error {
+	return DeriveRange(seed, basePath, 0, depth, fn)
+}
+
+// DeriveRange is like Derive but only derives the address indexes in [from, to).
+// The passphrase and base path of the seed are used when it carries them.
+func DeriveRange(seed seeds.Seed, basePath accounts.DerivationPath, from, to int, fn func(Result)) error {
+	return DeriveCoin(seed, coins.ETH, basePath, from, to, fn)
+}
+
+// DeriveCoin is like DeriveRange but derives the addresses of coin.
+func DeriveCoin(seed seeds.Seed, coin coins.Coin, basePath accounts.DerivationPath, from, to int, fn func(Result)) error {
+	if seed.Path != "" {
+		path, err := accounts.ParseDerivationPath(seed.Path)
+		if err != nil {
+			return errors.Wrapf(err, "invalid hd path %q", seed.Path)
+		}
+		basePath = path
+	}
+
+	// derive the base extended key once per seed, then only the final child per index
+	deriver, err := coin.NewDeriver(seed.Phrase, seed.Passphrase, basePath)
+	if err != nil {
+		return errors.Wrap(err, "failed to derive base key")
+	}
+	defer coins.Wipe(deriver)
+
+	for i := from; i < to; i++ {
+		w, err := deriver.Derive(uint32(i))
+		if err != nil {
+			fn(Result{Index: i, Err: errors.Wrap(err, "failed to derive wallet")})
+			continue
+		}
+		fn(Result{Index: i, Wallet: w})
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/planxnx/ethereum-wallet-generator/bip39"
+	"github.com/planxnx/ethereum-wallet-generator/internal/style"
+	"github.com/planxnx/ethereum-wallet-generator/internal/wipe"
+	"github.com/planxnx/ethereum-wallet-generator/seeds"
+)
+
+// phraseQuality is the report line of a phrase analyzed by seed-quality, it never holds the
+// phrase itself.
+type phraseQuality struct {
+	File string `json:"file,omitempty"`
+	Line int    `json:"line"`
+	bip39.Quality
+	// Nearest is the smallest entropy Distance to another phrase of the batch, at NearestFile
+	// and NearestLine, -1 without another valid phrase of the same length.
+	Nearest     int      `json:"nearest_distance"`
+	NearestFile string   `json:"nearest_file,omitempty"`
+	NearestLine int      `json:"nearest_line,omitempty"`
+	Flags       []string `json:"flags"`
+
+	entropy []byte
+}
+
+// runSeedQuality reports the entropy quality signals of every phrase of a batch, for the
+// auditors looking for seeds generated by a broken random generator.
+func runSeedQuality(args []string) {
+	fs := flag.NewFlagSet("seed-quality", flag.ExitOnError)
+	var seedPatterns seeds.Patterns
+	fs.Var(&seedPatterns, "seeds", "file containing list of BIP39 mnemonics (one per line), - to read them from stdin (default). Repeat it or use glob patterns to read several files in order")
+	seedsFormat := fs.String("seeds-format", string(seeds.FormatText), "seeds file line format: text (one mnemonic per line), or tsv/csv lines of mnemonic, passphrase, hdpath and label fields")
+	seedKeyConfig := addSeedKeyFlags(fs)
+	format := fs.String("format", "text", "report format [text, json: a JSON object per line]")
+	flaggedOnly := fs.Bool("flagged", false, "report only the flagged phrases")
+	parseFlags(fs, args)
+
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "Error: unknown --format %q, must be text or json\n", *format)
+		os.Exit(exitUsage)
+	}
+	if len(seedPatterns) == 0 {
+		seedPatterns = seeds.Patterns{seeds.Stdin}
+	}
+	input, err := seeds.NewInput(seedPatterns, seeds.Format(*seedsFormat))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitUsage)
+	}
+	if err := seedKeyConfig(input); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitUsage)
+	}
+
+	var phrases []*phraseQuality
+	seedCh, errCh := input.Stream(context.Background(), seeds.Range{}, seeds.DefaultReadAhead)
+	for seed := range seedCh {
+		file, line := input.Locate(seed.Line)
+		q, entropy := bip39.Analyze(seed.Phrase)
+		p := &phraseQuality{File: file, Line: line, Quality: q, Nearest: -1, Flags: q.Flags(), entropy: entropy}
+		if reason := bip39.Weakness(seed.Phrase); reason != "" {
+			p.Flags = append(p.Flags, "weak mnemonic: "+reason)
+		}
+		if entropy == nil {
+			p.Flags = append(p.Flags, "invalid mnemonic")
+		}
+		phrases = append(phrases, p)
+	}
+	if err := <-errCh; err != nil {
+		fatal("Failed to read seeds", "err", err)
+	}
+	if len(phrases) == 0 {
+		fmt.Fprintln(os.Stderr, "Error: no phrase to analyze")
+		os.Exit(exitUsage)
+	}
+	nearestPhrases(phrases)
+
+	counts := make(map[string]int)
+	flagged := 0
+	enc := json.NewEncoder(os.Stdout)
+	for _, p := range phrases {
+		wipe.Bytes(p.entropy)
+		for _, f := range p.Flags {
+			counts[strings.SplitN(f, ":", 2)[0]]++
+		}
+		if len(p.Flags) > 0 {
+			flagged++
+		} else if *flaggedOnly {
+			continue
+		}
+		if *format == "json" {
+			if p.Flags == nil {
+				p.Flags = []string{}
+			}
+			if err := enc.Encode(p); err != nil {
+				fatal("Failed to write report", "err", err)
+			}
+			continue
+		}
+		fmt.Println(p.text())
+	}
+
+	fmt.Fprintf(os.Stderr, "%d of %d phrases flagged", flagged, len(phrases))
+	for _, name := range []string{"repeated words", "clustered words", "biased entropy bits", "close entropy", "weak mnemonic", "invalid mnemonic"} {
+		if counts[name] > 0 {
+			fmt.Fprintf(os.Stderr, ", %d %s", counts[name], name)
+		}
+	}
+	fmt.Fprintln(os.Stderr)
+	if flagged > 0 {
+		exitCode = exitNoMatch
+	}
+}
+
+// nearestPhrases sets the nearest phrase of the same entropy length of every phrase, and flags
+// the ones too close to come from a random generator. It compares every pair.
+func nearestPhrases(phrases []*phraseQuality) {
+	for i, p := range phrases {
+		if p.entropy == nil {
+			continue
+		}
+		for _, o := range phrases[i+1:] {
+			d := bip39.Distance(p.entropy, o.entropy)
+			if d < 0 {
+				continue
+			}
+			if p.Nearest < 0 || d < p.Nearest {
+				p.Nearest, p.NearestFile, p.NearestLine = d, o.File, o.Line
+			}
+			if o.Nearest < 0 || d < o.Nearest {
+				o.Nearest, o.NearestFile, o.NearestLine = d, p.File, p.Line
+			}
+		}
+	}
+	for _, p := range phrases {
+		if p.Nearest >= 0 && bip39.TooClose(p.Nearest, p.Bits) {
+			p.Flags = append(p.Flags, fmt.Sprintf("close entropy: %d bits from %s", p.Nearest, location(p.NearestFile, p.NearestLine)))
+		}
+	}
+}
+
+// text returns the report line of the text format: the location, the signals and the flags.
+func (p *phraseQuality) text() string {
+	signals := fmt.Sprintf("words=%d repeated=%d clustered=%d", p.Words, p.Repeated, p.Cluster)
+	if p.Bits > 0 {
+		signals += fmt.Sprintf(" ones=%d/%d", p.Ones, p.Bits)
+	}
+	if p.Nearest >= 0 {
+		signals += fmt.Sprintf(" nearest=%d@%s", p.Nearest, location(p.NearestFile, p.NearestLine))
+	}
+	verdict := style.Paint(colorStdout, style.Match, "OK")
+	if len(p.Flags) > 0 {
+		verdict = style.Paint(colorStdout, style.Warning, strings.Join(p.Flags, ", "))
+	}
+	return fmt.Sprintf("%s\t%s\t%s", location(p.File, p.Line), signals, verdict)
+}
+
+// location formats a seed line with its file, if any.
+func location(file string, line int) string {
+	if file == "" {
+		return fmt.Sprint(line)
+	}
+	return fmt.Sprintf("%s:%d", file, line)
+}
@@ -0,0 +1,132 @@
+package seeds
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+func TestDecrypt(t *testing.T) {
+	// long enough to span several AES-CBC chunks
+	plaintext := strings.Repeat("legal winner thank year wave sausage worth useful legal winner thank yellow\n", 20)
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := map[string]struct {
+		encrypt    func(w io.Writer) io.WriteCloser
+		keys       *Keys
+		encryption Encryption
+	}{
+		"plaintext": {encryption: Plaintext},
+		"age identity": {
+			encrypt: func(w io.Writer) io.WriteCloser {
+				wc, _ := age.Encrypt(w, identity.Recipient())
+				return wc
+			},
+			keys:       &Keys{AgeIdentities: []age.Identity{identity}},
+			encryption: Age,
+		},
+		"age passphrase": {
+			encrypt: func(w io.Writer) io.WriteCloser {
+				r, _ := age.NewScryptRecipient("correct horse")
+				r.SetWorkFactor(10)
+				wc, _ := age.Encrypt(w, r)
+				return wc
+			},
+			encryption: Age,
+		},
+		"pgp symmetric": {
+			encrypt: func(w io.Writer) io.WriteCloser {
+				wc, _ := openpgp.SymmetricallyEncrypt(w, []byte("correct horse"), nil, nil)
+				return wc
+			},
+			encryption: PGP,
+		},
+		"openssl": {
+			encrypt: func(w io.Writer) io.WriteCloser {
+				return &opensslWriter{w: w, passphrase: "correct horse"}
+			},
+			encryption: OpenSSL,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if tc.encrypt == nil {
+				buf.WriteString(plaintext)
+			} else {
+				wc := tc.encrypt(&buf)
+				io.WriteString(wc, plaintext)
+				assert.NoError(t, wc.Close())
+			}
+			assert.Equal(t, tc.encryption, Detect(buf.Bytes()))
+
+			keys := tc.keys
+			if keys == nil {
+				keys, _ = NewKeys("", func() (string, error) { return "correct horse", nil })
+			}
+			r, err := decrypt("seeds", io.NopCloser(&buf), keys)
+			if !assert.NoError(t, err) {
+				return
+			}
+			actual, err := io.ReadAll(r)
+			assert.NoError(t, err)
+			assert.Equal(t, plaintext, string(actual))
+		})
+	}
+}
+
+func TestUnlockWrongPassphrase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "seeds.ssl")
+	var buf bytes.Buffer
+	w := &opensslWriter{w: &buf, passphrase: "correct horse"}
+	io.WriteString(w, "legal winner thank year wave sausage worth useful legal winner thank yellow\n")
+	w.Close()
+	if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	in, err := NewInput([]string{path}, FormatText)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Error(t, in.Unlock(), "no keys")
+	in.Keys, _ = NewKeys("", func() (string, error) { return "wrong horse", nil })
+	assert.Error(t, in.Unlock())
+	in.Keys, _ = NewKeys("", func() (string, error) { return "correct horse", nil })
+	assert.NoError(t, in.Unlock())
+}
+
+// opensslWriter encrypts like openssl enc -aes-256-cbc -pbkdf2, with a fixed salt.
+type opensslWriter struct {
+	w          io.Writer
+	passphrase string
+	buf        bytes.Buffer
+}
+
+func (o *opensslWriter) Write(p []byte) (int, error) { return o.buf.Write(p) }
+
+func (o *opensslWriter) Close() error {
+	salt := []byte("saltsalt")
+	derived := pbkdf2.Key([]byte(o.passphrase), salt, opensslIterations, 32+aes.BlockSize, sha256.New)
+	block, _ := aes.NewCipher(derived[:32])
+	pad := aes.BlockSize - o.buf.Len()%aes.BlockSize
+	data := append(o.buf.Bytes(), bytes.Repeat([]byte{byte(pad)}, pad)...)
+	cipher.NewCBCEncrypter(block, derived[32:]).CryptBlocks(data, data)
+	_, err := o.w.Write(append(append([]byte("Salted__"), salt...), data...))
+	return err
+}
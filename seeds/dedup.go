@@ -0,0 +1,55 @@
+package seeds
+
+import (
+	"context"
+	"crypto/sha256"
+	"strings"
+)
+
+// Duplicates modes, what happens to a seed already seen earlier in the input.
+const (
+	// DuplicatesKeep processes duplicates without looking for them.
+	DuplicatesKeep = "keep"
+	// DuplicatesReport processes duplicates and reports them.
+	DuplicatesReport = "report"
+	// DuplicatesSkip reports duplicates and drops them.
+	DuplicatesSkip = "skip"
+)
+
+// DuplicatesModes lists the supported duplicates modes.
+var DuplicatesModes = []string{DuplicatesKeep, DuplicatesReport, DuplicatesSkip}
+
+// Normalize returns the phrase with single spaces between its lowercased words.
+func Normalize(phrase string) string {
+	return strings.ToLower(strings.Join(strings.Fields(phrase), " "))
+}
+
+// Dedup forwards the seeds of in and calls onDuplicate, from its own goroutine, with every
+// seed whose normalized phrase, passphrase and path were already seen, along with the line
+// of the first one. Duplicates are dropped when skip is set. Only a hash of every seed is
+// kept in memory. Forwarding stops early if ctx is canceled.
+func Dedup(ctx context.Context, in <-chan Seed, skip bool, onDuplicate func(seed Seed, first int)) <-chan Seed {
+	out := make(chan Seed, cap(in))
+	go func() {
+		defer close(out)
+		seen := make(map[[16]byte]int)
+		for seed := range in {
+			sum := sha256.Sum256([]byte(Normalize(seed.Phrase) + "\x00" + seed.Passphrase + "\x00" + seed.Path))
+			key := [16]byte(sum[:16])
+			if first, ok := seen[key]; ok {
+				onDuplicate(seed, first)
+				if skip {
+					continue
+				}
+			} else {
+				seen[key] = seed.Line
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case out <- seed:
+			}
+		}
+	}()
+	return out
+}
@@ -0,0 +1,30 @@
+package seeds
+
+import (
+	"context"
+	"testing"
+)
+
+func TestDedup(t *testing.T) {
+	in := make(chan Seed, 4)
+	in <- Seed{Line: 1, Phrase: "abandon about"}
+	in <- Seed{Line: 2, Phrase: " Abandon   ABOUT "}
+	in <- Seed{Line: 3, Phrase: "abandon about", Passphrase: "TREZOR"}
+	in <- Seed{Line: 4, Phrase: "legal winner"}
+	close(in)
+
+	var dups [][2]int
+	var lines []int
+	for seed := range Dedup(context.Background(), in, true, func(seed Seed, first int) {
+		dups = append(dups, [2]int{seed.Line, first})
+	}) {
+		lines = append(lines, seed.Line)
+	}
+
+	if len(dups) != 1 || dups[0] != [2]int{2, 1} {
+		t.Errorf("duplicates = %v, want [[2 1]]", dups)
+	}
+	if len(lines) != 3 || lines[0] != 1 || lines[1] != 3 || lines[2] != 4 {
+		t.Errorf("forwarded lines = %v, want [1 3 4]", lines)
+	}
+}
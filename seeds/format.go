@@ -0,0 +1,80 @@
+package seeds
+
+import (
+	"encoding/csv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Format is the layout of a seeds file line.
+type Format string
+
+// Seeds file formats. The structured ones are mnemonic, passphrase, hdpath and label
+// fields, every field after the mnemonic being optional.
+const (
+	// FormatText is one mnemonic per line.
+	FormatText Format = "text"
+	// FormatTSV is tab separated fields.
+	FormatTSV Format = "tsv"
+	// FormatCSV is comma separated fields, quoted when they contain a comma.
+	FormatCSV Format = "csv"
+)
+
+// Formats lists every supported format.
+var Formats = []Format{FormatText, FormatTSV, FormatCSV}
+
+func (f Format) validate() error {
+	for _, format := range Formats {
+		if f == format {
+			return nil
+		}
+	}
+	return errors.Errorf("unknown seeds format %q, must be one of %v", f, Formats)
+}
+
+// Parse parses a seeds file line received on its own, eg. from a queue, into a seed without
+// line number.
+func (f Format) Parse(line string) (Seed, error) {
+	if err := f.validate(); err != nil {
+		return Seed{}, err
+	}
+	if strings.TrimSpace(line) == "" {
+		return Seed{}, errors.New("blank seed line")
+	}
+	return f.parse(line), nil
+}
+
+// parse parses a non-blank line into a seed, without its line number.
+func (f Format) parse(line string) Seed {
+	line = strings.TrimSuffix(line, "\r")
+	var fields []string
+	switch f {
+	case FormatTSV:
+		fields = strings.Split(line, "\t")
+	case FormatCSV:
+		r := csv.NewReader(strings.NewReader(line))
+		r.LazyQuotes = true
+		r.FieldsPerRecord = -1
+		var err error
+		if fields, err = r.Read(); err != nil {
+			fields = []string{line}
+		}
+	default:
+		return Seed{Phrase: strings.TrimSpace(line)}
+	}
+
+	// the passphrase is kept as is, spaces are part of it
+	field := func(i int) string {
+		if i < len(fields) {
+			return fields[i]
+		}
+		return ""
+	}
+	return Seed{
+		Phrase:     strings.TrimSpace(field(0)),
+		Passphrase: field(1),
+		Path:       strings.TrimSpace(field(2)),
+		Label:      strings.TrimSpace(field(3)),
+	}
+}
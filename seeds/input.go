@@ -0,0 +1,197 @@
+package seeds
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Patterns is a flag.Value collecting seeds file names and glob patterns, from repeated
+// flags or comma separated lists.
+type Patterns []string
+
+func (p *Patterns) String() string {
+	return strings.Join(*p, ",")
+}
+
+func (p *Patterns) Set(value string) error {
+	for _, pattern := range strings.Split(value, ",") {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			*p = append(*p, pattern)
+		}
+	}
+	return nil
+}
+
+// Input is an ordered list of seeds files read as a single stream. Line numbers, and so
+// ranges and checkpoints, continue from one file to the next; Locate maps them back.
+type Input struct {
+	Files  []string
+	Format Format
+	// Keys decrypt the encrypted files, which fail to read without.
+	Keys *Keys
+
+	mu sync.Mutex
+	// starts is the number of lines before each file that has started streaming.
+	starts []int
+}
+
+// NewInput expands the glob patterns to the files they match, in the given order and
+// sorted within each pattern. Stdin may only be given once.
+func NewInput(patterns []string, format Format) (*Input, error) {
+	if err := format.validate(); err != nil {
+		return nil, err
+	}
+	in := &Input{Format: format}
+	for _, pattern := range patterns {
+		if pattern == Stdin || !strings.ContainsAny(pattern, `*?[\`) {
+			in.Files = append(in.Files, pattern)
+			continue
+		}
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid seeds pattern %q", pattern)
+		}
+		if len(matches) == 0 {
+			return nil, errors.Errorf("no seeds file matches %q", pattern)
+		}
+		in.Files = append(in.Files, matches...)
+	}
+
+	stdin := 0
+	for _, f := range in.Files {
+		if f == Stdin {
+			stdin++
+		}
+	}
+	if stdin > 0 && len(in.Files) > 1 {
+		return nil, errors.New("stdin can't be combined with other seeds files")
+	}
+	return in, nil
+}
+
+// String returns the comma separated list of files.
+func (in *Input) String() string {
+	return strings.Join(in.Files, ",")
+}
+
+// IsStdin reports whether the input is read from the standard input, which can't be counted ahead.
+func (in *Input) IsStdin() bool {
+	return len(in.Files) == 1 && in.Files[0] == Stdin
+}
+
+// Stream reads seeds from every file in turn, see Stream.
+func (in *Input) Stream(ctx context.Context, rng Range, readAhead int) (<-chan Seed, <-chan error) {
+	return stream(ctx, readAhead, func(fn func(Seed) bool) error {
+		return in.scan(rng, true, func(_ int, seed Seed) bool { return fn(seed) })
+	})
+}
+
+// CountLines returns the number of non-blank lines inside rng across every file.
+func (in *Input) CountLines(rng Range) (int, error) {
+	counts, err := in.CountFileLines(rng)
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, n := range counts {
+		count += n
+	}
+	return count, nil
+}
+
+// CountFileLines returns the number of non-blank lines inside rng of every file, in Files order.
+func (in *Input) CountFileLines(rng Range) ([]int, error) {
+	counts := make([]int, len(in.Files))
+	err := in.scan(rng, false, func(file int, _ Seed) bool {
+		counts[file]++
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+// Locate returns the file and the line within that file of a stream line number. The file
+// is empty for a single file input, whose line numbers are already the file ones.
+func (in *Input) Locate(line int) (string, int) {
+	if len(in.Files) < 2 {
+		return "", line
+	}
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	i := sort.Search(len(in.starts), func(i int) bool { return in.starts[i] >= line }) - 1
+	if i < 0 {
+		return "", line
+	}
+	return in.Files[i], line - in.starts[i]
+}
+
+// Unlock checks the keys decrypt every encrypted file, asking the passphrase if one needs it,
+// so a missing or wrong key fails before any seed is processed.
+func (in *Input) Unlock() error {
+	for _, name := range in.Files {
+		if name == Stdin {
+			continue
+		}
+		f, err := in.open(name)
+		if err != nil {
+			return err
+		}
+		_, err = f.Read(make([]byte, 1))
+		f.Close()
+		if err != nil && err != io.EOF {
+			return errors.Wrapf(err, "failed to read %s", name)
+		}
+	}
+	return nil
+}
+
+// open opens the file name, decrypted.
+func (in *Input) open(name string) (io.ReadCloser, error) {
+	f, err := Open(name)
+	if err != nil {
+		return nil, err
+	}
+	r, err := decrypt(name, f, in.Keys)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+// scan calls fn with the file index of every seed of every file inside rng, recording where each
+// file starts if track is set.
+func (in *Input) scan(rng Range, track bool, fn func(int, Seed) bool) error {
+	offset := 0
+	for i, name := range in.Files {
+		if track {
+			in.mu.Lock()
+			in.starts = append(in.starts, offset)
+			in.mu.Unlock()
+		}
+
+		f, err := in.open(name)
+		if err != nil {
+			return err
+		}
+		var more bool
+		offset, more, err = scan(f, rng, in.Format, offset, func(seed Seed) bool { return fn(i, seed) })
+		f.Close()
+		if err != nil {
+			return errors.Wrapf(err, "failed to read %s", name)
+		}
+		if !more {
+			return nil
+		}
+	}
+	return nil
+}
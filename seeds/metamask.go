@@ -0,0 +1,187 @@
+package seeds
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// metamaskIterations is the PBKDF2 iteration count of the vaults written before MetaMask
+// recorded it in their key metadata.
+const metamaskIterations = 10000
+
+// metamaskHDKeyring is the keyring type of the MetaMask Secret Recovery Phrases.
+const metamaskHDKeyring = "HD Key Tree"
+
+// metamaskVault is the encrypted keyrings of a MetaMask wallet: the value of the vault of its
+// KeyringController state.
+type metamaskVault struct {
+	Data        string `json:"data"`
+	IV          string `json:"iv"`
+	Salt        string `json:"salt"`
+	KeyMetadata *struct {
+		Algorithm string `json:"algorithm"`
+		Params    struct {
+			Iterations int `json:"iterations"`
+		} `json:"params"`
+	} `json:"keyMetadata"`
+}
+
+// metamaskKeyring is a decrypted keyring of a vault, its data depending on its type.
+type metamaskKeyring struct {
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+// metamaskHDKeyringData is the data of a HD keyring. The mnemonic is a string, or the array of
+// its UTF-8 bytes for the recent versions of MetaMask.
+type metamaskHDKeyringData struct {
+	Mnemonic         json.RawMessage `json:"mnemonic"`
+	NumberOfAccounts int             `json:"numberOfAccounts"`
+	HDPath           string          `json:"hdPath"`
+}
+
+// metamask returns the Secret Recovery Phrases of the MetaMask vault r, one per line, decrypted
+// with the wallet password. r is the vault itself or a state export holding it, eg. the
+// chrome.storage.local data of the extension. The imported private keys are skipped.
+func (k *Keys) metamask(r io.Reader) (io.Reader, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	sum := sha256.Sum256(data)
+	if phrases, ok := k.metamaskPhrases.Load(sum); ok {
+		return bytes.NewReader(phrases.([]byte)), nil
+	}
+	vault, err := findMetamaskVault(data)
+	if err != nil {
+		return nil, err
+	}
+	salt, err := base64.StdEncoding.DecodeString(vault.Salt)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid MetaMask vault salt")
+	}
+	iv, err := base64.StdEncoding.DecodeString(vault.IV)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid MetaMask vault iv")
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(vault.Data)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid MetaMask vault data")
+	}
+	iterations := metamaskIterations
+	if m := vault.KeyMetadata; m != nil {
+		if m.Algorithm != "" && m.Algorithm != "PBKDF2" {
+			return nil, errors.Errorf("unsupported MetaMask vault key derivation %s", m.Algorithm)
+		}
+		if m.Params.Iterations > 0 {
+			iterations = m.Params.Iterations
+		}
+	}
+
+	passphrase, err := k.passphrase()
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(pbkdf2.Key([]byte(passphrase), salt, iterations, 32, sha256.New))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	// the vaults are encrypted with WebCrypto AES-GCM and a 16 bytes IV
+	gcm, err := cipher.NewGCMWithNonceSize(block, len(iv))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	plaintext, err := gcm.Open(nil, iv, ciphertext, nil)
+	if err != nil {
+		return nil, errors.New("wrong MetaMask password")
+	}
+
+	var keyrings []metamaskKeyring
+	if err := json.Unmarshal(plaintext, &keyrings); err != nil {
+		return nil, errors.Wrap(err, "invalid MetaMask keyrings")
+	}
+	var phrases bytes.Buffer
+	for _, keyring := range keyrings {
+		if keyring.Type != metamaskHDKeyring {
+			slog.Warn("MetaMask keyring skipped, only Secret Recovery Phrases are read", "type", keyring.Type)
+			continue
+		}
+		var hd metamaskHDKeyringData
+		if err := json.Unmarshal(keyring.Data, &hd); err != nil {
+			return nil, errors.Wrap(err, "invalid MetaMask HD keyring")
+		}
+		phrase, err := metamaskMnemonic(hd.Mnemonic)
+		if err != nil {
+			return nil, err
+		}
+		slog.Info("MetaMask Secret Recovery Phrase read", "accounts", hd.NumberOfAccounts, "hdpath", hd.HDPath)
+		phrases.WriteString(phrase + "\n")
+	}
+	if phrases.Len() == 0 {
+		return nil, errors.New("no Secret Recovery Phrase in the MetaMask vault")
+	}
+	k.metamaskPhrases.Store(sum, phrases.Bytes())
+	return bytes.NewReader(phrases.Bytes()), nil
+}
+
+// findMetamaskVault returns the vault of a MetaMask vault or state export.
+func findMetamaskVault(data []byte) (metamaskVault, error) {
+	var state struct {
+		metamaskVault
+		// Data is the vault ciphertext, or the state of the chrome.storage.local export
+		Data              json.RawMessage `json:"data"`
+		KeyringController *struct {
+			Vault string `json:"vault"`
+		} `json:"KeyringController"`
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return metamaskVault{}, errors.Wrap(err, "invalid MetaMask vault")
+	}
+	var vault metamaskVault
+	switch {
+	case state.KeyringController != nil && state.KeyringController.Vault != "":
+		if err := json.Unmarshal([]byte(state.KeyringController.Vault), &vault); err != nil {
+			return metamaskVault{}, errors.Wrap(err, "invalid MetaMask vault")
+		}
+	case bytes.HasPrefix(bytes.TrimSpace(state.Data), []byte("{")):
+		return findMetamaskVault(state.Data)
+	default:
+		if err := json.Unmarshal(data, &vault); err != nil {
+			return metamaskVault{}, errors.Wrap(err, "invalid MetaMask vault")
+		}
+	}
+	if vault.Data == "" || vault.IV == "" || vault.Salt == "" {
+		return metamaskVault{}, errors.New("no MetaMask vault found, expected its data, iv and salt")
+	}
+	return vault, nil
+}
+
+// metamaskMnemonic returns the mnemonic of a HD keyring, stored as a string or as its bytes.
+func metamaskMnemonic(raw json.RawMessage) (string, error) {
+	var phrase string
+	if err := json.Unmarshal(raw, &phrase); err == nil {
+		return strings.TrimSpace(phrase), nil
+	}
+	var codes []byte
+	var ints []int
+	if err := json.Unmarshal(raw, &ints); err != nil {
+		return "", errors.Wrap(err, "invalid MetaMask Secret Recovery Phrase")
+	}
+	for _, c := range ints {
+		if c < 0 || c > 0xff {
+			return "", errors.New("invalid MetaMask Secret Recovery Phrase")
+		}
+		codes = append(codes, byte(c))
+	}
+	return strings.TrimSpace(string(codes)), nil
+}
@@ -0,0 +1,95 @@
+package seeds
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+func TestDecryptMetaMask(t *testing.T) {
+	const (
+		phrase = "legal winner thank year wave sausage worth useful legal winner thank yellow"
+		other  = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	)
+	keyrings := []any{
+		map[string]any{"type": "HD Key Tree", "data": map[string]any{"mnemonic": phrase, "numberOfAccounts": 2, "hdPath": "m/44'/60'/0'/0"}},
+		map[string]any{"type": "Simple Key Pair", "data": []string{"4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318"}},
+		// the recent versions store the mnemonic as its bytes
+		map[string]any{"type": "HD Key Tree", "data": map[string]any{"mnemonic": bytesArray(other), "numberOfAccounts": 1, "hdPath": "m/44'/60'/0'/0"}},
+	}
+	vault := metamaskEncrypt(t, "correct horse", keyrings, 600000)
+	legacy := metamaskEncrypt(t, "correct horse", keyrings, 0)
+	state, _ := json.Marshal(map[string]any{"KeyringController": map[string]any{"vault": string(vault)}})
+	storage, _ := json.Marshal(map[string]any{"data": json.RawMessage(state), "meta": map[string]any{"version": 120}})
+
+	testCases := map[string][]byte{
+		"vault":         vault,
+		"legacy vault":  legacy,
+		"state":         state,
+		"local storage": append([]byte("\n  "), storage...),
+	}
+	for name, data := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, MetaMask, Detect(data))
+			keys, _ := NewKeys("", func() (string, error) { return "correct horse", nil })
+			r, err := decrypt("vault.json", io.NopCloser(bytes.NewReader(data)), keys)
+			if !assert.NoError(t, err) {
+				return
+			}
+			actual, err := io.ReadAll(r)
+			assert.NoError(t, err)
+			assert.Equal(t, phrase+"\n"+other+"\n", string(actual))
+		})
+	}
+
+	keys, _ := NewKeys("", func() (string, error) { return "wrong horse", nil })
+	_, err := decrypt("vault.json", io.NopCloser(bytes.NewReader(vault)), keys)
+	assert.ErrorContains(t, err, "wrong MetaMask password")
+	_, err = decrypt("vault.json", io.NopCloser(bytes.NewReader(vault)), nil)
+	assert.ErrorContains(t, err, "metamask encrypted")
+	_, err = decrypt("state.json", io.NopCloser(bytes.NewReader([]byte(`{"KeyringController":{}}`))), keys)
+	assert.ErrorContains(t, err, "no MetaMask vault found")
+}
+
+// metamaskEncrypt encrypts keyrings like the MetaMask browser-passworder, without key metadata
+// for the legacy 10000 iterations.
+func metamaskEncrypt(t *testing.T, password string, keyrings any, iterations int) []byte {
+	plaintext, err := json.Marshal(keyrings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	salt, iv := bytes.Repeat([]byte{1}, 32), bytes.Repeat([]byte{2}, 16)
+	rounds := iterations
+	if rounds == 0 {
+		rounds = metamaskIterations
+	}
+	block, _ := aes.NewCipher(pbkdf2.Key([]byte(password), salt, rounds, 32, sha256.New))
+	gcm, _ := cipher.NewGCMWithNonceSize(block, len(iv))
+	vault := map[string]any{
+		"data": base64.StdEncoding.EncodeToString(gcm.Seal(nil, iv, plaintext, nil)),
+		"iv":   base64.StdEncoding.EncodeToString(iv),
+		"salt": base64.StdEncoding.EncodeToString(salt),
+	}
+	if iterations != 0 {
+		vault["keyMetadata"] = map[string]any{"algorithm": "PBKDF2", "params": map[string]any{"iterations": iterations}}
+	}
+	data, _ := json.Marshal(vault)
+	return data
+}
+
+// bytesArray returns the UTF-8 bytes of s as a JSON array of numbers, not a base64 string.
+func bytesArray(s string) []int {
+	codes := make([]int, len(s))
+	for i := range len(s) {
+		codes[i] = int(s[i])
+	}
+	return codes
+}
@@ -0,0 +1,165 @@
+// Package seeds reads mnemonic seeds from files or the standard input, as plain lines or
+// tsv/csv rows carrying a passphrase, derivation path and label.
+package seeds
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultReadAhead is the default number of seeds buffered ahead of the consumer.
+	DefaultReadAhead = 1024
+
+	// MaxLineSize is the maximum length of a single seed line.
+	MaxLineSize = 1024 * 1024
+
+	// Stdin is the seeds file name reading from the standard input.
+	Stdin = "-"
+)
+
+// Seed is a single mnemonic read from the input, along with its 1-based line number.
+// Structured formats can also carry a BIP39 passphrase, a base derivation path overriding
+// the run one and a label attributing the results.
+type Seed struct {
+	Line       int
+	Phrase     string
+	Passphrase string `json:",omitempty"`
+	Path       string `json:",omitempty"`
+	Label      string `json:",omitempty"`
+}
+
+// Range selects a slice of input lines. Skip is the number of leading lines to ignore
+// and Take is the number of lines to process after that (0 or less means all remaining lines).
+type Range struct {
+	Skip int
+	Take int
+}
+
+// Contains reports whether the given 1-based line number is inside the range.
+func (r Range) Contains(line int) bool {
+	return line > r.Skip && (r.Take <= 0 || line <= r.Skip+r.Take)
+}
+
+// From returns the part of the range starting at the given 1-based line number.
+func (r Range) From(line int) Range {
+	skip := max(r.Skip, line-1)
+	if r.Take > 0 {
+		r.Take = max(r.Take-(skip-r.Skip), 1)
+	}
+	r.Skip = skip
+	return r
+}
+
+// done reports whether every line after the given line number is outside the range.
+func (r Range) done(line int) bool {
+	return r.Take > 0 && line >= r.Skip+r.Take
+}
+
+// ParseLineRange parses an inclusive 1-based line range such as "1000-2000" or "1000-".
+func ParseLineRange(s string) (Range, error) {
+	from, to, ok := strings.Cut(s, "-")
+	if !ok {
+		return Range{}, errors.Errorf("invalid line range %q, expected <from>-<to>", s)
+	}
+
+	start, err := strconv.Atoi(strings.TrimSpace(from))
+	if err != nil || start < 1 {
+		return Range{}, errors.Errorf("invalid line range %q, start must be a positive number", s)
+	}
+
+	rng := Range{Skip: start - 1}
+	if to = strings.TrimSpace(to); to != "" {
+		end, err := strconv.Atoi(to)
+		if err != nil || end < start {
+			return Range{}, errors.Errorf("invalid line range %q, end must be a number greater than or equal to start", s)
+		}
+		rng.Take = end - start + 1
+	}
+	return rng, nil
+}
+
+// Stream reads seeds line-by-line from r and sends the ones inside rng to the returned channel.
+// Blank lines are skipped and reading stops early if ctx is canceled. The error channel
+// receives at most one error and is closed after the seeds channel is closed.
+func Stream(ctx context.Context, r io.Reader, rng Range, readAhead int) (<-chan Seed, <-chan error) {
+	return stream(ctx, readAhead, func(fn func(Seed) bool) error {
+		_, _, err := scan(r, rng, FormatText, 0, fn)
+		return err
+	})
+}
+
+// stream runs read in a goroutine, sending the seeds it reads to the returned channel
+// until ctx is canceled.
+func stream(ctx context.Context, readAhead int, read func(fn func(Seed) bool) error) (<-chan Seed, <-chan error) {
+	if readAhead < 0 {
+		readAhead = DefaultReadAhead
+	}
+
+	out := make(chan Seed, readAhead)
+	errCh := make(chan error, 1)
+	go func() {
+		defer close(errCh)
+		defer close(out)
+
+		err := read(func(seed Seed) bool {
+			select {
+			case <-ctx.Done():
+				return false
+			case out <- seed:
+				return true
+			}
+		})
+		if err != nil {
+			errCh <- err
+		}
+	}()
+	return out, errCh
+}
+
+// Open opens the seeds file, or the standard input if filename is Stdin.
+func Open(filename string) (io.ReadCloser, error) {
+	if filename == Stdin {
+		return io.NopCloser(os.Stdin), nil
+	}
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return f, nil
+}
+
+// scan calls fn for every non-blank line of r inside rng, parsed in the given format, until fn
+// returns false. Lines are numbered from offset+1, scan returns the number of the last line
+// read and whether there may be more lines to read after r.
+func scan(r io.Reader, rng Range, format Format, offset int, fn func(Seed) bool) (int, bool, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), MaxLineSize)
+
+	line := offset
+	for scanner.Scan() {
+		line++
+		if !rng.Contains(line) {
+			continue
+		}
+
+		if text := scanner.Text(); strings.TrimSpace(text) != "" {
+			seed := format.parse(text)
+			seed.Line = line
+			if !fn(seed) {
+				return line, false, nil
+			}
+		}
+
+		if rng.done(line) {
+			return line, false, nil
+		}
+	}
+	return line, true, errors.WithStack(scanner.Err())
+}
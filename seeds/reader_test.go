@@ -0,0 +1,106 @@
+package seeds
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLineRange(t *testing.T) {
+	testCases := map[string]struct {
+		input    string
+		expected Range
+		isErr    bool
+	}{
+		"closed":    {input: "1000-2000", expected: Range{Skip: 999, Take: 1001}},
+		"open":      {input: "5-", expected: Range{Skip: 4}},
+		"single":    {input: "3-3", expected: Range{Skip: 2, Take: 1}},
+		"reversed":  {input: "10-2", isErr: true},
+		"zero":      {input: "0-2", isErr: true},
+		"no dash":   {input: "10", isErr: true},
+		"not digit": {input: "a-b", isErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			actual, err := ParseLineRange(tc.input)
+			if tc.isErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestStream(t *testing.T) {
+	input := "one\n\n  two  \nthree\nfour\n"
+
+	seedCh, errCh := Stream(context.Background(), strings.NewReader(input), Range{Skip: 1, Take: 3}, 0)
+	var actual []Seed
+	for seed := range seedCh {
+		actual = append(actual, seed)
+	}
+
+	assert.NoError(t, <-errCh)
+	assert.Equal(t, []Seed{{Line: 3, Phrase: "two"}, {Line: 4, Phrase: "three"}}, actual)
+}
+
+func TestInput(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{"a.txt": "one\ntwo\n", "b.txt": "\nthree\n"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	in, err := NewInput([]string{filepath.Join(dir, "*.txt")}, FormatText)
+	if err != nil {
+		t.Fatal(err)
+	}
+	count, err := in.CountLines(Range{})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, count)
+	counts, err := in.CountFileLines(Range{})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{2, 1}, counts)
+
+	seedCh, errCh := in.Stream(context.Background(), Range{Skip: 1}, 0)
+	var actual []Seed
+	for seed := range seedCh {
+		actual = append(actual, seed)
+	}
+	assert.NoError(t, <-errCh)
+	assert.Equal(t, []Seed{{Line: 2, Phrase: "two"}, {Line: 4, Phrase: "three"}}, actual)
+
+	file, line := in.Locate(4)
+	assert.Equal(t, filepath.Join(dir, "b.txt"), file)
+	assert.Equal(t, 2, line)
+
+	_, err = NewInput([]string{filepath.Join(dir, "*.csv")}, FormatText)
+	assert.Error(t, err)
+}
+
+func TestFormatParse(t *testing.T) {
+	testCases := map[string]struct {
+		format   Format
+		line     string
+		expected Seed
+	}{
+		"text":       {format: FormatText, line: "  one two \t", expected: Seed{Phrase: "one two"}},
+		"tsv":        {format: FormatTSV, line: "one two\t pass \tm/44'/60'/1'/0\tledger\r", expected: Seed{Phrase: "one two", Passphrase: " pass ", Path: "m/44'/60'/1'/0", Label: "ledger"}},
+		"tsv phrase": {format: FormatTSV, line: "one two", expected: Seed{Phrase: "one two"}},
+		"csv quoted": {format: FormatCSV, line: `one two,"a, b",,main`, expected: Seed{Phrase: "one two", Passphrase: "a, b", Label: "main"}},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.format.parse(tc.line))
+		})
+	}
+}
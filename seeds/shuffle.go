@@ -0,0 +1,31 @@
+package seeds
+
+import (
+	"context"
+	"math/rand/v2"
+)
+
+// Shuffle reads every seed of in, then forwards them in a random order drawn from the given
+// seed, so the same input and seed always give the same order. The whole input is held in
+// memory. Forwarding stops early if ctx is canceled.
+func Shuffle(ctx context.Context, in <-chan Seed, seed uint64) <-chan Seed {
+	out := make(chan Seed, cap(in))
+	go func() {
+		defer close(out)
+		var all []Seed
+		for s := range in {
+			all = append(all, s)
+		}
+
+		r := rand.New(rand.NewPCG(seed, seed))
+		r.Shuffle(len(all), func(i, j int) { all[i], all[j] = all[j], all[i] })
+		for _, s := range all {
+			select {
+			case <-ctx.Done():
+				return
+			case out <- s:
+			}
+		}
+	}()
+	return out
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/planxnx/ethereum-wallet-generator/internal/bloom"
+	"github.com/planxnx/ethereum-wallet-generator/wallets"
+)
+
+// addSeenFlags registers the flags of the filter of addresses already reported on fs and
+// returns a function returning its name, capacity and false positive rate once they have
+// been parsed.
+func addSeenFlags(fs *flag.FlagSet) func() (string, uint64, float64) {
+	name := fs.String("seen-filter", "", "Bloom filter file of the addresses reported by earlier runs, created if missing: the matches it holds are skipped and the new ones added, so campaigns of several runs report each address once")
+	capacity := fs.Uint64("seen-capacity", bloom.DefaultCapacity, "number of addresses a new -seen-filter is sized for, the false positive rate grows past it")
+	fpRate := fs.Float64("seen-fp-rate", bloom.DefaultFPRate, "false positive rate of a new -seen-filter, the share of the new matches wrongly skipped once it holds -seen-capacity addresses")
+	return func() (string, uint64, float64) {
+		return *name, *capacity, *fpRate
+	}
+}
+
+// openSeen opens the -seen-filter of the sinks, nil without one.
+func openSeen(name string, capacity uint64, fpRate float64) *bloom.Filter {
+	if name == "" {
+		return nil
+	}
+	f, err := bloom.Open(name, capacity, fpRate)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: --seen-filter: %v\n", err)
+		os.Exit(exitUsage)
+	}
+	if capacity, _ := f.Capacity(); f.Count() >= capacity {
+		slog.Warn("The seen filter is full, more new matches are wrongly skipped: rebuild it with a larger -seen-capacity", "file", name, "addresses", f.Count(), "capacity", capacity)
+	}
+	return f
+}
+
+// closeSeen saves the -seen-filter with the addresses of the run.
+func (s *resultSinks) closeSeen() {
+	if s.seen == nil {
+		return
+	}
+	if n := s.seenSkipped.Load(); n > 0 {
+		slog.Info("Skipped matches already reported by an earlier run", "matches", n, "file", s.seenPath)
+	}
+	if err := s.seen.Save(s.seenPath); err != nil {
+		slog.Error("Failed to save the seen filter", "file", s.seenPath, "err", err)
+		s.fail("seen")
+	}
+}
+
+// runRebuildSeen writes a new -seen-filter from the addresses of result DBs and address lists,
+// to resize a full filter or to build one from the results of earlier campaigns.
+func runRebuildSeen(args []string) {
+	fs := flag.NewFlagSet("rebuild-seen", flag.ExitOnError)
+	seenConfig := addSeenFlags(fs)
+	var dbPaths, addressFiles stringsFlag
+	fs.Var(&dbPaths, "db", "result DB whose stored addresses are added, can be repeated")
+	dbKey := fs.String("db-key", "", "SQLCipher passphrase of the -db files (requires a build with -tags sqlcipher)")
+	fs.Var(&addressFiles, "addresses", "file of addresses added (one per line), - for stdin, can be repeated")
+	parseFlags(fs, args)
+
+	name, capacity, fpRate := seenConfig()
+	if name == "" || len(dbPaths)+len(addressFiles) == 0 {
+		fmt.Fprintln(os.Stderr, "Error: --seen-filter and a --db or --addresses parameter required")
+		os.Exit(exitUsage)
+	}
+	var listed stringsFlag
+	for _, file := range addressFiles {
+		if err := readTargets(file, &listed); err != nil {
+			fatal("Failed to read addresses", "file", file, "err", err)
+		}
+	}
+	total := uint64(len(listed))
+	for _, path := range dbPaths {
+		var n int64
+		if err := openDB(path, *dbKey).Model(&wallets.Wallet{}).Count(&n).Error; err != nil {
+			fatal("Failed to query DB", "db", path, "err", err)
+		}
+		total += uint64(n)
+	}
+	if total > capacity {
+		slog.Info("Sizing the seen filter for every address", "addresses", total, "capacity", capacity)
+		capacity = total
+	}
+	f, err := bloom.New(capacity, fpRate)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitUsage)
+	}
+
+	for _, address := range listed {
+		f.Add(address)
+	}
+	for _, path := range dbPaths {
+		rows, err := openDB(path, *dbKey).Model(&wallets.Wallet{}).Select("address").Rows()
+		if err != nil {
+			fatal("Failed to query DB", "db", path, "err", err)
+		}
+		for rows.Next() {
+			var address string
+			if err := rows.Scan(&address); err != nil {
+				fatal("Failed to read DB", "db", path, "err", err)
+			}
+			f.Add(address)
+		}
+		if err := rows.Err(); err != nil {
+			fatal("Failed to read DB", "db", path, "err", err)
+		}
+		rows.Close()
+	}
+	if err := f.Save(name); err != nil {
+		fatal("Failed to save the seen filter", "file", name, "err", err)
+	}
+	fmt.Fprintf(os.Stderr, "Wrote %d addresses to %s (%d bytes, sized for %d at a %g false positive rate)\n", f.Count(), name, f.Size(), capacity, fpRate)
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// signalError is the cancellation cause of a context canceled by a signal.
+type signalError struct {
+	sig os.Signal
+}
+
+func (e signalError) Error() string {
+	return "received " + e.sig.String()
+}
+
+// withSignals returns a context canceled on the first SIGINT or SIGTERM, so the run can
+// stop cleanly. The default handling is restored after it, a second signal kills the process.
+func withSignals(parent context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(parent)
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		select {
+		case sig := <-sigCh:
+			signal.Stop(sigCh)
+			cancel(signalError{sig})
+		case <-ctx.Done():
+			signal.Stop(sigCh)
+		}
+	}()
+	return ctx, func() { cancel(context.Canceled) }
+}
+
+// interruptSignal returns the signal that canceled ctx, or nil if it wasn't canceled by one.
+func interruptSignal(ctx context.Context) os.Signal {
+	var sigErr signalError
+	if errors.As(context.Cause(ctx), &sigErr) {
+		return sigErr.sig
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"compress/gzip"
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/planxnx/ethereum-wallet-generator/internal/output"
+	"github.com/planxnx/ethereum-wallet-generator/store"
+	"github.com/planxnx/ethereum-wallet-generator/wallets"
+)
+
+// failingRepository fails every insert.
+type failingRepository struct {
+	store.Repository
+}
+
+func (failingRepository) Insert(*wallets.Wallet) error {
+	return errors.New("database is locked")
+}
+
+func TestAsyncDBFailure(t *testing.T) {
+	var failed atomic.Int64
+	sinks := &resultSinks{onFail: func() { failed.Add(1) }}
+	sinks.repo = store.NewAsyncRepository(failingRepository{store.NewInMemoryRepository()}, 4, sinks.dbInsertFailed)
+	sinks.Save(output.Record{Line: 1, Wallet: &wallets.Wallet{Address: "0x01"}})
+
+	if err := sinks.Flush(); err == nil {
+		t.Error("Flush must report the failed background insert")
+	}
+	// the insert failure and the failed commit
+	if !sinks.Failed() || failed.Load() != 2 {
+		t.Errorf("failed %v, onFail called %d times", sinks.Failed(), failed.Load())
+	}
+	if sinks.lostMatches.Load() != 1 {
+		t.Errorf("%d lost matches, want 1", sinks.lostMatches.Load())
+	}
+}
+
+func TestPlaintextSinks(t *testing.T) {
+	testCases := map[string]struct {
+		args []string
+		pgp  bool
+		// refused are the sinks refused by -no-plaintext, by flag name
+		refused []string
+	}{
+		"stdout":                    {refused: []string{"stdout"}},
+		"stdout no secrets":         {args: []string{"-no-secrets"}},
+		"stdout fields":             {args: []string{"-fields", "addr,hdpath"}},
+		"stdout fields with key":    {args: []string{"-fields", "addr,pk"}, refused: []string{"stdout"}},
+		"stdout hash only":          {args: []string{"-hash-only"}},
+		"stdout kms":                {args: []string{"-kms", "vault://wallets"}},
+		"stdout encrypted":          {args: []string{"-encrypt-output", "secret"}},
+		"file":                      {args: []string{"-out", "w.jsonl"}, refused: []string{"--out"}},
+		"file and matches":          {args: []string{"-out", "w.jsonl", "-matches-out", "m.jsonl"}, refused: []string{"--out", "--matches-out"}},
+		"file encrypted":            {args: []string{"-out", "w.jsonl", "-encrypt-output", "secret"}},
+		"file gpg":                  {args: []string{"-out", "w.jsonl"}, pgp: true},
+		"file fields":               {args: []string{"-out", "w.jsonl", "-fields", "addr,mnemonic"}, refused: []string{"--out"}},
+		"file kms":                  {args: []string{"-out", "w.jsonl", "-kms", "vault://wallets"}},
+		"db":                        {args: []string{"-db", "w.db"}, refused: []string{"--db"}},
+		"db fields":                 {args: []string{"-db", "w.db", "-fields", "addr,hdpath"}},
+		"db no secrets mnemonic":    {args: []string{"-db", "w.db", "-no-secrets", "-db-mnemonic"}},
+		"db encrypted keys":         {args: []string{"-db", "w.db", "-db-encrypt-keys", "secret"}},
+		"db encrypted keys, phrase": {args: []string{"-db", "w.db", "-db-encrypt-keys", "secret", "-db-mnemonic"}, refused: []string{"--db"}},
+		"db hash only":              {args: []string{"-db", "w.db", "-hash-only"}},
+		"db kms":                    {args: []string{"-db", "w.db", "-kms", "vault://wallets"}},
+		"db sqlcipher":              {args: []string{"-db", "w.db", "-db-key", "secret", "-db-mnemonic"}},
+		"qr address":                {args: []string{"-qr-dir", "qr"}},
+		"qr key":                    {args: []string{"-qr-dir", "qr", "-qr-content", "private-key"}, refused: []string{"--qr-dir"}},
+		"qr key fields":             {args: []string{"-qr-dir", "qr", "-qr-content", "both", "-fields", "addr"}},
+		"qr key kms":                {args: []string{"-qr-dir", "qr", "-qr-content", "private-key", "-kms", "vault://wallets"}, refused: []string{"--qr-dir"}},
+		"qr key gpg":                {args: []string{"-qr-dir", "qr", "-qr-content", "private-key"}, pgp: true},
+		"paper":                     {args: []string{"-paper-wallet-dir", "paper"}, refused: []string{"--paper-wallet-dir"}},
+		"paper kms":                 {args: []string{"-paper-wallet-dir", "paper", "-kms", "vault://wallets"}, refused: []string{"--paper-wallet-dir"}},
+		"paper no secrets":          {args: []string{"-paper-wallet-dir", "paper", "-no-secrets"}},
+		"paper gpg":                 {args: []string{"-paper-wallet-dir", "paper"}, pgp: true},
+		"keystore":                  {args: []string{"-keystore", "keys"}},
+		"keystore and file":         {args: []string{"-keystore", "keys", "-out", "w.jsonl"}, refused: []string{"--out"}},
+		"keystore, file no phrase":  {args: []string{"-keystore", "keys", "-out", "w.jsonl", "-fields", "addr,pk"}},
+		"keystore and db":           {args: []string{"-keystore", "keys", "-db", "w.db"}},
+		"everything":                {args: []string{"-db", "w.db", "-out", "w.jsonl", "-qr-dir", "qr", "-qr-content", "both", "-paper-wallet-dir", "paper"}, refused: []string{"--out", "--db", "--paper-wallet-dir", "--qr-dir"}},
+		"everything no secrets":     {args: []string{"-db", "w.db", "-out", "w.jsonl", "-qr-dir", "qr", "-qr-content", "both", "-paper-wallet-dir", "paper", "-no-secrets"}},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			fs := flag.NewFlagSet("scan", flag.ContinueOnError)
+			addSinkFlags(fs)
+			if err := fs.Parse(tc.args); err != nil {
+				t.Fatal(err)
+			}
+			fields, err := selectFields(fs.Lookup("fields").Value.String(), fs.Lookup("no-secrets").Value.String() == "true")
+			if err != nil {
+				t.Fatal(err)
+			}
+			var refused []string
+			for _, leak := range plaintextSinks(flagsPlaintextConfig(fs, fields, tc.pgp)) {
+				refused = append(refused, strings.Fields(leak)[0])
+			}
+			if !slices.Equal(refused, tc.refused) {
+				t.Errorf("refused %q, want %q", refused, tc.refused)
+			}
+		})
+	}
+}
+
+func TestOpenOutputResume(t *testing.T) {
+	dir := t.TempDir()
+	record := output.Record{Line: 1, Wallet: &wallets.Wallet{Address: "0x01"}}
+	run := func(path string, rotation output.Rotation, resume bool, records int) {
+		out := openOutput(output.FormatCSV, path, false, output.CompressGzip, nil, rotation, output.Options{Columns: []string{output.ColumnAddress}}, resume)
+		for range records {
+			if err := out.Write(record); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := out.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the resumed run appends its rows, without a second header
+	path := filepath.Join(dir, "wallets.csv")
+	run(path, output.Rotation{}, false, 2)
+	run(path, output.Rotation{}, true, 1)
+	f, err := os.Open(path + ".gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "address\n0x01\n0x01\n0x01\n" {
+		t.Errorf("resumed output %q", data)
+	}
+
+	// and continues after the last part
+	path = filepath.Join(dir, "parts.csv")
+	run(path, output.Rotation{Rows: 1}, false, 2)
+	run(path, output.Rotation{Rows: 1}, true, 1)
+	for part := 1; part <= 3; part++ {
+		if !fileExists(output.PartName(path, part) + ".gz") {
+			t.Errorf("part %d missing", part)
+		}
+	}
+
+	if appended, err := appendable(filepath.Join(dir, "missing.csv"), output.FormatCSV, nil); appended || err != nil {
+		t.Errorf("missing file appended %v, err %v", appended, err)
+	}
+	if _, err := appendable(filepath.Join(dir, "wallets.csv.gz"), output.FormatParquet, nil); err == nil {
+		t.Error("parquet file appended")
+	}
+	if _, err := appendable(filepath.Join(dir, "wallets.csv.gz"), output.FormatCSV, output.AgeEncrypter{}); err == nil {
+		t.Error("encrypted file appended")
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import "time"
+
+// Automatic slow seed detection: a seed is slow once it takes slowSeedFactor times the average
+// of the seeds before it, after the first slowSeedWarmup ones.
+const (
+	slowSeedFactor = 10
+	slowSeedWarmup = 20
+)
+
+// slowSeeds flags the seeds whose derivation took pathologically long, eg. of a malformed
+// line, above a fixed threshold or else a multiple of the average seed time.
+type slowSeeds struct {
+	// threshold is the fixed threshold, zero for the automatic one.
+	threshold time.Duration
+	total     time.Duration
+	seeds     int
+	flagged   int64
+}
+
+// check records the derivation time of a seed and returns the threshold it exceeded, zero if
+// it isn't slow.
+func (s *slowSeeds) check(elapsed time.Duration) time.Duration {
+	threshold := s.threshold
+	if threshold == 0 && s.seeds >= slowSeedWarmup {
+		threshold = s.total / time.Duration(s.seeds) * slowSeedFactor
+	}
+	s.total += elapsed
+	s.seeds++
+	if threshold == 0 || elapsed <= threshold {
+		return 0
+	}
+	s.flagged++
+	return threshold
+}
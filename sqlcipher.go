@@ -0,0 +1,15 @@
+//go:build sqlcipher
+
+package main
+
+import (
+	"database/sql"
+	"net/url"
+
+	_ "github.com/mutecomm/go-sqlcipher/v4"
+)
+
+// openEncryptedSQL opens the SQLCipher database at path, encrypted with the given passphrase.
+func openEncryptedSQL(path, key string) (*sql.DB, error) {
+	return sql.Open("sqlite3", path+"?_pragma_key="+url.QueryEscape(key)+"&_pragma_cipher_page_size=4096")
+}
@@ -0,0 +1,14 @@
+//go:build !sqlcipher
+
+package main
+
+import (
+	"database/sql"
+
+	"github.com/pkg/errors"
+)
+
+// openEncryptedSQL fails, SQLCipher needs cgo and the sqlcipher build tag.
+func openEncryptedSQL(path, key string) (*sql.DB, error) {
+	return nil, errors.New("--db-key requires a build with SQLCipher support: go build -tags sqlcipher (cgo)")
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/planxnx/ethereum-wallet-generator/store"
+	"github.com/planxnx/ethereum-wallet-generator/wallets"
+)
+
+// runStats reports what a DB holds: rows per run, derivation path and seed file, the
+// distribution of address leading zeros and the DB size.
+func runStats(args []string) {
+	fs := flag.NewFlagSet("stats", flag.ExitOnError)
+	dbPath := fs.String("db", "", "sqlite DB file to report on eg. wallets.db (a bare file name is read from ./db) or out/wallets.db, or a postgres:// or mysql:// DSN")
+	dbKey := fs.String("db-key", "", "SQLCipher passphrase of an encrypted DB")
+	parseFlags(fs, args)
+
+	if *dbPath == "" {
+		fmt.Fprintln(os.Stderr, "Error: --db parameter required")
+		os.Exit(exitUsage)
+	}
+	var size int64 = -1
+	if !isServerDSN(*dbPath) && *dbPath != memoryDB {
+		info, err := os.Stat(sqlitePath(*dbPath))
+		if err != nil {
+			fatal("Failed to open sqlite DB", "err", err)
+		}
+		size = info.Size()
+	}
+
+	db := openDB(*dbPath, *dbKey)
+	stats, err := collectStats(db)
+	if err != nil {
+		fatal("Failed to query DB", "err", err)
+	}
+	stats.size = size
+	stats.print(os.Stdout)
+}
+
+// dbStats are the totals reported by the stats command.
+type dbStats struct {
+	wallets, seeds int64
+	runs           []runCount
+	paths          []groupCount
+	seedFiles      []groupCount
+	// leadingZeros counts the addresses by their number of leading zero hex digits.
+	leadingZeros map[int]int64
+	size         int64
+}
+
+type groupCount struct {
+	Key   string
+	Count int64
+}
+
+type runCount struct {
+	store.Run
+	Count int64
+}
+
+// collectStats runs the stats queries on db.
+func collectStats(db *gorm.DB) (*dbStats, error) {
+	s := &dbStats{leadingZeros: make(map[int]int64)}
+	wallets := db.Model(&wallets.Wallet{})
+	if err := wallets.Session(&gorm.Session{}).Count(&s.wallets).Error; err != nil {
+		return nil, err
+	}
+	if err := wallets.Session(&gorm.Session{}).Distinct("seed_hash").Where("seed_hash <> ''").Count(&s.seeds).Error; err != nil {
+		return nil, err
+	}
+
+	var counts []groupCount
+	if err := wallets.Session(&gorm.Session{}).Select("run_id AS key, COUNT(*) AS count").Group("run_id").Scan(&counts).Error; err != nil {
+		return nil, err
+	}
+	var runs []store.Run
+	if err := db.Order("started_at").Find(&runs).Error; err != nil {
+		return nil, err
+	}
+	perRun := make(map[string]int64, len(counts))
+	for _, c := range counts {
+		perRun[c.Key] = c.Count
+	}
+	for _, r := range runs {
+		s.runs = append(s.runs, runCount{r, perRun[r.RunID]})
+		delete(perRun, r.RunID)
+	}
+	// wallets stored before runs were recorded, or by a run missing from the runs table
+	for id, n := range perRun {
+		s.runs = append(s.runs, runCount{store.Run{RunID: id}, n})
+	}
+
+	// addresses of one account share the path up to the address index
+	if err := wallets.Session(&gorm.Session{}).Select("hd_path AS key, COUNT(*) AS count").Group("hd_path").Scan(&counts).Error; err != nil {
+		return nil, err
+	}
+	perPath := make(map[string]int64)
+	for _, c := range counts {
+		perPath[path.Dir(c.Key)] += c.Count
+	}
+	s.paths = sortedCounts(perPath)
+
+	if err := wallets.Session(&gorm.Session{}).Select("seed_file AS key, COUNT(*) AS count").Group("seed_file").Scan(&counts).Error; err != nil {
+		return nil, err
+	}
+	perFile := make(map[string]int64)
+	for _, c := range counts {
+		perFile[c.Key] += c.Count
+	}
+	s.seedFiles = sortedCounts(perFile)
+
+	rows, err := wallets.Session(&gorm.Session{}).Select("address").Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var address string
+		if err := rows.Scan(&address); err != nil {
+			return nil, err
+		}
+		digits := strings.TrimPrefix(address, "0x")
+		s.leadingZeros[len(digits)-len(strings.TrimLeft(digits, "0"))]++
+	}
+	return s, rows.Err()
+}
+
+// sortedCounts returns the counts by decreasing count, then key.
+func sortedCounts(m map[string]int64) []groupCount {
+	counts := make([]groupCount, 0, len(m))
+	for k, n := range m {
+		counts = append(counts, groupCount{k, n})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].Key < counts[j].Key
+	})
+	return counts
+}
+
+// print writes the report to w.
+func (s *dbStats) print(w io.Writer) {
+	fmt.Fprintf(w, "Wallets:        %d\n", s.wallets)
+	fmt.Fprintf(w, "Distinct seeds: %d\n", s.seeds)
+	if s.size >= 0 {
+		fmt.Fprintf(w, "DB size:        %s\n", formatSize(s.size))
+	}
+
+	fmt.Fprintf(w, "\nRuns (%d):\n", len(s.runs))
+	for _, r := range s.runs {
+		if r.Command == "" {
+			id := r.RunID
+			if id == "" {
+				id = "(no run)"
+			}
+			fmt.Fprintf(w, "  %-16s %10d wallets\n", id, r.Count)
+			continue
+		}
+		ended := "not ended"
+		if r.EndedAt != nil {
+			ended = r.EndedAt.Sub(r.StartedAt).Round(time.Millisecond).String()
+		}
+		fmt.Fprintf(w, "  %-16s %10d wallets  %-9s %s  %s  %s\n", r.RunID, r.Count, r.Command, r.StartedAt.Format("2006-01-02 15:04:05"), ended, r.Version)
+	}
+
+	fmt.Fprintln(w, "\nDerivation paths:")
+	for _, c := range s.paths {
+		fmt.Fprintf(w, "  %-24s %10d\n", c.Key+"/*", c.Count)
+	}
+	fmt.Fprintln(w, "\nSeed files:")
+	for _, c := range s.seedFiles {
+		if c.Key == "" {
+			c.Key = "(none)"
+		}
+		fmt.Fprintf(w, "  %-24s %10d\n", c.Key, c.Count)
+	}
+
+	fmt.Fprintln(w, "\nLeading zero digits:")
+	zeros := make([]int, 0, len(s.leadingZeros))
+	for n := range s.leadingZeros {
+		zeros = append(zeros, n)
+	}
+	sort.Ints(zeros)
+	for _, n := range zeros {
+		fmt.Fprintf(w, "  %2d %10d\n", n, s.leadingZeros[n])
+	}
+}
+
+// formatSize formats a byte count with a binary unit.
+func formatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
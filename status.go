@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/planxnx/ethereum-wallet-generator/internal/redact"
+)
+
+// lastError is the last error logged by the command, reported by the -status endpoint.
+var lastError atomic.Pointer[statusError]
+
+// statusError is an error of the -status endpoint.
+type statusError struct {
+	Time    time.Time `json:"time"`
+	Message string    `json:"message"`
+}
+
+// runStatus is the -status response.
+type runStatus struct {
+	Status  string    `json:"status"`
+	Command string    `json:"command"`
+	Started time.Time `json:"started"`
+	// Uptime is in seconds, Rate in addresses per second since the start.
+	Uptime    float64 `json:"uptime"`
+	Seeds     int64   `json:"seeds"`
+	Processed int64   `json:"processed"`
+	Total     int64   `json:"total"`
+	// Progress is the fraction of Total processed, omitted when the total is unknown.
+	Progress  *float64     `json:"progress,omitempty"`
+	Rate      float64      `json:"rate"`
+	Matches   int64        `json:"matches"`
+	Errors    int64        `json:"errors"`
+	LastError *statusError `json:"last_error,omitempty"`
+}
+
+// serveStatus serves the JSON status of the run on listen, host:port optionally followed by
+// the path, for supervisors health checking long runs. It returns once the listener is open.
+func serveStatus(listen, command string) error {
+	addr, path := listen, "/"
+	if i := strings.IndexByte(listen, '/'); i >= 0 {
+		addr, path = listen[:i], listen[i:]
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.Handle(path, statusHandler(command))
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() { _ = srv.Serve(ln) }()
+	return nil
+}
+
+// statusHandler returns the handler of the -status endpoint, reporting the counters of
+// runMetrics and the lastError of command.
+func statusHandler(command string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		snap := runMetrics.Snapshot()
+		st := runStatus{
+			Status:    "running",
+			Command:   command,
+			Started:   snap.Started.UTC(),
+			Uptime:    snap.Uptime.Seconds(),
+			Seeds:     snap.Seeds,
+			Processed: snap.Addresses,
+			Total:     snap.Total,
+			Matches:   snap.Matches,
+			Errors:    snap.Errors,
+			LastError: lastError.Load(),
+		}
+		if snap.Total > 0 {
+			progress := min(float64(snap.Addresses)/float64(snap.Total), 1)
+			st.Progress = &progress
+		}
+		if s := snap.Uptime.Seconds(); s > 0 {
+			st.Rate = float64(snap.Addresses) / s
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(st)
+	})
+}
+
+// errorRecorder is a slog handler keeping the last error record in lastError, scrubbed by
+// redactor if set, before handing every record to the handler it wraps.
+type errorRecorder struct {
+	slog.Handler
+	redactor *redact.Redactor
+	// err is the err attribute added by With, if any.
+	err string
+}
+
+func (h errorRecorder) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level >= slog.LevelError {
+		msg, errText := r.Message, h.err
+		r.Attrs(func(a slog.Attr) bool {
+			if a.Key == "err" {
+				errText = a.Value.String()
+			}
+			return true
+		})
+		if errText != "" {
+			msg = fmt.Sprintf("%s: %s", msg, errText)
+		}
+		if h.redactor != nil {
+			msg = h.redactor.String(msg)
+		}
+		lastError.Store(&statusError{Time: r.Time.UTC(), Message: msg})
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h errorRecorder) WithAttrs(attrs []slog.Attr) slog.Handler {
+	for _, a := range attrs {
+		if a.Key == "err" {
+			h.err = a.Value.String()
+		}
+	}
+	h.Handler = h.Handler.WithAttrs(attrs)
+	return h
+}
+
+func (h errorRecorder) WithGroup(name string) slog.Handler {
+	h.Handler = h.Handler.WithGroup(name)
+	return h
+}
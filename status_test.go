@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/planxnx/ethereum-wallet-generator/internal/metrics"
+	"github.com/planxnx/ethereum-wallet-generator/internal/redact"
+)
+
+func TestStatusHandler(t *testing.T) {
+	previous := runMetrics
+	t.Cleanup(func() {
+		runMetrics = previous
+		lastError.Store(nil)
+	})
+	runMetrics = metrics.New("scan")
+	runMetrics.SetTotal(8)
+	runMetrics.Seed(2)
+	runMetrics.Match()
+	runMetrics.Error("db")
+
+	// the errors logged are reported without the secrets of the run
+	const secret = "correct-horse-battery-staple"
+	logger := slog.New(errorRecorder{Handler: slog.NewTextHandler(io.Discard, nil), redactor: redact.New(secret)})
+	logger.Warn("Slow DB write")
+	logger.Error("DB save failed", "err", errors.New("open "+secret+": permission denied"))
+
+	srv := httptest.NewServer(statusHandler("scan"))
+	defer srv.Close()
+	resp, err := srv.Client().Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var st runStatus
+	if err := json.NewDecoder(resp.Body).Decode(&st); err != nil {
+		t.Fatal(err)
+	}
+	if st.Status != "running" || st.Command != "scan" || st.Seeds != 1 || st.Processed != 2 || st.Total != 8 || st.Matches != 1 || st.Errors != 1 {
+		t.Errorf("status %+v", st)
+	}
+	if st.Progress == nil || *st.Progress != 0.25 {
+		t.Errorf("progress %v, want 0.25", st.Progress)
+	}
+	if st.LastError == nil || st.LastError.Message != "DB save failed: open "+redact.Replacement+": permission denied" {
+		t.Errorf("last error %+v", st.LastError)
+	}
+}
+
+func TestServeStatusRefused(t *testing.T) {
+	if err := serveStatus("256.0.0.1:http/status", "scan"); err == nil {
+		t.Error("served status on an invalid address")
+	}
+}
+
+func TestStatusHandlerUnknownTotal(t *testing.T) {
+	previous := runMetrics
+	t.Cleanup(func() { runMetrics = previous })
+	runMetrics = metrics.New("generate")
+
+	rec := httptest.NewRecorder()
+	statusHandler("generate").ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	var st map[string]any
+	if err := json.NewDecoder(rec.Body).Decode(&st); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := st["progress"]; ok {
+		t.Errorf("progress reported without a total: %v", st)
+	}
+}
@@ -0,0 +1,145 @@
+package store
+
+import (
+	"log/slog"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/planxnx/ethereum-wallet-generator/wallets"
+)
+
+// DefaultQueueSize is the default capacity of the AsyncRepository write queue.
+const DefaultQueueSize = 1024
+
+type asyncOp struct {
+	wallet *wallets.Wallet
+	flush  chan error
+}
+
+// AsyncRepository moves writes of an underlying repository to a dedicated goroutine
+// fed by a bounded queue. Insert only blocks when the queue is full. The first failed insert
+// or commit is latched: every later Commit and Close returns it.
+type AsyncRepository struct {
+	repo  Repository
+	queue chan asyncOp
+	done  chan struct{}
+	// onError is called from the writer goroutine with every wallet that failed to be
+	// inserted, it may be nil.
+	onError func(wallet *wallets.Wallet, err error)
+
+	closeOnce sync.Once
+	closeErr  error
+
+	errMu sync.Mutex
+	err   error
+}
+
+// NewAsyncRepository returns a repository writing to repo in the background, onError is
+// called with the wallets whose insert failed, they are logged if it is nil.
+func NewAsyncRepository(repo Repository, queueSize int, onError func(wallet *wallets.Wallet, err error)) Repository {
+	if queueSize < 1 {
+		queueSize = DefaultQueueSize
+	}
+	r := &AsyncRepository{
+		repo:    repo,
+		queue:   make(chan asyncOp, queueSize),
+		done:    make(chan struct{}),
+		onError: onError,
+	}
+	go r.run()
+	return r
+}
+
+func (r *AsyncRepository) run() {
+	defer close(r.done)
+	for op := range r.queue {
+		if op.flush != nil {
+			if err := r.repo.Commit(); err != nil {
+				r.latch(err)
+			}
+			op.flush <- r.latched()
+			continue
+		}
+
+		if err := r.repo.Insert(op.wallet); err != nil {
+			// returned by every Commit from now on
+			r.latch(err)
+			if r.onError != nil {
+				r.onError(op.wallet, err)
+			} else {
+				slog.Error("Failed to insert wallet to db", "err", err)
+			}
+		}
+	}
+}
+
+// Insert queues the wallet for writing.
+func (r *AsyncRepository) Insert(wallet *wallets.Wallet) error {
+	r.queue <- asyncOp{wallet: wallet}
+	return nil
+}
+
+func (r *AsyncRepository) Result() []*wallets.Wallet {
+	return r.repo.Result()
+}
+
+// Commit waits for every queued wallet to be written and commits the underlying repository.
+// It returns the first insert or commit error of the repository, if any, even if it was
+// already returned by an earlier Commit.
+func (r *AsyncRepository) Commit() error {
+	flush := make(chan error, 1)
+	r.queue <- asyncOp{flush: flush}
+	return errors.WithStack(<-flush)
+}
+
+// Close drains the queue and closes the underlying repository.
+func (r *AsyncRepository) Close() error {
+	r.closeOnce.Do(func() {
+		commitErr := r.Commit()
+		close(r.queue)
+		<-r.done
+		if err := r.repo.Close(); err != nil {
+			r.closeErr = errors.WithStack(err)
+			return
+		}
+		r.closeErr = commitErr
+	})
+	return r.closeErr
+}
+
+// StartRun inserts the run if the underlying repository records runs, it must be called
+// before any wallet is inserted.
+func (r *AsyncRepository) StartRun(run *Run) error {
+	if recorder, ok := r.repo.(RunRecorder); ok {
+		return recorder.StartRun(run)
+	}
+	return nil
+}
+
+// FinishRun waits for every queued wallet to be written, then finishes the run if the
+// underlying repository records runs. No wallet may be inserted meanwhile. The run is
+// finished even after a failed insert, which Commit and Close report.
+func (r *AsyncRepository) FinishRun(run *Run) error {
+	_ = r.Commit()
+	if recorder, ok := r.repo.(RunRecorder); ok {
+		return recorder.FinishRun(run)
+	}
+	return nil
+}
+
+// latch records err if it is the first error of the repository.
+func (r *AsyncRepository) latch(err error) {
+	r.errMu.Lock()
+	defer r.errMu.Unlock()
+	if r.err == nil {
+		r.err = err
+	}
+}
+
+// latched returns the first error of the repository, if any.
+func (r *AsyncRepository) latched() error {
+	r.errMu.Lock()
+	defer r.errMu.Unlock()
+	return r.err
+}
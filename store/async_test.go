@@ -0,0 +1,52 @@
+package store
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/planxnx/ethereum-wallet-generator/wallets"
+)
+
+// failingRepository fails the insert of the wallets of its bad address.
+type failingRepository struct {
+	Repository
+	bad string
+}
+
+func (r *failingRepository) Insert(wallet *wallets.Wallet) error {
+	if wallet.Address == r.bad {
+		return errors.New("disk I/O error")
+	}
+	return r.Repository.Insert(wallet)
+}
+
+func TestAsyncRepositoryLatchesErrors(t *testing.T) {
+	var failed []*wallets.Wallet
+	repo := NewAsyncRepository(&failingRepository{Repository: NewInMemoryRepository(), bad: "0x02"}, 4, func(w *wallets.Wallet, err error) {
+		failed = append(failed, w)
+	})
+	for _, address := range []string{"0x01", "0x02", "0x03"} {
+		if err := repo.Insert(&wallets.Wallet{Address: address}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := repo.Commit(); err == nil {
+		t.Error("Commit must return the failed insert")
+	}
+	// later commits and Close keep reporting it
+	if err := repo.Insert(&wallets.Wallet{Address: "0x04"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Commit(); err == nil {
+		t.Error("a second Commit must still return the failed insert")
+	}
+	if err := repo.Close(); err == nil {
+		t.Error("Close must return the failed insert")
+	}
+	if len(failed) != 1 || failed[0].Address != "0x02" {
+		t.Errorf("onError called with %v", failed)
+	}
+	if got := len(repo.Result()); got != 3 {
+		t.Errorf("%d wallets stored, want 3", got)
+	}
+}
@@ -0,0 +1,70 @@
+package store
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// ConflictPolicy decides what happens when an inserted wallet address is already stored.
+type ConflictPolicy string
+
+const (
+	// ConflictSkip keeps the stored row and drops the new one.
+	ConflictSkip ConflictPolicy = "skip"
+	// ConflictUpdate overwrites the stored row with the new one.
+	ConflictUpdate ConflictPolicy = "update"
+	// ConflictMerge keeps the keys and origin of the stored row and only refreshes the labels
+	// and proofs a later run adds: the seed label, the signature, the ICAP address and the
+	// address label, each one only if the new row has it.
+	ConflictMerge ConflictPolicy = "merge"
+	// ConflictError fails the insert.
+	ConflictError ConflictPolicy = "error"
+)
+
+// DefaultConflictPolicy is the default conflict policy.
+const DefaultConflictPolicy = ConflictSkip
+
+// uniqueAddressIndexName is the unique index conflicts are detected on.
+const uniqueAddressIndexName = "idx_wallets_address"
+
+// ParseConflictPolicy parses a conflict policy name.
+func ParseConflictPolicy(s string) (ConflictPolicy, error) {
+	switch p := ConflictPolicy(s); p {
+	case ConflictSkip, ConflictUpdate, ConflictMerge, ConflictError:
+		return p, nil
+	case "ignore":
+		return ConflictSkip, nil
+	default:
+		return "", errors.Errorf("unknown conflict policy %q, must be skip, update, merge or error", s)
+	}
+}
+
+// wrapIndexError explains the usual reason the unique address index can't be created.
+func wrapIndexError(err error) error {
+	return errors.Wrap(err, "failed to create the unique address index, the DB may already contain duplicate addresses")
+}
+
+// mergedColumns are the columns ConflictMerge refreshes, each one with the column telling
+// whether the new row has it: the signed message goes with its signature.
+var mergedColumns = []struct{ column, present string }{
+	{"seed_label", "seed_label"},
+	{"signed_message", "signature"},
+	{"signature", "signature"},
+	{"icap", "icap"},
+	{"address_label", "address_label"},
+}
+
+// mergeAssignments returns the SET list of ConflictMerge in the dialect of the DB, the new
+// row being excluded in sqlite and postgres and VALUES() in mysql.
+func mergeAssignments(dialect string) string {
+	newValue := func(column string) string { return "excluded." + column }
+	if dialect == "mysql" {
+		newValue = func(column string) string { return "VALUES(" + column + ")" }
+	}
+	s := "updated_at = " + newValue("updated_at")
+	for _, c := range mergedColumns {
+		s += fmt.Sprintf(", %s = CASE WHEN %s <> '' THEN %s ELSE wallets.%s END", c.column, newValue(c.present), newValue(c.column), c.column)
+	}
+	return s
+}
@@ -0,0 +1,114 @@
+package store
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/planxnx/ethereum-wallet-generator/wallets"
+)
+
+// newRepos returns the constructors of the SQL repositories, each storing into a DB with
+// transactions of maxTxSize rows and policy.
+func newRepos(maxTxSize uint64, policy ConflictPolicy) map[string]func(db *sql.DB) (Repository, error) {
+	return map[string]func(db *sql.DB) (Repository, error){
+		"raw": func(db *sql.DB) (Repository, error) { return NewSQLRepository(db, maxTxSize, policy) },
+		"gorm": func(db *sql.DB) (Repository, error) {
+			gdb, err := gorm.Open(&sqlite.Dialector{Conn: db}, &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+			if err != nil {
+				return nil, err
+			}
+			return NewGormRepository(gdb, maxTxSize, policy)
+		},
+	}
+}
+
+// openMemoryDB returns an in-memory sqlite DB closed at the end of the test.
+func openMemoryDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	db.SetMaxOpenConns(1)
+	return db
+}
+
+func TestConflictMerge(t *testing.T) {
+	for name, newRepo := range newRepos(1, ConflictMerge) {
+		t.Run(name, func(t *testing.T) {
+			db := openMemoryDB(t)
+			repo, err := newRepo(db)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			const address = "0x9858effd232b4033e47d90003d41ec34ecaeda94"
+			rows := []*wallets.Wallet{
+				{Address: address, PrivateKey: "first", SeedLine: 1, SeedLabel: "cold", RunID: "run1"},
+				{Address: address, PrivateKey: "second", SeedLine: 2, Signature: "0xsig", SignedMessage: "msg", RunID: "run2"},
+			}
+			for _, w := range rows {
+				if err := repo.Insert(w); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := repo.Commit(); err != nil {
+				t.Fatal(err)
+			}
+
+			var (
+				count                                 int
+				key, label, signature, message, runID string
+				line                                  int
+			)
+			if err := db.QueryRow(`SELECT COUNT(*) FROM wallets`).Scan(&count); err != nil || count != 1 {
+				t.Fatalf("%d rows, %v", count, err)
+			}
+			if err := db.QueryRow(`SELECT private_key, seed_line, seed_label, signature, signed_message, run_id FROM wallets`).Scan(&key, &line, &label, &signature, &message, &runID); err != nil {
+				t.Fatal(err)
+			}
+			if key != "first" || line != 1 || runID != "run1" {
+				t.Errorf("replaced the stored row: key %q, line %d, run %q", key, line, runID)
+			}
+			if label != "cold" || signature != "0xsig" || message != "msg" {
+				t.Errorf("merged label %q, signature %q, message %q", label, signature, message)
+			}
+		})
+	}
+}
+
+func TestConflictErrorMidBatch(t *testing.T) {
+	for name, newRepo := range newRepos(10, ConflictError) {
+		t.Run(name, func(t *testing.T) {
+			db := openMemoryDB(t)
+			repo, err := newRepo(db)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for i, address := range []string{"0x01", "0x02", "0x01", "0x03"} {
+				err := repo.Insert(&wallets.Wallet{Address: address, SeedLine: i + 1})
+				if conflict := i == 2; conflict != (err != nil) {
+					t.Fatalf("insert %d of %s: %v", i, address, err)
+				}
+			}
+			if err := repo.Commit(); err != nil {
+				t.Fatal(err)
+			}
+
+			var count int
+			if err := db.QueryRow(`SELECT COUNT(*) FROM wallets`).Scan(&count); err != nil || count != 3 {
+				t.Fatalf("%d rows, %v", count, err)
+			}
+			var line int
+			if err := db.QueryRow(`SELECT seed_line FROM wallets WHERE address = '0x01'`).Scan(&line); err != nil || line != 1 {
+				t.Errorf("first row replaced: line %d, %v", line, err)
+			}
+		})
+	}
+}
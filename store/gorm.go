@@ -0,0 +1,179 @@
+package store
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/planxnx/ethereum-wallet-generator/wallets"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DefaultMaxTxSize is the default number of inserts per transaction.
+const DefaultMaxTxSize = 1000
+
+type GormRepository struct {
+	db        *gorm.DB
+	mu        sync.Mutex
+	tx        *gorm.DB
+	txSize    uint64
+	maxTxSize uint64
+	conflict  clause.Expression
+	// savePoints wraps every insert in a savepoint, for the policies failing on conflicts.
+	savePoints bool
+}
+
+// insertSavePoint is the savepoint a failed insert is rolled back to, a failed statement
+// aborting the whole transaction in postgres along with the rows inserted before it.
+const insertSavePoint = "ewg_insert"
+
+// NewGormRepository applies the pending schema migrations and returns a repository
+// that commits every maxTxSize inserts, handling duplicate addresses with policy.
+func NewGormRepository(db *gorm.DB, maxTxSize uint64, policy ConflictPolicy) (Repository, error) {
+	if _, err := Migrate(db); err != nil {
+		return nil, err
+	}
+
+	var conflict clause.Expression
+	switch policy {
+	case ConflictSkip:
+		conflict = clause.OnConflict{Columns: []clause.Column{{Name: "address"}}, DoNothing: true}
+	case ConflictUpdate:
+		conflict = clause.OnConflict{Columns: []clause.Column{{Name: "address"}}, UpdateAll: true}
+	case ConflictMerge:
+		conflict = mergeClause{dialect: db.Dialector.Name()}
+	}
+	return &GormRepository{
+		db:         db,
+		maxTxSize:  maxTxSize,
+		conflict:   conflict,
+		savePoints: conflict == nil,
+	}, nil
+}
+
+func (r *GormRepository) Insert(wallet *wallets.Wallet) error {
+	if r.db == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.tx == nil {
+		r.tx = r.db.Begin()
+	}
+
+	if r.savePoints {
+		if err := r.tx.SavePoint(insertSavePoint).Error; err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	tx := r.tx
+	if r.conflict != nil {
+		tx = tx.Clauses(r.conflict)
+	}
+	if err := tx.Create(wallet).Error; err != nil {
+		if r.savePoints {
+			if rollbackErr := r.tx.RollbackTo(insertSavePoint).Error; rollbackErr != nil {
+				return errors.WithStack(rollbackErr)
+			}
+		}
+		return errors.WithStack(err)
+	}
+	if r.savePoints {
+		if err := r.tx.Exec("RELEASE SAVEPOINT " + insertSavePoint).Error; err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	r.txSize++
+
+	if r.txSize >= r.maxTxSize {
+		if err := r.commit(); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	return nil
+}
+
+func (r *GormRepository) Result() []*wallets.Wallet {
+	return nil
+}
+
+func (r *GormRepository) Close() error {
+	if err := r.Commit(); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+func (r *GormRepository) Commit() error {
+	if r.db == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.commit(); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+func (r *GormRepository) StartRun(run *Run) error {
+	if r.db == nil {
+		return nil
+	}
+	return errors.WithStack(r.db.Create(run).Error)
+}
+
+func (r *GormRepository) FinishRun(run *Run) error {
+	if r.db == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.commit(); err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(r.db.Model(run).Select("ended_at", "matches").Updates(run).Error)
+}
+
+// commit unsafe method, should be called inside package only
+func (r *GormRepository) commit() error {
+	if r.tx != nil {
+		if err := r.tx.Commit().Error; err != nil {
+			return errors.WithStack(err)
+		}
+		r.txSize = 0
+		r.tx = nil
+	}
+	return nil
+}
+
+// mergeClause is the ON CONFLICT clause of ConflictMerge, whose conditional assignments
+// clause.OnConflict can't express in every dialect.
+type mergeClause struct{ dialect string }
+
+func (c mergeClause) Name() string { return "ON CONFLICT" }
+
+func (c mergeClause) Build(builder clause.Builder) {
+	if c.dialect == "mysql" {
+		builder.WriteString("ON DUPLICATE KEY UPDATE ")
+	} else {
+		builder.WriteString("(address) DO UPDATE SET ")
+	}
+	builder.WriteString(mergeAssignments(c.dialect))
+}
+
+// MergeClause drops the ON CONFLICT name written before the expression in mysql, which
+// has its own syntax.
+func (c mergeClause) MergeClause(cl *clause.Clause) {
+	cl.Expression = c
+	if c.dialect == "mysql" {
+		cl.Name = ""
+	}
+}
@@ -0,0 +1,55 @@
+package store
+
+import (
+	"time"
+
+	"github.com/planxnx/ethereum-wallet-generator/wallets"
+)
+
+// InstrumentedRepository reports the latency of the inserts and commits of a repository.
+type InstrumentedRepository struct {
+	repo    Repository
+	observe func(op string, d time.Duration)
+}
+
+// NewInstrumentedRepository returns repo calling observe with "insert" or "commit" and the
+// duration of every insert and commit. Runs are recorded if repo records them.
+func NewInstrumentedRepository(repo Repository, observe func(op string, d time.Duration)) Repository {
+	return &InstrumentedRepository{repo: repo, observe: observe}
+}
+
+func (r *InstrumentedRepository) Insert(wallet *wallets.Wallet) error {
+	start := time.Now()
+	err := r.repo.Insert(wallet)
+	r.observe("insert", time.Since(start))
+	return err
+}
+
+func (r *InstrumentedRepository) Result() []*wallets.Wallet {
+	return r.repo.Result()
+}
+
+func (r *InstrumentedRepository) Commit() error {
+	start := time.Now()
+	err := r.repo.Commit()
+	r.observe("commit", time.Since(start))
+	return err
+}
+
+func (r *InstrumentedRepository) Close() error {
+	return r.repo.Close()
+}
+
+func (r *InstrumentedRepository) StartRun(run *Run) error {
+	if recorder, ok := r.repo.(RunRecorder); ok {
+		return recorder.StartRun(run)
+	}
+	return nil
+}
+
+func (r *InstrumentedRepository) FinishRun(run *Run) error {
+	if recorder, ok := r.repo.(RunRecorder); ok {
+		return recorder.FinishRun(run)
+	}
+	return nil
+}
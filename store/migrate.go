@@ -0,0 +1,238 @@
+package store
+
+import (
+	"database/sql"
+	"log/slog"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Migration is one versioned change of the database schema. Migrations are applied in
+// version order, each at most once, and never change once released: a schema change is
+// made by appending a new migration.
+type Migration struct {
+	Version int
+	Name    string
+	up      func(tx *gorm.DB) error
+}
+
+// AppliedMigration is the row of the schema_migrations table recording an applied migration.
+type AppliedMigration struct {
+	Version   int `gorm:"primaryKey;autoIncrement:false"`
+	Name      string
+	AppliedAt time.Time
+}
+
+// TableName names the table of AppliedMigration.
+func (AppliedMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// walletV1 is the wallets table of migration 1, the schema AutoMigrate created before versioned
+// migrations, so existing databases only get their missing columns added.
+type walletV1 struct {
+	Address             string `gorm:"size:64"`
+	ChecksumAddress     string
+	PrivateKey          string
+	PublicKey           string
+	CompressedPublicKey string
+	Mnemonic            string
+	HDPath              string
+	SeedFile            string
+	SeedLine            int
+	SeedLabel           string
+	SeedHash            string
+	AccountIndex        int
+	AddressIndex        int
+	RunID               string `gorm:"size:32;index:idx_wallets_run_id"`
+	gorm.Model
+	Bits int
+}
+
+func (walletV1) TableName() string { return "wallets" }
+
+// runV2 is the runs table of migration 2.
+type runV2 struct {
+	RunID     string `gorm:"primaryKey;size:32"`
+	Command   string
+	StartedAt time.Time
+	EndedAt   *time.Time
+	Config    string
+	Version   string
+	Matches   int64
+}
+
+func (runV2) TableName() string { return "runs" }
+
+// walletV4 holds the proof of control columns migration 4 adds to the wallets table.
+type walletV4 struct {
+	SignedMessage string
+	Signature     string
+}
+
+func (walletV4) TableName() string { return "wallets" }
+
+// walletV5 holds the Avalanche address columns migration 5 adds to the wallets table.
+type walletV5 struct {
+	AvaxXAddress string
+	AvaxPAddress string
+}
+
+func (walletV5) TableName() string { return "wallets" }
+
+// walletV6 is the address column migration 6 widens to fit the Cardano base addresses.
+type walletV6 struct {
+	Address string `gorm:"size:128"`
+}
+
+func (walletV6) TableName() string { return "wallets" }
+
+// walletV7 holds the encrypted private key columns migration 7 adds to the wallets table.
+type walletV7 struct {
+	PrivateKeySalt       string
+	PrivateKeyNonce      string
+	PrivateKeyCiphertext string
+}
+
+func (walletV7) TableName() string { return "wallets" }
+
+// walletV8 adds the salted private key hash of hash-only rows.
+type walletV8 struct {
+	PrivateKeyHash string
+}
+
+func (walletV8) TableName() string { return "wallets" }
+
+// walletV9 adds the ICAP form of the addresses.
+type walletV9 struct {
+	ICAP string
+}
+
+func (walletV9) TableName() string { return "wallets" }
+
+// walletV10 adds the label of the known addresses.
+type walletV10 struct {
+	AddressLabel string
+}
+
+func (walletV10) TableName() string { return "wallets" }
+
+// Migrations lists every migration in version order.
+var Migrations = []Migration{
+	{Version: 1, Name: "wallets table", up: func(tx *gorm.DB) error {
+		return tx.AutoMigrate(&walletV1{})
+	}},
+	{Version: 2, Name: "runs table", up: func(tx *gorm.DB) error {
+		return tx.AutoMigrate(&runV2{})
+	}},
+	{Version: 3, Name: "unique wallet address index", up: func(tx *gorm.DB) error {
+		if tx.Migrator().HasIndex(&walletV1{}, uniqueAddressIndexName) {
+			return nil
+		}
+		if err := tx.Exec("CREATE UNIQUE INDEX " + uniqueAddressIndexName + " ON wallets(address)").Error; err != nil {
+			return wrapIndexError(err)
+		}
+		return nil
+	}},
+	{Version: 4, Name: "wallet signature columns", up: func(tx *gorm.DB) error {
+		return tx.AutoMigrate(&walletV4{})
+	}},
+	{Version: 5, Name: "wallet avalanche columns", up: func(tx *gorm.DB) error {
+		return tx.AutoMigrate(&walletV5{})
+	}},
+	{Version: 6, Name: "wider wallet address column", up: func(tx *gorm.DB) error {
+		// sqlite doesn't enforce the size of text columns
+		if tx.Dialector.Name() == "sqlite" {
+			return nil
+		}
+		return tx.Migrator().AlterColumn(&walletV6{}, "Address")
+	}},
+	{Version: 7, Name: "wallet encrypted private key columns", up: func(tx *gorm.DB) error {
+		return tx.AutoMigrate(&walletV7{})
+	}},
+	{Version: 8, Name: "wallet private key hash column", up: func(tx *gorm.DB) error {
+		return tx.AutoMigrate(&walletV8{})
+	}},
+	{Version: 9, Name: "wallet icap column", up: func(tx *gorm.DB) error {
+		return tx.AutoMigrate(&walletV9{})
+	}},
+	{Version: 10, Name: "wallet address label column", up: func(tx *gorm.DB) error {
+		return tx.AutoMigrate(&walletV10{})
+	}},
+}
+
+// LatestSchemaVersion is the schema version once every migration is applied.
+var LatestSchemaVersion = Migrations[len(Migrations)-1].Version
+
+// SchemaVersion returns the version of the last migration applied to db, 0 if none.
+func SchemaVersion(db *gorm.DB) (int, error) {
+	if !db.Migrator().HasTable(&AppliedMigration{}) {
+		return 0, nil
+	}
+	var version sql.NullInt64
+	if err := db.Model(&AppliedMigration{}).Select("MAX(version)").Scan(&version).Error; err != nil {
+		return 0, errors.WithStack(err)
+	}
+	return int(version.Int64), nil
+}
+
+// AppliedMigrations returns the migrations applied to db in version order.
+func AppliedMigrations(db *gorm.DB) ([]AppliedMigration, error) {
+	if !db.Migrator().HasTable(&AppliedMigration{}) {
+		return nil, nil
+	}
+	var applied []AppliedMigration
+	if err := db.Order("version").Find(&applied).Error; err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return applied, nil
+}
+
+// Migrate applies the pending migrations to db, each in its own transaction, and returns them.
+// It fails if db has a newer schema than this build knows, instead of writing rows it can't
+// describe.
+func Migrate(db *gorm.DB) ([]Migration, error) {
+	if err := db.AutoMigrate(&AppliedMigration{}); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	version, err := SchemaVersion(db)
+	if err != nil {
+		return nil, err
+	}
+	if version > LatestSchemaVersion {
+		return nil, errors.Errorf("DB schema version %d is newer than the version %d this build supports, upgrade the program", version, LatestSchemaVersion)
+	}
+
+	var applied []Migration
+	for _, m := range Migrations {
+		if m.Version <= version {
+			continue
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := m.up(tx); err != nil {
+				return err
+			}
+			return tx.Create(&AppliedMigration{Version: m.Version, Name: m.Name, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			return applied, errors.Wrapf(err, "migration %d (%s) failed", m.Version, m.Name)
+		}
+		slog.Info("Applied DB migration", "version", m.Version, "name", m.Name)
+		applied = append(applied, m)
+	}
+	return applied, nil
+}
+
+// migrateSQL applies the pending migrations to a sqlite database opened with database/sql.
+func migrateSQL(db *sql.DB) error {
+	gdb, err := gorm.Open(&sqlite.Dialector{Conn: db}, &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	_, err = Migrate(gdb)
+	return err
+}
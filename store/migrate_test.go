@@ -0,0 +1,63 @@
+package store
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestMigrateLegacyDB(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	// a wallets table created before run IDs and versioned migrations
+	if _, err := db.Exec(`CREATE TABLE wallets (id integer PRIMARY KEY AUTOINCREMENT, created_at datetime, updated_at datetime, deleted_at datetime, address text, mnemonic text, hd_path text, bits integer)`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`INSERT INTO wallets (address) VALUES ('0x9858effd232b4033e47d90003d41ec34ecaeda94')`); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := migrateSQL(db); err != nil {
+			t.Fatalf("migration %d: %v", i, err)
+		}
+	}
+
+	var version int
+	if err := db.QueryRow(`SELECT MAX(version) FROM schema_migrations`).Scan(&version); err != nil {
+		t.Fatal(err)
+	}
+	if version != LatestSchemaVersion {
+		t.Errorf("schema version = %d, want %d", version, LatestSchemaVersion)
+	}
+	var rows int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM wallets WHERE run_id IS NULL`).Scan(&rows); err != nil {
+		t.Fatal(err)
+	}
+	if rows != 1 {
+		t.Errorf("legacy rows = %d, want 1", rows)
+	}
+}
+
+func TestMigrateNewerDB(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	if err := migrateSQL(db); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`INSERT INTO schema_migrations (version, name) VALUES (?, 'future')`, LatestSchemaVersion+1); err != nil {
+		t.Fatal(err)
+	}
+	if err := migrateSQL(db); err == nil {
+		t.Error("migrating a DB with a newer schema succeeded")
+	}
+}
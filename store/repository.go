@@ -0,0 +1,13 @@
+// Package store persists wallets in memory or in a SQL database.
+package store
+
+import "github.com/planxnx/ethereum-wallet-generator/wallets"
+
+// Repository stores wallets. Inserts may be buffered until Commit or Close.
+type Repository interface {
+	Insert(wallet *wallets.Wallet) error
+	// Result returns the stored wallets, for the repositories that keep them in memory.
+	Result() []*wallets.Wallet
+	Commit() error
+	Close() error
+}
@@ -0,0 +1,41 @@
+package store
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+)
+
+// Run is the row of the runs table describing one run writing to a database. Wallets
+// carry the RunID of the run that stored them.
+type Run struct {
+	RunID     string `gorm:"primaryKey;size:32"`
+	Command   string
+	StartedAt time.Time
+	// EndedAt stays empty when the run didn't shut down cleanly.
+	EndedAt *time.Time
+	// Config is the JSON encoded configuration of the run.
+	Config  string
+	Version string
+	Matches int64
+}
+
+// TableName names the table of Run.
+func (Run) TableName() string {
+	return "runs"
+}
+
+// NewRunID returns a random run ID.
+func NewRunID() string {
+	var id [8]byte
+	_, _ = rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
+
+// RunRecorder is implemented by the repositories recording the runs writing to them.
+type RunRecorder interface {
+	// StartRun inserts the run.
+	StartRun(run *Run) error
+	// FinishRun commits the pending wallets, then stores the end time and matches of the run.
+	FinishRun(run *Run) error
+}
@@ -0,0 +1,134 @@
+package store
+
+import (
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/planxnx/ethereum-wallet-generator/wallets"
+)
+
+const insertWalletQuery = `INSERT INTO wallets (created_at, updated_at, address, checksum_address, private_key, public_key, compressed_public_key, mnemonic, hd_path, seed_file, seed_line, seed_label, seed_hash, account_index, address_index, signed_message, signature, avax_x_address, avax_p_address, private_key_salt, private_key_nonce, private_key_ciphertext, private_key_hash, icap, address_label, bits, run_id) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+// conflictClauses are appended to insertWalletQuery for each conflict policy.
+var conflictClauses = map[ConflictPolicy]string{
+	ConflictSkip: ` ON CONFLICT(address) DO NOTHING`,
+	ConflictUpdate: ` ON CONFLICT(address) DO UPDATE SET updated_at = excluded.updated_at, checksum_address = excluded.checksum_address,
+	private_key = excluded.private_key, public_key = excluded.public_key, compressed_public_key = excluded.compressed_public_key,
+	mnemonic = excluded.mnemonic, hd_path = excluded.hd_path, seed_file = excluded.seed_file, seed_line = excluded.seed_line, seed_label = excluded.seed_label, seed_hash = excluded.seed_hash,
+	account_index = excluded.account_index, address_index = excluded.address_index, signed_message = excluded.signed_message, signature = excluded.signature,
+	avax_x_address = excluded.avax_x_address, avax_p_address = excluded.avax_p_address,
+	private_key_salt = excluded.private_key_salt, private_key_nonce = excluded.private_key_nonce, private_key_ciphertext = excluded.private_key_ciphertext, private_key_hash = excluded.private_key_hash, icap = excluded.icap, address_label = excluded.address_label, bits = excluded.bits, run_id = excluded.run_id, deleted_at = NULL`,
+	ConflictMerge: ` ON CONFLICT(address) DO UPDATE SET ` + mergeAssignments("sqlite"),
+}
+
+// SQLRepository writes wallets with database/sql prepared statements, bypassing GORM reflection.
+type SQLRepository struct {
+	db        *sql.DB
+	query     string
+	mu        sync.Mutex
+	tx        *sql.Tx
+	stmt      *sql.Stmt
+	txSize    uint64
+	maxTxSize uint64
+}
+
+// NewSQLRepository applies the pending schema migrations to the sqlite database and returns
+// a repository that commits every maxTxSize inserts, handling duplicate addresses with policy.
+func NewSQLRepository(db *sql.DB, maxTxSize uint64, policy ConflictPolicy) (Repository, error) {
+	if err := migrateSQL(db); err != nil {
+		return nil, err
+	}
+	if maxTxSize == 0 {
+		maxTxSize = 1
+	}
+	return &SQLRepository{
+		db:        db,
+		query:     insertWalletQuery + conflictClauses[policy],
+		maxTxSize: maxTxSize,
+	}, nil
+}
+
+func (r *SQLRepository) Insert(wallet *wallets.Wallet) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.tx == nil {
+		tx, err := r.db.Begin()
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		stmt, err := tx.Prepare(r.query)
+		if err != nil {
+			_ = tx.Rollback()
+			return errors.WithStack(err)
+		}
+		r.tx, r.stmt = tx, stmt
+	}
+
+	now := time.Now()
+	if _, err := r.stmt.Exec(now, now, wallet.Address, wallet.ChecksumAddress, wallet.PrivateKey, wallet.PublicKey, wallet.CompressedPublicKey, wallet.Mnemonic, wallet.HDPath, wallet.SeedFile, wallet.SeedLine, wallet.SeedLabel, wallet.SeedHash, wallet.AccountIndex, wallet.AddressIndex, wallet.SignedMessage, wallet.Signature, wallet.AvaxXAddress, wallet.AvaxPAddress, wallet.PrivateKeySalt, wallet.PrivateKeyNonce, wallet.PrivateKeyCiphertext, wallet.PrivateKeyHash, wallet.ICAP, wallet.AddressLabel, wallet.Bits, wallet.RunID); err != nil {
+		return errors.WithStack(err)
+	}
+	r.txSize++
+
+	if r.txSize >= r.maxTxSize {
+		if err := r.commit(); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+func (r *SQLRepository) Result() []*wallets.Wallet {
+	return nil
+}
+
+func (r *SQLRepository) Commit() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.commit(); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+func (r *SQLRepository) Close() error {
+	if err := r.Commit(); err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(r.db.Close())
+}
+
+func (r *SQLRepository) StartRun(run *Run) error {
+	_, err := r.db.Exec(`INSERT INTO runs (run_id, command, started_at, config, version, matches) VALUES (?, ?, ?, ?, ?, ?)`,
+		run.RunID, run.Command, run.StartedAt, run.Config, run.Version, run.Matches)
+	return errors.WithStack(err)
+}
+
+func (r *SQLRepository) FinishRun(run *Run) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.commit(); err != nil {
+		return errors.WithStack(err)
+	}
+	_, err := r.db.Exec(`UPDATE runs SET ended_at = ?, matches = ? WHERE run_id = ?`, run.EndedAt, run.Matches, run.RunID)
+	return errors.WithStack(err)
+}
+
+// commit unsafe method, should be called inside package only
+func (r *SQLRepository) commit() error {
+	if r.tx != nil {
+		_ = r.stmt.Close()
+		if err := r.tx.Commit(); err != nil {
+			return errors.WithStack(err)
+		}
+		r.txSize = 0
+		r.tx, r.stmt = nil, nil
+	}
+	return nil
+}
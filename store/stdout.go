@@ -1,4 +1,4 @@
-package repository
+package store
 
 import (
 	"sync"
@@ -6,11 +6,13 @@ import (
 	"github.com/planxnx/ethereum-wallet-generator/wallets"
 )
 
+// InMemoryRepository keeps the wallets in memory, it is safe for concurrent inserts.
 type InMemoryRepository struct {
 	walletsMu sync.Mutex
 	wallets   []*wallets.Wallet
 }
 
+// NewInMemoryRepository returns an empty in-memory repository.
 func NewInMemoryRepository() Repository {
 	return &InMemoryRepository{
 		wallets: make([]*wallets.Wallet, 0),
@@ -28,6 +30,10 @@ func (r *InMemoryRepository) Result() []*wallets.Wallet {
 	return r.wallets
 }
 
+func (r *InMemoryRepository) Commit() error {
+	return nil
+}
+
 func (r *InMemoryRepository) Close() error {
 	return nil
 }
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"container/heap"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/planxnx/ethereum-wallet-generator/filter"
+	"github.com/planxnx/ethereum-wallet-generator/internal/output"
+)
+
+// topMatches keeps the n best scoring matches seen, in a min-heap whose root is the worst
+// kept one, replaced whenever a better match comes.
+type topMatches struct {
+	n      int
+	score  filter.Scorer
+	ranked rankedHeap
+	seen   int
+}
+
+type ranked struct {
+	record output.Record
+	score  int
+	// seq orders the matches of equal score, the earlier seen ranking first.
+	seq int
+}
+
+type rankedHeap []ranked
+
+func (h rankedHeap) Len() int { return len(h) }
+func (h rankedHeap) Less(i, j int) bool {
+	if h[i].score != h[j].score {
+		return h[i].score < h[j].score
+	}
+	return h[i].seq > h[j].seq
+}
+func (h rankedHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *rankedHeap) Push(x any)   { *h = append(*h, x.(ranked)) }
+func (h *rankedHeap) Pop() any {
+	old := *h
+	r := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return r
+}
+
+func newTopMatches(n int, score filter.Scorer) *topMatches {
+	return &topMatches{n: n, score: score}
+}
+
+// Add ranks a match, keeping it if it is among the n best seen.
+func (t *topMatches) Add(r output.Record) {
+	t.seen++
+	entry := ranked{record: r, score: t.score(common.HexToAddress(r.Wallet.Address)), seq: t.seen}
+	if len(t.ranked) < t.n {
+		heap.Push(&t.ranked, entry)
+		return
+	}
+	if worst := t.ranked[0]; entry.score > worst.score {
+		t.ranked[0] = entry
+		heap.Fix(&t.ranked, 0)
+	}
+}
+
+// Best returns the kept matches, best first.
+func (t *topMatches) Best() []output.Record {
+	sorted := append(rankedHeap(nil), t.ranked...)
+	sort.Sort(sort.Reverse(sorted))
+	records := make([]output.Record, len(sorted))
+	for i, r := range sorted {
+		records[i] = r.record
+	}
+	return records
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/planxnx/ethereum-wallet-generator/internal/upload"
+)
+
+// runUploader uploads the result artifacts of the command, nil unless -upload is set.
+var runUploader *upload.Uploader
+
+// addUploadFlags registers the artifact upload flags on fs and returns a function setting
+// runUploader once the flags have been parsed.
+func addUploadFlags(fs *flag.FlagSet) func() {
+	dest := fs.String("upload", "", "upload the -out files as they are rotated and closed, then the sqlite -db, -checkpoint and -summary-json files when the run ends, to s3://BUCKET/PREFIX[?region=R&endpoint=URL] or gs://BUCKET/PREFIX")
+	sse := fs.String("upload-sse", upload.SSES3, fmt.Sprintf("server-side encryption of the S3 uploads [%s, %s]", upload.SSES3, upload.SSEKMS))
+	kmsKey := fs.String("upload-kms-key", "", "KMS key ID encrypting the aws:kms S3 uploads, or Cloud KMS key name encrypting the GCS uploads (default the provider managed key)")
+
+	return func() {
+		if *dest == "" {
+			return
+		}
+		u, err := upload.New(*dest, *sse, *kmsKey)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --upload: %v\n", err)
+			os.Exit(exitUsage)
+		}
+		runUploader = u
+	}
+}
+
+// uploadArtifacts uploads the files of a finished run, skipping the empty names and the
+// files the run didn't write. Failures are logged, the files stay where they are.
+func uploadArtifacts(names ...string) {
+	if runUploader == nil {
+		return
+	}
+	for _, name := range names {
+		if name == "" {
+			continue
+		}
+		if _, err := os.Stat(name); err != nil {
+			continue
+		}
+		uploadArtifact(name)
+	}
+}
+
+func uploadArtifact(name string) {
+	if err := runUploader.Upload(context.Background(), name); err != nil {
+		slog.Error("Upload failed", "file", name, "err", err)
+		runMetrics.Error("upload")
+		return
+	}
+	slog.Info("Uploaded", "file", name, "to", runUploader.Destination(name))
+}
+
+// uploadedFile uploads its file once it is closed.
+type uploadedFile struct {
+	io.WriteCloser
+	name string
+}
+
+// uploadOnClose returns f uploading the file at name when it is closed, f itself without
+// -upload.
+func uploadOnClose(f io.WriteCloser, name string) io.WriteCloser {
+	if runUploader == nil {
+		return f
+	}
+	return &uploadedFile{WriteCloser: f, name: name}
+}
+
+func (f *uploadedFile) Close() error {
+	if err := f.WriteCloser.Close(); err != nil {
+		return err
+	}
+	uploadArtifact(f.name)
+	return nil
+}
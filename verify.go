@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/planxnx/ethereum-wallet-generator/coins"
+	"github.com/planxnx/ethereum-wallet-generator/internal/keycrypt"
+	"github.com/planxnx/ethereum-wallet-generator/internal/style"
+	"github.com/planxnx/ethereum-wallet-generator/seeds"
+	"github.com/planxnx/ethereum-wallet-generator/wallets"
+)
+
+// verifyArrow separates the mnemonic from the address it is expected to derive.
+const verifyArrow = "->"
+
+// runVerify checks that the mnemonic of every `mnemonic -> address` line derives its address
+// under one of the base paths, for the services verifying backups in bulk.
+func runVerify(args []string) {
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	var seedPatterns seeds.Patterns
+	fs.Var(&seedPatterns, "seeds", "file of `mnemonic -> address` lines, - to read them from stdin (default). Repeat it or use glob patterns to read several files in order")
+	seedsFormat := fs.String("seeds-format", string(seeds.FormatText), "seeds file line format: text, or tsv/csv lines whose first field is `mnemonic -> address`, followed by the passphrase and hd path fields")
+	seedKeyConfig := addSeedKeyFlags(fs)
+	passphrase := fs.String("passphrase", "", "BIP39 passphrase of the lines without one")
+	var basePaths stringsFlag
+	fs.Var(&basePaths, "path", "base derivation path searched, the address index is appended to it. Repeat it to search several, the hd path field of a line replaces them (default the one of the --coin, m/44'/60'/0'/0 for eth)")
+	from := fs.Int("from", 0, "first address index searched under every path")
+	depth := fs.Int("depth", 10, "number of address indexes searched under every path")
+	checksumChainID := fs.Uint64("checksum-chainid", 0, "check the mixed-case EVM addresses against the EIP-1191 checksum of this chain ID (eg. 30 for RSK) instead of EIP-55 (0)")
+	coinConfig := addCoinFlags(fs)
+	dbPath := fs.String("db", "", "verify the wallets stored in this result DB instead: each one is re-derived from its private key, its mnemonic or its seed line and hd path, the seed files recorded by its run are read unless given with --seeds")
+	dbKey := fs.String("db-key", "", "SQLCipher passphrase of the -db file (requires a build with -tags sqlcipher)")
+	dbEncryptKeys := fs.String("db-encrypt-keys", "", "passphrase the private keys of -db were encrypted with, they are checked from their seed only without it")
+	parseFlags(fs, args)
+
+	if *dbPath != "" {
+		coin, err := coinConfig()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitUsage)
+		}
+		var input *seeds.Input
+		if len(seedPatterns) > 0 {
+			if input, err = seeds.NewInput(seedPatterns, seeds.Format(*seedsFormat)); err == nil {
+				err = seedKeyConfig(input)
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(exitUsage)
+			}
+		}
+		v := &dbVerifier{coin: coin, passphrase: *passphrase}
+		if *dbEncryptKeys != "" {
+			v.decrypter = keycrypt.NewDecrypter(*dbEncryptKeys)
+		}
+		runVerifyDB(*dbPath, *dbKey, v, input, seedKeyConfig, seeds.Format(*seedsFormat))
+		return
+	}
+	if len(seedPatterns) == 0 {
+		seedPatterns = seeds.Patterns{seeds.Stdin}
+	}
+	input, err := seeds.NewInput(seedPatterns, seeds.Format(*seedsFormat))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitUsage)
+	}
+	if err := seedKeyConfig(input); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitUsage)
+	}
+	coin, err := coinConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitUsage)
+	}
+	paths := []accounts.DerivationPath{coin.BasePath()}
+	if len(basePaths) > 0 {
+		paths = paths[:0]
+		for _, p := range basePaths {
+			path, err := accounts.ParseDerivationPath(p)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: invalid --path %q: %v\n", p, err)
+				os.Exit(exitUsage)
+			}
+			paths = append(paths, path)
+		}
+	}
+	v := &verifier{coin: coin, paths: paths, from: max(*from, 0), depth: max(*depth, 1), checksumChainID: *checksumChainID}
+
+	seedCh, errCh := input.Stream(context.Background(), seeds.Range{}, seeds.DefaultReadAhead)
+	passed, failed := 0, 0
+	for seed := range seedCh {
+		if seed.Passphrase == "" {
+			seed.Passphrase = *passphrase
+		}
+		file, line := input.Locate(seed.Line)
+		where := fmt.Sprint(line)
+		if file != "" {
+			where = fmt.Sprintf("%s:%d", file, line)
+		}
+		expected, path, reason := v.verify(seed)
+		if reason != "" {
+			failed++
+			fmt.Printf("%s\t%s\t%s\t%s\n", where, style.Paint(colorStdout, style.Error, "FAIL"), expected, reason)
+			continue
+		}
+		passed++
+		fmt.Printf("%s\t%s\t%s\t%s\n", where, style.Paint(colorStdout, style.Match, "PASS"), expected, path)
+	}
+	if err := <-errCh; err != nil {
+		fatal("Failed to read seeds", "err", err)
+	}
+	if passed+failed == 0 {
+		fmt.Fprintln(os.Stderr, "Error: no `mnemonic -> address` line to verify")
+		os.Exit(exitUsage)
+	}
+	if failed > 0 {
+		fmt.Fprintf(os.Stderr, "%d of %d lines failed\n", failed, passed+failed)
+		os.Exit(exitNoMatch)
+	}
+	fmt.Fprintf(os.Stderr, "All %d lines passed\n", passed)
+}
+
+// verifier searches the address indexes of its base paths for the expected address of a line.
+type verifier struct {
+	coin        coins.Coin
+	paths       []accounts.DerivationPath
+	from, depth int
+	// checksumChainID selects the EIP-1191 checksum of the chain, EIP-55 if zero.
+	checksumChainID uint64
+}
+
+// verify returns the expected address of the line of seed and the hd path deriving it, or
+// why it doesn't pass. The reasons never quote the mnemonic.
+func (v *verifier) verify(seed seeds.Seed) (expected, path, reason string) {
+	phrase, expected, ok := strings.Cut(seed.Phrase, verifyArrow)
+	phrase, expected = strings.TrimSpace(phrase), strings.TrimSpace(expected)
+	if !ok || phrase == "" || expected == "" {
+		return expected, "", "malformed line, expected `mnemonic -> address`"
+	}
+	if reason := v.checkAddress(expected); reason != "" {
+		return expected, "", reason
+	}
+	if err := checkMnemonic(seeds.Seed{Phrase: phrase}); err != nil {
+		return expected, "", err.Error()
+	}
+
+	paths := v.paths
+	if seed.Path != "" {
+		p, err := accounts.ParseDerivationPath(seed.Path)
+		if err != nil {
+			return expected, "", fmt.Sprintf("invalid hd path %q", seed.Path)
+		}
+		paths = []accounts.DerivationPath{p}
+	}
+	for _, base := range paths {
+		deriver, err := v.coin.NewDeriver(phrase, seed.Passphrase, base)
+		if err != nil {
+			return expected, "", fmt.Sprintf("failed to derive base key of %s: %v", base, err)
+		}
+		for i := v.from; i < v.from+v.depth; i++ {
+			w, err := deriver.Derive(uint32(i))
+			if err != nil || addressKey(v.coin, w.Address) != addressKey(v.coin, expected) {
+				continue
+			}
+			coins.Wipe(deriver)
+			return expected, v.coin.Path(base, uint32(i)), ""
+		}
+		coins.Wipe(deriver)
+	}
+	return expected, "", fmt.Sprintf("not derived under %s at indexes %d-%d", joinPaths(paths), v.from, v.from+v.depth-1)
+}
+
+// checkAddress returns why expected can't be an address of the coin, a mixed-case EVM address
+// must also match its EIP-55 or EIP-1191 checksum to catch a mistyped character.
+func (v *verifier) checkAddress(expected string) string {
+	if !coins.EVM(v.coin) {
+		return ""
+	}
+	if !common.IsHexAddress(expected) {
+		return "invalid address"
+	}
+	hex := strings.TrimPrefix(strings.TrimPrefix(expected, "0x"), "0X")
+	if hex != strings.ToLower(hex) && hex != strings.ToUpper(hex) && wallets.ChecksumAddress(common.HexToAddress(expected), v.checksumChainID) != "0x"+hex {
+		if v.checksumChainID != 0 {
+			return fmt.Sprintf("address fails its EIP-1191 checksum of chain %d", v.checksumChainID)
+		}
+		return "address fails its EIP-55 checksum"
+	}
+	return ""
+}
+
+func joinPaths(paths []accounts.DerivationPath) string {
+	s := make([]string, len(paths))
+	for i, p := range paths {
+		s[i] = p.String()
+	}
+	return strings.Join(s, ", ")
+}
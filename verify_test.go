@@ -0,0 +1,49 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+
+	"github.com/planxnx/ethereum-wallet-generator/coins"
+	"github.com/planxnx/ethereum-wallet-generator/seeds"
+)
+
+const testMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+func TestVerifier(t *testing.T) {
+	v := &verifier{coin: coins.ETH, paths: []accounts.DerivationPath{coins.ETH.BasePath()}, depth: 2}
+	testCases := map[string]struct {
+		seed seeds.Seed
+		// path is the hd path of a passing line, reason a part of the reason of a failing one
+		path, reason string
+	}{
+		"match":            {seed: seeds.Seed{Phrase: testMnemonic + " -> 0x6Fac4D18c912343BF86fa7049364Dd4E424Ab9C0"}, path: "m/44'/60'/0'/0/1"},
+		"match lower case": {seed: seeds.Seed{Phrase: testMnemonic + " -> 0x9858effd232b4033e47d90003d41ec34ecaeda94"}, path: "m/44'/60'/0'/0/0"},
+		"mismatch":         {seed: seeds.Seed{Phrase: testMnemonic + " -> 0x0000000000000000000000000000000000000001"}, reason: "not derived under m/44'/60'/0'/0 at indexes 0-1"},
+		"beyond depth":     {seed: seeds.Seed{Phrase: testMnemonic + " -> 0x9858effd232b4033e47d90003d41ec34ecaeda94", Path: "m/44'/60'/1'/0"}, reason: "not derived under m/44'/60'/1'/0"},
+		"passphrase":       {seed: seeds.Seed{Phrase: testMnemonic + " -> 0x9858effd232b4033e47d90003d41ec34ecaeda94", Passphrase: "TREZOR"}, reason: "not derived"},
+		"bad checksum":     {seed: seeds.Seed{Phrase: testMnemonic + " -> 0x9858EFFD232B4033E47d90003D41EC34EcaEda94"}, reason: "EIP-55 checksum"},
+		"invalid address":  {seed: seeds.Seed{Phrase: testMnemonic + " -> 0x9858"}, reason: "invalid address"},
+		"malformed":        {seed: seeds.Seed{Phrase: testMnemonic}, reason: "malformed line"},
+		"invalid mnemonic": {seed: seeds.Seed{Phrase: "abandon abandon -> 0x9858effd232b4033e47d90003d41ec34ecaeda94"}, reason: "invalid"},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_, path, reason := v.verify(tc.seed)
+			if tc.reason == "" {
+				if reason != "" || path != tc.path {
+					t.Errorf("failed %q, path %s, want %s", reason, path, tc.path)
+				}
+				return
+			}
+			if !strings.Contains(reason, tc.reason) {
+				t.Errorf("reason %q, want %q", reason, tc.reason)
+			}
+			if strings.Contains(reason, "abandon") {
+				t.Errorf("reason %q quotes the mnemonic", reason)
+			}
+		})
+	}
+}
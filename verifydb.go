@@ -0,0 +1,279 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/planxnx/ethereum-wallet-generator/coins"
+	"github.com/planxnx/ethereum-wallet-generator/internal/envelope"
+	"github.com/planxnx/ethereum-wallet-generator/internal/keycrypt"
+	"github.com/planxnx/ethereum-wallet-generator/internal/style"
+	"github.com/planxnx/ethereum-wallet-generator/seeds"
+	"github.com/planxnx/ethereum-wallet-generator/store"
+	"github.com/planxnx/ethereum-wallet-generator/wallets"
+)
+
+// dbVerifier checks the wallets stored in a result DB against their private key and the seed
+// line they were derived from.
+type dbVerifier struct {
+	// coin derives the rows of the runs that didn't record their coin, runCoins the others.
+	coin     coins.Coin
+	runCoins map[string]coins.Coin
+	// runSeeds is the single seed file of the runs whose rows recorded no file name, read unless
+	// -seeds is given.
+	runSeeds map[string]string
+	// phrases are the seed lines of the rows stored without their mnemonic, by seed file and line.
+	phrases    map[seedLine]seeds.Seed
+	passphrase string
+	// decrypter opens the private keys stored with -db-encrypt-keys, if set.
+	decrypter *keycrypt.Decrypter
+}
+
+// seedLine is a line of a seed file, the file is empty for a run reading a single one.
+type seedLine struct {
+	file string
+	line int
+}
+
+// runVerifyDB re-derives every wallet of the DB at dbPath from its private key, its mnemonic
+// or its seed line and hd path, printing the rows whose stored address doesn't match.
+func runVerifyDB(dbPath, dbKey string, v *dbVerifier, input *seeds.Input, seedKeyConfig func(*seeds.Input) error, format seeds.Format) {
+	if !isServerDSN(dbPath) && dbPath != memoryDB {
+		if _, err := os.Stat(sqlitePath(dbPath)); err != nil {
+			fatal("Failed to open sqlite DB", "err", err)
+		}
+	}
+	db := openDB(dbPath, dbKey)
+
+	var runs []store.Run
+	if err := db.Find(&runs).Error; err != nil {
+		fatal("Failed to query DB", "err", err)
+	}
+	v.runCoins, v.runSeeds = make(map[string]coins.Coin), make(map[string]string)
+	for _, run := range runs {
+		var config map[string]string
+		if json.Unmarshal([]byte(run.Config), &config) != nil {
+			continue
+		}
+		if name := config["seeds"]; input == nil && name != "" && name != seeds.Stdin && !strings.ContainsAny(name, ",*?[") {
+			v.runSeeds[run.RunID] = name
+		}
+		if config["coin"] == "" {
+			continue
+		}
+		coin, err := coins.Lookup(config["coin"], config["address-type"])
+		if err != nil {
+			slog.Warn("Unknown coin of a run, its wallets are checked as --coin ones", "run_id", run.RunID, "err", err)
+			continue
+		}
+		v.runCoins[run.RunID] = coin
+	}
+
+	// the seed lines are only read for the rows without a usable mnemonic
+	rows, err := db.Model(&wallets.Wallet{}).Select("run_id", "seed_file", "seed_line", "mnemonic").Where("hd_path <> '' AND seed_line > 0").Rows()
+	if err != nil {
+		fatal("Failed to query DB", "err", err)
+	}
+	needed := make(map[string]map[int]bool)
+	for rows.Next() {
+		var (
+			runID, file, mnemonic string
+			line                  int
+		)
+		if err := rows.Scan(&runID, &file, &line, &mnemonic); err != nil {
+			fatal("Failed to read DB", "err", err)
+		}
+		if mnemonic != "" && !strings.HasPrefix(mnemonic, envelope.Prefix) {
+			continue
+		}
+		if file == "" {
+			file = v.runSeeds[runID]
+		}
+		if needed[file] == nil {
+			needed[file] = make(map[int]bool)
+		}
+		needed[file][line] = true
+	}
+	rows.Close()
+	v.phrases = readSeedLines(needed, input, seedKeyConfig, format)
+
+	query := db.Model(&wallets.Wallet{}).Order("id")
+	rows, err = query.Rows()
+	if err != nil {
+		fatal("Failed to query DB", "err", err)
+	}
+	defer rows.Close()
+	passed, failed, unchecked := 0, 0, 0
+	for rows.Next() {
+		var wallet wallets.Wallet
+		if err := query.ScanRows(rows, &wallet); err != nil {
+			fatal("Failed to read DB", "err", err)
+		}
+		ok, reason := v.verify(&wallet)
+		switch {
+		case ok:
+			passed++
+		case reason == "":
+			unchecked++
+			slog.Debug("Wallet not checked (stored without private key or seed)", "id", wallet.ID, "address", wallet.Address)
+		default:
+			failed++
+			fmt.Printf("%d\t%s\t%s\t%s\n", wallet.ID, wallet.Address, style.Paint(colorStdout, style.Error, "FAIL"), reason)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		fatal("Failed to read DB", "err", err)
+	}
+	fmt.Fprintf(os.Stderr, "%d wallets passed, %d failed, %d not checked (stored without private key or seed)\n", passed, failed, unchecked)
+	if failed > 0 {
+		exitCode = exitNoMatch
+	}
+}
+
+// readSeedLines returns the needed lines of the seed files, by file name as recorded in the
+// DB. The rows of a run reading a single file recorded no file name, their lines are read from
+// input, which also replaces the recorded files it reads.
+func readSeedLines(needed map[string]map[int]bool, input *seeds.Input, seedKeyConfig func(*seeds.Input) error, format seeds.Format) map[seedLine]seeds.Seed {
+	phrases := make(map[seedLine]seeds.Seed)
+	read := func(in *seeds.Input, recorded string) {
+		seedCh, errCh := in.Stream(context.Background(), seeds.Range{}, seeds.DefaultReadAhead)
+		for seed := range seedCh {
+			file, line := in.Locate(seed.Line)
+			if recorded != "" {
+				file = recorded
+			}
+			if needed[file][line] {
+				phrases[seedLine{file, line}] = seed
+			}
+			// a single file read alone is located without its name
+			if file == "" && len(in.Files) == 1 && needed[in.Files[0]][line] {
+				phrases[seedLine{in.Files[0], line}] = seed
+			}
+		}
+		if err := <-errCh; err != nil {
+			fatal("Failed to read seeds", "err", err)
+		}
+	}
+	if input != nil {
+		read(input, "")
+		for _, name := range input.Files {
+			delete(needed, name)
+		}
+		delete(needed, "")
+	}
+	for name := range needed {
+		if name == "" {
+			slog.Warn("Wallets stored without their seed file, give it with --seeds to check them from their seed line")
+			continue
+		}
+		in, err := seeds.NewInput(seeds.Patterns{name}, format)
+		if err == nil {
+			err = seedKeyConfig(in)
+		}
+		if err != nil {
+			slog.Warn("Seed file unavailable, its wallets are checked without it", "file", name, "err", err)
+			continue
+		}
+		read(in, name)
+	}
+	return phrases
+}
+
+// verify checks a stored wallet, returning whether it passed or why it failed. A wallet that
+// couldn't be checked returns false and an empty reason.
+func (v *dbVerifier) verify(w *wallets.Wallet) (bool, string) {
+	coin := v.coin
+	if c, ok := v.runCoins[w.RunID]; ok {
+		coin = c
+	}
+	checked := false
+
+	key := w.PrivateKey
+	if strings.HasPrefix(key, envelope.Prefix) {
+		key = ""
+	}
+	if w.PrivateKeyCiphertext != "" && v.decrypter != nil {
+		plain, err := v.decrypter.Decrypt(keycrypt.Sealed{Salt: w.PrivateKeySalt, Nonce: w.PrivateKeyNonce, Ciphertext: w.PrivateKeyCiphertext})
+		if err != nil {
+			return false, fmt.Sprintf("private key doesn't decrypt: %v", err)
+		}
+		key = plain
+	}
+	if key != "" && coins.EVM(coin) {
+		privateKey, err := crypto.HexToECDSA(strings.TrimPrefix(key, "0x"))
+		if err != nil {
+			return false, "invalid private key"
+		}
+		if address := crypto.PubkeyToAddress(privateKey.PublicKey).Hex(); addressKey(coin, address) != addressKey(coin, w.Address) {
+			return false, fmt.Sprintf("the private key derives %s", address)
+		}
+		checked = true
+	}
+
+	seed, reason := v.seed(w)
+	if reason != "" {
+		return false, reason
+	}
+	if seed.Phrase == "" || w.HDPath == "" {
+		return checked, ""
+	}
+	path, err := accounts.ParseDerivationPath(w.HDPath)
+	if err != nil || len(path) == 0 {
+		return false, fmt.Sprintf("invalid hd path %q", w.HDPath)
+	}
+	base := path[:len(path)-1]
+	index := uint32(w.AddressIndex)
+	if coin.Path(base, index) != w.HDPath {
+		// a path the coin doesn't build from a base path and address index
+		return checked, ""
+	}
+	deriver, err := coin.NewDeriver(seed.Phrase, seed.Passphrase, base)
+	if err != nil {
+		return false, fmt.Sprintf("failed to derive base key: %v", err)
+	}
+	defer coins.Wipe(deriver)
+	derived, err := deriver.Derive(index)
+	if err != nil {
+		return false, fmt.Sprintf("failed to derive %s: %v", w.HDPath, err)
+	}
+	if addressKey(coin, derived.Address) != addressKey(coin, w.Address) {
+		return false, fmt.Sprintf("%s of the seed derives %s", w.HDPath, derived.Address)
+	}
+	if key != "" && key != derived.PrivateKey {
+		return false, "the private key isn't the one of the seed"
+	}
+	if w.PrivateKeyHash != "" && !keycrypt.VerifyHash(w.PrivateKeyHash, derived.PrivateKey) {
+		return false, "the private key hash isn't the one of the seed key"
+	}
+	return true, ""
+}
+
+// seed returns the mnemonic a wallet was derived from, its stored one or else its seed line,
+// or why the line doesn't match the stored seed hash. It is empty if neither is available.
+func (v *dbVerifier) seed(w *wallets.Wallet) (seeds.Seed, string) {
+	if w.Mnemonic != "" && !strings.HasPrefix(w.Mnemonic, envelope.Prefix) {
+		return seeds.Seed{Phrase: w.Mnemonic, Passphrase: v.passphrase}, ""
+	}
+	file := w.SeedFile
+	if file == "" {
+		file = v.runSeeds[w.RunID]
+	}
+	seed, ok := v.phrases[seedLine{file, w.SeedLine}]
+	if !ok {
+		return seeds.Seed{}, ""
+	}
+	if w.SeedHash != "" && seedHash(seed.Phrase) != w.SeedHash {
+		return seeds.Seed{}, fmt.Sprintf("%sline %d doesn't match the stored seed hash, the file changed or the row is corrupted", filePrefix(file), w.SeedLine)
+	}
+	if seed.Passphrase == "" {
+		seed.Passphrase = v.passphrase
+	}
+	return seed, ""
+}
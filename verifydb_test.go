@@ -0,0 +1,62 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/planxnx/ethereum-wallet-generator/coins"
+	"github.com/planxnx/ethereum-wallet-generator/internal/keycrypt"
+	"github.com/planxnx/ethereum-wallet-generator/seeds"
+	"github.com/planxnx/ethereum-wallet-generator/wallets"
+)
+
+func TestDBVerifier(t *testing.T) {
+	const (
+		address = "0x6Fac4D18c912343BF86fa7049364Dd4E424Ab9C0"
+		key     = "9a983cb3d832fbde5ab49d692b7a8bf5b5d232479c99333d0fc8e1d21f1b55b6"
+		path    = "m/44'/60'/0'/0/1"
+	)
+	encrypter, err := keycrypt.NewEncrypter("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := encrypter.Encrypt(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash, err := keycrypt.Hash(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := &dbVerifier{
+		coin:      coins.ETH,
+		phrases:   map[seedLine]seeds.Seed{{"seeds.txt", 3}: {Line: 3, Phrase: testMnemonic}},
+		decrypter: keycrypt.NewDecrypter("secret"),
+	}
+
+	testCases := map[string]struct {
+		wallet wallets.Wallet
+		passed bool
+		// reason is a part of the reason of a failing wallet, empty for one that couldn't be checked
+		reason string
+	}{
+		"private key":       {wallet: wallets.Wallet{Address: address, PrivateKey: key}, passed: true},
+		"mnemonic":          {wallet: wallets.Wallet{Address: address, Mnemonic: testMnemonic, HDPath: path, AddressIndex: 1}, passed: true},
+		"seed line":         {wallet: wallets.Wallet{Address: address, SeedFile: "seeds.txt", SeedLine: 3, SeedHash: seedHash(testMnemonic), HDPath: path, AddressIndex: 1}, passed: true},
+		"encrypted key":     {wallet: wallets.Wallet{Address: address, PrivateKeySalt: sealed.Salt, PrivateKeyNonce: sealed.Nonce, PrivateKeyCiphertext: sealed.Ciphertext}, passed: true},
+		"key hash":          {wallet: wallets.Wallet{Address: address, PrivateKeyHash: hash, Mnemonic: testMnemonic, HDPath: path, AddressIndex: 1}, passed: true},
+		"other key":         {wallet: wallets.Wallet{Address: "0x9858effd232b4033e47d90003d41ec34ecaeda94", PrivateKey: key}, reason: "the private key derives " + address},
+		"other index":       {wallet: wallets.Wallet{Address: address, Mnemonic: testMnemonic, HDPath: "m/44'/60'/0'/0/0", AddressIndex: 0}, reason: "m/44'/60'/0'/0/0 of the seed derives"},
+		"changed seed file": {wallet: wallets.Wallet{Address: address, SeedFile: "seeds.txt", SeedLine: 3, SeedHash: seedHash("other"), HDPath: path, AddressIndex: 1}, reason: "doesn't match the stored seed hash"},
+		"wrong key hash":    {wallet: wallets.Wallet{Address: address, PrivateKeyHash: hash + "00", Mnemonic: testMnemonic, HDPath: path, AddressIndex: 1}, reason: "private key hash"},
+		"unknown seed":      {wallet: wallets.Wallet{Address: address, SeedFile: "other.txt", SeedLine: 3, HDPath: path, AddressIndex: 1}},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			passed, reason := v.verify(&tc.wallet)
+			if passed != tc.passed || !strings.Contains(reason, tc.reason) || (tc.reason == "") != (reason == "") {
+				t.Errorf("passed %v, reason %q, want %v and %q", passed, reason, tc.passed, tc.reason)
+			}
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/usbwallet"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/planxnx/ethereum-wallet-generator/bip39"
+	"github.com/planxnx/ethereum-wallet-generator/internal/wipe"
+	"github.com/planxnx/ethereum-wallet-generator/wallets"
+)
+
+// trezorPINMatrix is the layout of the Trezor PIN matrix, whose digits are only shown on
+// the device: the PIN is typed as the positions of its digits.
+const trezorPINMatrix = "  7 8 9\n  4 5 6\n  1 2 3\n"
+
+// runVerifyHW compares the addresses of a connected Ledger or Trezor with the ones derived
+// in software from a mnemonic, to check a backup before wiping the device.
+func runVerifyHW(args []string) {
+	fs := flag.NewFlagSet("verify-hw", flag.ExitOnError)
+	mnemonic := fs.String("mnemonic", "", "BIP39 mnemonic backing up the device, read from stdin if empty")
+	passphrase := fs.String("passphrase", "", "optional BIP39 passphrase, also sent to a Trezor asking for one")
+	device := fs.String("device", "any", "hardware wallet to verify [any, ledger, trezor]")
+	basePath := fs.String("path", wallets.DefaultBaseDerivationPathString, "base derivation path, the address index is appended to it")
+	from := fs.Int("from", 0, "first address index to compare")
+	depth := fs.Int("depth", 5, "number of addresses to compare")
+	timeout := fs.Duration("timeout", 30*time.Second, "how long to wait for the device to be connected and unlocked")
+	parseFlags(fs, args)
+
+	stdin := bufio.NewReader(os.Stdin)
+	phrase := strings.TrimSpace(*mnemonic)
+	if phrase == "" {
+		line, err := stdin.ReadString('\n')
+		if err != nil && line == "" {
+			fmt.Fprintln(os.Stderr, "Error: --mnemonic parameter or a mnemonic on stdin required")
+			os.Exit(exitUsage)
+		}
+		phrase = strings.TrimSpace(line)
+	}
+	path, err := accounts.ParseDerivationPath(*basePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: invalid --path: %v\n", err)
+		os.Exit(exitUsage)
+	}
+	hubs, err := openHardwareHubs(*device)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitUsage)
+	}
+
+	wallet, err := waitHardwareWallet(hubs, *timeout)
+	if err != nil {
+		fatal("No hardware wallet found", "err", err)
+	}
+	defer wallet.Close()
+	if err := openHardwareWallet(wallet, *passphrase, stdin); err != nil {
+		fatal("Failed to open hardware wallet", "url", wallet.URL(), "err", err)
+	}
+	fmt.Fprintf(os.Stderr, "Comparing %d addresses of %s\n", max(*depth, 1), wallet.URL())
+
+	seed := bip39.NewSeed(phrase, *passphrase)
+	hd, err := wallets.NewHDWallet(seed, path)
+	wipe.Bytes(seed)
+	if err != nil {
+		fatal("Failed to derive base key", "err", err)
+	}
+	defer hd.Wipe()
+	mismatches := 0
+	for i := max(*from, 0); i < max(*from, 0)+max(*depth, 1); i++ {
+		key, err := hd.Derive(uint32(i))
+		if err != nil {
+			fatal("Wallet derivation failed", "index", i, "err", err)
+		}
+		software := crypto.PubkeyToAddress(key.PublicKey)
+		wipe.Key(key)
+		account, err := wallet.Derive(hd.Path(uint32(i)), false)
+		if err != nil {
+			fatal("Hardware wallet derivation failed", "index", i, "err", err)
+		}
+		result := "ok"
+		if account.Address != software {
+			result = "MISMATCH"
+			mismatches++
+		}
+		fmt.Printf("%s\t%s\t%s\t%s\n", hd.Path(uint32(i)), software.Hex(), account.Address.Hex(), result)
+	}
+	if mismatches > 0 {
+		fmt.Fprintf(os.Stderr, "%d of %d addresses differ, the mnemonic doesn't back up this device\n", mismatches, max(*depth, 1))
+		os.Exit(exitNoMatch)
+	}
+	fmt.Fprintln(os.Stderr, "Every address matches")
+}
+
+// openHardwareHubs returns the USB hubs of the device kind, any trying every one.
+func openHardwareHubs(device string) ([]*usbwallet.Hub, error) {
+	var constructors []func() (*usbwallet.Hub, error)
+	switch device {
+	case "ledger":
+		constructors = append(constructors, usbwallet.NewLedgerHub)
+	case "trezor":
+		constructors = append(constructors, usbwallet.NewTrezorHubWithHID, usbwallet.NewTrezorHubWithWebUSB)
+	case "any":
+		constructors = append(constructors, usbwallet.NewLedgerHub, usbwallet.NewTrezorHubWithHID, usbwallet.NewTrezorHubWithWebUSB)
+	default:
+		return nil, fmt.Errorf("unknown --device %q, must be any, ledger or trezor", device)
+	}
+	var hubs []*usbwallet.Hub
+	for _, newHub := range constructors {
+		hub, err := newHub()
+		if err != nil {
+			// a build without cgo has no USB support
+			return nil, fmt.Errorf("USB hardware wallets unavailable: %v", err)
+		}
+		hubs = append(hubs, hub)
+	}
+	return hubs, nil
+}
+
+// waitHardwareWallet returns the first wallet found by the hubs, polling until timeout.
+func waitHardwareWallet(hubs []*usbwallet.Hub, timeout time.Duration) (accounts.Wallet, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		var found []accounts.Wallet
+		for _, hub := range hubs {
+			found = append(found, hub.Wallets()...)
+		}
+		switch {
+		case len(found) == 1:
+			return found[0], nil
+		case len(found) > 1:
+			return nil, fmt.Errorf("%d devices connected, keep only the one to verify", len(found))
+		case time.Now().After(deadline):
+			return nil, errors.New("no Ledger or Trezor connected")
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+// openHardwareWallet opens the wallet, asking for the PIN of a Trezor on stdin and sending it
+// passphrase as its passphrase.
+func openHardwareWallet(wallet accounts.Wallet, passphrase string, stdin *bufio.Reader) error {
+	err := wallet.Open("")
+	if errors.Is(err, usbwallet.ErrTrezorPINNeeded) {
+		fmt.Fprintf(os.Stderr, "Enter the positions of the PIN digits shown on the Trezor:\n%s> ", trezorPINMatrix)
+		pin, _ := stdin.ReadString('\n')
+		err = wallet.Open(strings.TrimSpace(pin))
+	}
+	if errors.Is(err, usbwallet.ErrTrezorPassphraseNeeded) {
+		err = wallet.Open(passphrase)
+	}
+	if err != nil {
+		return err
+	}
+	// a Ledger answers once its Ethereum app is open
+	if _, err := wallet.Status(); err != nil {
+		return err
+	}
+	return nil
+}
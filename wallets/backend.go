@@ -0,0 +1,332 @@
+package wallets
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"math/big"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/pkg/errors"
+
+	"github.com/planxnx/ethereum-wallet-generator/bip39"
+	"github.com/planxnx/ethereum-wallet-generator/internal/wipe"
+)
+
+// AccountsScheme is the URL scheme of the AccountsWallet wallets and accounts.
+const AccountsScheme = "hd"
+
+var (
+	_ accounts.Wallet  = (*AccountsWallet)(nil)
+	_ accounts.Backend = (*AccountsBackend)(nil)
+)
+
+// AccountsWallet is the go-ethereum accounts.Wallet of a mnemonic, deriving its accounts and
+// signing with their keys in memory, so that go-ethereum based applications sign with the
+// wallets of a mnemonic without copying their keys around. Its methods may run concurrently.
+//
+// The accounts are tracked once pinned by Derive or found by SelfDerive, only the tracked
+// ones sign. The passphrase signing methods aren't supported, the keys being unlocked from
+// the start, and Open does nothing but refuse a closed wallet.
+type AccountsWallet struct {
+	url accounts.URL
+
+	mu   sync.RWMutex
+	seed []byte
+	// accounts are the tracked accounts in the order they were pinned, paths their
+	// derivation paths.
+	accounts []accounts.Account
+	paths    map[common.Address]accounts.DerivationPath
+}
+
+// NewAccountsWallet returns the accounts.Wallet of a BIP39 mnemonic and passphrase. Its URL
+// is hd:// followed by the address of the default path m/44'/60'/0'/0/0, which identifies
+// the mnemonic without revealing it.
+func NewAccountsWallet(mnemonic, passphrase string) (*AccountsWallet, error) {
+	entropy, err := bip39.EntropyFromMnemonic(mnemonic)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	wipe.Bytes(entropy)
+	w := &AccountsWallet{
+		seed:  bip39.NewSeed(mnemonic, passphrase),
+		paths: make(map[common.Address]accounts.DerivationPath),
+	}
+	key, err := DeriveWallet(w.seed, append(slices.Clone(DefaultBaseDerivationPath), 0))
+	if err != nil {
+		return nil, err
+	}
+	defer wipe.Key(key)
+	w.url = accounts.URL{Scheme: AccountsScheme, Path: crypto.PubkeyToAddress(key.PublicKey).Hex()}
+	return w, nil
+}
+
+// URL implements accounts.Wallet.
+func (w *AccountsWallet) URL() accounts.URL {
+	return w.url
+}
+
+// Status implements accounts.Wallet, it returns Open until the wallet is closed.
+func (w *AccountsWallet) Status() (string, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.seed == nil {
+		return "Closed", accounts.ErrWalletClosed
+	}
+	return "Open", nil
+}
+
+// Open implements accounts.Wallet, the passphrase is unused.
+func (w *AccountsWallet) Open(string) error {
+	_, err := w.Status()
+	return err
+}
+
+// Close implements accounts.Wallet, it wipes the seed and the wallet can't derive nor sign
+// anymore.
+func (w *AccountsWallet) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.seed != nil {
+		wipe.Bytes(w.seed)
+		w.seed = nil
+	}
+	return nil
+}
+
+// Accounts implements accounts.Wallet, it returns the tracked accounts.
+func (w *AccountsWallet) Accounts() []accounts.Account {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return slices.Clone(w.accounts)
+}
+
+// Contains implements accounts.Wallet.
+func (w *AccountsWallet) Contains(account accounts.Account) bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	_, ok := w.paths[account.Address]
+	return ok && (account.URL == accounts.URL{} || account.URL == w.accountURL(w.paths[account.Address]))
+}
+
+// Derive implements accounts.Wallet, returning the account at path, tracked if pin is set.
+func (w *AccountsWallet) Derive(path accounts.DerivationPath, pin bool) (accounts.Account, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	key, err := w.key(path)
+	if err != nil {
+		return accounts.Account{}, err
+	}
+	defer wipe.Key(key)
+	account := accounts.Account{Address: crypto.PubkeyToAddress(key.PublicKey), URL: w.accountURL(path)}
+	if pin {
+		w.pin(account, path)
+	}
+	return account, nil
+}
+
+// SelfDerive implements accounts.Wallet, it tracks in the background the accounts of each
+// base path found used on chain, from the base path up incrementing its last component until
+// the first account without balance nor nonce, which is only tracked for the last base path.
+// A nil chain does nothing, and the discovery stops at the first error of chain.
+func (w *AccountsWallet) SelfDerive(bases []accounts.DerivationPath, chain ethereum.ChainStateReader) {
+	if chain == nil || len(bases) == 0 {
+		return
+	}
+	bases = slices.Clone(bases)
+	go func() {
+		ctx := context.Background()
+		for i, base := range bases {
+			path := slices.Clone(base)
+			for {
+				account, err := w.Derive(path, false)
+				if err != nil {
+					return
+				}
+				balance, err := chain.BalanceAt(ctx, account.Address, nil)
+				if err != nil {
+					return
+				}
+				nonce, err := chain.NonceAt(ctx, account.Address, nil)
+				if err != nil {
+					return
+				}
+				empty := balance.Sign() == 0 && nonce == 0
+				if empty && i < len(bases)-1 {
+					break
+				}
+				w.mu.Lock()
+				w.pin(account, path)
+				w.mu.Unlock()
+				if empty {
+					break
+				}
+				path = slices.Clone(path)
+				path[len(path)-1]++
+			}
+		}
+	}()
+}
+
+// SignData implements accounts.Wallet, signing the keccak256 hash of data whatever its
+// mime type.
+func (w *AccountsWallet) SignData(account accounts.Account, _ string, data []byte) ([]byte, error) {
+	return w.signHash(account, crypto.Keccak256(data))
+}
+
+// SignDataWithPassphrase implements accounts.Wallet, it isn't supported.
+func (w *AccountsWallet) SignDataWithPassphrase(accounts.Account, string, string, []byte) ([]byte, error) {
+	return nil, accounts.ErrNotSupported
+}
+
+// SignText implements accounts.Wallet, signing the EIP-191 hash of text. The signature is
+// in the [R || S || V] format where V is 0 or 1.
+func (w *AccountsWallet) SignText(account accounts.Account, text []byte) ([]byte, error) {
+	return w.signHash(account, accounts.TextHash(text))
+}
+
+// SignTextWithPassphrase implements accounts.Wallet, it isn't supported.
+func (w *AccountsWallet) SignTextWithPassphrase(accounts.Account, string, []byte) ([]byte, error) {
+	return nil, accounts.ErrNotSupported
+}
+
+// SignTx implements accounts.Wallet, signing tx with the latest signer of chainID.
+func (w *AccountsWallet) SignTx(account accounts.Account, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	key, err := w.accountKey(account)
+	if err != nil {
+		return nil, err
+	}
+	defer wipe.Key(key)
+	signed, err := types.SignTx(tx, types.LatestSignerForChainID(chainID), key)
+	return signed, errors.WithStack(err)
+}
+
+// SignTxWithPassphrase implements accounts.Wallet, it isn't supported.
+func (w *AccountsWallet) SignTxWithPassphrase(accounts.Account, string, *types.Transaction, *big.Int) (*types.Transaction, error) {
+	return nil, accounts.ErrNotSupported
+}
+
+func (w *AccountsWallet) signHash(account accounts.Account, hash []byte) ([]byte, error) {
+	key, err := w.accountKey(account)
+	if err != nil {
+		return nil, err
+	}
+	defer wipe.Key(key)
+	sig, err := crypto.Sign(hash, key)
+	return sig, errors.WithStack(err)
+}
+
+// accountKey returns the private key of a tracked account.
+func (w *AccountsWallet) accountKey(account accounts.Account) (*ecdsa.PrivateKey, error) {
+	if !w.Contains(account) {
+		return nil, accounts.ErrUnknownAccount
+	}
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.key(w.paths[account.Address])
+}
+
+// key derives the private key at path, w.mu must be held.
+func (w *AccountsWallet) key(path accounts.DerivationPath) (*ecdsa.PrivateKey, error) {
+	if w.seed == nil {
+		return nil, accounts.ErrWalletClosed
+	}
+	return DeriveWallet(w.seed, path)
+}
+
+// pin tracks account, w.mu must be held for writing.
+func (w *AccountsWallet) pin(account accounts.Account, path accounts.DerivationPath) {
+	if _, ok := w.paths[account.Address]; ok {
+		return
+	}
+	w.accounts = append(w.accounts, account)
+	w.paths[account.Address] = slices.Clone(path)
+}
+
+// accountURL returns the URL of the account at path, the wallet URL followed by the path.
+func (w *AccountsWallet) accountURL(path accounts.DerivationPath) accounts.URL {
+	return accounts.URL{Scheme: w.url.Scheme, Path: w.url.Path + "/" + path.String()}
+}
+
+// AccountsBackend is the go-ethereum accounts.Backend of a set of AccountsWallet, to add to
+// an accounts.Manager. Its methods may run concurrently.
+type AccountsBackend struct {
+	mu      sync.RWMutex
+	wallets []accounts.Wallet
+	feed    event.Feed
+	scope   event.SubscriptionScope
+}
+
+// NewAccountsBackend returns a backend of the wallets.
+func NewAccountsBackend(wallets ...*AccountsWallet) *AccountsBackend {
+	b := &AccountsBackend{}
+	for _, w := range wallets {
+		b.wallets = append(b.wallets, w)
+	}
+	b.sort()
+	return b
+}
+
+// Wallets implements accounts.Backend, it returns the wallets sorted by URL.
+func (b *AccountsBackend) Wallets() []accounts.Wallet {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return slices.Clone(b.wallets)
+}
+
+// Subscribe implements accounts.Backend, sink receiving the arrival and drop of wallets.
+func (b *AccountsBackend) Subscribe(sink chan<- accounts.WalletEvent) event.Subscription {
+	return b.scope.Track(b.feed.Subscribe(sink))
+}
+
+// Add adds the wallet, announced as arrived to the subscribers. A wallet of the same URL is
+// replaced.
+func (b *AccountsBackend) Add(w *AccountsWallet) {
+	b.mu.Lock()
+	if i := b.index(w.URL()); i >= 0 {
+		b.wallets[i] = w
+	} else {
+		b.wallets = append(b.wallets, w)
+		b.sort()
+	}
+	b.mu.Unlock()
+	b.feed.Send(accounts.WalletEvent{Wallet: w, Kind: accounts.WalletArrived})
+}
+
+// Remove closes and removes the wallet of url, announced as dropped to the subscribers. It
+// reports whether the backend had the wallet.
+func (b *AccountsBackend) Remove(url accounts.URL) bool {
+	b.mu.Lock()
+	i := b.index(url)
+	if i < 0 {
+		b.mu.Unlock()
+		return false
+	}
+	w := b.wallets[i]
+	b.wallets = slices.Delete(b.wallets, i, i+1)
+	b.mu.Unlock()
+	_ = w.Close()
+	b.feed.Send(accounts.WalletEvent{Wallet: w, Kind: accounts.WalletDropped})
+	return true
+}
+
+// Close ends the subscriptions.
+func (b *AccountsBackend) Close() {
+	b.scope.Close()
+}
+
+// index returns the index of the wallet of url, or -1, b.mu must be held.
+func (b *AccountsBackend) index(url accounts.URL) int {
+	return slices.IndexFunc(b.wallets, func(w accounts.Wallet) bool { return w.URL() == url })
+}
+
+func (b *AccountsBackend) sort() {
+	sort.Slice(b.wallets, func(i, j int) bool { return b.wallets[i].URL().Cmp(b.wallets[j].URL()) < 0 })
+}
@@ -0,0 +1,128 @@
+package wallets
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const testMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+func TestAccountsWallet(t *testing.T) {
+	w, err := NewAccountsWallet(testMnemonic, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if url := w.URL().String(); url != "hd://0x9858EfFD232B4033E47d90003D41EC34EcaEda94" {
+		t.Errorf("URL = %s", url)
+	}
+
+	path := accounts.DerivationPath{0x80000000 + 44, 0x80000000 + 60, 0x80000000, 0, 1}
+	account, err := w.Derive(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if account.Address != common.HexToAddress("0x6fac4d18c912343bf86fa7049364dd4e424ab9c0") {
+		t.Errorf("Derive = %s", account.Address.Hex())
+	}
+	if _, err := w.SignText(account, []byte("hello")); err != accounts.ErrUnknownAccount {
+		t.Errorf("signing with an account not pinned: %v, want ErrUnknownAccount", err)
+	}
+	if _, err := w.Derive(path, true); err != nil {
+		t.Fatal(err)
+	}
+	if !w.Contains(account) || len(w.Accounts()) != 1 {
+		t.Errorf("pinned account not tracked, accounts %v", w.Accounts())
+	}
+
+	sig, err := w.SignText(account, []byte("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pub, err := crypto.SigToPub(accounts.TextHash([]byte("hello")), sig); err != nil || crypto.PubkeyToAddress(*pub) != account.Address {
+		t.Errorf("SignText signature recovers %v, err %v", pub, err)
+	}
+
+	chainID := big.NewInt(1)
+	tx := types.NewTx(&types.DynamicFeeTx{ChainID: chainID, Nonce: 1, Gas: 21000, GasFeeCap: big.NewInt(1), GasTipCap: big.NewInt(1), To: &account.Address, Value: big.NewInt(1)})
+	signed, err := w.SignTx(account, tx, chainID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if from, err := types.Sender(types.LatestSignerForChainID(chainID), signed); err != nil || from != account.Address {
+		t.Errorf("SignTx sender = %s, err %v", from.Hex(), err)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.SignText(account, []byte("hello")); err != accounts.ErrWalletClosed {
+		t.Errorf("signing with a closed wallet: %v, want ErrWalletClosed", err)
+	}
+}
+
+// usedChain reports a nonce for the used addresses.
+type usedChain map[common.Address]bool
+
+func (c usedChain) BalanceAt(context.Context, common.Address, *big.Int) (*big.Int, error) {
+	return new(big.Int), nil
+}
+
+func (c usedChain) StorageAt(context.Context, common.Address, common.Hash, *big.Int) ([]byte, error) {
+	return nil, nil
+}
+
+func (c usedChain) CodeAt(context.Context, common.Address, *big.Int) ([]byte, error) {
+	return nil, nil
+}
+
+func (c usedChain) NonceAt(_ context.Context, address common.Address, _ *big.Int) (uint64, error) {
+	if c[address] {
+		return 1, nil
+	}
+	return 0, nil
+}
+
+func TestAccountsWalletSelfDerive(t *testing.T) {
+	w, err := NewAccountsWallet(testMnemonic, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain := usedChain{common.HexToAddress("0x9858effd232b4033e47d90003d41ec34ecaeda94"): true}
+	base := append(accounts.DerivationPath{}, DefaultBaseDerivationPath...)
+	w.SelfDerive([]accounts.DerivationPath{append(base, 0)}, chain)
+	for deadline := time.Now().Add(5 * time.Second); len(w.Accounts()) < 2 && time.Now().Before(deadline); {
+		time.Sleep(10 * time.Millisecond)
+	}
+	// the used account and the next empty one
+	if got := w.Accounts(); len(got) != 2 || got[1].Address != common.HexToAddress("0x6fac4d18c912343bf86fa7049364dd4e424ab9c0") {
+		t.Errorf("self derived accounts %v", got)
+	}
+}
+
+func TestAccountsBackend(t *testing.T) {
+	a, err := NewAccountsWallet(testMnemonic, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := NewAccountsBackend()
+	defer b.Close()
+	manager := accounts.NewManager(nil, b)
+	defer manager.Close()
+	b.Add(a)
+	for deadline := time.Now().Add(5 * time.Second); len(manager.Wallets()) == 0 && time.Now().Before(deadline); {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got, err := manager.Wallet(a.URL().String()); err != nil || got != a {
+		t.Errorf("manager wallet %v, %v", got, err)
+	}
+	if !b.Remove(a.URL()) || len(b.Wallets()) != 0 {
+		t.Error("wallet not removed")
+	}
+}
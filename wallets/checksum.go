@@ -0,0 +1,30 @@
+package wallets
+
+import (
+	"encoding/hex"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// ChecksumAddress returns the EIP-55 mixed-case hex of addr or, with a non zero chainID, its
+// EIP-1191 one on that chain, eg. 30 for the RSK mainnet whose wallets check it instead.
+func ChecksumAddress(addr common.Address, chainID uint64) string {
+	if chainID == 0 {
+		return addr.Hex()
+	}
+	// the hash also covers the chain ID, so a checksum of one chain fails on another
+	lower := []byte(hex.EncodeToString(addr[:]))
+	hash := crypto.Keccak256([]byte(strconv.FormatUint(chainID, 10) + "0x" + string(lower)))
+	for i, c := range lower {
+		nibble := hash[i/2] & 0x0f
+		if i%2 == 0 {
+			nibble = hash[i/2] >> 4
+		}
+		if c >= 'a' && nibble > 7 {
+			lower[i] = c - 'a' + 'A'
+		}
+	}
+	return "0x" + string(lower)
+}
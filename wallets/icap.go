@@ -0,0 +1,43 @@
+package wallets
+
+import (
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// ICAP BBAN lengths: the direct form fits the addresses below 2^155, the basic one any address.
+const (
+	icapDirectLength = 30
+	icapBasicLength  = 31
+)
+
+// ICAP returns the Inter exchange Client Address Protocol form of addr, the XE IBAN of its
+// base36 encoding: the 34 character direct form when it fits, else the 35 character basic one.
+func ICAP(addr common.Address) string {
+	bban := strings.ToUpper(new(big.Int).SetBytes(addr[:]).Text(36))
+	length := icapDirectLength
+	if len(bban) > icapDirectLength {
+		length = icapBasicLength
+	}
+	bban = strings.Repeat("0", length-len(bban)) + bban
+	return "XE" + icapChecksum(bban) + bban
+}
+
+// icapChecksum returns the two ISO 7064 mod 97 check digits of the XE IBAN of bban.
+func icapChecksum(bban string) string {
+	// the country code and 00 check digits move to the end, letters become 10 to 35
+	var digits strings.Builder
+	for _, c := range bban + "XE00" {
+		if c >= 'A' && c <= 'Z' {
+			digits.WriteString(strconv.Itoa(int(c - 'A' + 10)))
+		} else {
+			digits.WriteRune(c)
+		}
+	}
+	n, _ := new(big.Int).SetString(digits.String(), 10)
+	check := 98 - new(big.Int).Mod(n, big.NewInt(97)).Int64()
+	return string([]byte{byte('0' + check/10), byte('0' + check%10)})
+}
@@ -0,0 +1,161 @@
+package wallets
+
+import (
+	"io"
+	"sync"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+
+	"github.com/planxnx/ethereum-wallet-generator/internal/wipe"
+)
+
+const (
+	// incrementalBatch is the number of consecutive keys whose points are made affine with a
+	// single field inversion.
+	incrementalBatch = 256
+	// DefaultWalkLength is the number of consecutive keys a walk of NewGeneratorIncrementalFrom
+	// tries before starting again from a new random scalar.
+	DefaultWalkLength = 1 << 24
+)
+
+// NewGeneratorIncrementalFrom is NewGeneratorKeyspaceFrom walking the keyspace: every walk
+// starts from a 256 bit random scalar read from random and tries the following keys, adding
+// the generator point to the public key of the previous one instead of a scalar
+// multiplication per key, and making batches of points affine with one inversion. A walk
+// ends at its first accepted key, so the keys of two matches are never a known offset apart,
+// or after DefaultWalkLength keys. Concurrent callers walk apart.
+func NewGeneratorIncrementalFrom(random io.Reader, accept func(address string) bool) Generator {
+	var (
+		mu   sync.Mutex
+		idle []*walk
+	)
+	return func() (*Wallet, error) {
+		mu.Lock()
+		var w *walk
+		if n := len(idle); n > 0 {
+			w, idle = idle[n-1], idle[:n-1]
+		} else {
+			w = &walk{random: random}
+		}
+		mu.Unlock()
+		defer func() {
+			mu.Lock()
+			idle = append(idle, w)
+			mu.Unlock()
+		}()
+		return w.next(accept)
+	}
+}
+
+// walk is a sequence of consecutive keys from a random scalar.
+type walk struct {
+	random io.Reader
+	// key is the key of point, the first one of the next batch.
+	key   btcec.ModNScalar
+	point btcec.JacobianPoint
+	// start is the key of addresses[0], the batch whose addresses from cursor are pending.
+	start     btcec.ModNScalar
+	addresses [incrementalBatch]string
+	cursor    int
+	remaining int
+}
+
+// next returns the wallet of the next key of the walk, only its Address unless accept takes it.
+func (w *walk) next(accept func(address string) bool) (*Wallet, error) {
+	if w.remaining <= 0 {
+		if err := w.reseed(); err != nil {
+			return nil, err
+		}
+	}
+	if w.cursor == incrementalBatch {
+		if err := w.batch(); err != nil {
+			return nil, err
+		}
+	}
+	i := w.cursor
+	address := w.addresses[i]
+	w.cursor++
+	w.remaining--
+	if accept != nil && !accept(address) {
+		return &Wallet{Address: address}, nil
+	}
+
+	// a match ends the walk
+	w.remaining = 0
+	var key btcec.ModNScalar
+	key.SetInt(uint32(i)).Add(&w.start)
+	raw := key.Bytes()
+	key.Zero()
+	defer wipe.Bytes(raw[:])
+	privateKey, err := crypto.ToECDSA(raw[:])
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer wipe.Key(privateKey)
+	return NewFromPrivatekey(privateKey)
+}
+
+// reseed starts a new walk from a random scalar.
+func (w *walk) reseed() error {
+	raw := make([]byte, 32)
+	defer wipe.Bytes(raw)
+	for {
+		if _, err := io.ReadFull(w.random, raw); err != nil {
+			return errors.WithStack(err)
+		}
+		if overflow := w.key.SetByteSlice(raw); !overflow && !w.key.IsZero() {
+			break
+		}
+	}
+	btcec.ScalarBaseMultNonConst(&w.key, &w.point)
+	w.cursor = incrementalBatch
+	w.remaining = DefaultWalkLength
+	return nil
+}
+
+// batch computes the addresses of the incrementalBatch keys from w.key.
+func (w *walk) batch() error {
+	var (
+		g      btcec.JacobianPoint
+		points [incrementalBatch]btcec.JacobianPoint
+		// products[i] is the product of the Z coordinates of points up to i
+		products [incrementalBatch]btcec.FieldVal
+	)
+	btcec.GeneratorJacobian(&g)
+	for i := range points {
+		points[i].Set(&w.point)
+		if points[i].Z.IsZero() {
+			// the point at infinity of a walk reaching the curve order
+			if err := w.reseed(); err != nil {
+				return err
+			}
+			return w.batch()
+		}
+		btcec.AddNonConst(&points[i], &g, &w.point)
+		products[i].Set(&points[i].Z)
+		if i > 0 {
+			products[i].Mul(&products[i-1])
+		}
+	}
+	w.start.Set(&w.key)
+	w.key.Add(new(btcec.ModNScalar).SetInt(incrementalBatch))
+
+	// Montgomery's trick: the inverse of every Z from the inverse of their product
+	var inverse, zInv, zInv2 btcec.FieldVal
+	inverse.Set(&products[incrementalBatch-1]).Inverse()
+	for i := incrementalBatch - 1; i >= 0; i-- {
+		zInv.Set(&inverse)
+		if i > 0 {
+			zInv.Mul(&products[i-1])
+			inverse.Mul(&points[i].Z)
+		}
+		zInv2.SquareVal(&zInv)
+		points[i].X.Mul(&zInv2).Normalize()
+		points[i].Y.Mul(zInv2.Mul(&zInv)).Normalize()
+		w.addresses[i] = affineAddress(&points[i].X, &points[i].Y)
+	}
+	w.cursor = 0
+	return nil
+}
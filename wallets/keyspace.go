@@ -0,0 +1,67 @@
+package wallets
+
+import (
+	"encoding/hex"
+	"io"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+
+	"github.com/planxnx/ethereum-wallet-generator/internal/wipe"
+)
+
+// NewGeneratorKeyspaceFrom returns a generator of random private key wallets for vanity
+// searches, skipping BIP39 and BIP32 like NewGeneratorPrivatekeyFrom but computing no more
+// than the address of the keys accept rejects: their wallets only hold their Address. The
+// other details are computed for the accepted keys alone. A nil accept accepts every key.
+func NewGeneratorKeyspaceFrom(random io.Reader, accept func(address string) bool) Generator {
+	return func() (*Wallet, error) {
+		raw := make([]byte, 32)
+		defer wipe.Bytes(raw)
+		var scalar btcec.ModNScalar
+		defer scalar.Zero()
+		for {
+			if _, err := io.ReadFull(random, raw); err != nil {
+				return nil, errors.WithStack(err)
+			}
+			// the candidates beyond the curve order or zero aren't valid keys
+			if overflow := scalar.SetByteSlice(raw); !overflow && !scalar.IsZero() {
+				break
+			}
+		}
+
+		var point btcec.JacobianPoint
+		btcec.ScalarBaseMultNonConst(&scalar, &point)
+		address := pointAddress(&point)
+		if accept != nil && !accept(address) {
+			return &Wallet{Address: address}, nil
+		}
+
+		privateKey, err := crypto.ToECDSA(raw)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		defer wipe.Key(privateKey)
+		return NewFromPrivatekey(privateKey)
+	}
+}
+
+// pointAddress returns the lower case hex address of the public key point.
+func pointAddress(point *btcec.JacobianPoint) string {
+	point.ToAffine()
+	return affineAddress(&point.X, &point.Y)
+}
+
+// affineAddress returns the lower case hex address of the public key of normalized affine
+// coordinates x and y.
+func affineAddress(x, y *btcec.FieldVal) string {
+	var xy [64]byte
+	x.PutBytesUnchecked(xy[:32])
+	y.PutBytesUnchecked(xy[32:])
+	hash := crypto.Keccak256(xy[:])
+	address := make([]byte, 42)
+	copy(address, "0x")
+	hex.Encode(address[2:], hash[12:])
+	return b2s(address)
+}
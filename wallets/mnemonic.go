@@ -1,113 +1,162 @@
 package wallets
 
 import (
-        "crypto/ecdsa"
-        "encoding/hex"
-        "errors"
-        "unsafe"
-
-        "github.com/ethereum/go-ethereum/common"
-        "github.com/ethereum/go-ethereum/crypto"
-        "gorm.io/gorm"
-)
+	"crypto/ecdsa"
+	"crypto/rand"
+	"io"
+
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/pkg/errors"
 
-type (
-        // Generator is a function that generates a wallet.
-        Generator func() (*Wallet, error)
-
-        // Wallet is a struct that contains the information of a wallet.
-        Wallet struct {
-                Address    string
-                PrivateKey string
-                Mnemonic   string
-                HDPath     string
-                gorm.Model
-                Bits int
-        }
+	"github.com/planxnx/ethereum-wallet-generator/bip39"
+	"github.com/planxnx/ethereum-wallet-generator/internal/wipe"
 )
 
 const (
-        // DefaultMnemonicBits is the default number of bits to use when generating a mnemonic. default is 128 bits (12 words).
-        DefaultMnemonicBits = 128
+	// DefaultBaseDerivationPathString is the base HD path (without address index) used to derive wallets.
+	DefaultBaseDerivationPathString = "m/44'/60'/0'/0"
 )
 
-// DefaultGenerator is the default wallet generator.
-var DefaultGenerator = NewGeneratorMnemonic(DefaultMnemonicBits)
+// DefaultBaseDerivationPath is the parsed form of DefaultBaseDerivationPathString.
+var DefaultBaseDerivationPath = accounts.DerivationPath{0x80000000 + 44, 0x80000000 + 60, 0x80000000 + 0, 0}
 
-// NewWallet returns a new wallet using the default generator.
-func NewWallet() (*Wallet, error) {
-        return DefaultGenerator()
+// NewGeneratorMnemonic returns a generator that creates wallets from random mnemonics of the given bit size.
+func NewGeneratorMnemonic(bitSize int) Generator {
+	return NewGeneratorMnemonicFrom(rand.Reader, bitSize)
 }
 
-// NewFromPrivatekey returns a new wallet from a given private key.
-func NewFromPrivatekey(privateKey *ecdsa.PrivateKey) (*Wallet, error) {
-        if privateKey == nil {
-                return nil, errors.New("private key is nil")
-        }
-
-        publicKey := &privateKey.PublicKey
-
-        // toString PrivateKey
-        priveKeyBytes := crypto.FromECDSA(privateKey)
-        privHex := make([]byte, len(priveKeyBytes)*2)
-        hex.Encode(privHex, priveKeyBytes)
-        privString := b2s(privHex)
-
-        // toString PublicKey
-        publicKeyBytes := crypto.Keccak256(crypto.FromECDSAPub(publicKey)[1:])[12:]
-        if len(publicKeyBytes) > common.AddressLength {
-                publicKeyBytes = publicKeyBytes[len(publicKeyBytes)-common.AddressLength:]
-        }
-        pubHex := make([]byte, len(publicKeyBytes)*2+2)
-        copy(pubHex[:2], "0x")
-        hex.Encode(pubHex[2:], publicKeyBytes)
-        pubString := b2s(pubHex)
-
-        return &Wallet{
-                Address:    pubString,
-                PrivateKey: privString,
-        }, nil
+// NewGeneratorMnemonicFrom is NewGeneratorMnemonic reading the entropy of the mnemonics from random.
+func NewGeneratorMnemonicFrom(random io.Reader, bitSize int) Generator {
+	return func() (*Wallet, error) {
+		mnemonic, err := newMnemonic(random, bitSize)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		path := make(accounts.DerivationPath, len(DefaultBaseDerivationPath)+1)
+		copy(path, DefaultBaseDerivationPath)
+
+		seed := bip39.NewSeed(mnemonic, "")
+		privateKey, err := DeriveWallet(seed, path)
+		wipe.Bytes(seed)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		defer wipe.Key(privateKey)
+
+		wallet, err := NewFromPrivatekey(privateKey)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		wallet.Bits = bitSize
+		wallet.Mnemonic = mnemonic
+		wallet.HDPath = path.String()
+		return wallet, nil
+	}
 }
 
-// b2s converts a byte slice to a string without memory allocation.
-func b2s(b []byte) string {
-        return *(*string)(unsafe.Pointer(&b))
+// NewMnemonic returns a random mnemonic of the given bit size.
+func NewMnemonic(bitSize int) (string, error) {
+	return newMnemonic(rand.Reader, bitSize)
 }
-package wallets
 
-import (
-        "crypto/rand"
-        "testing"
-)
+// NewMnemonicFrom is NewMnemonic reading the entropy of the mnemonic from random.
+func NewMnemonicFrom(random io.Reader, bitSize int) (string, error) {
+	return newMnemonic(random, bitSize)
+}
+
+func newMnemonic(random io.Reader, bitSize int) (string, error) {
+	entropy, err := bip39.NewEntropyFrom(random, bitSize)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	defer wipe.Bytes(entropy)
+
+	mnemonic, err := bip39.NewMnemonic(entropy)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	return mnemonic, nil
+}
+
+// DeriveWallet derives the private key at the given path from a BIP39 seed.
+func DeriveWallet(seed []byte, path accounts.DerivationPath) (*ecdsa.PrivateKey, error) {
+	key, err := deriveExtendedKey(seed, path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer key.Zero()
+	return toECDSA(key)
+}
+
+// HDWallet caches the extended key of a base derivation path,
+// so sequential child keys only cost a single derivation step each.
+type HDWallet struct {
+	basePath accounts.DerivationPath
+	baseKey  *hdkeychain.ExtendedKey
+}
+
+// NewHDWallet derives and caches the extended key at basePath from a BIP39 seed.
+func NewHDWallet(seed []byte, basePath accounts.DerivationPath) (*HDWallet, error) {
+	key, err := deriveExtendedKey(seed, basePath)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &HDWallet{
+		basePath: basePath,
+		baseKey:  key,
+	}, nil
+}
+
+// Derive returns the private key of the child at the given index under the base path.
+func (w *HDWallet) Derive(index uint32) (*ecdsa.PrivateKey, error) {
+	key, err := w.baseKey.Derive(index)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer key.Zero()
+	return toECDSA(key)
+}
+
+// Wipe zeroes the cached base key, the HDWallet can't derive keys anymore.
+func (w *HDWallet) Wipe() {
+	w.baseKey.Zero()
+}
+
+// Path returns the full derivation path of the child at the given index.
+func (w *HDWallet) Path(index uint32) accounts.DerivationPath {
+	path := make(accounts.DerivationPath, len(w.basePath)+1)
+	copy(path, w.basePath)
+	path[len(w.basePath)] = index
+	return path
+}
+
+func deriveExtendedKey(seed []byte, path accounts.DerivationPath) (*hdkeychain.ExtendedKey, error) {
+	key, err := hdkeychain.NewMaster(seed, &chaincfg.MainNetParams)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	for _, n := range path {
+		child, err := key.Derive(n)
+		// every intermediate key is zeroed once its child is derived
+		key.Zero()
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		key = child
+	}
+	return key, nil
+}
 
-func TestByteToString(t *testing.T) {
-        {
-                expectedBytes := []byte("hello world")
-                expected := string(expectedBytes)
-                actual := b2s(expectedBytes)
-                if expected != actual {
-                        t.Errorf("expected: %s, actual: %s", expected, actual)
-                }
-        }
-        {
-                expectedBytes := make([]byte, 1024*1024)
-                if _, err := rand.Read(expectedBytes); err != nil {
-                        t.Error(err)
-                        t.FailNow()
-                }
-
-                expected := string(expectedBytes)
-                actual := b2s(expectedBytes)
-                if expected != actual {
-                        t.Errorf("expected: %s, actual: %s", expected, actual)
-                }
-        }
-        {
-                expectedBytes := make([]byte, 1024*1024)
-                expected := string(expectedBytes)
-                actual := b2s(expectedBytes)
-                if expected != actual {
-                        t.Errorf("expected: %s, actual: %s", expected, actual)
-                }
-        }
+func toECDSA(key *hdkeychain.ExtendedKey) (*ecdsa.PrivateKey, error) {
+	privateKey, err := key.ECPrivKey()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer privateKey.Zero()
+	return privateKey.ToECDSA(), nil
 }
@@ -0,0 +1,42 @@
+package wallets
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/planxnx/ethereum-wallet-generator/bip39"
+)
+
+func TestHDWalletDerive(t *testing.T) {
+	seed := bip39.NewSeed("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about", "")
+	expectedAddresses := []string{
+		"0x9858effd232b4033e47d90003d41ec34ecaeda94",
+		"0x6fac4d18c912343bf86fa7049364dd4e424ab9c0",
+	}
+
+	hd, err := NewHDWallet(seed, DefaultBaseDerivationPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, expected := range expectedAddresses {
+		cached, err := hd.Derive(uint32(i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		full, err := DeriveWallet(seed, hd.Path(uint32(i)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, full, cached)
+
+		wallet, err := NewFromPrivatekey(cached)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, expected, wallet.Address)
+		assert.Equal(t, expected, strings.ToLower(wallet.ChecksumAddress))
+	}
+}
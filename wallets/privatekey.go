@@ -1,16 +1,29 @@
 package wallets
 
 import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"io"
+
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/pkg/errors"
+
+	"github.com/planxnx/ethereum-wallet-generator/internal/wipe"
 )
 
+// NewGeneratorPrivatekey returns a generator that creates wallets from random private keys.
 func NewGeneratorPrivatekey() Generator {
+	return NewGeneratorPrivatekeyFrom(rand.Reader)
+}
+
+// NewGeneratorPrivatekeyFrom is NewGeneratorPrivatekey reading the private keys from random.
+func NewGeneratorPrivatekeyFrom(random io.Reader) Generator {
 	return func() (*Wallet, error) {
-		privateKey, err := crypto.GenerateKey()
+		privateKey, err := randomKey(random)
 		if err != nil {
-			return nil, errors.WithStack(err)
+			return nil, err
 		}
+		defer wipe.Key(privateKey)
 
 		wallet, err := NewFromPrivatekey(privateKey)
 		if err != nil {
@@ -20,3 +33,18 @@ func NewGeneratorPrivatekey() Generator {
 		return wallet, nil
 	}
 }
+
+// randomKey reads 32 byte candidates from random until one is a valid secp256k1 key, below
+// the curve order and non zero.
+func randomKey(random io.Reader) (*ecdsa.PrivateKey, error) {
+	raw := make([]byte, 32)
+	defer wipe.Bytes(raw)
+	for {
+		if _, err := io.ReadFull(random, raw); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if key, err := crypto.ToECDSA(raw); err == nil {
+			return key, nil
+		}
+	}
+}
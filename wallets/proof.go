@@ -0,0 +1,57 @@
+package wallets
+
+import (
+	"encoding/hex"
+	"errors"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// DefaultProofMessage is the message signed by default to prove the control of an address,
+// {address} is replaced by the checksum address of the wallet.
+const DefaultProofMessage = "I control {address}"
+
+// Sign signs the message with the EIP-191 personal_sign scheme, after replacing {address}
+// with the checksum address, and verifies the signature recovers the wallet address. It sets
+// SignedMessage and Signature, the 0x hex r, s, v signature with v of 27 or 28, as returned
+// by eth_sign and checked by ecrecover.
+func (w *Wallet) Sign(message string) error {
+	key, err := crypto.HexToECDSA(w.PrivateKey)
+	if err != nil {
+		return err
+	}
+	message = strings.ReplaceAll(message, "{address}", crypto.PubkeyToAddress(key.PublicKey).Hex())
+	sig, err := crypto.Sign(accounts.TextHash([]byte(message)), key)
+	if err != nil {
+		return err
+	}
+	sig[crypto.RecoveryIDOffset] += 27
+	signature := "0x" + hex.EncodeToString(sig)
+	if err := VerifySignature(w.Address, message, signature); err != nil {
+		return err
+	}
+	w.SignedMessage, w.Signature = message, signature
+	return nil
+}
+
+// VerifySignature checks that the personal_sign signature of message recovers address.
+func VerifySignature(address, message, signature string) error {
+	sig, err := hex.DecodeString(strings.TrimPrefix(signature, "0x"))
+	if err != nil || len(sig) != crypto.SignatureLength {
+		return errors.New("malformed signature")
+	}
+	if sig[crypto.RecoveryIDOffset] >= 27 {
+		sig[crypto.RecoveryIDOffset] -= 27
+	}
+	pub, err := crypto.SigToPub(accounts.TextHash([]byte(message)), sig)
+	if err != nil {
+		return err
+	}
+	if crypto.PubkeyToAddress(*pub) != common.HexToAddress(address) {
+		return errors.New("signature recovers another address")
+	}
+	return nil
+}
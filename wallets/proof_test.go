@@ -0,0 +1,37 @@
+package wallets
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestSign(t *testing.T) {
+	key, err := crypto.HexToECDSA("1ab42cc412b618bdea3a599e3c9bae199ebf030895b039e9db1e30dafb12b727")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := NewFromPrivatekey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Sign(DefaultProofMessage); err != nil {
+		t.Fatal(err)
+	}
+	if w.SignedMessage != "I control 0x9858EfFD232B4033E47d90003D41EC34EcaEda94" {
+		t.Errorf("signed message = %q", w.SignedMessage)
+	}
+	if len(w.Signature) != 132 || !strings.HasSuffix(w.Signature, "1b") && !strings.HasSuffix(w.Signature, "1c") {
+		t.Errorf("signature = %q", w.Signature)
+	}
+	if err := VerifySignature(w.Address, w.SignedMessage, w.Signature); err != nil {
+		t.Error(err)
+	}
+	if err := VerifySignature(w.Address, w.SignedMessage+".", w.Signature); err == nil {
+		t.Error("verified the signature of another message")
+	}
+	if err := VerifySignature("0x0000000000000000000000000000000000000001", w.SignedMessage, w.Signature); err == nil {
+		t.Error("verified the signature of another address")
+	}
+}
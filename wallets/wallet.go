@@ -9,6 +9,8 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
 	"gorm.io/gorm"
+
+	"github.com/planxnx/ethereum-wallet-generator/internal/wipe"
 )
 
 type (
@@ -17,10 +19,41 @@ type (
 
 	// Wallet is a struct that contains the information of a wallet.
 	Wallet struct {
-		Address    string
-		PrivateKey string
-		Mnemonic   string
-		HDPath     string
+		Address             string `gorm:"size:128"`
+		ChecksumAddress     string
+		PrivateKey          string
+		PublicKey           string
+		CompressedPublicKey string
+		Mnemonic            string
+		HDPath              string
+		SeedFile            string
+		SeedLine            int
+		SeedLabel           string
+		SeedHash            string
+		AccountIndex        int
+		AddressIndex        int
+		// SignedMessage and Signature prove the control of the address, see Sign.
+		SignedMessage string
+		Signature     string
+		// AvaxXAddress and AvaxPAddress are the Avalanche X-chain and P-chain addresses of
+		// the same address index, set by the avax coin.
+		AvaxXAddress string
+		AvaxPAddress string
+		// PrivateKeySalt, PrivateKeyNonce and PrivateKeyCiphertext hold the private key
+		// encrypted with a passphrase in DB rows, PrivateKey is empty then.
+		PrivateKeySalt       string
+		PrivateKeyNonce      string
+		PrivateKeyCiphertext string
+		// PrivateKeyHash is the salted hash proving the private key of a row stored
+		// without it, see keycrypt.Hash.
+		PrivateKeyHash string
+		// ICAP is the XE... ICAP form of the address, set with -icap, see ICAP.
+		ICAP string
+		// AddressLabel names the known owner of the address, eg. an exchange or a token
+		// contract, set with -address-labels.
+		AddressLabel string
+		// RunID identifies the run that stored the wallet.
+		RunID string `gorm:"size:32;index"`
 		gorm.Model
 		Bits int
 	}
@@ -51,10 +84,12 @@ func NewFromPrivatekey(privateKey *ecdsa.PrivateKey) (*Wallet, error) {
 	priveKeyBytes := crypto.FromECDSA(privateKey)
 	privHex := make([]byte, len(priveKeyBytes)*2)
 	hex.Encode(privHex, priveKeyBytes)
+	wipe.Bytes(priveKeyBytes)
 	privString := b2s(privHex)
 
 	// toString PublicKey
-	publicKeyBytes := crypto.Keccak256(crypto.FromECDSAPub(publicKey)[1:])[12:]
+	uncompressed := crypto.FromECDSAPub(publicKey)
+	publicKeyBytes := crypto.Keccak256(uncompressed[1:])[12:]
 	if len(publicKeyBytes) > common.AddressLength {
 		publicKeyBytes = publicKeyBytes[len(publicKeyBytes)-common.AddressLength:]
 	}
@@ -64,8 +99,11 @@ func NewFromPrivatekey(privateKey *ecdsa.PrivateKey) (*Wallet, error) {
 	pubString := b2s(pubHex)
 
 	return &Wallet{
-		Address:    pubString,
-		PrivateKey: privString,
+		Address:             pubString,
+		ChecksumAddress:     common.BytesToAddress(publicKeyBytes).Hex(),
+		PrivateKey:          privString,
+		PublicKey:           hex.EncodeToString(uncompressed),
+		CompressedPublicKey: hex.EncodeToString(crypto.CompressPubkey(publicKey)),
 	}, nil
 }
 
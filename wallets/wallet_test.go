@@ -1,8 +1,13 @@
 package wallets
 
 import (
+	"bytes"
 	"crypto/rand"
+	"math/big"
 	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
 )
 
 func TestByteToString(t *testing.T) {
@@ -36,3 +41,97 @@ func TestByteToString(t *testing.T) {
 		}
 	}
 }
+
+func TestICAP(t *testing.T) {
+	for address, expected := range map[string]string{
+		"0x00c5496aee77c1ba1f0854206a26dda82a81d6d8": "XE7338O073KYGTWWZN0F2WZ0R8PX5ZPPZS",
+		"0x52dc504a422f0e2a9e7632a34a50f1a82f8224c7": "XE499OG1EH8ZZI0KXC6N83EKGT1BM97P2O7",
+		"0x11c5496aee77c1ba1f0854206a26dda82a81d6d8": "XE1222Q908LN1QBBU6XUQSO1OHWJIOS46OO",
+	} {
+		if actual := ICAP(common.HexToAddress(address)); actual != expected {
+			t.Errorf("ICAP(%s) = %s, want %s", address, actual, expected)
+		}
+	}
+}
+
+func TestChecksumAddress(t *testing.T) {
+	for _, tt := range []struct {
+		chainID  uint64
+		expected string
+	}{
+		{0, "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"},
+		{30, "0x5aaEB6053f3e94c9b9a09f33669435E7ef1bEAeD"},
+		{30, "0xFb6916095cA1Df60bb79ce92cE3EA74c37c5d359"},
+		{30, "0xDBF03B407c01E7CD3cBea99509D93F8Dddc8C6FB"},
+		{31, "0x5aAeb6053F3e94c9b9A09F33669435E7EF1BEaEd"},
+		{31, "0xFb6916095CA1dF60bb79CE92ce3Ea74C37c5D359"},
+	} {
+		if actual := ChecksumAddress(common.HexToAddress(tt.expected), tt.chainID); actual != tt.expected {
+			t.Errorf("ChecksumAddress(%s, %d) = %s", tt.expected, tt.chainID, actual)
+		}
+	}
+}
+
+func TestKeyspaceGenerator(t *testing.T) {
+	calls, computed := 0, ""
+	gen := NewGeneratorKeyspaceFrom(rand.Reader, func(address string) bool {
+		calls++
+		computed = address
+		return calls%2 == 0
+	})
+	for i := 0; i < 20; i++ {
+		w, err := gen()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i%2 == 0 {
+			if w.PrivateKey != "" || len(w.Address) != 42 {
+				t.Errorf("rejected wallet %+v, want only its address", w)
+			}
+			continue
+		}
+		key, err := crypto.HexToECDSA(w.PrivateKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected, err := NewFromPrivatekey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if *w != *expected || computed != expected.Address {
+			t.Errorf("accepted wallet %+v of address %s, want %+v", w, computed, expected)
+		}
+	}
+}
+
+func TestIncrementalGenerator(t *testing.T) {
+	start := make([]byte, 32)
+	start[31] = 1
+	random := bytes.NewReader(append(start, bytes.Repeat([]byte{7}, 32)...))
+	calls := 0
+	gen := NewGeneratorIncrementalFrom(random, func(address string) bool {
+		calls++
+		return calls == 300
+	})
+	for i := 1; i <= 301; i++ {
+		w, err := gen()
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the walk from 1 ends at its match, the next one starts from 0x0707...
+		key := new(big.Int).SetInt64(int64(i))
+		if i == 301 {
+			key.SetBytes(bytes.Repeat([]byte{7}, 32))
+		}
+		expected, err := NewFromPrivatekey(crypto.ToECDSAUnsafe(key.FillBytes(make([]byte, 32))))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if w.Address != expected.Address {
+			t.Fatalf("key %d: address %s, want %s", i, w.Address, expected.Address)
+		}
+		if i == 300 && *w != *expected {
+			t.Errorf("accepted wallet %+v, want %+v", w, expected)
+		}
+	}
+}